@@ -1,8 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -20,6 +21,13 @@ const (
 	menuView appState = iota
 	installView
 	actionView
+	formView
+	arrangeView
+	reportView
+	confirmView
+	wizardView
+	tailView
+	hangView
 )
 
 type model struct {
@@ -31,6 +39,19 @@ type model struct {
 	isProcessing bool
 	progress     string
 	actionMsg    string
+	form         *form
+	arrange      *arrangement
+	report       *report
+	confirm      *confirmation
+	wizard       *wizard
+	tail         *logTail
+	hang         *hangPrompt
+	checklist    *checklist
+	menuGroup    string // the submenu shown, "" for the main menu
+	inSession    bool
+	updates      []string // found by the launch check, for the banner
+	failedAction string   // the last action that failed, for Report a Bug
+	failedLog    string
 }
 
 // Set consistent height and width for all views
@@ -60,9 +81,18 @@ var (
 	// Dimmed style for non-selected options
 	disabledStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 
+	// Where the current screen is, under the title of submenus and above other screens
+	breadcrumbStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).PaddingLeft(2)
+
 	// Log and action message styles
 	logStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("63")).Padding(1, 2).Width(viewWidth)
 	actionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00ff00")).Padding(1, 2).Align(lipgloss.Center).Width(viewWidth)
+
+	// Session status line under the menu title
+	sessionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00")).Width(viewWidth)
+
+	// Update banner above the menu title
+	bannerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00")).Bold(true).PaddingLeft(2).Width(viewWidth)
 )
 
 type statusMsg struct {
@@ -71,13 +101,103 @@ type statusMsg struct {
 }
 
 func initialModel() model {
-	// Clear the terminal screen
-	clearScreen()
+	inSession := niriRunning()
+	m := model{
+		state:     menuView,
+		choices:   menuChoices("", inSession),
+		inSession: inSession,
+	}
+	if wizardFlag || isFirstRun() {
+		m.state = wizardView
+		m.wizard = newWizard()
+	}
+	return m
+}
+
+// sessionChoices are the menu entries that need a running niri session.
+var sessionChoices = map[string]bool{
+	"Monitor Profiles":  true,
+	"Arrange Monitors":  true,
+	"HiDPI Scaling":     true,
+	"Output Settings":   true,
+	"Active Session":    true,
+	"Wayland Protocols": true,
+	"Test Suspend":      true,
+}
+
+// menuGroup is a submenu of the main menu: one area of the setup.
+type menuGroup struct {
+	name  string
+	items []string
+}
+
+// menuGroups are the areas of the main menu, in order. The main menu
+// holds them between the wizard and Exit.
+var menuGroups = []menuGroup{
+	{"Install", []string{"Install Niri", "Browser Setup", "Qt Wayland", "OBS Studio", "Gaming", "Polkit Agent", "Keyring", "SSH and GPG Agents"}},
+	{"System", []string{"Setup System", "Desktop Conflicts", "Kernel Modules", "GPU Driver", "GPU Firmware", "Laptop Power", "Test Suspend", "Webcam", "Screen Sharing", "Gamepads", "Supervision"}},
+	{"Configure", []string{"Configure Niri", "Test Config", "System-wide Config", "Dotfiles", "Import Config", "Layout", "Animations", "Appearance", "Color Scheme", "Waybar Workspaces", "Night Light", "Key Bindings", "Cheat Sheet", "Function Keys", "Media Keys", "App Environment", "Default Apps", "Input Method", "On-screen Keyboard", "Tablet", "Monitor Profiles", "Arrange Monitors", "HiDPI Scaling", "Output Settings"}},
+	{"Diagnose", []string{"Doctor", "Test Graphics", "Wayland Protocols", "Active Session", "Session Info", "Session Log", "Last Session Log", "Watch Session Logs", "Validate Config", "Lint Config", "Format Config", "Save Logs", "Collect Debug Info", "Report a Bug"}},
+	{"Maintain", []string{"Update Niri", "Update Check", "Package Lock", "Managed State", "History", "Security Audit", "Export Environment", "Import Environment", "Remote Setup", "Rollback Setup", "Boot Environments"}},
+}
+
+// menuGroupNamed returns the area called name, or nil.
+func menuGroupNamed(name string) *menuGroup {
+	for i := range menuGroups {
+		if menuGroups[i].name == name {
+			return &menuGroups[i]
+		}
+	}
+	return nil
+}
+
+// menuGroupOf returns the area holding the menu entry choice, or "" if
+// no area does.
+func menuGroupOf(choice string) string {
+	for _, g := range menuGroups {
+		if containsString(g.items, choice) {
+			return g.name
+		}
+	}
+	return ""
+}
+
+// menuChoices returns the entries of the submenu group, or of the main
+// menu if group is empty, leaving out the session-only entries when niri
+// isn't running. Submenus end with Back.
+func menuChoices(group string, inSession bool) []string {
+	if g := menuGroupNamed(group); g != nil {
+		var choices []string
+		for _, c := range g.items {
+			if inSession || !sessionChoices[c] {
+				choices = append(choices, c)
+			}
+		}
+		return append(choices, "Back")
+	}
+	choices := []string{"First-run Wizard"}
+	for _, g := range menuGroups {
+		choices = append(choices, g.name)
+	}
+	return append(choices, "Exit")
+}
 
-	return model{
-		state:   menuView,
-		choices: []string{"Install Niri", "Setup System", "Configure Niri", "Validate Config", "Save Logs", "Exit"},
+// openMenu shows the submenu group, or the main menu if group is empty.
+// Going back puts the cursor on the area just left.
+func (m model) openMenu(group string) (tea.Model, tea.Cmd) {
+	left := m.menuGroup
+	m.state = menuView
+	m.menuGroup = group
+	m.choices = menuChoices(group, m.inSession)
+	m.cursor = 0
+	if group == "" {
+		for i, c := range m.choices {
+			if c == left {
+				m.cursor = i
+			}
+		}
 	}
+	return m, nil
 }
 
 func clearScreen() {
@@ -87,7 +207,7 @@ func clearScreen() {
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return checkUpdateBanner()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -96,8 +216,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.state {
 		case menuView:
 			switch msg.String() {
-			case "ctrl+c", "q":
+			case "ctrl+c":
 				return m, tea.Quit
+			case "q", "esc", "backspace":
+				if m.menuGroup != "" {
+					return m.openMenu("")
+				}
+				if msg.String() == "q" {
+					return m, tea.Quit
+				}
 			case "up":
 				if m.cursor > 0 {
 					m.cursor--
@@ -107,43 +234,114 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.cursor++
 				}
 			case "enter":
-				m.selected = m.choices[m.cursor]
-				m.isProcessing = true
-				switch m.selected {
-				case "Install Niri":
-					m.state = installView
-					return m, installNiri()
-				case "Setup System":
-					m.state = installView
-					return m, setupSystem()
-				case "Configure Niri":
-					m.state = actionView
-					m.actionMsg = "Configuring Niri..."
-					return m, configureNiri()
-				case "Validate Config":
-					m.state = actionView
-					m.actionMsg = "Validating Niri config..."
-					return m, validateNiriConfig()
-				case "Save Logs":
-					m.state = actionView
-					m.actionMsg = "Saving logs..."
-					return m, saveLogsToFile(m)
-				case "Exit":
-					return m, tea.Quit
+				choice := m.choices[m.cursor]
+				if choice == "Back" {
+					return m.openMenu("")
+				}
+				if menuGroupNamed(choice) != nil {
+					return m.openMenu(choice)
 				}
+				m.selected = choice
+				m.isProcessing = true
+				return m.runChoice()
 			}
+		case formView:
+			return m.updateForm(msg)
+		case arrangeView:
+			return m.updateArrange(msg)
+		case reportView:
+			return m.updateReport(msg)
+		case confirmView:
+			return m.updateConfirm(msg)
+		case wizardView:
+			return m.updateWizard(msg)
+		case tailView:
+			return m.updateTail(msg)
+		case hangView:
+			return m.updateHang(msg)
 		case installView, actionView:
+			if m.state == installView && m.checklist != nil {
+				return m.updateChecklist(msg)
+			}
 			// Disable input during processing
+			if m.isProcessing {
+				return m, nil
+			}
+			switch msg.String() {
+			case "g":
+				if m.failedAction != "" {
+					m.state = actionView
+					m.actionMsg = "Preparing the issue..."
+					return m, reportBug(m)
+				}
+			case "esc", "q", "backspace":
+				m.state = menuView
+				m.logs = nil
+				m.checklist = nil
+			}
+			return m, nil
+		}
+	case formMsg:
+		m.state = formView
+		m.form = msg.form
+		return m, nil
+	case reportMsg:
+		m.state = reportView
+		m.report = msg.report
+		return m, nil
+	case confirmMsg:
+		m.state = confirmView
+		m.confirm = msg.confirmation
+		return m, nil
+	case checklistMsg:
+		return m.showChecklist(msg)
+	case hangMsg:
+		return m.showHang(msg.prompt)
+	case hangDoneMsg:
+		if m.hang == msg.prompt {
+			m.state = m.hang.prev
+			m.hang = nil
+		}
+		return m, nil
+	case tailMsg:
+		m.state = tailView
+		m.tail = msg.tail
+		return m, tailTick()
+	case tailTickMsg:
+		if m.state != tailView {
 			return m, nil
 		}
+		m.tail.poll()
+		return m, tailTick()
+	case updateBannerMsg:
+		m.updates = msg.updates
+		return m, nil
+	case rerunMsg:
+		return m.rerun(msg.choice)
+	case arrangementMsg:
+		m.state = arrangeView
+		m.arrange = msg.arrangement
+		return m, nil
 	case statusMsg:
 		// Append logs and handle state transitions
 		m.logs = append(m.logs, msg.status)
 		m.isProcessing = false
-		if msg.err == nil && m.state == installView {
+		if msg.err != nil {
+			m.failedAction, m.failedLog = m.selected, msg.status
+		}
+		if m.wizard == nil && m.selected != "" {
+			recordHistory(historyMenu, m.selected, msg.status, msg.err)
+		}
+		if m.wizard != nil {
+			m.wizard.finish(msg)
+			m.state = wizardView
+			return m, nil
+		}
+		if msg.err == nil && m.state == installView && (m.checklist == nil || !m.checklist.failed()) {
 			// Automatically return to the menu after installation
 			m.state = menuView
 			m.logs = nil // Clear logs before returning to menu
+			m.checklist = nil
 		} else if msg.err == nil && m.state == actionView {
 			// Automatically return to the menu after actions
 			m.state = menuView
@@ -155,32 +353,398 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// runChoice starts the menu entry m.selected.
+func (m model) runChoice() (tea.Model, tea.Cmd) {
+	switch m.selected {
+	case "First-run Wizard":
+		m.state = wizardView
+		m.wizard = newWizard()
+		m.isProcessing = false
+		return m, nil
+	case "Install Niri":
+		m.checklist = nil
+		if !pkgBootstrapped() {
+			install := installNiri()
+			if zfsRoot() {
+				install = func() tea.Msg {
+					return confirmMsg{bootEnvironmentConfirmation("Installing Niri...", installNiri())}
+				}
+			}
+			m.state = confirmView
+			m.confirm = pkgBootstrapConfirmation("Bootstrapping pkg...", install)
+			return m, nil
+		}
+		if zfsRoot() {
+			m.state = confirmView
+			m.confirm = bootEnvironmentConfirmation("Installing Niri...", installNiri())
+			return m, nil
+		}
+		m.state = installView
+		return m, installNiri()
+	case "Update Niri":
+		if zfsRoot() {
+			m.state = confirmView
+			m.confirm = bootEnvironmentConfirmation("Updating Niri...", withUnlockedStack(updateNiri()))
+			return m, nil
+		}
+		m.state = actionView
+		m.actionMsg = "Updating Niri..."
+		return m, withUnlockedStack(updateNiri())
+	case "Package Lock":
+		m.state = formView
+		m.form = newPackageLockForm()
+		return m, nil
+	case "Setup System":
+		m.checklist = nil
+		m.state = actionView
+		m.actionMsg = "Working out what Setup System would change..."
+		return m, previewSetupSystem(savedStepModes())
+	case "Desktop Conflicts":
+		m.state = actionView
+		m.actionMsg = "Looking for other desktop setups..."
+		return m, checkConflicts()
+	case "Kernel Modules":
+		m.state = actionView
+		m.actionMsg = "Checking kernel modules..."
+		return m, kernelModulesView()
+	case "GPU Driver":
+		m.state = actionView
+		m.actionMsg = "Detecting the GPU..."
+		return m, gpuDriverView()
+	case "GPU Firmware":
+		m.state = actionView
+		m.actionMsg = "Checking the GPU firmware..."
+		return m, gpuFirmwareView()
+	case "Configure Niri":
+		m.state = actionView
+		if d := loadDotfiles(); d != nil {
+			m.actionMsg = "Applying your dotfiles..."
+			return m, applyDotfilesCmd(d)
+		}
+		m.actionMsg = "Preparing the config..."
+		return m, previewConfig(m.inSession)
+	case "Test Config":
+		m.state = formView
+		m.form = newTestConfigForm()
+		return m, nil
+	case "System-wide Config":
+		m.state = actionView
+		m.actionMsg = "Installing the system-wide config..."
+		return m, installSystemConfig()
+	case "Layout":
+		m.state = formView
+		m.form = newLayoutForm()
+		return m, nil
+	case "Animations":
+		m.state = formView
+		m.form = newAnimationsForm()
+		return m, nil
+	case "Night Light":
+		m.state = formView
+		m.form = newWlsunsetForm()
+		return m, nil
+	case "Function Keys":
+		m.state = actionView
+		m.actionMsg = "Setting up brightness and volume keys..."
+		return m, setupFunctionKeys()
+	case "Media Keys":
+		m.state = actionView
+		m.actionMsg = "Setting up media player keys..."
+		return m, setupMediaKeys()
+	case "Browser Setup":
+		m.state = formView
+		m.form = newBrowserForm()
+		return m, nil
+	case "App Environment":
+		m.state = formView
+		m.form = newEnvPresetsForm()
+		return m, nil
+	case "Qt Wayland":
+		m.state = actionView
+		m.actionMsg = "Looking for Qt programs..."
+		return m, setupQtWayland()
+	case "Polkit Agent":
+		m.state = formView
+		m.form = newPolkitForm()
+		return m, nil
+	case "Input Method":
+		m.state = formView
+		m.form = newInputMethodForm()
+		return m, nil
+	case "On-screen Keyboard":
+		m.state = actionView
+		m.actionMsg = "Setting up the on-screen keyboard..."
+		return m, setupOSK()
+	case "Tablet":
+		m.state = formView
+		m.form = newTabletForm()
+		return m, nil
+	case "Keyring":
+		m.state = formView
+		m.form = newKeyringForm()
+		return m, nil
+	case "SSH and GPG Agents":
+		m.state = formView
+		m.form = newAgentsForm()
+		return m, nil
+	case "Default Apps":
+		m.state = formView
+		m.form = newDefaultAppsForm()
+		return m, nil
+	case "Appearance":
+		m.state = formView
+		m.form = newAppearanceForm()
+		return m, nil
+	case "Color Scheme":
+		m.state = formView
+		m.form = newPaletteForm()
+		return m, nil
+	case "Waybar Workspaces":
+		m.state = actionView
+		m.actionMsg = "Setting up the waybar workspaces..."
+		return m, setupWaybarWorkspaces()
+	case "Dotfiles":
+		m.state = formView
+		m.form = newDotfilesForm()
+		return m, nil
+	case "Update Check":
+		m.state = formView
+		m.form = newUpdateCheckForm()
+		return m, nil
+	case "Supervision":
+		m.state = formView
+		m.form = newSupervisorForm()
+		return m, nil
+	case "Test Graphics":
+		m.state = actionView
+		m.actionMsg = "Testing graphics..."
+		return m, testGraphicsCmd()
+	case "Wayland Protocols":
+		m.state = actionView
+		m.actionMsg = "Querying the compositor..."
+		return m, checkProtocolsCmd()
+	case "Laptop Power":
+		m.state = formView
+		m.form = newLaptopForm()
+		return m, nil
+	case "Monitor Profiles":
+		m.state = actionView
+		m.actionMsg = "Generating kanshi monitor profiles..."
+		return m, setupKanshi()
+	case "Arrange Monitors":
+		m.state = actionView
+		m.actionMsg = "Detecting outputs..."
+		return m, loadArrangement()
+	case "HiDPI Scaling":
+		m.state = actionView
+		m.actionMsg = "Detecting displays..."
+		return m, loadScalingForm()
+	case "Output Settings":
+		m.state = actionView
+		m.actionMsg = "Detecting displays..."
+		return m, loadOutputForm()
+	case "Active Session":
+		m.state = actionView
+		m.actionMsg = "Querying niri..."
+		return m, activeSession()
+	case "Export Environment":
+		m.state = formView
+		m.form = newExportEnvironmentForm()
+		return m, nil
+	case "Import Environment":
+		m.state = formView
+		m.form = newImportEnvironmentForm()
+		return m, nil
+	case "Import Config":
+		m.state = formView
+		m.form = newImportConfigForm()
+		return m, nil
+	case "Remote Setup":
+		m.state = formView
+		m.form = newRemoteForm()
+		return m, nil
+	case "Rollback Setup":
+		m.state = actionView
+		m.actionMsg = "Looking for boot environments..."
+		return m, loadRollback()
+	case "Boot Environments":
+		m.state = actionView
+		m.actionMsg = "Looking for boot environments..."
+		return m, bootEnvironmentsView()
+	case "Managed State":
+		m.state = actionView
+		m.actionMsg = "Checking the changes NiriSetup made..."
+		return m, managedStateView()
+	case "History":
+		m.state = actionView
+		m.actionMsg = "Reading the history..."
+		return m, historyView()
+	case "Security Audit":
+		m.state = actionView
+		m.actionMsg = "Checking for known vulnerabilities..."
+		return m, securityAudit()
+	case "Doctor":
+		m.state = actionView
+		m.actionMsg = "Checking for problems..."
+		return m, doctor()
+	case "Session Log":
+		m.state = actionView
+		m.actionMsg = "Reading the niri log..."
+		return m, sessionLogView()
+	case "Last Session Log":
+		m.state = actionView
+		m.actionMsg = "Reading the niri log..."
+		return m, lastSessionLogView(0)
+	case "Watch Session Logs":
+		m.state = actionView
+		m.actionMsg = "Opening the session logs..."
+		return m, watchSessionLogs()
+	case "Validate Config":
+		m.state = actionView
+		m.actionMsg = "Validating Niri config..."
+		return m, validateNiriConfig()
+	case "Lint Config":
+		m.state = actionView
+		m.actionMsg = "Linting Niri config..."
+		return m, lintNiriConfig()
+	case "Key Bindings":
+		m.state = actionView
+		m.actionMsg = "Checking the key bindings..."
+		return m, keyBindingsView()
+	case "Cheat Sheet":
+		m.state = actionView
+		m.actionMsg = "Writing the cheat sheet..."
+		return m, saveCheatSheet()
+	case "Session Info":
+		m.state = actionView
+		m.actionMsg = "Reading the session environment..."
+		return m, sessionInfo()
+	case "Screen Sharing":
+		m.state = actionView
+		m.actionMsg = "Checking screen sharing..."
+		return m, screenSharingCmd(false, false)
+	case "OBS Studio":
+		m.state = actionView
+		m.actionMsg = "Setting up OBS Studio..."
+		return m, setupOBS()
+	case "Test Suspend":
+		m.state = confirmView
+		m.confirm = &confirmation{
+			prompt:    "The machine suspends now. Wake it up again and NiriSetup checks that the GPU, niri and the outputs came back.\n\nSuspend?",
+			actionMsg: "Waiting for the machine to suspend and resume...",
+			yes:       testSuspendCmd(),
+		}
+		return m, nil
+	case "Gaming":
+		m.state = actionView
+		m.actionMsg = "Setting up gaming..."
+		return m, setupGaming()
+	case "Gamepads":
+		m.state = actionView
+		m.actionMsg = "Setting up gamepad permissions..."
+		return m, setupGamepads()
+	case "Webcam":
+		m.state = actionView
+		m.actionMsg = "Setting up the webcam..."
+		return m, setupWebcam()
+	case "Format Config":
+		m.state = actionView
+		m.actionMsg = "Formatting Niri config..."
+		return m, formatNiriConfig()
+	case "Save Logs":
+		m.state = actionView
+		m.actionMsg = "Saving logs..."
+		return m, saveLogsToFile(m)
+	case "Collect Debug Info":
+		m.state = actionView
+		m.actionMsg = "Collecting debug info..."
+		return m, collectDebugInfo(m)
+	case "Report a Bug":
+		m.state = actionView
+		m.actionMsg = "Preparing the issue..."
+		return m, reportBug(m)
+	case "Exit":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
 func (m model) View() string {
+	var view string
 	switch m.state {
 	case menuView:
 		return m.renderMenuView()
 	case installView:
-		return m.renderInstallView()
+		view = m.renderInstallView()
 	case actionView:
-		return m.renderActionView()
+		view = m.renderActionView()
+	case formView:
+		view = m.renderFormView()
+	case arrangeView:
+		view = m.renderArrangeView()
+	case reportView:
+		view = m.renderReportView()
+	case confirmView:
+		view = m.renderConfirmView()
+	case wizardView:
+		view = m.renderWizardView()
+	case tailView:
+		view = m.renderTailView()
+	case hangView:
+		view = m.renderHangView()
 	default:
-		return "Unknown state!"
+		return T("Unknown state!")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, breadcrumbStyle.Render(m.breadcrumb()), view)
+}
+
+// breadcrumb shows where the current screen is, starting from the main
+// menu, e.g. Main › Configure › Output Settings.
+func (m model) breadcrumb() string {
+	crumbs := []string{T("Main")}
+	switch {
+	case m.wizard != nil:
+		crumbs = append(crumbs, T("First-run Wizard"))
+	case m.state == menuView:
+		crumbs = append(crumbs, T(m.menuGroup))
+	case m.selected != "":
+		if m.menuGroup != "" {
+			crumbs = append(crumbs, T(m.menuGroup))
+		}
+		crumbs = append(crumbs, T(m.selected))
 	}
+	return strings.Join(crumbs, " › ")
 }
 
 func (m model) renderMenuView() string {
     // Title section, centered and fixed width
-    title := titleStyle.Render("Niri Setup Assistant for GhostBSD")
+    title := titleStyle.Render(T("Niri Setup Assistant for GhostBSD"))
+    if m.inSession {
+        title = lipgloss.JoinVertical(lipgloss.Left, title, sessionStyle.Render(T("niri session detected: session actions enabled")))
+    }
+
+    if m.menuGroup != "" {
+        title = lipgloss.JoinVertical(lipgloss.Left, title, breadcrumbStyle.Render(m.breadcrumb()))
+    }
+
+    if banner := updateBanner(m.updates); banner != "" {
+        title = lipgloss.JoinVertical(lipgloss.Left, bannerStyle.Render(banner), title)
+    }
 
     // Menu rendering with fixed width and left alignment
     menu := strings.Builder{}
     for i, choice := range m.choices {
+        label := T(choice)
+        if menuGroupNamed(choice) != nil {
+            // Areas open a submenu
+            label += " ›"
+        }
         if m.cursor == i {
             // Selected item with cursor, ensure the same width for alignment
-            menu.WriteString(cursorStyle.Render(fmt.Sprintf("> %-"+fmt.Sprintf("%d", menuItemWidth-2)+"s", choice)) + "\n")
+            menu.WriteString(cursorStyle.Render(fmt.Sprintf("> %-"+fmt.Sprintf("%d", menuItemWidth-2)+"s", label)) + "\n")
         } else {
             // Non-selected items with consistent width and left padding
-            menu.WriteString(disabledStyle.Render(fmt.Sprintf("  %-"+fmt.Sprintf("%d", menuItemWidth-2)+"s", choice)) + "\n")
+            menu.WriteString(disabledStyle.Render(fmt.Sprintf("  %-"+fmt.Sprintf("%d", menuItemWidth-2)+"s", label)) + "\n")
         }
     }
 
@@ -189,14 +753,17 @@ func (m model) renderMenuView() string {
 }
 
 func (m model) renderInstallView() string {
+	if m.checklist != nil {
+		return m.renderChecklist()
+	}
 	// Title and logs section with consistent width
-	s := titleStyle.Render("Installing Niri...")
+	s := titleStyle.Render(T("Installing Niri..."))
 
 	// Logs section
 	for _, log := range m.logs {
 		s += logStyle.Render(log + "\n")
 	}
-	s += logStyle.Render("Please wait...\n")
+	s += logStyle.Render(m.actionHint() + "\n")
 
 	// Ensure fixed height for the view
 	return lipgloss.JoinVertical(lipgloss.Left, s)
@@ -204,12 +771,87 @@ func (m model) renderInstallView() string {
 
 func (m model) renderActionView() string {
 	// Display the action message prominently with consistent width
-	return lipgloss.JoinVertical(lipgloss.Left, actionStyle.Render(fmt.Sprintf("%s\n\nPlease wait...", m.actionMsg)))
+	hint := m.actionHint()
+	return lipgloss.JoinVertical(lipgloss.Left, actionStyle.Render(fmt.Sprintf("%s\n\n%s", T(m.actionMsg), hint)))
+}
+
+// actionHint tells what can be done on the install and action screens:
+// wait while the action runs, then go back or report a failure.
+func (m model) actionHint() string {
+	switch {
+	case m.isProcessing:
+		return T("Please wait...")
+	case m.failedAction != "":
+		return T("Press g to report this failure on GitHub") + "\n" + T("esc back")
+	default:
+		return T("esc back")
+	}
 }
 
 func isPackageInstalled(pkg string) bool {
 	cmd := exec.Command("pkg", "info", pkg)
-	return cmd.Run() == nil
+	return runCommand(cmd) == nil
+}
+
+// hasCommand reports whether name can be found in $PATH.
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// installPackage installs pkg with pkg(8), skipping it if it is already
+// installed, and returns a log line describing the outcome.
+func installPackage(pkg string) (string, error) {
+	if !pkgBootstrapped() {
+		return Tf("Failed to install %s: pkg isn't bootstrapped yet, run pkg bootstrap first", pkg), preflightFailure(errPkgNotBootstrapped)
+	}
+	if isPackageInstalled(pkg) {
+		// Still part of the setup when it is replayed elsewhere.
+		recordArgs(nil, "sudo", "pkg", "install", "-y", pkg)
+		return Tf("Already installed: %s", pkg), nil
+	}
+
+	cmd := exec.Command("sudo", "pkg", "install", "-y", pkg)
+	out, err := commandCombinedOutput(cmd)
+	if err != nil {
+		outStr := strings.TrimSpace(string(out))
+		return Tf("Failed to install %s: %s", pkg, outStr), err
+	}
+	return Tf("Successfully installed %s", pkg), nil
+}
+
+// writeRootFile writes content to a root-owned path by staging it in a
+// temporary file and installing it with sudo.
+func writeRootFile(path, content string, mode os.FileMode) error {
+	tmp, err := os.CreateTemp("", "nirisetup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	cmd := exec.Command("sudo", "install", "-o", "root", "-m", fmt.Sprintf("%o", mode), tmp.Name(), path)
+	if err := ensureSudo(cmd); err != nil {
+		return err
+	}
+	// The temporary file means nothing in a replay, so the recording gets
+	// the content instead of the command, which otherwise runs as
+	// commandCombinedOutput runs it.
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	start := traceStart(cmd)
+	cmd, err = superviseCommand(cmd, out.Reset)
+	traceEnd(cmd, start, err)
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(out.String()))
+	}
+	recordFile(path, []byte(content), mode, true, false)
+	trackFile(path, []byte(content), false)
+	return nil
 }
 
 // findRenderDevice looks for the first DRM render node in /dev/dri/.
@@ -231,305 +873,499 @@ func findRenderDevice() string {
 	return renderNodes[0]
 }
 
+// niriPackages are installed by Install Niri.
+var niriPackages = []string{"drm-kmod", "mesa-libs", "mesa-dri", "consolekit2", "dbus", "niri", "xwayland-satellite", "seatd", "waybar", "grim", "jq", "wofi", "alacritty", "pam_xdg", "fuzzel", "swaylock", "foot", "wlsunset", "swaybg", "mako", "swayidle"}
+
+// installNiri installs the niri packages. Locked packages are unlocked
+// while it runs, so that their dependencies can be updated, and with --lock
+// or lock_packages = yes in the settings the whole stack is locked after.
 func installNiri() tea.Cmd {
-	return func() tea.Msg {
-		pkgs := []string{"drm-kmod", "mesa-libs", "mesa-dri", "consolekit2", "dbus", "niri", "xwayland-satellite", "seatd", "waybar", "grim", "jq", "wofi", "alacritty", "pam_xdg", "fuzzel", "swaylock", "foot", "wlsunset", "swaybg", "mako", "swayidle"}
-		var logs []string
-		var failed []string
+	return installNiriWith(nil)
+}
 
+// installNiriWith installs the niri packages followed by extra, the
+// packages of the components install --profile picks.
+func installNiriWith(extra []string) tea.Cmd {
+	return withHooks("pre-install", "post-install", withUnlockedStack(func() tea.Msg {
+		if !pkgBootstrapped() {
+			return statusMsg{status: T("pkg isn't bootstrapped on this system yet, so nothing can be installed. Run pkg bootstrap first, or install --yes to have NiriSetup do it."), err: preflightFailure(errPkgNotBootstrapped)}
+		}
+		pkgs := withExtraPackages(extra)
+		var steps []setupStep
 		for _, pkg := range pkgs {
-			// Skip packages that are already installed
-			if isPackageInstalled(pkg) {
-				logs = append(logs, fmt.Sprintf("Already installed: %s", pkg))
-				continue
-			}
-
-			cmd := exec.Command("sudo", "pkg", "install", "-y", pkg)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				outStr := strings.TrimSpace(string(out))
-				logs = append(logs, fmt.Sprintf("Failed to install %s: %s", pkg, outStr))
-				failed = append(failed, pkg)
-				continue
+			steps = append(steps, setupStep{name: Tf("Install %s", pkg), lock: "pkg", run: func() ([]string, error) {
+				line, err := installPackage(pkg)
+				return []string{line}, err
+			}})
+		}
+		logs, failedSteps := runSteps("Installing Niri...", steps)
+		var failed []string
+		for i, step := range steps {
+			if containsString(failedSteps, step.name) {
+				failed = append(failed, pkgs[i])
 			}
-
-			logs = append(logs, fmt.Sprintf("Successfully installed %s", pkg))
 		}
 
 		if len(failed) > 0 {
-			logs = append(logs, fmt.Sprintf("\nFailed packages (%d): %s", len(failed), strings.Join(failed, ", ")))
+			logs = append(logs, Tf("\nFailed packages (%d): %s", len(failed), strings.Join(failed, ", ")))
 			return statusMsg{status: strings.Join(logs, "\n"), err: fmt.Errorf("%d packages failed to install", len(failed))}
 		}
 
+		if lockFlag || loadSettings()["lock_packages"] == "yes" {
+			if err := setPackageLock(true, niriPackages); err != nil {
+				logs = append(logs, Tf("Warning: Failed to lock the niri packages: %v", err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, T("Locked the niri packages, pkg upgrade leaves them alone (see Package Lock)"))
+		}
+
 		return statusMsg{status: strings.Join(logs, "\n")}
+	}))
+}
+
+// withExtraPackages returns the niri packages followed by those of extra
+// that aren't among them.
+func withExtraPackages(extra []string) []string {
+	pkgs := append([]string{}, niriPackages...)
+	for _, pkg := range extra {
+		if !containsString(pkgs, pkg) {
+			pkgs = append(pkgs, pkg)
+		}
 	}
+	return pkgs
 }
 
-func setupSystem() tea.Cmd {
+// listInstallPackages shows what install would install, for
+// install --dry-run.
+func listInstallPackages(extra []string) tea.Cmd {
 	return func() tea.Msg {
-		var logs []string
-
-		// Step 1: Enable and start required services
-		steps := []struct {
-			desc string
-			cmd  []string
-		}{
-			{"Enabling dbus service", []string{"sudo", "sysrc", "dbus_enable=YES"}},
-			{"Starting dbus service", []string{"sudo", "service", "dbus", "start"}},
-			{"Enabling seatd service", []string{"sudo", "sysrc", "seatd_enable=YES"}},
-			{"Starting seatd service", []string{"sudo", "service", "seatd", "start"}},
-		}
-
-		for _, step := range steps {
-			cmd := exec.Command(step.cmd[0], step.cmd[1:]...)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				// seatd may already be running; don't fail on that
-				outStr := string(out)
-				if !strings.Contains(outStr, "already running") {
-					logs = append(logs, fmt.Sprintf("Warning: %s: %s", step.desc, outStr))
-				} else {
-					logs = append(logs, fmt.Sprintf("%s: already running", step.desc))
-				}
+		pkgs := withExtraPackages(extra)
+		lines := []string{Tf("install would install these %d packages:", len(pkgs))}
+		for _, pkg := range pkgs {
+			if isPackageInstalled(pkg) {
+				lines = append(lines, "  "+Tf("%s (already installed)", pkg))
 			} else {
-				logs = append(logs, fmt.Sprintf("%s: OK", step.desc))
+				lines = append(lines, "  "+pkg)
 			}
 		}
+		lines = append(lines, "", T("Dry run: nothing was installed."))
+		return statusMsg{status: strings.Join(lines, "\n")}
+	}
+}
 
-		// Step 2: Add user to video group for GPU/DRM access
-		currentUser := os.Getenv("USER")
-		if currentUser == "" {
-			currentUser = os.Getenv("LOGNAME")
-		}
-		if currentUser != "" {
-			cmd := exec.Command("sudo", "pw", "groupmod", "video", "-m", currentUser)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				logs = append(logs, fmt.Sprintf("Warning: Adding user to video group: %s", string(out)))
-			} else {
-				logs = append(logs, fmt.Sprintf("Added user '%s' to video group: OK", currentUser))
+// setupSystem runs Setup System, leaving out the steps the settings skip.
+func setupSystem() tea.Cmd {
+	return setupSystemWith(savedStepModes())
+}
+
+// setupSystemSteps are the steps of Setup System.
+func setupSystemSteps() []setupStep {
+	return []setupStep{
+		{
+			name:      "Enable and start dbus",
+			lock:      "rc.conf",
+			satisfied: func() bool { return serviceRunning("dbus") },
+			run:       func() ([]string, error) { return enableService("dbus") },
+		},
+		{
+			name:      "Enable and start seatd",
+			lock:      "rc.conf",
+			satisfied: func() bool { return serviceRunning("seatd") },
+			run:       func() ([]string, error) { return enableService("seatd") },
+		},
+		{
+			name:      "Add you to the video group",
+			satisfied: func() bool { return inGroup(userName(), "video") },
+			run: func() ([]string, error) {
+				currentUser := userName()
+				if currentUser == "" {
+					return []string{T("Warning: Could not determine current user for group setup")}, errors.New("no user")
+				}
+				cmd := exec.Command("sudo", "pw", "groupmod", "video", "-m", currentUser)
+				out, err := commandCombinedOutput(cmd)
+				if err != nil {
+					return []string{Tf("Warning: Adding user to video group: %s", string(out))}, err
+				}
+				return []string{Tf("Added user '%s' to video group: OK", currentUser)}, nil
+			},
+		},
+		{
+			name:      "Load the DRM kernel module",
+			lock:      "rc.conf",
+			satisfied: drmModuleLoaded,
+			run: func() ([]string, error) {
+				var lines []string
+				var failed error
+				cmd := exec.Command("sudo", "kldload", "drm")
+				out, err := commandCombinedOutput(cmd)
+				if err != nil {
+					outStr := string(out)
+					if strings.Contains(outStr, "already loaded") || strings.Contains(outStr, "module already loaded") {
+						lines = append(lines, T("Loading DRM kernel module: already loaded"))
+					} else {
+						lines = append(lines, Tf("Warning: Loading DRM kernel module: %s", outStr))
+						failed = err
+					}
+				} else {
+					lines = append(lines, T("Loading DRM kernel module: OK"))
+				}
+
+				// Ensure drm is loaded at boot
+				cmd = exec.Command("sudo", "sysrc", "kld_list+=drm")
+				out, err = commandCombinedOutput(cmd)
+				if err != nil {
+					lines = append(lines, Tf("Warning: Persisting DRM module to boot: %s", string(out)))
+					failed = err
+				} else {
+					lines = append(lines, T("Persisting DRM module to boot: OK"))
+				}
+				return lines, failed
+			},
+		},
+		{
+			name: "Prepare the login environment in .profile",
+			run:  setupProfile,
+		},
+		{
+			name: "Write the session launcher",
+			run:  writeSessionLauncher,
+		},
+		{
+			name: "Set the session environment in config.kdl",
+			run: func() ([]string, error) {
+				// Tell programs in the niri session which desktop they run in
+				if _, cfg, err := readNiriConfig(); err != nil {
+					return []string{T("No config.kdl yet: Configure Niri sets XDG_CURRENT_DESKTOP and XDG_SESSION_TYPE")}, nil
+				} else if setSessionEnvironment(cfg) == cfg {
+					return []string{T("XDG_CURRENT_DESKTOP and XDG_SESSION_TYPE already in config.kdl: OK")}, nil
+				} else if path, err := updateNiriConfig(setSessionEnvironment); err != nil {
+					return []string{Tf("Warning: Could not write to %s: %v", path, err)}, err
+				} else {
+					return []string{Tf("Added XDG_CURRENT_DESKTOP=niri and XDG_SESSION_TYPE=wayland to %s: OK", path)}, nil
+				}
+			},
+		},
+		// The last two steps only warn: right after "Add you to the video
+		// group" the device stays closed to this process until the next
+		// login, and other desktops don't stop niri from starting.
+		{
+			name:  "Check the DRM render device",
+			needs: []string{"Load the DRM kernel module", "Add you to the video group"},
+			run: func() ([]string, error) {
+				renderDev := findRenderDevice()
+				if renderDev == "" {
+					return []string{
+						T("Warning: No DRM render device found in /dev/dri/"),
+						renderNodeHint(),
+					}, nil
+				}
+				lines := []string{Tf("Found DRM render device: %s", renderDev)}
+				// Check if the device is readable by the current user
+				f, err := os.Open(renderDev)
+				if err != nil {
+					return append(lines,
+						Tf("Warning: Cannot access %s: %v (check video group membership)", renderDev, err),
+						T("  Doctor can give the video group access to it through /etc/devfs.rules.")), nil
+				}
+				f.Close()
+				return append(lines, Tf("DRM render device %s is accessible: OK", renderDev)), nil
+			},
+		},
+		{
+			name: "Look for other desktops",
+			run: func() ([]string, error) {
+				if conflicts := findConflicts(); len(conflicts) > 0 {
+					return []string{Tf("Warning: Found %d other desktop setups that may get in niri's way, see Desktop Conflicts", len(conflicts))}, nil
+				}
+				return nil, nil
+			},
+		},
+	}
+}
+
+// setupSystemWith runs Setup System, skipping or forcing its steps as
+// modes says.
+func setupSystemWith(modes map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		logs, failed := runSteps("Setting up the system...", applyStepModes(setupSystemSteps(), modes))
+
+		logs = append(logs, "")
+		if len(failed) > 0 {
+			var names []string
+			for _, name := range failed {
+				names = append(names, T(name))
 			}
-		} else {
-			logs = append(logs, "Warning: Could not determine current user for group setup")
+			logs = append(logs, Tf("Failed steps (%d): %s", len(failed), strings.Join(names, ", ")))
+			return statusMsg{status: strings.Join(logs, "\n"), err: fmt.Errorf("%d setup steps failed", len(failed))}
 		}
+		logs = append(logs, T("System setup complete. You may need to log out and back in for group changes to take effect."))
+		logs = append(logs, "")
+		logs = append(logs, T("To start niri, switch to a TTY (Ctrl+Alt+F2) and run:"))
+		logs = append(logs, "  "+startCommand())
+
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// drmModuleLoaded reports whether drm is loaded and in kld_list.
+func drmModuleLoaded() bool {
+	loaded, err := loadedModules()
+	return err == nil && loaded["drm"] && kldList()["drm"]
+}
 
-		// Step 3: Load DRM kernel module if not loaded
-		cmd := exec.Command("sudo", "kldload", "drm")
-		out, err := cmd.CombinedOutput()
+// enableService enables and starts an rc.d service. A service that is
+// already running is fine.
+func enableService(name string) ([]string, error) {
+	var lines []string
+	var failed error
+	for _, step := range []struct {
+		desc string
+		cmd  []string
+	}{
+		{Tf("Enabling %s service", name), []string{"sudo", "sysrc", name + "_enable=YES"}},
+		{Tf("Starting %s service", name), []string{"sudo", "service", name, "start"}},
+	} {
+		cmd := exec.Command(step.cmd[0], step.cmd[1:]...)
+		out, err := commandCombinedOutput(cmd)
 		if err != nil {
 			outStr := string(out)
-			if strings.Contains(outStr, "already loaded") || strings.Contains(outStr, "module already loaded") {
-				logs = append(logs, "Loading DRM kernel module: already loaded")
+			if !strings.Contains(outStr, "already running") {
+				lines = append(lines, Tf("Warning: %s: %s", step.desc, outStr))
+				failed = err
 			} else {
-				logs = append(logs, fmt.Sprintf("Warning: Loading DRM kernel module: %s", outStr))
+				lines = append(lines, Tf("%s: already running", step.desc))
 			}
 		} else {
-			logs = append(logs, "Loading DRM kernel module: OK")
+			lines = append(lines, Tf("%s: OK", step.desc))
 		}
+	}
+	return lines, failed
+}
 
-		// Step 4: Ensure drm is loaded at boot
-		cmd = exec.Command("sudo", "sysrc", "kld_list+=drm")
-		out, err = cmd.CombinedOutput()
+// setupProfile sets XDG_RUNTIME_DIR, LIBSEAT_BACKEND and the XDG base
+// directories in ~/.profile.
+func setupProfile() ([]string, error) {
+	var logs []string
+	var failed error
+	// Set up XDG_RUNTIME_DIR via pam_xdg or profile
+	homeDir, _ := userHomeDir()
+	profilePath := filepath.Join(homeDir, ".profile")
+
+	// Check if already in .profile
+	profileContent, err := os.ReadFile(profilePath)
+	profileStr := string(profileContent)
+	if err != nil || !strings.Contains(profileStr, "XDG_RUNTIME_DIR") {
+		err := appendFile(profilePath, profileRuntimeDir())
 		if err != nil {
-			logs = append(logs, fmt.Sprintf("Warning: Persisting DRM module to boot: %s", string(out)))
+			logs = append(logs, Tf("Warning: Could not write to %s: %v", profilePath, err))
+			failed = err
+		} else if pamXDGConfigured() {
+			logs = append(logs, Tf("pam_xdg sets XDG_RUNTIME_DIR at login, noted in %s: OK", profilePath))
 		} else {
-			logs = append(logs, "Persisting DRM module to boot: OK")
+			logs = append(logs, Tf("Added XDG_RUNTIME_DIR to %s: OK", profilePath))
+			// Re-read for next check
+			profileContent, _ = os.ReadFile(profilePath)
+			profileStr = string(profileContent)
 		}
+	} else {
+		logs = append(logs, T("XDG_RUNTIME_DIR already in .profile: OK"))
+	}
 
-		// Step 5: Set up XDG_RUNTIME_DIR via pam_xdg or profile
-		homeDir, _ := os.UserHomeDir()
-		profilePath := filepath.Join(homeDir, ".profile")
-		xdgLine := fmt.Sprintf("export XDG_RUNTIME_DIR=/tmp/%d-runtime-dir", os.Geteuid())
-
-		// Check if already in .profile
-		profileContent, err := os.ReadFile(profilePath)
-		profileStr := string(profileContent)
-		if err != nil || !strings.Contains(profileStr, "XDG_RUNTIME_DIR") {
-			f, err := os.OpenFile(profilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				logs = append(logs, fmt.Sprintf("Warning: Could not write to %s: %v", profilePath, err))
-			} else {
-				f.WriteString("\n# Set XDG_RUNTIME_DIR for Wayland compositors\n")
-				f.WriteString(xdgLine + "\n")
-				f.Close()
-				logs = append(logs, fmt.Sprintf("Added XDG_RUNTIME_DIR to %s: OK", profilePath))
-				// Re-read for next check
-				profileContent, _ = os.ReadFile(profilePath)
-				profileStr = string(profileContent)
-			}
+	// Set LIBSEAT_BACKEND for ConsoleKit2 session management
+	if !strings.Contains(profileStr, "LIBSEAT_BACKEND") {
+		if err := appendFile(profilePath, "export LIBSEAT_BACKEND=consolekit2\n"); err != nil {
+			logs = append(logs, Tf("Warning: Could not write to %s: %v", profilePath, err))
+			failed = err
 		} else {
-			logs = append(logs, "XDG_RUNTIME_DIR already in .profile: OK")
+			logs = append(logs, T("Added LIBSEAT_BACKEND=consolekit2 to .profile: OK"))
 		}
+	} else {
+		logs = append(logs, T("LIBSEAT_BACKEND already in .profile: OK"))
+	}
 
-		// Step 5b: Set LIBSEAT_BACKEND for ConsoleKit2 session management
-		if !strings.Contains(profileStr, "LIBSEAT_BACKEND") {
-			f, err := os.OpenFile(profilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				logs = append(logs, fmt.Sprintf("Warning: Could not write to %s: %v", profilePath, err))
-			} else {
-				f.WriteString("export LIBSEAT_BACKEND=consolekit2\n")
-				f.Close()
-				logs = append(logs, "Added LIBSEAT_BACKEND=consolekit2 to .profile: OK")
-			}
-		} else {
-			logs = append(logs, "LIBSEAT_BACKEND already in .profile: OK")
-		}
+	// Define the XDG base directories
+	logs = append(logs, setupXDGBaseDirs(homeDir, profilePath)...)
+	return logs, failed
+}
 
-		// Step 6: Verify DRM render device is accessible
-		renderDev := findRenderDevice()
-		if renderDev != "" {
-			logs = append(logs, fmt.Sprintf("Found DRM render device: %s", renderDev))
-			// Check if the device is readable by the current user
-			f, err := os.Open(renderDev)
-			if err != nil {
-				logs = append(logs, fmt.Sprintf("Warning: Cannot access %s: %v (check video group membership)", renderDev, err))
-			} else {
-				f.Close()
-				logs = append(logs, fmt.Sprintf("DRM render device %s is accessible: OK", renderDev))
-			}
-		} else {
-			logs = append(logs, "Warning: No DRM render device found in /dev/dri/")
-			logs = append(logs, "  GPU drivers may not be loaded. Check that drm and your GPU kernel module are loaded.")
-		}
+// findConfigTemplate locates the config.kdl template shipped with
+// NiriSetup, next to the executable or in the current directory.
+func findConfigTemplate() (string, error) {
+	// Determine the source config.kdl path (same directory as the executable)
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", errors.New("Failed to determine executable path")
+	}
+	srcConfig := filepath.Join(filepath.Dir(exePath), "config.kdl")
 
-		logs = append(logs, "")
-		logs = append(logs, "System setup complete. You may need to log out and back in for group changes to take effect.")
-		logs = append(logs, "")
-		logs = append(logs, "To start niri, switch to a TTY (Ctrl+Alt+F2) and run:")
-		logs = append(logs, "  LIBSEAT_BACKEND=consolekit2 ck-launch-session dbus-launch niri --session")
+	// Fall back to current working directory
+	if _, err := os.Stat(srcConfig); os.IsNotExist(err) {
+		cwd, _ := os.Getwd()
+		srcConfig = filepath.Join(cwd, "config.kdl")
+	}
 
-		return statusMsg{status: strings.Join(logs, "\n")}
+	if _, err := os.Stat(srcConfig); os.IsNotExist(err) {
+		return "", errors.New("config.kdl not found next to executable or in current directory")
 	}
+	return srcConfig, nil
 }
 
+// configureNiri writes the config template, or applies the dotfiles, and
+// then runs the post-configure hook.
 func configureNiri() tea.Cmd {
-	return func() tea.Msg {
-		homeDir, err := os.UserHomeDir()
+	return withHooks("", "post-configure", func() tea.Msg {
+		if d := loadDotfiles(); d != nil {
+			return applyDotfilesCmd(d)()
+		}
+
+		homeDir, err := userHomeDir()
 		if err != nil {
-			return statusMsg{status: "Failed to determine home directory", err: err}
+			return statusMsg{status: T("Failed to determine home directory"), err: preflightFailure(err)}
 		}
 
 		// Create ~/.config/niri directory
 		niriConfigDir := filepath.Join(homeDir, ".config", "niri")
 		if err := os.MkdirAll(niriConfigDir, 0755); err != nil {
-			return statusMsg{status: fmt.Sprintf("Failed to create config directory: %v", err), err: err}
-		}
-
-		// Determine the source config.kdl path (same directory as the executable)
-		exePath, err := os.Executable()
-		if err != nil {
-			return statusMsg{status: "Failed to determine executable path", err: err}
-		}
-		srcConfig := filepath.Join(filepath.Dir(exePath), "config.kdl")
-
-		// Fall back to current working directory
-		if _, err := os.Stat(srcConfig); os.IsNotExist(err) {
-			cwd, _ := os.Getwd()
-			srcConfig = filepath.Join(cwd, "config.kdl")
-		}
-
-		if _, err := os.Stat(srcConfig); os.IsNotExist(err) {
-			return statusMsg{status: "config.kdl not found next to executable or in current directory", err: err}
+			return statusMsg{status: Tf("Failed to create config directory: %v", err), err: preflightFailure(err)}
 		}
 
 		// Copy config.kdl to ~/.config/niri/config.kdl
-		srcData, err := os.ReadFile(srcConfig)
+		configStr, renderDev, err := niriConfigTemplate()
 		if err != nil {
-			return statusMsg{status: fmt.Sprintf("Failed to read source config: %v", err), err: err}
+			return statusMsg{status: err.Error(), err: preflightFailure(err)}
 		}
 
-		// Detect DRM render device and add debug config if found
-		configStr := string(srcData)
-		renderDev := findRenderDevice()
-		if renderDev != "" && !strings.Contains(configStr, "render-drm-device") {
-			debugBlock := fmt.Sprintf("\n// Explicitly set the DRM render device for EGL display creation.\ndebug {\n    render-drm-device \"%s\"\n}\n", renderDev)
-			configStr += debugBlock
-		}
+		// Create ~/Pictures and the other user directories, where the
+		// template saves screenshots.
+		dirLogs := setupUserDirs(homeDir)
 
 		destConfig := filepath.Join(niriConfigDir, "config.kdl")
-		if err := os.WriteFile(destConfig, []byte(configStr), 0644); err != nil {
-			return statusMsg{status: fmt.Sprintf("Failed to write config: %v", err), err: err}
+		if err := writeNiriConfig(destConfig, configStr); err != nil {
+			return statusMsg{status: Tf("Failed to write config: %v", err), err: err}
 		}
 
-		msg := fmt.Sprintf("Niri configuration copied to %s", destConfig)
+		msg := Tf("Niri configuration copied to %s", destConfig)
+		msg += "\n" + strings.Join(dirLogs, "\n")
 		if renderDev != "" {
-			msg += fmt.Sprintf("\nDRM render device set to: %s", renderDev)
+			msg += "\n" + Tf("DRM render device set to: %s", renderDev)
 		}
-		msg += "\n\nTo start niri, switch to a TTY (Ctrl+Alt+F2) and run:"
-		msg += "\n  LIBSEAT_BACKEND=consolekit2 ck-launch-session dbus-launch niri --session"
+		msg += "\n\n" + T("To start niri, switch to a TTY (Ctrl+Alt+F2) and run:")
+		msg += "\n  " + startCommand()
 		return statusMsg{status: msg}
+	})
+}
+
+// niriConfigTemplate reads the config template and pins this machine's DRM
+// render device in it. It also returns the render device, if one was found.
+func niriConfigTemplate() (string, string, error) {
+	srcConfig, err := findConfigTemplate()
+	if err != nil {
+		return "", "", errors.New(T(err.Error()))
+	}
+	srcData, err := os.ReadFile(srcConfig)
+	if err != nil {
+		return "", "", errors.New(Tf("Failed to read source config: %v", err))
+	}
+
+	// Detect DRM render device and add debug config if found
+	configStr := string(srcData)
+	renderDev := findRenderDevice()
+	if renderDev != "" && !strings.Contains(configStr, "render-drm-device") {
+		debugBlock := fmt.Sprintf("\n// Explicitly set the DRM render device for EGL display creation.\ndebug {\n    render-drm-device \"%s\"\n}\n", renderDev)
+		configStr += debugBlock
+	}
+	if homeDir, err := userHomeDir(); err == nil {
+		configStr = setScreenshotPath(configStr, homeDir)
 	}
+	return configStr, renderDev, nil
 }
 
 func validateNiriConfig() tea.Cmd {
 	return func() tea.Msg {
 		cmd := exec.Command("niri", "validate")
-		out, err := cmd.CombinedOutput()
+		out, err := commandCombinedOutput(cmd)
+		if errors.Is(err, exec.ErrNotFound) {
+			return statusMsg{status: Tf("Validation failed: %s", err), err: preflightFailure(err)}
+		}
 		if err != nil {
-			return statusMsg{status: fmt.Sprintf("Validation failed: %s", string(out)), err: err}
+			return statusMsg{status: Tf("Validation failed: %s", string(out)), err: validationFailure(err)}
 		}
-		return statusMsg{status: "Niri configuration is valid."}
+		return statusMsg{status: T("Niri configuration is valid.")}
 	}
 }
 
 func saveLogsToFile(m model) tea.Cmd {
 	return func() tea.Msg {
-		logFile := filepath.Join(os.TempDir(), "nirisetup.log")
+		logFile := logFilePath()
 		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
-			return statusMsg{status: "Failed to open log file for writing", err: err}
+			return statusMsg{status: T("Failed to open log file for writing"), err: err}
 		}
 		defer file.Close()
 
 		for _, log := range m.logs {
 			if _, err := file.WriteString(log + "\n"); err != nil {
-				return statusMsg{status: "Failed to write to log file", err: err}
+				return statusMsg{status: T("Failed to write to log file"), err: err}
 			}
 		}
-		return statusMsg{status: fmt.Sprintf("Logs saved to %s", logFile)}
+		return statusMsg{status: Tf("Logs saved to %s", logFile)}
 	}
 }
 
+// setupEnvironment points XDG_RUNTIME_DIR at the runtime directory of the
+// user running NiriSetup, creating it if needed. A directory left behind
+// with the wrong owner or mode only gets a warning: Doctor can repair it.
+// With pam_xdg the login has set it up already.
 func setupEnvironment() {
 	// Get the current user's ID
 	userID := os.Geteuid()
 
+	if pamXDGConfigured() {
+		if os.Getenv("XDG_RUNTIME_DIR") == "" {
+			setenv("XDG_RUNTIME_DIR", fmt.Sprintf("/var/run/user/%d", userID))
+		}
+		return
+	}
+
 	// Construct the runtime directory path using the user ID
 	runtimeDir := fmt.Sprintf("/tmp/%d-runtime-dir", userID)
 
 	// Set the XDG_RUNTIME_DIR environment variable
-	os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	setenv("XDG_RUNTIME_DIR", runtimeDir)
 
-	// Check if the directory exists, if not create it
-	if _, err := os.Stat(runtimeDir); os.IsNotExist(err) {
+	info, err := os.Stat(runtimeDir)
+	if os.IsNotExist(err) {
 		// Create the directory with 0700 permissions to ensure it's secure
 		if err := os.Mkdir(runtimeDir, 0700); err != nil {
-			log.Fatalf("Failed to create runtime directory: %v", err)
-		}
-	} else {
-		// Check if the existing directory is owned by the current user
-		info, err := os.Stat(runtimeDir)
-		if err != nil {
-			log.Fatalf("Failed to stat runtime directory: %v", err)
-		}
-
-		// Get the owner UID of the existing directory
-		stat, ok := info.Sys().(*syscall.Stat_t)
-		if !ok {
-			log.Fatalf("Failed to get ownership information of runtime directory")
-		}
-
-		if stat.Uid != uint32(userID) {
-			log.Fatalf("XDG_RUNTIME_DIR '%s' is owned by UID %d, not our UID %d", runtimeDir, stat.Uid, userID)
+			fmt.Fprintln(os.Stderr, Tf("Warning: Failed to create runtime directory: %v", err))
 		}
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, Tf("Warning: Failed to stat runtime directory: %v", err))
+		return
+	}
+	problem := ""
+	switch stat, ok := info.Sys().(*syscall.Stat_t); {
+	case ok && stat.Uid != uint32(userID):
+		problem = Tf("%s belongs to UID %d, not to you", runtimeDir, stat.Uid)
+	case info.Mode().Perm() != 0700:
+		problem = Tf("%s has mode %o instead of 700", runtimeDir, info.Mode().Perm())
+	}
+	if problem != "" {
+		tracef("XDG_RUNTIME_DIR: %s", problem)
+		fmt.Fprintln(os.Stderr, Tf("Warning: XDG_RUNTIME_DIR is unusable: %s. Doctor can repair it.", problem))
 	}
 }
 
 func main() {
-	setupEnvironment()
-	p := tea.NewProgram(initialModel())
-	if err := p.Start(); err != nil {
-		log.Fatalf("Alas, there's been an error: %v", err)
+	if err := newRootCmd().Execute(); err != nil {
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		// Usage errors: nothing ran.
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitPreflight)
 	}
 }
-
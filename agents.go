@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sshAgentScript starts ssh-agent on the socket given as its argument. The
+// socket path is fixed so that config.kdl can export it, which means the
+// agent and socket of the previous session have to go first.
+const sshAgentScript = `#!/bin/sh
+# Generated by NiriSetup: start ssh-agent for the niri session.
+pkill -u "$(id -u)" -f "ssh-agent -D -a $1"
+rm -f "$1"
+exec ssh-agent -D -a "$1"
+`
+
+// pinentries are the Wayland-friendly gpg-agent password prompts, by
+// package.
+var pinentries = map[string]string{
+	"pinentry-gnome": "/usr/local/bin/pinentry-gnome3",
+	"pinentry-qt5":   "/usr/local/bin/pinentry-qt5",
+}
+
+func newAgentsForm() *form {
+	return &form{
+		title: "SSH and GPG Agents",
+		fields: []formField{
+			{label: "SSH agent", value: "ssh-agent", options: []string{"ssh-agent", "gpg-agent", "None"},
+				help: "Keeps your unlocked SSH keys for the session and exports SSH_AUTH_SOCK to the programs niri starts. gpg-agent can serve SSH keys too, including ones on a smartcard."},
+			{label: "GPG pinentry", value: "pinentry-gnome", options: []string{"pinentry-gnome", "pinentry-qt5", "Unchanged"},
+				help: "The password prompt gpg-agent shows. The default curses and GTK 2 prompts don't work in niri: they need a terminal or X11."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			return setupAgents(values[0], values[1]), nil
+		},
+	}
+}
+
+// setConfLine sets the directive name in a gpg-agent.conf style file to
+// line, replacing an existing one or appending it.
+func setConfLine(conf, name, line string) string {
+	lines := strings.Split(strings.TrimRight(conf, "\n"), "\n")
+	for i, l := range lines {
+		if fields := strings.Fields(l); len(fields) > 0 && fields[0] == name {
+			lines[i] = line
+			return strings.Join(lines, "\n") + "\n"
+		}
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+	return strings.Join(append(lines, line), "\n") + "\n"
+}
+
+// setupAgents starts the chosen SSH agent with niri, exports its socket in
+// the environment block and gives gpg-agent a pinentry that works on
+// Wayland.
+func setupAgents(sshAgent, pinentry string) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		fail := func(err error) tea.Msg {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+
+		script := filepath.Join(homeDir, ".local", "bin", "nirisetup-ssh-agent")
+
+		pkgs := []string{"gnupg"}
+		if pinentry != "Unchanged" {
+			pkgs = append(pkgs, pinentry)
+		}
+		for _, pkg := range pkgs {
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return fail(err)
+			}
+		}
+
+		gnupgDir := filepath.Join(homeDir, ".gnupg")
+		confPath := filepath.Join(gnupgDir, "gpg-agent.conf")
+		conf, _ := os.ReadFile(confPath)
+		updated := string(conf)
+		if pinentry != "Unchanged" {
+			updated = setConfLine(updated, "pinentry-program", "pinentry-program "+pinentries[pinentry])
+		}
+		if sshAgent == "gpg-agent" {
+			updated = setConfLine(updated, "enable-ssh-support", "enable-ssh-support")
+		}
+		if updated != string(conf) {
+			if err := os.MkdirAll(gnupgDir, 0700); err != nil {
+				return fail(err)
+			}
+			chownToUser(gnupgDir)
+			if err := writeFile(confPath, []byte(updated), 0600); err != nil {
+				logs = append(logs, Tf("Failed to write %s: %v", confPath, err))
+				return fail(err)
+			}
+			logs = append(logs, Tf("Updated %s: OK", confPath))
+			// gpg-agent reads its config at start; the next gpg call starts it again.
+			runCommand(exec.Command("gpgconf", "--kill", "gpg-agent"))
+		}
+
+		var socket string
+		switch sshAgent {
+		case "ssh-agent":
+			socket = filepath.Join(runtimeDirPath(), "ssh-agent.socket")
+			if err := os.MkdirAll(filepath.Dir(script), 0755); err != nil {
+				return fail(err)
+			}
+			if err := writeFile(script, []byte(sshAgentScript), 0755); err != nil {
+				logs = append(logs, Tf("Failed to write %s: %v", script, err))
+				return fail(err)
+			}
+			logs = append(logs, Tf("Wrote %s: OK", script))
+			path, err := updateNiriConfig(func(cfg string) string {
+				cfg = setSpawnAtStartup(cfg, script, socket)
+				return setKDLNode(cfg, []string{"environment"}, "SSH_AUTH_SOCK "+kdlQuote(socket))
+			})
+			if err != nil {
+				logs = append(logs, Tf("Failed to update config: %v", err))
+				return fail(err)
+			}
+			logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "ssh-agent", path))
+		case "gpg-agent":
+			out, err := commandOutput(exec.Command("gpgconf", "--list-dirs", "agent-ssh-socket"))
+			if err != nil {
+				logs = append(logs, Tf("gpgconf failed: %v", err))
+				return fail(err)
+			}
+			socket = strings.TrimSpace(string(out))
+			path, err := updateNiriConfig(func(cfg string) string {
+				cfg = removeSpawnAtStartup(cfg, script)
+				cfg = setSpawnAtStartup(cfg, "gpgconf", "--launch", "gpg-agent")
+				return setKDLNode(cfg, []string{"environment"}, "SSH_AUTH_SOCK "+kdlQuote(socket))
+			})
+			if err != nil {
+				logs = append(logs, Tf("Failed to update config: %v", err))
+				return fail(err)
+			}
+			logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "gpg-agent", path))
+		}
+		if socket != "" {
+			logs = append(logs, Tf("SSH_AUTH_SOCK=%s", socket))
+			logs = append(logs, T("Log out and back in, then add your keys with ssh-add."))
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// animationNames are the individual animations niri lets you configure.
+var animationNames = []string{
+	"workspace-switch",
+	"window-open",
+	"window-close",
+	"horizontal-view-movement",
+	"window-movement",
+	"window-resize",
+	"config-notification-open-close",
+	"screenshot-ui-open",
+	"overview-open-close",
+}
+
+// animationStyles are the choices per animation. "default" leaves the
+// animation to niri, the ease-out and linear entries are easing curves.
+// An animation set up in a way the editor doesn't offer, such as a
+// cubic-bezier curve or a custom shader, shows as "custom" and is kept as
+// it is unless another style is picked.
+var animationStyles = []string{"default", "off", "spring", "ease-out-quad", "ease-out-cubic", "ease-out-expo", "linear"}
+
+// defaultEasingDuration is the easing duration offered when no animation
+// in the config uses an easing curve.
+const defaultEasingDuration = "250"
+
+// isEasingStyle reports whether style is one of the easing curves.
+func isEasingStyle(style string) bool {
+	return strings.HasPrefix(style, "ease-out-") || style == "linear"
+}
+
+// animationChildren returns the names of the nodes inside the block of
+// the named animation, and whether the config has the animation at all.
+func animationChildren(cfg, name string) ([]string, bool) {
+	if _, ok := kdlGet(cfg, []string{"animations"}, name); !ok {
+		return nil, false
+	}
+	from, to, ok := kdlLocate(cfg, []string{"animations", name})
+	if !ok {
+		return nil, true
+	}
+	var names []string
+	for _, sp := range kdlChildren(cfg, from, to) {
+		names = append(names, kdlNodeName(cfg, sp))
+	}
+	return names, true
+}
+
+// currentAnimationStyle works out which of animationStyles the config uses
+// for the named animation, or "custom" if it is none of them.
+func currentAnimationStyle(cfg, name string) string {
+	children, ok := animationChildren(cfg, name)
+	if !ok {
+		return "default"
+	}
+	path := []string{"animations", name}
+	switch {
+	case len(children) == 1 && children[0] == "off":
+		return "off"
+	case len(children) == 1 && children[0] == "spring":
+		// The spring's own parameters are kept when it stays a spring.
+		return "spring"
+	}
+	for _, child := range children {
+		if child != "curve" && child != "duration-ms" {
+			return "custom"
+		}
+	}
+	if curve, ok := kdlGet(cfg, path, "curve"); ok {
+		curve = kdlUnquote(curve)
+		for _, s := range animationStyles {
+			if s == curve && isEasingStyle(s) {
+				return s
+			}
+		}
+	}
+	return "custom"
+}
+
+// currentEasingDuration returns the duration of the first animation that
+// uses one of the easing curves.
+func currentEasingDuration(cfg string) string {
+	for _, name := range animationNames {
+		if isEasingStyle(currentAnimationStyle(cfg, name)) {
+			if ms, ok := kdlGet(cfg, []string{"animations", name}, "duration-ms"); ok {
+				return ms
+			}
+		}
+	}
+	return defaultEasingDuration
+}
+
+// newAnimationsForm prefills the animation editor from the current config.
+// Only the settings whose field is changed are written back.
+func newAnimationsForm() *form {
+	cfg := ""
+	if path, err := niriConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			cfg = string(data)
+		}
+	}
+
+	enabled := "On"
+	if _, off := kdlGet(cfg, []string{"animations"}, "off"); off {
+		enabled = "Off"
+	}
+	slowdown, ok := kdlGet(cfg, []string{"animations"}, "slowdown")
+	if !ok {
+		slowdown = "1.0"
+	}
+
+	fields := []formField{
+		{label: "Animations", value: enabled, options: []string{"On", "Off"}},
+		{label: "Slowdown factor", value: slowdown},
+		{label: "Easing duration (ms)", value: currentEasingDuration(cfg)},
+	}
+	for _, name := range animationNames {
+		style := currentAnimationStyle(cfg, name)
+		options := animationStyles
+		if style == "custom" {
+			options = append(append([]string{}, animationStyles...), "custom")
+		}
+		fields = append(fields, formField{label: name, value: style, options: options})
+	}
+	initial := make([]string, len(fields))
+	for i, f := range fields {
+		initial[i] = f.value
+	}
+	return &form{title: "Animations", fields: fields, submit: func(values []string) (tea.Cmd, error) {
+		return submitAnimations(initial, values)
+	}}
+}
+
+func submitAnimations(initial, values []string) (tea.Cmd, error) {
+	slowdown, err := strconv.ParseFloat(strings.TrimSpace(values[1]), 64)
+	if err != nil || slowdown <= 0 || slowdown > 20 {
+		return nil, errors.New(T("slowdown must be a number above 0 and at most 20"))
+	}
+	duration, err := strconv.Atoi(strings.TrimSpace(values[2]))
+	if err != nil || duration < 10 || duration > 5000 {
+		return nil, errors.New(T("easing duration must be between 10 and 5000 ms"))
+	}
+	changed := make([]bool, len(values))
+	for i := range values {
+		changed[i] = strings.TrimSpace(values[i]) != strings.TrimSpace(initial[i])
+	}
+	return writeAnimations(values[0] == "Off", slowdown, duration, values[3:], changed), nil
+}
+
+// animationNode renders the config node for one animation in the chosen
+// style.
+func animationNode(name, style string, duration int) string {
+	switch style {
+	case "off":
+		return name + " {\n    off\n}"
+	case "spring":
+		return name + " {\n    spring damping-ratio=1.0 stiffness=800 epsilon=0.0001\n}"
+	}
+	return fmt.Sprintf("%s {\n    duration-ms %d\n    curve %s\n}", name, duration, kdlQuote(style))
+}
+
+// writeAnimations updates the animations block with the editor values.
+// changed tells which fields were edited, in the order of the form;
+// settings whose field was left alone stay as they are, except that a new
+// easing duration applies to every animation that uses an easing curve.
+func writeAnimations(off bool, slowdown float64, duration int, styles []string, changed []bool) tea.Cmd {
+	return func() tea.Msg {
+		path, err := updateNiriConfig(func(cfg string) string {
+			block := []string{"animations"}
+			if changed[0] {
+				if off {
+					cfg = setKDLNode(cfg, block, "off")
+				} else {
+					cfg = removeKDLNode(cfg, block, "off")
+				}
+			}
+			if changed[1] {
+				if slowdown == 1 {
+					cfg = removeKDLNode(cfg, block, "slowdown")
+				} else {
+					cfg = setKDLNode(cfg, block, "slowdown "+strconv.FormatFloat(slowdown, 'f', -1, 64))
+				}
+			}
+			for i, name := range animationNames {
+				style := styles[i]
+				switch {
+				case style == "custom":
+					continue
+				case changed[3+i]:
+				case changed[2] && isEasingStyle(style):
+				default:
+					continue
+				}
+				if style == "default" {
+					cfg = removeKDLNode(cfg, block, name)
+				} else {
+					cfg = setKDLNode(cfg, block, animationNode(name, style, duration))
+				}
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write animation settings: %v", err), err: err}
+		}
+		return statusMsg{status: Tf("Animation settings written to %s", path)}
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ansiblePlaybook is the playbook equivalent of Install Niri, Setup System
+// and Configure Niri. The first %s is the list of components, the second
+// the list of packages and the third the tasks the components add.
+const ansiblePlaybook = `# Generated by NiriSetup. Sets up the niri Wayland compositor on GhostBSD.
+#
+# Needs the community.general collection. Run it with e.g.
+#   ansible-playbook -i hosts nirisetup.yml -e niri_user=alice
+#
+# Unlike NiriSetup, this doesn't pin a DRM render device in the config, as
+# that depends on each host's GPU.
+#
+# Components: %s
+- name: Set up niri
+  hosts: all
+  become: true
+  vars:
+    # With become, ansible_user_id is root; set niri up for the user who
+    # ran sudo, or the one Ansible logs in as, unless -e niri_user= is given.
+    niri_user: "{{ ansible_env.SUDO_USER | default(ansible_user | default('')) }}"
+    niri_home: "/home/{{ niri_user }}"
+
+  tasks:
+    - name: Check the user niri is set up for
+      ansible.builtin.assert:
+        that: niri_user not in ['', 'root']
+        fail_msg: "Pass the user to set niri up for with -e niri_user=<name>"
+
+    - name: Install niri and the desktop packages
+      community.general.pkgng:
+        name:
+%s        state: present
+
+    - name: Enable dbus and seatd
+      community.general.sysrc:
+        name: "{{ item }}_enable"
+        value: "YES"
+      loop: [dbus, seatd]
+
+    - name: Start dbus and seatd
+      ansible.builtin.service:
+        name: "{{ item }}"
+        state: started
+      loop: [dbus, seatd]
+
+    - name: Add the user to the video group
+      ansible.builtin.user:
+        name: "{{ niri_user }}"
+        groups: video
+        append: true
+
+    - name: Load the DRM kernel module
+      ansible.builtin.command: kldload -n drm
+      changed_when: false
+
+    - name: Load the DRM kernel module at boot
+      community.general.sysrc:
+        name: kld_list
+        value: drm
+        state: value_present
+
+    - name: Set XDG_RUNTIME_DIR in .profile
+      ansible.builtin.lineinfile:
+        path: "{{ niri_home }}/.profile"
+        regexp: "^export XDG_RUNTIME_DIR="
+        line: "export XDG_RUNTIME_DIR=/tmp/$(id -u)-runtime-dir"
+        create: true
+        owner: "{{ niri_user }}"
+        mode: "0644"
+
+    - name: Set the XDG base directories in .profile
+      ansible.builtin.lineinfile:
+        path: "{{ niri_home }}/.profile"
+        regexp: "^export {{ item.name }}="
+        line: 'export {{ item.name }}="$HOME/{{ item.dir }}"'
+        create: true
+        owner: "{{ niri_user }}"
+        mode: "0644"
+      loop:
+        - { name: XDG_CONFIG_HOME, dir: .config }
+        - { name: XDG_DATA_HOME, dir: .local/share }
+        - { name: XDG_CACHE_HOME, dir: .cache }
+        - { name: XDG_STATE_HOME, dir: .local/state }
+
+    - name: Set LIBSEAT_BACKEND in .profile
+      ansible.builtin.lineinfile:
+        path: "{{ niri_home }}/.profile"
+        regexp: "^export LIBSEAT_BACKEND="
+        line: "export LIBSEAT_BACKEND=consolekit2"
+        create: true
+        owner: "{{ niri_user }}"
+        mode: "0644"
+
+    - name: Create the niri config directory
+      ansible.builtin.file:
+        path: "{{ niri_home }}/.config/niri"
+        state: directory
+        owner: "{{ niri_user }}"
+        mode: "0755"
+
+    - name: Install the niri config
+      ansible.builtin.copy:
+        src: config.kdl
+        dest: "{{ niri_home }}/.config/niri/config.kdl"
+        owner: "{{ niri_user }}"
+        mode: "0644"
+%s`
+
+// ansibleFontconfigTask installs the fontconfig fallbacks of the CJK and
+// emoji fonts component, which files/fontconfig.conf holds.
+const ansibleFontconfigTask = `
+    - name: Create the fontconfig directory
+      ansible.builtin.file:
+        path: "{{ niri_home }}/.config/fontconfig/conf.d"
+        state: directory
+        owner: "{{ niri_user }}"
+        mode: "0755"
+
+    - name: Install the font fallbacks
+      ansible.builtin.copy:
+        src: fontconfig.conf
+        dest: "{{ niri_home }}/.config/fontconfig/conf.d/60-nirisetup-fallbacks.conf"
+        owner: "{{ niri_user }}"
+        mode: "0644"
+`
+
+// ansibleComponents are what the wizard components add to the playbook:
+// their packages and the changes to config.kdl that don't depend on the
+// host. What does, like the lid switch and the suspend rule of Laptop power
+// or the key that toggles the on-screen keyboard, is left to NiriSetup.
+var ansibleComponents = map[string]struct {
+	packages []string
+	config   func(cfg string) string
+}{
+	"Function keys": {[]string{"brightnessctl"}, func(cfg string) string {
+		for _, bind := range []string{
+			`XF86MonBrightnessUp allow-when-locked=true { spawn "brightnessctl" "set" "5%+"; }`,
+			`XF86MonBrightnessDown allow-when-locked=true { spawn "brightnessctl" "set" "5%-"; }`,
+			`XF86AudioRaiseVolume allow-when-locked=true { spawn "mixer" "vol.volume=+5%"; }`,
+			`XF86AudioLowerVolume allow-when-locked=true { spawn "mixer" "vol.volume=-5%"; }`,
+		} {
+			cfg = setKDLNode(cfg, []string{"binds"}, bind)
+		}
+		return cfg
+	}},
+	"Media keys": {[]string{"playerctl"}, func(cfg string) string {
+		for _, bind := range mediaKeyBinds {
+			cfg = setKDLNode(cfg, []string{"binds"}, bind)
+		}
+		return cfg
+	}},
+	"Laptop power": {nil, func(cfg string) string {
+		return setSpawnAtStartup(cfg, "swayidle", "-w", "timeout", "300", "swaylock -f", "before-sleep", "swaylock -f")
+	}},
+	"Qt Wayland plugins":  {[]string{qtToolkits[0].plugin, qtToolkits[1].plugin}, nil},
+	"OBS Studio":          {append([]string{"obs-studio"}, screenSharingPackages...), nil},
+	"Gaming":              {gamingPackages, gamingConfig},
+	"CJK and emoji fonts": {fontPackages, nil},
+	"On-screen keyboard":  {[]string{"wvkbd"}, nil},
+}
+
+// componentPackages returns the packages of components.
+func componentPackages(components []string) []string {
+	var packages []string
+	for _, name := range components {
+		for _, pkg := range ansibleComponents[name].packages {
+			if !containsString(packages, pkg) {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	return packages
+}
+
+// ansibleComponentNames returns the components of profile followed by
+// those named, or the components last chosen in the wizard when neither is
+// given.
+func ansibleComponentNames(profile string, names []string) ([]string, error) {
+	if profile == "" && len(names) == 0 {
+		for _, name := range strings.Split(loadSettings()["wizard_components"], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+	var chosen []string
+	if profile != "" {
+		found := false
+		for _, p := range wizardProfiles {
+			if strings.EqualFold(p.name, profile) {
+				chosen, found = append(chosen, p.components...), true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown profile %q", profile)
+		}
+	}
+	for _, name := range names {
+		known := false
+		for _, c := range wizardComponents {
+			if strings.EqualFold(c.name, name) {
+				known = true
+				if !containsString(chosen, c.name) {
+					chosen = append(chosen, c.name)
+				}
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown component %q", name)
+		}
+	}
+	return chosen, nil
+}
+
+// exportAnsible writes nirisetup.yml and files/config.kdl to dir, laid out
+// so ansible-playbook finds the config next to the playbook, with the
+// packages and config of components added.
+func exportAnsible(dir string, components []string) tea.Cmd {
+	return func() tea.Msg {
+		template, err := findConfigTemplate()
+		if err != nil {
+			return statusMsg{status: T(err.Error()), err: preflightFailure(err)}
+		}
+		config, err := os.ReadFile(template)
+		if err != nil {
+			return statusMsg{status: Tf("Failed to read source config: %v", err), err: preflightFailure(err)}
+		}
+
+		packages := withExtraPackages(componentPackages(components))
+		cfg := string(config)
+		var tasks string
+		fonts := containsString(components, "CJK and emoji fonts")
+		if fonts {
+			tasks = ansibleFontconfigTask
+		}
+		for _, name := range components {
+			if c := ansibleComponents[name]; c.config != nil {
+				cfg = c.config(cfg)
+			}
+		}
+		var pkgs strings.Builder
+		for _, pkg := range packages {
+			fmt.Fprintf(&pkgs, "          - %s\n", pkg)
+		}
+		listed := strings.Join(components, ", ")
+		if listed == "" {
+			listed = "none"
+		}
+
+		playbook := filepath.Join(dir, "nirisetup.yml")
+		files := filepath.Join(dir, "files")
+		if err := os.MkdirAll(files, 0755); err != nil {
+			return statusMsg{status: Tf("Failed to create %s: %v", files, err), err: err}
+		}
+		if err := writeFile(playbook, []byte(fmt.Sprintf(ansiblePlaybook, listed, pkgs.String(), tasks)), 0644); err != nil {
+			return statusMsg{status: Tf("Failed to write %s: %v", playbook, err), err: err}
+		}
+		if err := writeFile(filepath.Join(files, "config.kdl"), []byte(cfg), 0644); err != nil {
+			return statusMsg{status: Tf("Failed to write %s: %v", filepath.Join(files, "config.kdl"), err), err: err}
+		}
+		if fonts {
+			if err := writeFile(filepath.Join(files, "fontconfig.conf"), []byte(fontconfigFallbacks("SC")), 0644); err != nil {
+				return statusMsg{status: Tf("Failed to write %s: %v", filepath.Join(files, "fontconfig.conf"), err), err: err}
+			}
+		}
+		return statusMsg{status: Tf("Wrote Ansible playbook to %s", playbook)}
+	}
+}
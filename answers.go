@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// wizardAnswers pre-answers the first-run wizard for unattended runs. forms
+// holds the values for the form of each step, by the step's answers
+// section; steps whose section the file leaves out are skipped, as are the
+// steps skip names.
+type wizardAnswers struct {
+	path  string
+	skip  []string
+	forms map[string][]manifestVar
+}
+
+// answerKey turns a form label or a step name into the key an answer file
+// uses for it: "Focus ring width" is focus-ring-width.
+func answerKey(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "-")
+}
+
+// answerForms build the form each answers section fills in.
+var answerForms = map[string]func(w *wizard) *form{
+	"components": (*wizard).componentsForm,
+	"keyboard":   func(*wizard) *form { return newKeyboardForm() },
+	"layout":     func(*wizard) *form { return newLayoutForm() },
+}
+
+// readAnswers reads and checks the answer file at path.
+func readAnswers(path string) (*wizardAnswers, error) {
+	path = expandHome(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseAnswers(path, string(data))
+}
+
+// parseAnswers parses an answer file, which is written in the same subset
+// of YAML as manifests:
+//
+//	skip: [Install packages]
+//	profile: laptop
+//	components: [Function keys, Media keys]
+//	keyboard:
+//	  layout: us,de
+//	  options: grp:alt_shift_toggle
+//	layout:
+//	  gaps: 8
+//
+// profile and components are two ways of answering the same question, so
+// only one of them may be given. Every answer is checked against the form
+// it fills in, so that a typo stops the run before it changes anything.
+func parseAnswers(path, data string) (*wizardAnswers, error) {
+	a := &wizardAnswers{path: path, forms: map[string][]manifestVar{}}
+	steps := newWizard().steps
+	err := yamlTopLevel(path, data, func(key string, items []string, vars []manifestVar, line int) error {
+		switch key {
+		case "skip":
+			if vars != nil {
+				return fmt.Errorf("%s:%d: skip must be a list of steps", path, line)
+			}
+			for _, name := range items {
+				step, ok := wizardStepNamed(steps, name)
+				if !ok {
+					return fmt.Errorf("%s:%d: no wizard step is called %q", path, line, name)
+				}
+				a.skip = append(a.skip, step)
+			}
+		case "profile", "components":
+			if _, ok := a.forms["components"]; ok {
+				return fmt.Errorf("%s:%d: give either profile or components, not both", path, line)
+			}
+			if vars != nil || (key == "profile" && len(items) != 1) {
+				return fmt.Errorf("%s:%d: %s must be a list of components or the name of a profile", path, line, key)
+			}
+			a.forms["components"] = componentAnswers(key, items)
+		case "keyboard", "layout":
+			if items != nil {
+				return fmt.Errorf("%s:%d: %s must map settings to values", path, line, key)
+			}
+			a.forms[key] = vars
+		default:
+			return fmt.Errorf("%s:%d: unknown key %q, expected skip, profile, components, keyboard or layout", path, line, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for section := range a.forms {
+		// Submitting only checks the values; the command it returns isn't run.
+		f := answerForms[section](&wizard{})
+		if err := a.fill(section, f); err != nil {
+			return nil, err
+		}
+		if _, err := f.submit(f.values()); err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", path, section, err)
+		}
+	}
+	return a, nil
+}
+
+// componentAnswers turns a profile or a list of components into answers
+// for the components form.
+func componentAnswers(key string, items []string) []manifestVar {
+	if key == "profile" {
+		return []manifestVar{{"profile", items[0]}}
+	}
+	vars := []manifestVar{{"profile", "Custom"}}
+	for _, c := range wizardComponents {
+		value := "No"
+		for _, item := range items {
+			if answerKey(item) == answerKey(c.name) {
+				value = "Yes"
+			}
+		}
+		vars = append(vars, manifestVar{c.name, value})
+	}
+	for _, item := range items {
+		// An unknown component is reported by fill.
+		known := false
+		for _, c := range wizardComponents {
+			known = known || answerKey(item) == answerKey(c.name)
+		}
+		if !known {
+			vars = append(vars, manifestVar{item, "Yes"})
+		}
+	}
+	return vars
+}
+
+// wizardStepNamed returns the name of the step called name, in any case.
+func wizardStepNamed(steps []wizardStep, name string) (string, bool) {
+	for _, s := range steps {
+		if answerKey(s.name) == answerKey(name) {
+			return s.name, true
+		}
+	}
+	return "", false
+}
+
+// fill sets the fields of f to the answers of section. Fields are named by
+// their label, and option fields take one of their options in any case.
+func (a *wizardAnswers) fill(section string, f *form) error {
+	for _, v := range a.forms[section] {
+		var field *formField
+		var names []string
+		for i := range f.fields {
+			names = append(names, answerKey(f.fields[i].label))
+			if answerKey(f.fields[i].label) == answerKey(v.name) {
+				field = &f.fields[i]
+			}
+		}
+		if field == nil {
+			return fmt.Errorf("%s: %s has no %q, expected one of %s", a.path, section, v.name, strings.Join(names, ", "))
+		}
+		if len(field.options) == 0 {
+			field.value = v.value
+			continue
+		}
+		found := false
+		for _, opt := range field.options {
+			if strings.EqualFold(opt, v.value) {
+				field.value, found = opt, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %s %s must be one of %s, got %q", a.path, section, answerKey(v.name), strings.Join(field.options, ", "), v.value)
+		}
+	}
+	return nil
+}
+
+// skips reports whether the answers leave step out.
+func (a *wizardAnswers) skips(step wizardStep) bool {
+	if containsString(a.skip, step.name) {
+		return true
+	}
+	_, answered := a.forms[step.answers]
+	return step.answers != "" && !answered
+}
+
+// runAnswers runs the first-run wizard from the answer file at path,
+// without asking anything. The steps run through the model as they do when
+// the wizard is driven by hand, with the forms filled in from the file and
+// confirmations accepted. It stops at the first step that fails.
+func runAnswers(path string) error {
+	a, err := readAnswers(path)
+	if err != nil {
+		return finish("wizard", err.Error(), preflightFailure(err))
+	}
+	m := initialModel()
+	m.state = wizardView
+	m.wizard = newWizard()
+	w := m.wizard
+	p := &plainUI{m: m}
+	for {
+		step := &w.steps[w.current]
+		last := w.current == len(w.steps)-1
+		switch {
+		case p.m.state == formView:
+			if err := a.fill(step.answers, p.m.form); err != nil {
+				return finish("wizard", err.Error(), preflightFailure(err))
+			}
+			p.press("enter")
+			if p.m.state == formView {
+				err := errors.New(p.m.form.err)
+				return finish("wizard", Tf("%s: %v", T(step.name), err), preflightFailure(err))
+			}
+		case p.m.state == confirmView:
+			fmt.Println(T(p.m.confirm.prompt))
+			p.press("y")
+		case p.m.state != wizardView:
+			err := errors.New(Tf("%s asked a question the answer file can't answer", T(step.name)))
+			return finish("wizard", err.Error(), preflightFailure(err))
+		case step.status == stepFailed:
+			return finish("wizard", w.output, w.err)
+		case last && step.status == stepDone:
+			return finish("wizard", w.output, nil)
+		case !last && a.skips(*step):
+			fmt.Println(Tf("%s (skipped)", T(step.name)))
+			p.press("s")
+		default:
+			fmt.Println(T(step.name))
+			p.press("enter")
+		}
+		if p.m.state == wizardView && step.status == stepDone && !last {
+			fmt.Println(w.output)
+		}
+	}
+}
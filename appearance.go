@@ -0,0 +1,250 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// colorSchemes maps the choices of the Appearance screen to the
+// freedesktop color-scheme preference the settings portal hands out.
+var colorSchemes = map[string]string{
+	"Dark":    "prefer-dark",
+	"Light":   "prefer-light",
+	"Default": "default",
+}
+
+// setINIValue sets key in section of an INI style file, adding the section
+// or key as needed and keeping everything else. The empty section is the
+// part before the first section header.
+func setINIValue(content, section, key, value string) string {
+	header := "[" + section + "]"
+	if section == "" {
+		header = ""
+	}
+	entry := key + "=" + value
+	var out []string
+	current := ""
+	done := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if current == header && !done {
+				// Add it before the blank lines ending the section.
+				n := len(out)
+				for n > 0 && strings.TrimSpace(out[n-1]) == "" {
+					n--
+				}
+				out = append(out[:n], append([]string{entry}, out[n:]...)...)
+				done = true
+			}
+			current = trimmed
+		}
+		if k, _, ok := strings.Cut(trimmed, "="); ok && current == header && strings.TrimSpace(k) == key {
+			line = entry
+			done = true
+		}
+		out = append(out, line)
+	}
+	if !done {
+		if current != header {
+			out = append(out, "", header)
+		}
+		out = append(out, entry)
+	}
+	return strings.TrimLeft(strings.Join(out, "\n"), "\n") + "\n"
+}
+
+// updateINIFile applies setINIValue to the file at path for each key.
+func updateINIFile(path, section string, values [][2]string) error {
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	for _, kv := range values {
+		content = setINIValue(content, section, kv[0], kv[1])
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, []byte(content), 0644)
+}
+
+// iniValue returns the value of key in section of an INI style file.
+func iniValue(content, section, key string) (string, bool) {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			current = trimmed
+			continue
+		}
+		if k, v, ok := strings.Cut(trimmed, "="); ok && current == "["+section+"]" && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", false
+}
+
+// gtkSetting reads a setting back from the GTK 3 settings.ini.
+func gtkSetting(key string) (string, bool) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", false
+	}
+	data, _ := os.ReadFile(filepath.Join(homeDir, ".config", "gtk-3.0", "settings.ini"))
+	return iniValue(string(data), "Settings", key)
+}
+
+// currentColorScheme reads the dark theme preference back from the GTK 3
+// settings.
+func currentColorScheme() string {
+	v, ok := gtkSetting("gtk-application-prefer-dark-theme")
+	switch {
+	case !ok:
+		return "Default"
+	case v == "1" || v == "true":
+		return "Dark"
+	}
+	return "Light"
+}
+
+// iconThemes are the icon themes offered, with the package holding each.
+var iconThemes = []struct {
+	name string
+	pkg  string
+}{
+	{"Papirus", "papirus-icon-theme"},
+	{"Papirus-Dark", "papirus-icon-theme"},
+	{"Adwaita", "adwaita-icon-theme"},
+}
+
+func newAppearanceForm() *form {
+	icons := []string{"Unchanged"}
+	for _, t := range iconThemes {
+		icons = append(icons, t.name)
+	}
+	icon := "Unchanged"
+	if v, ok := gtkSetting("gtk-icon-theme-name"); ok && containsString(icons, v) {
+		icon = v
+	}
+	return &form{
+		title: "Appearance",
+		fields: []formField{
+			{label: "Color scheme", value: currentColorScheme(), options: []string{"Dark", "Light", "Default"},
+				help: "Programs that follow the freedesktop color-scheme preference switch through the settings portal; GTK programs read settings.ini and Qt programs follow the GTK theme."},
+			{label: "Icon theme", value: icon, options: icons,
+				help: "Installed if needed and used by GTK programs, fuzzel and the waybar taskbar."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			icon := values[1]
+			if icon == "Unchanged" {
+				icon = ""
+			}
+			return setAppearance(values[0], icon), nil
+		},
+	}
+}
+
+// setIconTheme installs the icon theme and selects it in fuzzel and in the
+// waybar taskbar, which don't follow the GTK setting.
+func setIconTheme(homeDir, theme string) ([]string, error) {
+	var logs []string
+	for _, t := range iconThemes {
+		if t.name == theme {
+			line, err := installPackage(t.pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return logs, err
+			}
+		}
+	}
+	if hasCommand("gsettings") {
+		if out, err := commandCombinedOutput(exec.Command("gsettings", "set", "org.gnome.desktop.interface", "icon-theme", theme)); err != nil {
+			logs = append(logs, Tf("Warning: gsettings: %s", strings.TrimSpace(string(out))))
+		}
+	}
+
+	fuzzel := filepath.Join(homeDir, ".config", "fuzzel", "fuzzel.ini")
+	if err := updateINIFile(fuzzel, "main", [][2]string{{"icon-theme", theme}}); err != nil {
+		logs = append(logs, Tf("Failed to write %s: %v", fuzzel, err))
+		return logs, err
+	}
+	logs = append(logs, Tf("Updated %s: OK", fuzzel))
+
+	if path, err := waybarConfigPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			if _, err := updateWaybarConfig(func(cfg map[string]any) {
+				if taskbar, ok := cfg["wlr/taskbar"].(map[string]any); ok {
+					taskbar["icon-theme"] = theme
+				}
+			}); err != nil {
+				logs = append(logs, Tf("Warning: Could not update %s: %v", path, err))
+			} else {
+				logs = append(logs, Tf("Updated %s: OK", path))
+			}
+		}
+	}
+	return logs, nil
+}
+
+// setAppearance sets the color scheme everywhere programs look for it: the
+// org.gnome.desktop.interface settings behind the settings portal, the
+// GTK 3 and GTK 4 settings, and the Qt platform theme in config.kdl. A
+// non-empty icon theme is installed and selected too.
+func setAppearance(scheme, icon string) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+
+		if hasCommand("gsettings") {
+			out, err := commandCombinedOutput(exec.Command("gsettings", "set", "org.gnome.desktop.interface", "color-scheme", colorSchemes[scheme]))
+			if err != nil {
+				logs = append(logs, Tf("Warning: gsettings: %s", strings.TrimSpace(string(out))))
+			} else {
+				logs = append(logs, Tf("Set color-scheme to %s: OK", colorSchemes[scheme]))
+			}
+		} else {
+			logs = append(logs, T("gsettings not found, skipping the portal preference (install glib)"))
+		}
+
+		settings := [][2]string{{"gtk-application-prefer-dark-theme", "0"}}
+		if scheme == "Dark" {
+			settings[0][1] = "1"
+		}
+		if icon != "" {
+			settings = append(settings, [2]string{"gtk-icon-theme-name", icon})
+		}
+		for _, dir := range []string{"gtk-3.0", "gtk-4.0"} {
+			path := filepath.Join(homeDir, ".config", dir, "settings.ini")
+			if err := updateINIFile(path, "Settings", settings); err != nil {
+				logs = append(logs, Tf("Failed to write %s: %v", path, err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, Tf("Updated %s: OK", path))
+		}
+
+		if icon != "" {
+			lines, err := setIconTheme(homeDir, icon)
+			logs = append(logs, lines...)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			return setKDLNode(cfg, []string{"environment"}, `QT_QPA_PLATFORMTHEME "gtk3"`)
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to %s: OK", "QT_QPA_PLATFORMTHEME=gtk3", path))
+		logs = append(logs, T("Running programs pick up the portal preference right away; restart the others."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Sides an output can be placed on, relative to the primary output.
+const (
+	sideRight = "right of"
+	sideLeft  = "left of"
+	sideAbove = "above"
+	sideBelow = "below"
+)
+
+type arrangedOutput struct {
+	name          string
+	width, height int // logical size, after the transform
+	transform     string
+	side          string
+	x, y          int
+}
+
+// arrangement is the state of the multi-monitor arrangement editor. The
+// primary output sits at the origin and every other output is placed on
+// one of its sides, stacking outwards in list order.
+type arrangement struct {
+	outputs  []arrangedOutput
+	primary  int
+	selected int
+	err      string
+}
+
+var (
+	canvasStyle         = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("240"))
+	canvasSelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00ff00")).Bold(true)
+)
+
+const (
+	canvasWidth  = 46
+	canvasHeight = 12
+)
+
+// loadArrangement builds the editor state from the enabled outputs of the
+// running session, guessing each output's side from its current position.
+func loadArrangement() tea.Cmd {
+	return func() tea.Msg {
+		outputs, err := niriOutputs()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to query outputs, run this from inside a niri session: %v", err), err: err}
+		}
+		a := &arrangement{}
+		for _, o := range outputs {
+			if o.Logical == nil {
+				continue
+			}
+			a.outputs = append(a.outputs, arrangedOutput{
+				name:      o.Name,
+				width:     o.Logical.Width,
+				height:    o.Logical.Height,
+				transform: ipcTransform(o.Logical.Transform),
+				x:         o.Logical.X,
+				y:         o.Logical.Y,
+			})
+		}
+		if len(a.outputs) < 2 {
+			return statusMsg{status: T("At least two enabled outputs are needed to arrange them"), err: fmt.Errorf("%d outputs enabled", len(a.outputs))}
+		}
+
+		// The output closest to the origin becomes the primary.
+		for i, o := range a.outputs {
+			p := a.outputs[a.primary]
+			if abs(o.x)+abs(o.y) < abs(p.x)+abs(p.y) {
+				a.primary = i
+			}
+		}
+		p := a.outputs[a.primary]
+		for i := range a.outputs {
+			o := &a.outputs[i]
+			switch {
+			case o.x >= p.x+p.width:
+				o.side = sideRight
+			case o.x+o.width <= p.x:
+				o.side = sideLeft
+			case o.y+o.height <= p.y:
+				o.side = sideAbove
+			default:
+				o.side = sideBelow
+			}
+		}
+		a.layout()
+		return arrangementMsg{a}
+	}
+}
+
+type arrangementMsg struct {
+	arrangement *arrangement
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// layout computes positions from the primary and each output's side, then
+// shifts everything so that no coordinate is negative.
+func (a *arrangement) layout() {
+	p := &a.outputs[a.primary]
+	p.x, p.y = 0, 0
+	right, left, above, below := p.width, 0, 0, p.height
+	for i := range a.outputs {
+		if i == a.primary {
+			continue
+		}
+		o := &a.outputs[i]
+		switch o.side {
+		case sideRight:
+			o.x, o.y = right, 0
+			right += o.width
+		case sideLeft:
+			left -= o.width
+			o.x, o.y = left, 0
+		case sideAbove:
+			above -= o.height
+			o.x, o.y = 0, above
+		case sideBelow:
+			o.x, o.y = 0, below
+			below += o.height
+		}
+	}
+	minX, minY := 0, 0
+	for _, o := range a.outputs {
+		minX, minY = min(minX, o.x), min(minY, o.y)
+	}
+	for i := range a.outputs {
+		a.outputs[i].x -= minX
+		a.outputs[i].y -= minY
+	}
+}
+
+func (m model) updateArrange(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a := m.arrange
+	a.err = ""
+	sel := a.selected
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "backspace":
+		m.state = menuView
+		m.arrange = nil
+		m.isProcessing = false
+		return m, nil
+	case "tab":
+		a.selected = (a.selected + 1) % len(a.outputs)
+	case "shift+tab":
+		a.selected = (a.selected - 1 + len(a.outputs)) % len(a.outputs)
+	case "p":
+		a.primary = sel
+	case "r", "f":
+		o := &a.outputs[sel]
+		t := rotateTransform(o.transform)
+		if msg.String() == "f" {
+			t = flipTransform(o.transform)
+		}
+		if isSideways(t) != isSideways(o.transform) {
+			o.width, o.height = o.height, o.width
+		}
+		o.transform = t
+	case "left", "right", "up", "down":
+		if sel == a.primary {
+			a.err = T("Select another output to move it around the primary")
+			return m, nil
+		}
+		a.outputs[sel].side = map[string]string{
+			"left": sideLeft, "right": sideRight, "up": sideAbove, "down": sideBelow,
+		}[msg.String()]
+	case "enter":
+		m.state = actionView
+		m.actionMsg = "Writing output positions..."
+		outputs := a.outputs
+		primary := a.outputs[a.primary].name
+		m.arrange = nil
+		return m, writeArrangement(outputs, primary)
+	}
+	a.layout()
+	return m, nil
+}
+
+// writeArrangement stores the computed positions and the transforms in the
+// output blocks of the config and marks the primary output to receive focus
+// at startup.
+func writeArrangement(outputs []arrangedOutput, primary string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := updateNiriConfig(func(cfg string) string {
+			for _, o := range outputs {
+				block := []string{"output " + o.name}
+				cfg = setKDLNode(cfg, block, fmt.Sprintf("position x=%d y=%d", o.x, o.y))
+				cfg = setTransform(cfg, o.name, o.transform)
+				if o.name == primary {
+					cfg = setKDLNode(cfg, block, "focus-at-startup")
+				} else {
+					cfg = removeKDLNode(cfg, block, "focus-at-startup")
+				}
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write output positions: %v", err), err: err}
+		}
+		var lines []string
+		for _, o := range outputs {
+			lines = append(lines, Tf("%s: position x=%d y=%d", o.name, o.x, o.y))
+			if o.transform != "normal" {
+				lines = append(lines, Tf("%s: transform %s", o.name, o.transform))
+			}
+		}
+		lines = append(lines, Tf("Primary output: %s", primary), Tf("Updated %s", path))
+		return statusMsg{status: strings.Join(lines, "\n")}
+	}
+}
+
+// renderCanvas draws the outputs as boxes scaled down to the canvas size.
+func (a *arrangement) renderCanvas() string {
+	maxX, maxY := 1, 1
+	for _, o := range a.outputs {
+		maxX, maxY = max(maxX, o.x+o.width), max(maxY, o.y+o.height)
+	}
+	// Terminal cells are roughly twice as tall as they are wide.
+	scale := max(float64(maxX)/canvasWidth, float64(maxY)/(2*canvasHeight))
+
+	grid := make([][]rune, canvasHeight)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", canvasWidth))
+	}
+	for _, o := range a.outputs {
+		x0, y0 := int(float64(o.x)/scale), int(float64(o.y)/scale/2)
+		x1 := min(canvasWidth-1, max(x0+1, int(float64(o.x+o.width)/scale)-1))
+		y1 := min(canvasHeight-1, max(y0+1, int(float64(o.y+o.height)/scale/2)-1))
+		for x := x0; x <= x1; x++ {
+			grid[y0][x], grid[y1][x] = '─', '─'
+		}
+		for y := y0; y <= y1; y++ {
+			grid[y][x0], grid[y][x1] = '│', '│'
+		}
+		grid[y0][x0], grid[y0][x1], grid[y1][x0], grid[y1][x1] = '┌', '┐', '└', '┘'
+		label := []rune(o.name)
+		if len(label) > x1-x0-1 {
+			label = label[:max(0, x1-x0-1)]
+		}
+		cy := (y0 + y1) / 2
+		cx := x0 + 1 + (x1-x0-1-len(label))/2
+		copy(grid[cy][cx:], label)
+	}
+
+	lines := make([]string, len(grid))
+	for i, row := range grid {
+		lines[i] = string(row)
+	}
+	return canvasStyle.Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderArrangeView() string {
+	a := m.arrange
+	title := titleStyle.Render(T("Arrange Monitors"))
+
+	list := strings.Builder{}
+	for i, o := range a.outputs {
+		desc := fmt.Sprintf("%s (%dx%d) ", o.name, o.width, o.height)
+		if o.transform != "normal" {
+			desc = fmt.Sprintf("%s (%dx%d, %s) ", o.name, o.width, o.height, o.transform)
+		}
+		if i == a.primary {
+			desc += T("primary")
+		} else {
+			desc += Tf("%s primary", T(o.side))
+		}
+		if i == a.selected {
+			list.WriteString(canvasSelectedStyle.Render("> "+desc) + "\n")
+		} else {
+			list.WriteString(disabledStyle.Render("  "+desc) + "\n")
+		}
+	}
+
+	parts := []string{title, a.renderCanvas(), menuStyle.Render(list.String())}
+	if a.err != "" {
+		parts = append(parts, formErrorStyle.Render(a.err))
+	}
+	parts = append(parts, formHelpStyle.Render(T("tab select • arrows place • r rotate • f flip • p primary • enter save • esc cancel")))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// auditResult is the part of `pkg audit --raw=json-compact` the audit uses.
+type auditResult struct {
+	Packages map[string]struct {
+		Version string `json:"version"`
+		Issues  []struct {
+			Affected    []string `json:"Affected versions"`
+			Description string   `json:"description"`
+			CVE         []string `json:"cve"`
+			URL         string   `json:"url"`
+		} `json:"issues"`
+	} `json:"packages"`
+}
+
+// auditScope lists the installed niri packages and the packages they
+// depend on directly, which is what the audit looks at.
+func auditScope() []string {
+	seen := map[string]bool{}
+	var installed []string
+	for _, pkg := range niriPackages {
+		if isPackageInstalled(pkg) {
+			installed = append(installed, pkg)
+			seen[pkg] = true
+		}
+	}
+	if len(installed) == 0 {
+		return nil
+	}
+	args := append([]string{"query", "%dn"}, installed...)
+	if out, err := commandOutput(exec.Command("pkg", args...)); err == nil {
+		for _, dep := range strings.Fields(string(out)) {
+			seen[dep] = true
+		}
+	}
+	scope := make([]string, 0, len(seen))
+	for pkg := range seen {
+		scope = append(scope, pkg)
+	}
+	sort.Strings(scope)
+	return scope
+}
+
+// fixedByUpdate reports whether the repository has a version of pkg that
+// pkg audit no longer flags, and which version that is.
+func fixedByUpdate(pkg, installed string) (string, bool) {
+	out, err := commandOutput(exec.Command("pkg", "rquery", "%v", pkg))
+	if err != nil {
+		return "", false
+	}
+	latest := strings.TrimSpace(string(out))
+	if latest == "" || latest == installed {
+		return latest, false
+	}
+	return latest, runCommand(exec.Command("pkg", "audit", "-q", pkg+"-"+latest)) == nil
+}
+
+// runAudit audits the niri stack and describes the result. The error is
+// set when vulnerabilities were found or the audit couldn't run.
+func runAudit() (string, error) {
+	if !hasCommand("pkg") {
+		err := errors.New("pkg not found")
+		return T("pkg is needed to audit the installed packages"), preflightFailure(err)
+	}
+	scope := auditScope()
+	if len(scope) == 0 {
+		err := errors.New("nothing installed")
+		return T("None of the niri packages are installed yet, there is nothing to audit"), preflightFailure(err)
+	}
+
+	// -F fetches the current vulnerability database, which needs root. pkg
+	// audit exits with 1 when it finds something, so the output decides.
+	out, err := commandOutput(exec.Command("sudo", "pkg", "audit", "-F", "--raw=json-compact"))
+	var result auditResult
+	if jerr := json.Unmarshal(out, &result); jerr != nil {
+		if err == nil {
+			err = jerr
+		}
+		return Tf("Failed to run pkg audit: %v", err), preflightFailure(err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, Tf("Audited %d packages: niri, its desktop packages and their dependencies.", len(scope)))
+	problems, unfixed := 0, 0
+	for _, pkg := range scope {
+		entry, ok := result.Packages[pkg]
+		if !ok || len(entry.Issues) == 0 {
+			continue
+		}
+		problems++
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, Tf("%s %s is vulnerable:", pkg, entry.Version))
+		for _, issue := range entry.Issues {
+			fmt.Fprintf(&b, "  - %s\n", issue.Description)
+			if len(issue.Affected) > 0 {
+				fmt.Fprintf(&b, "    %s %s\n", T("Affected:"), strings.Join(issue.Affected, ", "))
+			}
+			if len(issue.CVE) > 0 {
+				fmt.Fprintf(&b, "    CVE: %s\n", strings.Join(issue.CVE, ", "))
+			}
+			if issue.URL != "" {
+				fmt.Fprintf(&b, "    %s\n", issue.URL)
+			}
+		}
+		latest, fixed := fixedByUpdate(pkg, entry.Version)
+		switch {
+		case fixed:
+			fmt.Fprintln(&b, "  "+Tf("Fixed by updating to %s: sudo pkg upgrade %s", latest, pkg))
+		case latest != "" && latest != entry.Version:
+			fmt.Fprintln(&b, "  "+Tf("The repository has %s, but it is still affected", latest))
+			unfixed++
+		default:
+			fmt.Fprintln(&b, "  "+T("No fixed version in the repository yet"))
+			unfixed++
+		}
+	}
+
+	fmt.Fprintln(&b)
+	if problems == 0 {
+		fmt.Fprintln(&b, T("No known vulnerabilities."))
+		return b.String(), nil
+	}
+	fmt.Fprintln(&b, Tf("%d vulnerable packages, %d without a fixed version yet.", problems, unfixed))
+	return b.String(), fmt.Errorf("%d vulnerable packages", problems)
+}
+
+// securityAudit shows the audit of the niri stack as a report.
+func securityAudit() tea.Cmd {
+	return func() tea.Msg {
+		body, err := runAudit()
+		var f *failure
+		if errors.As(err, &f) && f.code == exitPreflight {
+			return statusMsg{status: body, err: err}
+		}
+		return reportMsg{&report{title: "Security Audit", body: body, refresh: securityAudit()}}
+	}
+}
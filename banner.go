@@ -0,0 +1,184 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// updateBannerInterval is how long a check's result is reused before
+	// the next launch checks again.
+	updateBannerInterval = 24 * time.Hour
+	// updateBannerTimeout bounds the request for the latest NiriSetup
+	// commit, so that a slow network doesn't keep the check running.
+	updateBannerTimeout = 5 * time.Second
+	// niriSetupHeadURL answers with the commit the default branch is at.
+	niriSetupHeadURL = "https://api.github.com/repos/xcrsz/NiriSetup/commits/HEAD"
+)
+
+// pkgRemoteVersion picks the package and the repository's version out of
+// a line of pkg version -vRL=.
+var pkgRemoteVersion = regexp.MustCompile(`^(\S+)-[^-\s]+\s.*\(remote has ([^)]+)\)`)
+
+// updateBannerMsg carries the updates found at launch.
+type updateBannerMsg struct {
+	updates []string
+}
+
+func updateBannerCachePath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".cache", "nirisetup", "updates"), nil
+}
+
+// cachedUpdates returns the updates the last check found and whether that
+// check is recent enough to be reused. The cache holds the time of the
+// check on its first line and one update per line after it.
+func cachedUpdates() ([]string, bool) {
+	path, err := updateBannerCachePath()
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	checked, err := time.Parse(time.RFC3339, lines[0])
+	if err != nil {
+		return nil, false
+	}
+	return lines[1:], time.Since(checked) < updateBannerInterval
+}
+
+// saveUpdates caches the result of a check.
+func saveUpdates(updates []string) {
+	path, err := updateBannerCachePath()
+	if err != nil {
+		return
+	}
+	data := strings.Join(append([]string{time.Now().Format(time.RFC3339)}, updates...), "\n") + "\n"
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(data), 0644)
+	}
+	if err != nil {
+		tracef("write %s failed: %v", path, err)
+		return
+	}
+	chownToUser(path)
+}
+
+// outdatedNiriPackages lists the niri packages the local copy of the
+// package catalog has newer versions of. It doesn't refresh the catalog,
+// which needs root and the network; pkg update and the weekly Update Check
+// do that.
+func outdatedNiriPackages() []string {
+	if !pkgBootstrapped() {
+		// pkg would ask about bootstrapping itself.
+		return nil
+	}
+	out, err := commandOutput(exec.Command("pkg", "version", "-U", "-vRL="))
+	if err != nil {
+		return nil
+	}
+	var updates []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if m := pkgRemoteVersion.FindStringSubmatch(line); m != nil && containsString(niriPackages, m[1]) {
+			updates = append(updates, m[1]+" "+m[2])
+		}
+	}
+	return updates
+}
+
+// niriSetupOutdated reports whether GitHub has a newer NiriSetup than the
+// commit this binary was built from. Builds with local changes, or without
+// the commit recorded, are never outdated.
+func niriSetupOutdated() bool {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return false
+	}
+	var revision string
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision":
+			revision = s.Value
+		case s.Key == "vcs.modified" && s.Value == "true":
+			return false
+		}
+	}
+	if revision == "" {
+		return false
+	}
+
+	req, err := http.NewRequest("GET", niriSetupHeadURL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Accept", "application/vnd.github.sha")
+	req.Header.Set("User-Agent", "NiriSetup")
+	resp, err := (&http.Client{Timeout: updateBannerTimeout}).Do(req)
+	if err != nil {
+		tracef("checking for a newer NiriSetup failed: %v", err)
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		tracef("checking for a newer NiriSetup failed: %s", resp.Status)
+		return false
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return false
+	}
+	head = []byte(strings.TrimSpace(string(head)))
+	return len(head) > 0 && string(head) != revision
+}
+
+// checkUpdateBanner looks for updates to niri and NiriSetup in the
+// background, at most once a day; in between it reports what the last
+// check found. update_banner = no in the settings turns it off.
+func checkUpdateBanner() tea.Cmd {
+	if loadSettings()["update_banner"] == "no" {
+		return nil
+	}
+	return func() tea.Msg {
+		if updates, fresh := cachedUpdates(); fresh {
+			return updateBannerMsg{updates}
+		}
+		updates := outdatedNiriPackages()
+		if niriSetupOutdated() {
+			updates = append(updates, "NiriSetup")
+		}
+		saveUpdates(updates)
+		return updateBannerMsg{updates}
+	}
+}
+
+// updateBanner is the line shown above the menu, or "" if there is
+// nothing to update.
+func updateBanner(updates []string) string {
+	if len(updates) == 0 {
+		return ""
+	}
+	banner := Tf("Updates available: %s.", strings.Join(updates, ", "))
+	if !containsString(updates, "NiriSetup") || len(updates) > 1 {
+		banner += " " + T("Maintain › Update Niri installs the packages.")
+	}
+	if containsString(updates, "NiriSetup") {
+		banner += " " + T("To update NiriSetup, run git pull and go build again.")
+	}
+	return banner
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bootEnvironmentPrefix starts the name of every boot environment
+// NiriSetup creates, which is how Rollback Setup finds them again. Older
+// releases named them nirisetup-pre-<date>, which it matches too.
+const bootEnvironmentPrefix = "nirisetup-"
+
+// bootEnvironmentsKept is how many of the newest NiriSetup boot
+// environments pruning leaves alone.
+const bootEnvironmentsKept = 3
+
+// zfsRoot reports whether / is on ZFS and bectl is available, i.e. whether
+// boot environments can be used.
+var zfsRoot = sync.OnceValue(func() bool {
+	return hasCommand("bectl") && rootPool() != ""
+})
+
+// createBootEnvironment snapshots the running system into a new boot
+// environment and returns its name.
+func createBootEnvironment() (string, error) {
+	name := bootEnvironmentPrefix + time.Now().Format("2006-01-02-150405")
+	if out, err := commandCombinedOutput(exec.Command("sudo", "bectl", "create", name)); err != nil {
+		return name, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return name, nil
+}
+
+// withBootEnvironment creates a boot environment and then runs action. If
+// the boot environment can't be created, action doesn't run.
+func withBootEnvironment(action tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		name, err := createBootEnvironment()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to create boot environment %s, nothing was changed: %v", name, err), err: preflightFailure(err)}
+		}
+		line := Tf("Created boot environment %s (undo with Rollback Setup)", name)
+		if bes, err := setupBootEnvironments(); err == nil {
+			if old := prunableBootEnvironments(bes); len(old) > 0 {
+				line += "\n" + Tf("%d older boot environments can be deleted in Boot Environments to free space in the pool", len(old))
+			}
+		}
+		msg, ok := action().(statusMsg)
+		if !ok {
+			return statusMsg{status: line}
+		}
+		msg.status = line + "\n" + msg.status
+		return msg
+	}
+}
+
+// bootEnvironmentConfirmation asks whether to create a boot environment
+// before action changes the system. Declining still runs action.
+func bootEnvironmentConfirmation(actionMsg string, action tea.Cmd) *confirmation {
+	return &confirmation{
+		prompt:    "This system runs on ZFS. Create a boot environment first, so the whole setup can be undone with Rollback Setup?",
+		actionMsg: actionMsg,
+		yes:       withBootEnvironment(action),
+		no:        action,
+	}
+}
+
+// bootEnvironment is a line of bectl list. active holds bectl's N (in use
+// now) and R (used on reboot) flags, or "-".
+type bootEnvironment struct {
+	name    string
+	active  string
+	space   string
+	created string
+}
+
+// inUse reports whether be is booted or will be booted next.
+func (be bootEnvironment) inUse() bool {
+	return strings.ContainsAny(be.active, "NR")
+}
+
+// setupBootEnvironments lists the boot environments created by NiriSetup,
+// oldest first.
+func setupBootEnvironments() ([]bootEnvironment, error) {
+	out, err := commandOutput(exec.Command("bectl", "list", "-H", "-c", "creation"))
+	if err != nil {
+		return nil, err
+	}
+	var bes []bootEnvironment
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) >= 5 && strings.HasPrefix(fields[0], bootEnvironmentPrefix) {
+			bes = append(bes, bootEnvironment{name: fields[0], active: fields[1], space: fields[3], created: fields[4]})
+		}
+	}
+	return bes, nil
+}
+
+// prunableBootEnvironments returns the boot environments older than the
+// newest bootEnvironmentsKept, leaving out those in use.
+func prunableBootEnvironments(bes []bootEnvironment) []bootEnvironment {
+	var old []bootEnvironment
+	for _, be := range bes[:max(0, len(bes)-bootEnvironmentsKept)] {
+		if !be.inUse() {
+			old = append(old, be)
+		}
+	}
+	return old
+}
+
+// rootPool returns the ZFS pool the root file system is on.
+func rootPool() string {
+	out, err := commandOutput(exec.Command("mount", "-p"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == "/" && fields[2] == "zfs" {
+			return strings.Split(fields[0], "/")[0]
+		}
+	}
+	return ""
+}
+
+// bootEnvironmentsView lists the NiriSetup boot environments with the
+// space they hold, and offers to delete the old ones.
+func bootEnvironmentsView() tea.Cmd {
+	return func() tea.Msg {
+		if !zfsRoot() {
+			err := errors.New("not on ZFS")
+			return statusMsg{status: T("Boot environments need a ZFS root file system and bectl"), err: preflightFailure(err)}
+		}
+		bes, err := setupBootEnvironments()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to list boot environments: %v", err), err: preflightFailure(err)}
+		}
+		var b strings.Builder
+		if pool := rootPool(); pool != "" {
+			if out, err := commandOutput(exec.Command("zpool", "list", "-H", "-o", "cap,free", pool)); err == nil {
+				if f := strings.Fields(string(out)); len(f) == 2 {
+					b.WriteString(Tf("Pool %s: %s used, %s free", pool, f[0], f[1]) + "\n\n")
+				}
+			}
+		}
+		if len(bes) == 0 {
+			b.WriteString(T("There is no boot environment from before a NiriSetup run"))
+		}
+		for _, be := range bes {
+			flag := ""
+			if be.inUse() {
+				flag = "  " + T("(in use)")
+			}
+			fmt.Fprintf(&b, "%-32s %-17s %8s%s\n", be.name, be.created, be.space, flag)
+		}
+		r := &report{title: "Boot Environments", body: b.String(), refresh: bootEnvironmentsView()}
+		if old := prunableBootEnvironments(bes); len(old) > 0 {
+			r.actions = []reportAction{{label: Tf("Delete the %d oldest, keeping the newest %d", len(old), bootEnvironmentsKept), cmd: confirmPrune(old)}}
+		}
+		return reportMsg{r}
+	}
+}
+
+// confirmPrune asks before destroying the boot environments old.
+func confirmPrune(old []bootEnvironment) tea.Cmd {
+	return func() tea.Msg {
+		var names []string
+		for _, be := range old {
+			names = append(names, be.name)
+		}
+		return confirmMsg{&confirmation{
+			prompt:    Tf("Delete these boot environments? They can't be rolled back to afterwards.\n\n%s", strings.Join(names, "\n")),
+			actionMsg: "Deleting boot environments...",
+			yes:       pruneBootEnvironments(names),
+		}}
+	}
+}
+
+// pruneBootEnvironments destroys the boot environments names along with
+// the snapshots they were cloned from.
+func pruneBootEnvironments(names []string) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		var failed error
+		for _, name := range names {
+			if out, err := commandCombinedOutput(exec.Command("sudo", "bectl", "destroy", "-o", name)); err != nil {
+				logs = append(logs, Tf("Failed to delete %s: %s", name, strings.TrimSpace(string(out))))
+				failed = err
+				continue
+			}
+			logs = append(logs, Tf("Deleted boot environment %s: OK", name))
+		}
+		return statusMsg{status: strings.Join(logs, "\n"), err: failed}
+	}
+}
+
+// loadRollback finds the boot environment from before the most recent
+// setup and asks for confirmation before switching back to it.
+func loadRollback() tea.Cmd {
+	return func() tea.Msg {
+		if !zfsRoot() {
+			err := errors.New("not on ZFS")
+			return statusMsg{status: T("Boot environments need a ZFS root file system and bectl"), err: preflightFailure(err)}
+		}
+		bes, err := setupBootEnvironments()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to list boot environments: %v", err), err: preflightFailure(err)}
+		}
+		if len(bes) == 0 {
+			err := errors.New("no boot environment")
+			return statusMsg{status: T("There is no boot environment from before a NiriSetup run"), err: preflightFailure(err)}
+		}
+		name := bes[len(bes)-1].name
+		return confirmMsg{&confirmation{
+			prompt:    Tf("Activate boot environment %s? After a reboot the system is back to how it was before that setup, and all changes made since then are gone.", name),
+			actionMsg: "Activating boot environment...",
+			yes:       rollbackTo(name),
+		}}
+	}
+}
+
+// rollbackTo activates the boot environment name for the next boot.
+func rollbackTo(name string) tea.Cmd {
+	return func() tea.Msg {
+		if out, err := commandCombinedOutput(exec.Command("sudo", "bectl", "activate", name)); err != nil {
+			return statusMsg{status: Tf("Failed to activate %s: %s", name, strings.TrimSpace(string(out))), err: err}
+		}
+		return statusMsg{status: Tf("Boot environment %s will be used from the next boot. Reboot to finish the rollback.", name)}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errPkgNotBootstrapped is what installs fail with on a fresh system.
+var errPkgNotBootstrapped = errors.New("pkg isn't bootstrapped")
+
+// pkgBootstrapped reports whether pkg itself is installed. On a fresh
+// FreeBSD system /usr/sbin/pkg is only the bootstrap tool, which turns
+// every install into a question about fetching pkg first.
+func pkgBootstrapped() bool {
+	return runCommand(exec.Command("pkg", "-N")) == nil
+}
+
+// bootstrapPkg installs pkg with pkg bootstrap.
+func bootstrapPkg() (string, error) {
+	out, err := commandCombinedOutput(exec.Command("sudo", "pkg", "bootstrap", "-y"))
+	if err != nil {
+		return Tf("Failed to bootstrap pkg: %s", strings.TrimSpace(string(out))), err
+	}
+	return T("Bootstrapped pkg: OK"), nil
+}
+
+// withPkgBootstrap bootstraps pkg if it isn't yet and then runs action.
+// If the bootstrap fails, action doesn't run.
+func withPkgBootstrap(action tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		if pkgBootstrapped() {
+			return action()
+		}
+		line, err := bootstrapPkg()
+		if err != nil {
+			return statusMsg{status: line, err: preflightFailure(err)}
+		}
+		msg := action()
+		if status, ok := msg.(statusMsg); ok {
+			status.status = line + "\n" + status.status
+			return status
+		}
+		return msg
+	}
+}
+
+// pkgBootstrapConfirmation asks before bootstrapping pkg for action.
+func pkgBootstrapConfirmation(actionMsg string, action tea.Cmd) *confirmation {
+	return &confirmation{
+		prompt:    "pkg isn't set up on this system yet, so no package can be installed. Run pkg bootstrap to install it from the FreeBSD package servers first?",
+		actionMsg: actionMsg,
+		yes:       withPkgBootstrap(action),
+	}
+}
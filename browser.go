@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chromiumFlags make Chromium use Wayland when it is available and draw
+// its own window decorations there.
+var chromiumFlags = []string{"--ozone-platform-hint=auto", "--enable-features=WaylandWindowDecorations"}
+
+func newBrowserForm() *form {
+	return &form{
+		title: "Browser Setup",
+		fields: []formField{
+			{label: "Browser", value: "Firefox", options: []string{"Firefox", "Chromium", "Both"}},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			return setupBrowser(values[0] != "Chromium", values[0] != "Firefox"), nil
+		},
+	}
+}
+
+// writeChromiumFlags adds the Wayland flags to ~/.config/chromium-flags.conf,
+// keeping the flags already there.
+func writeChromiumFlags() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(homeDir, ".config", "chromium-flags.conf")
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = []string{"# Generated by NiriSetup: run Chromium as a Wayland client."}
+	}
+	for _, flag := range chromiumFlags {
+		name := strings.SplitN(flag, "=", 2)[0]
+		found := false
+		for i, line := range lines {
+			if strings.SplitN(strings.TrimSpace(line), "=", 2)[0] == name {
+				lines[i], found = flag, true
+			}
+		}
+		if !found {
+			lines = append(lines, flag)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, writeFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// setupBrowser installs Firefox, Chromium or both and makes them run as
+// Wayland clients, so they aren't scaled up blurrily through Xwayland on
+// HiDPI screens.
+func setupBrowser(firefox, chromium bool) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		var pkgs []string
+		if firefox {
+			pkgs = append(pkgs, "firefox")
+		}
+		if chromium {
+			pkgs = append(pkgs, "chromium")
+		}
+		for _, pkg := range pkgs {
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		if chromium {
+			path, err := writeChromiumFlags()
+			if err != nil {
+				logs = append(logs, Tf("Failed to write %s: %v", path, err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, Tf("Added %s to %s: OK", strings.Join(chromiumFlags, " "), path))
+		}
+		if firefox {
+			path, err := updateNiriConfig(func(cfg string) string {
+				return setKDLNode(cfg, []string{"environment"}, `MOZ_ENABLE_WAYLAND "1"`)
+			})
+			if err != nil {
+				logs = append(logs, Tf("Failed to update config: %v", err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, Tf("Added %s to %s: OK", "MOZ_ENABLE_WAYLAND=1", path))
+		}
+		logs = append(logs, T("Restart the browser for the change to take effect."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
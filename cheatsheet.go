@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cheatSheetGroup is a section of the cheat sheet and the actions that
+// belong in it, by prefix.
+type cheatSheetGroup struct {
+	title    string
+	prefixes []string
+}
+
+// cheatSheetGroups sort the bindings; the group with the longest
+// matching prefix wins and anything else ends up under "Other".
+var cheatSheetGroups = []cheatSheetGroup{
+	{"Programs", []string{"spawn"}},
+	{"Windows", []string{"close-window", "focus-window", "move-window", "consume", "expel", "toggle-window", "fullscreen-window", "center-window", "switch-focus"}},
+	{"Columns", []string{"focus-column", "move-column", "center-column", "maximize-column", "switch-preset-column", "set-column", "reset-window-height", "set-window-height", "switch-preset-window", "toggle-column"}},
+	{"Workspaces", []string{"focus-workspace", "move-workspace", "move-column-to-workspace", "move-window-to-workspace", "toggle-overview"}},
+	{"Monitors", []string{"focus-monitor", "move-column-to-monitor", "move-window-to-monitor", "move-workspace-to-monitor", "power-off-monitors"}},
+	{"Screenshots", []string{"screenshot"}},
+	{"Session", []string{"quit", "show-hotkey-overlay", "toggle-keyboard-shortcuts-inhibit"}},
+}
+
+// cheatSheetEntry is one binding on the cheat sheet.
+type cheatSheetEntry struct {
+	keys string
+	what string
+}
+
+// hotkeyTitle is the property that gives a bind its description in
+// niri's hotkey overlay.
+const hotkeyTitle = `hotkey-overlay-title=`
+
+// describeBind returns what the bind at sp does, in words.
+func describeBind(cfg string, sp kdlSpan) string {
+	head := cfg[sp.start:sp.end]
+	if i := strings.Index(head, hotkeyTitle); i >= 0 {
+		rest := head[i+len(hotkeyTitle):]
+		if title := kdlUnquote(rest[:kdlSkip(rest, 0)]); title != "" {
+			return title
+		}
+	}
+	from, to, ok := kdlBody(cfg, sp)
+	if !ok {
+		return ""
+	}
+	children := kdlChildren(cfg, from, to)
+	if len(children) == 0 {
+		return ""
+	}
+	name := kdlNodeName(cfg, children[0])
+	args := strings.TrimSpace(strings.ReplaceAll(cfg[children[0].start+len(name):children[0].end], `"`, ""))
+	if name == "spawn" || name == "spawn-sh" {
+		return Tf("Run %s", args)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(name, "-", " ") + " " + args)
+}
+
+// cheatSheet groups the bindings of cfg for the cheat sheet.
+func cheatSheet(cfg string) ([]string, map[string][]cheatSheetEntry) {
+	groups := map[string][]cheatSheetEntry{}
+	for _, sp := range kdlBlockChildren(cfg, []string{"binds"}) {
+		action, _ := bindAction(cfg, sp)
+		title, best := "Other", 0
+		for _, g := range cheatSheetGroups {
+			for _, p := range g.prefixes {
+				if strings.HasPrefix(action, p) && len(p) > best {
+					title, best = g.title, len(p)
+				}
+			}
+		}
+		if strings.HasPrefix(kdlNodeName(cfg, sp), "XF86") {
+			title = "Hardware keys"
+		}
+		groups[title] = append(groups[title], cheatSheetEntry{kdlNodeName(cfg, sp), describeBind(cfg, sp)})
+	}
+	var order []string
+	for _, g := range cheatSheetGroups {
+		order = append(order, g.title)
+	}
+	order = append(order, "Hardware keys", "Other")
+	var titles []string
+	for _, t := range order {
+		if len(groups[t]) > 0 {
+			titles = append(titles, t)
+		}
+	}
+	return titles, groups
+}
+
+// cheatSheetMarkdown renders the bindings of cfg as Markdown tables.
+func cheatSheetMarkdown(cfg string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", T("niri key bindings"))
+	fmt.Fprintf(&b, "%s\n", T("Mod is the Super (Windows) key, or Alt when niri runs in a window."))
+	titles, groups := cheatSheet(cfg)
+	for _, title := range titles {
+		fmt.Fprintf(&b, "\n## %s\n\n| %s | %s |\n|---|---|\n", T(title), T("Keys"), T("Action"))
+		for _, e := range groups[title] {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", e.keys, strings.ReplaceAll(e.what, "|", `\|`))
+		}
+	}
+	return b.String()
+}
+
+// cheatSheetText renders the bindings of cfg as aligned plain text, for
+// the image.
+func cheatSheetText(cfg string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", T("niri key bindings"))
+	titles, groups := cheatSheet(cfg)
+	for _, title := range titles {
+		width := 0
+		for _, e := range groups[title] {
+			width = max(width, len(e.keys))
+		}
+		fmt.Fprintf(&b, "\n%s\n", T(title))
+		for _, e := range groups[title] {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, e.keys, e.what)
+		}
+	}
+	return b.String()
+}
+
+// documentsDir returns the user's documents folder.
+func documentsDir() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return userDir(homeDir, "DOCUMENTS"), nil
+}
+
+// runCheatSheet writes the cheat sheet of the user's bindings to
+// ~/Documents as Markdown, and as a PNG image too when ImageMagick is
+// installed.
+func runCheatSheet() (string, error) {
+	_, cfg, err := readNiriConfig()
+	if err != nil {
+		return err.Error(), err
+	}
+	if len(kdlBlockChildren(cfg, []string{"binds"})) == 0 {
+		err := errors.New("no key bindings")
+		return T("The config has no key bindings to put on a cheat sheet"), preflightFailure(err)
+	}
+	dir, err := documentsDir()
+	if err != nil {
+		return T("Failed to determine home directory"), preflightFailure(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Tf("Failed to create %s: %v", dir, err), err
+	}
+	chownToUser(dir)
+	md := filepath.Join(dir, "niri-keys.md")
+	if err := writeFile(md, []byte(cheatSheetMarkdown(cfg)), 0644); err != nil {
+		return Tf("Failed to write %s: %v", md, err), err
+	}
+
+	magick := ""
+	for _, name := range []string{"magick", "convert"} {
+		if hasCommand(name) {
+			magick = name
+			break
+		}
+	}
+	if magick == "" {
+		return Tf("Cheat sheet saved to %s (install ImageMagick to also get an image)", md), nil
+	}
+	txt, err := os.CreateTemp("", "niri-keys-*.txt")
+	if err != nil {
+		return Tf("Cheat sheet saved to %s, but the image failed: %v", md, err), err
+	}
+	txt.WriteString(cheatSheetText(cfg))
+	txt.Close()
+	defer os.Remove(txt.Name())
+	png := filepath.Join(dir, "niri-keys.png")
+	cmd := exec.Command(magick, "-background", "white", "-fill", "black", "-font", "DejaVu-Sans-Mono", "-pointsize", "16", "text:"+txt.Name(), "-trim", "-bordercolor", "white", "-border", "24", png)
+	if out, err := commandCombinedOutput(cmd); err != nil {
+		return Tf("Cheat sheet saved to %s, but the image failed: %v", md, errors.New(strings.TrimSpace(string(out)))), err
+	}
+	chownToUser(png)
+	return Tf("Cheat sheet saved to %s and %s", md, png), nil
+}
+
+// saveCheatSheet writes the key binding cheat sheet.
+func saveCheatSheet() tea.Cmd {
+	return func() tea.Msg {
+		status, err := runCheatSheet()
+		return statusMsg{status: status, err: err}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type checkStatus int
+
+const (
+	checkPending checkStatus = iota
+	checkRunning
+	checkDone
+	checkFailed
+)
+
+// checkItem is a step as the checklist shows it.
+type checkItem struct {
+	name   string
+	status checkStatus
+	detail []string
+}
+
+// checklist is the progress of a run of steps on the install screen. The
+// cursor picks a step whose details enter shows or hides.
+type checklist struct {
+	title  string
+	items  []checkItem
+	cursor int
+	open   map[int]bool
+}
+
+// checklistMsg carries the state of a run of steps to the interface after
+// each step starts or ends.
+type checklistMsg struct {
+	title string
+	items []checkItem
+}
+
+var (
+	checkRunningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00")).Bold(true)
+	checkPendingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	checkDetailStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).PaddingLeft(6).Width(viewWidth + 20)
+)
+
+// failed reports whether any step of c failed.
+func (c *checklist) failed() bool {
+	for _, item := range c.items {
+		if item.status == checkFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func (m model) showChecklist(msg checklistMsg) (tea.Model, tea.Cmd) {
+	c := m.checklist
+	if c == nil || c.title != msg.title {
+		c = &checklist{title: msg.title, open: map[int]bool{}}
+		m.checklist = c
+	}
+	if m.state == actionView {
+		// After a confirmation, steps start out on the action screen.
+		m.state = installView
+	}
+	for i, item := range msg.items {
+		if item.status == checkFailed && (i >= len(c.items) || c.items[i].status != checkFailed) {
+			// Failures show their details right away.
+			c.open[i] = true
+		}
+	}
+	c.items = msg.items
+	return m, nil
+}
+
+// updateChecklist moves through the steps and opens or closes their
+// details. Once the run is over, esc goes back to the menu.
+func (m model) updateChecklist(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.checklist
+	switch msg.String() {
+	case "up", "k":
+		c.cursor = max(0, c.cursor-1)
+	case "down", "j":
+		c.cursor = min(len(c.items)-1, c.cursor+1)
+	case "enter", " ":
+		c.open[c.cursor] = !c.open[c.cursor]
+	case "esc", "q", "backspace":
+		if !m.isProcessing {
+			m.state = menuView
+			m.checklist = nil
+			m.logs = nil
+		}
+	case "g":
+		if !m.isProcessing && m.failedAction != "" {
+			m.state = actionView
+			m.actionMsg = "Preparing the issue..."
+			return m, reportBug(m)
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderChecklist() string {
+	c := m.checklist
+	var b strings.Builder
+	for i, item := range c.items {
+		var line string
+		switch item.status {
+		case checkDone:
+			line = wizardDoneStyle.Render("✓ " + T(item.name))
+		case checkFailed:
+			line = wizardFailedStyle.Render("✗ " + T(item.name))
+		case checkRunning:
+			line = checkRunningStyle.Render("▸ " + T(item.name))
+		default:
+			line = checkPendingStyle.Render("• " + T(item.name))
+		}
+		cursor := "  "
+		if i == c.cursor {
+			cursor = cursorStyle.Render("> ")
+		}
+		b.WriteString(cursor + line + "\n")
+		if c.open[i] && len(item.detail) > 0 {
+			b.WriteString(checkDetailStyle.Render(strings.Join(item.detail, "\n")) + "\n")
+		}
+	}
+
+	help := T("↑/↓ select • enter details")
+	switch {
+	case m.isProcessing:
+		help += " • " + T("Please wait...")
+	case m.failedAction != "":
+		help += " • " + T("g report on GitHub • esc back")
+	default:
+		help += " • " + T("esc back")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(T(c.title)),
+		b.String(),
+		formHelpStyle.Render(help),
+	)
+}
@@ -0,0 +1,909 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// Command line options, shared by the interface and the subcommands.
+var (
+	wizardFlag  bool
+	answersFlag string
+	langFlag    string
+	plainFlag   bool
+	yesFlag     bool
+	verboseFlag bool
+	recordFlag  string
+	userFlag    string
+	lockFlag    bool
+)
+
+// Exit codes of the subcommands. Each run also ends with a summary line
+// such as "nirisetup: command=install status=partial exit=1".
+const (
+	exitOK         = 0
+	exitPartial    = 1 // the step ran, but parts of it failed
+	exitPreflight  = 2 // the step couldn't start and changed nothing
+	exitValidation = 3 // niri rejected the resulting config
+)
+
+var exitStatus = map[int]string{
+	exitOK:         "ok",
+	exitPartial:    "partial",
+	exitPreflight:  "preflight",
+	exitValidation: "validation",
+}
+
+// failure tags an error with the exit code it should produce. Untagged
+// errors count as partial failures.
+type failure struct {
+	code int
+	err  error
+}
+
+func (f *failure) Error() string { return f.err.Error() }
+func (f *failure) Unwrap() error { return f.err }
+
+// preflightFailure marks err as a failed precondition.
+func preflightFailure(err error) error {
+	return &failure{exitPreflight, err}
+}
+
+// validationFailure marks err as niri rejecting a config.
+func validationFailure(err error) error {
+	return &failure{exitValidation, err}
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var f *failure
+	if errors.As(err, &f) {
+		return f.code
+	}
+	return exitPartial
+}
+
+// exitError ends a subcommand whose result has already been printed.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string { return exitStatus[e.code] }
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "NiriSetup",
+		Short: "Set up the niri Wayland compositor on GhostBSD",
+		Long: `NiriSetup installs and configures the niri Wayland compositor on GhostBSD.
+
+Run without a command to open the interactive menu, or use one of the
+commands below to run a single step from a script.`,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			if answersFlag != "" {
+				return runAnswers(answersFlag)
+			}
+			return runInterface()
+		},
+	}
+	root.PersistentFlags().StringVar(&langFlag, "lang", "", "UI language, e.g. fr (defaults to $LANG)")
+	root.PersistentFlags().BoolVar(&plainFlag, "plain", false, "numbered prompts and plain output for screen readers (automatic when TERM=dumb or not on a terminal)")
+	root.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "log every command run, environment change and file written, with timings, to the screen and "+logFilePath())
+	root.PersistentFlags().StringVar(&recordFlag, "record", "", "also write the changes made as a standalone sh script to this file")
+	root.PersistentFlags().StringVar(&userFlag, "user", "", "when running as root, configure this user's account instead of root's")
+	root.Flags().BoolVar(&wizardFlag, "wizard", false, "start with the guided first-run wizard")
+	root.Flags().StringVar(&answersFlag, "answers", "", "run the first-run wizard unattended, answering its questions from this YAML file")
+	root.RegisterFlagCompletionFunc("lang", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		langs := []string{"en"}
+		for code := range catalogs {
+			langs = append(langs, code)
+		}
+		sort.Strings(langs)
+		return langs, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	var installProfile string
+	var installDryRun bool
+	install := systemActionCmd("install", "Install niri and the desktop packages with pkg", func() tea.Cmd {
+		var extra []string
+		if installProfile != "" {
+			components, err := ansibleComponentNames(installProfile, nil)
+			if err != nil {
+				return func() tea.Msg { return statusMsg{status: err.Error(), err: preflightFailure(err)} }
+			}
+			extra = componentPackages(components)
+		}
+		if installDryRun {
+			return listInstallPackages(extra)
+		}
+		if yesFlag {
+			return withPkgBootstrap(installNiriWith(extra))
+		}
+		return installNiriWith(extra)
+	})
+	install.Flags().BoolVar(&lockFlag, "lock", false, "pkg lock the niri packages afterwards, so that pkg upgrade leaves them alone")
+	install.Flags().BoolVarP(&yesFlag, "yes", "y", false, "run pkg bootstrap first if pkg isn't set up yet")
+	install.Flags().StringVar(&installProfile, "profile", "", "also install the packages of the components of this wizard profile: "+strings.Join(wizardProfileNames(), ", "))
+	install.Flags().BoolVar(&installDryRun, "dry-run", false, "only list the packages install would install")
+	install.RegisterFlagCompletionFunc("profile", cobra.FixedCompletions(wizardProfileNames(), cobra.ShellCompDirectiveNoFileComp))
+	root.AddCommand(
+		install,
+		systemActionCmd("update", "Upgrade the niri packages, unlocking them for the update if they are locked", func() tea.Cmd { return withUnlockedStack(updateNiri()) }),
+		actionCmd("lock", "pkg lock the installed niri packages", func() tea.Cmd { return lockNiriStack(true) }),
+		actionCmd("unlock", "pkg unlock the installed niri packages", func() tea.Cmd { return lockNiriStack(false) }),
+		setupCmd(),
+		rollbackCmd(),
+		bootEnvironmentsCmd(),
+		&cobra.Command{
+			Use:   "status",
+			Short: "List the files, rc variables and groups NiriSetup changed, with their current and expected values",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runStatus()
+				return finish("status", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "history",
+			Short: "List the actions run through NiriSetup, newest first, with when they ran and how they ended",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runHistory()
+				return finish("history", status, err)
+			},
+		},
+		conflictsCmd(),
+		modulesCmd(),
+		systemActionCmd("gpu-driver", "Install and load the GPU driver when /dev/dri has no render node", setupGPUDriver),
+		systemActionCmd("firmware", "Install the firmware packages the GPU driver needs and check that it loads them", setupGPUFirmware),
+		configureCmd(),
+		applyCmd(),
+		&cobra.Command{
+			Use:   "test-config [file]",
+			Short: "Run niri in a window with a config (default: the template) without touching the real one",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				path := ""
+				if len(args) > 0 {
+					path = args[0]
+				}
+				status, err := runTestDrive(path, true)
+				return finish("test-config", status, err)
+			},
+		},
+		formatCmd(),
+		bindsCmd(),
+		&cobra.Command{
+			Use:   "cheat-sheet",
+			Short: "Save a cheat sheet of the key bindings to ~/Documents",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runCheatSheet()
+				return finish("cheat-sheet", status, err)
+			},
+		},
+		actionCmd("qt-wayland", "Install the Qt Wayland plugins the installed Qt programs need", setupQtWayland),
+		actionCmd("obs", "Install OBS Studio with PipeWire screen capture", setupOBS),
+		actionCmd("gaming", "Install MangoHud and gamescope where available and add window rules for games", setupGaming),
+		gamepadsCmd(),
+		actionCmd("waybar-workspaces", "Show niri's workspaces and the focused window in waybar", setupWaybarWorkspaces),
+		actionCmd("osk", "Install the wvkbd on-screen keyboard with a toggle key and waybar button", setupOSK),
+		actionCmd("test-suspend", "Suspend, and after resuming check that the GPU, niri and the outputs came back", testSuspendCmd),
+		actionCmd("webcam", "Install and start webcamd so cameras work in niri", setupWebcam),
+		&cobra.Command{
+			Use:       "browser <firefox|chromium|both>",
+			Short:     "Install a browser and make it run natively on Wayland",
+			Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+			ValidArgs: []string{"firefox", "chromium", "both"},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				return runAction("browser", setupBrowser(args[0] != "chromium", args[0] != "firefox"))
+			},
+		},
+		&cobra.Command{
+			Use:       "color-scheme <" + strings.Join(paletteIDs(), "|") + ">",
+			Short:     "Apply a color scheme to niri, waybar, foot, alacritty, fuzzel and mako",
+			Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+			ValidArgs: paletteIDs(),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				p, _ := paletteByID(args[0])
+				return runAction("color-scheme", applyPalette(p))
+			},
+		},
+		&cobra.Command{
+			Use:       "polkit <lxqt-policykit|mate-polkit>",
+			Short:     "Install a polkit agent and start it with niri",
+			Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+			ValidArgs: []string{"lxqt-policykit", "mate-polkit"},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				for _, a := range polkitAgents {
+					if a.pkg == args[0] {
+						return runAction("polkit", setupPolkitAgent(a))
+					}
+				}
+				return nil
+			},
+		},
+		actionCmd("validate", "Check the niri config with niri validate", validateNiriConfig),
+		&cobra.Command{
+			Use:   "lint",
+			Short: "Look for duplicate bindings, missing programs, unknown outputs and conflicting variables in the config",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runLint()
+				return finish("lint", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "audit",
+			Short: "Check niri and its packages for known vulnerabilities with pkg audit",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runAudit()
+				return finish("audit", status, err)
+			},
+		},
+		doctorCmd(),
+		&cobra.Command{
+			Use:   "session-log [file]",
+			Short: "Point out the last error in niri's log and explain known failures",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				path := ""
+				if len(args) > 0 {
+					path = args[0]
+				}
+				status, err := runSessionLogAnalysis(path)
+				return finish("session-log", strings.TrimRight(status, "\n"), err)
+			},
+		},
+		&cobra.Command{
+			Use:   "debug-info",
+			Short: "Collect logs, config and system details into a redacted tarball for bug reports",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runDebugBundle(nil)
+				return finish("debug-info", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "report-bug",
+			Short: "Print the URL of a GitHub issue prefilled with the last niri crash and a system summary",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				d, ok := crashIssue()
+				if !ok {
+					d = issueDraft{repo: nirisetupIssues}
+				}
+				return finish("report-bug", d.url(), nil)
+			},
+		},
+		&cobra.Command{
+			Use:   "session-info",
+			Short: "Show the session environment, seat and services, flagging what differs from the setup",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runSessionInfo()
+				return finish("session-info", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "protocols",
+			Short: "List the Wayland protocols niri advertises and flag those installed programs miss",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runCheckProtocols()
+				return finish("protocols", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "screen-sharing",
+			Short: "Install PipeWire and the portals and start them with niri",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runScreenSharingSetup()
+				return finish("screen-sharing", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "screencast-test",
+			Short: "Request a screencast through xdg-desktop-portal and check that PipeWire gets the video",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runScreencastTest()
+				return finish("screencast-test", status, err)
+			},
+		},
+		&cobra.Command{
+			Use:   "test-graphics",
+			Short: "Run an EGL and Vulkan smoke test and tell hardware from software rendering",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := prepare(); err != nil {
+					return err
+				}
+				status, err := runTestGraphics()
+				return finish("test-graphics", status, err)
+			},
+		},
+		exportCmd(),
+		importCmd(),
+		migrateCmd(),
+		&cobra.Command{
+			Use:   "remote <user@host>",
+			Short: "Copy NiriSetup to a GhostBSD machine over ssh and set it up there",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				setLanguage(langFlag)
+				return remoteSetup(args[0])
+			},
+		},
+	)
+	return root
+}
+
+// exportCmd groups the commands that describe the setup for other tools.
+func exportCmd() *cobra.Command {
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export the setup for other provisioning tools",
+	}
+	var profile string
+	var components []string
+	ansible := &cobra.Command{
+		Use:   "ansible [dir]",
+		Short: "Write the setup as an Ansible playbook (default dir: nirisetup-ansible)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "nirisetup-ansible"
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			setLanguage(langFlag)
+			chosen, err := ansibleComponentNames(profile, components)
+			if err != nil {
+				return finish("export ansible", err.Error(), preflightFailure(err))
+			}
+			return runAction("export ansible", exportAnsible(dir, chosen))
+		},
+	}
+	ansible.Flags().StringVar(&profile, "profile", "", "add the components of this wizard profile: "+strings.Join(wizardProfileNames(), ", "))
+	ansible.RegisterFlagCompletionFunc("profile", cobra.FixedCompletions(wizardProfileNames(), cobra.ShellCompDirectiveNoFileComp))
+	ansible.Flags().StringSliceVar(&components, "components", nil, "add these wizard components, by name, e.g. --components \"Media keys,Gaming\" (default: those last chosen in the wizard)")
+	export.AddCommand(ansible)
+	export.AddCommand(&cobra.Command{
+		Use:   "environment [file]",
+		Short: "Write the packages, rc.conf services and desktop configs to a tarball",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := defaultSnapshotPath()
+			if len(args) > 0 {
+				path = args[0]
+			}
+			setLanguage(langFlag)
+			return runAction("export environment", exportEnvironment(path))
+		},
+	})
+	return export
+}
+
+func importCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Apply an environment tarball made by export environment",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			return runAction("import", importEnvironment(args[0]))
+		},
+	}
+}
+
+func migrateCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate <sway|i3|hyprland> [file]",
+		Short: "Translate the config of another window manager into the niri config",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			path := ""
+			if len(args) > 1 {
+				path = args[1]
+			}
+			mg, err := readMigration(args[0], path)
+			if err != nil {
+				return finish("migrate", err.Error(), preflightFailure(err))
+			}
+			if dryRun {
+				return finish("migrate", mg.describe(), nil)
+			}
+			status, err := runMigration(mg)
+			return finish("migrate", status, err)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the translation, don't change the niri config")
+	return cmd
+}
+
+func setupCmd() *cobra.Command {
+	var dryRun bool
+	var skip, force []string
+	modes := func() map[string]string {
+		modes := savedStepModes()
+		for _, name := range skip {
+			modes[name] = stepSkip
+		}
+		for _, name := range force {
+			modes[name] = stepForce
+		}
+		return modes
+	}
+	cmd := systemActionCmd("setup", "Enable services, load the DRM module and prepare the login environment", func() tea.Cmd {
+		return setupSystemWith(modes())
+	})
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		for _, names := range []*[]string{&skip, &force} {
+			for i, arg := range *names {
+				name, ok := stepNamed(arg)
+				if !ok {
+					return finish("setup", Tf("There is no step %q. The steps are: %s", arg, strings.Join(setupStepNames(), ", ")), preflightFailure(fmt.Errorf("unknown step %q", arg)))
+				}
+				(*names)[i] = name
+			}
+		}
+		if !dryRun {
+			return run(cmd, args)
+		}
+		if err := prepare(); err != nil {
+			return err
+		}
+		body, _ := pendingSetupChanges(modes())
+		return finish("setup", body, nil)
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the changes to .profile, rc.conf and config.kdl setup would make")
+	cmd.Flags().StringSliceVar(&skip, "skip", nil, "leave out these steps, by name, e.g. --skip .profile")
+	cmd.Flags().StringSliceVar(&force, "force", nil, "run these steps even if they look done")
+	return cmd
+}
+
+func applyCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "apply <manifest.yaml>",
+		Short: "Install the packages, start the services and set the config a manifest declares, where missing",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			m, err := readManifest(args[0])
+			if err != nil {
+				return finish("apply", err.Error(), preflightFailure(err))
+			}
+			if dryRun {
+				return finish("apply", planManifest(m).describe(m), nil)
+			}
+			status, err := applyManifest(m)
+			return finish("apply", status, err)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show what is missing, don't change anything")
+	return cmd
+}
+
+// actionCmd wraps a menu action as a subcommand that runs it once and prints
+// the result.
+func actionCmd(name, short string, action func() tea.Cmd) *cobra.Command {
+	return &cobra.Command{
+		Use:   name,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			return runAction(name, action())
+		},
+	}
+}
+
+// systemActionCmd is actionCmd for steps that change the system, which can
+// be undone with a ZFS boot environment.
+func systemActionCmd(name, short string, action func() tea.Cmd) *cobra.Command {
+	var be bool
+	cmd := actionCmd(name, short, func() tea.Cmd {
+		if be {
+			return withBootEnvironment(action())
+		}
+		return action()
+	})
+	cmd.Flags().BoolVar(&be, "boot-environment", false, "create a ZFS boot environment first, so that rollback can undo the step")
+	return cmd
+}
+
+// gamepadsCmd sets up game controllers, switching USB HID devices to the
+// hidbus stack only when asked to, as the menu asks first.
+func gamepadsCmd() *cobra.Command {
+	var usbhid bool
+	cmd := actionCmd("gamepads", "Give the session access to game controllers through devfs rules and the HID drivers", func() tea.Cmd {
+		return setupGamepadsWith(usbhid)
+	})
+	cmd.Flags().BoolVar(&usbhid, "usbhid", false, "also set "+usbhidTunable+"=1, which moves every USB input device, keyboards and mice too, to the hidbus stack at the next boot")
+	return cmd
+}
+
+func rollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Activate the ZFS boot environment from before the last setup",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			switch msg := loadRollback()().(type) {
+			case confirmMsg:
+				if !yesFlag {
+					return finish("rollback", msg.confirmation.prompt+"\n"+T("Pass --yes to go ahead."), preflightFailure(errors.New("not confirmed")))
+				}
+				return runAction("rollback", msg.confirmation.yes)
+			case statusMsg:
+				return finish("rollback", msg.status, msg.err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "activate the boot environment without asking")
+	return cmd
+}
+
+func bootEnvironmentsCmd() *cobra.Command {
+	var prune bool
+	cmd := &cobra.Command{
+		Use:   "boot-environments",
+		Short: "List the ZFS boot environments NiriSetup created, or delete the old ones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			msg := bootEnvironmentsView()()
+			r, ok := msg.(reportMsg)
+			if !ok {
+				status := msg.(statusMsg)
+				return finish("boot-environments", status.status, status.err)
+			}
+			if !prune {
+				return finish("boot-environments", r.report.body, nil)
+			}
+			if len(r.report.actions) == 0 {
+				return finish("boot-environments", Tf("Nothing to delete: NiriSetup keeps the newest %d boot environments", bootEnvironmentsKept), nil)
+			}
+			confirm := r.report.actions[0].cmd().(confirmMsg)
+			if !yesFlag {
+				return finish("boot-environments", confirm.confirmation.prompt+"\n"+T("Pass --yes to go ahead."), preflightFailure(errors.New("not confirmed")))
+			}
+			return runAction("boot-environments", confirm.confirmation.yes)
+		},
+	}
+	cmd.Flags().BoolVar(&prune, "prune", false, "delete all but the newest boot environments")
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "delete without asking")
+	return cmd
+}
+
+func modulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "modules",
+		Short: "Show which DRM, GPU and input kernel modules are loaded and configured",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			statuses, gpus, err := moduleStatuses()
+			if err != nil {
+				return finish("modules", Tf("Failed to run kldstat: %v", err), preflightFailure(err))
+			}
+			issues := moduleIssues(statuses, gpus)
+			text := strings.TrimRight(describeModules(statuses, issues), "\n")
+			if len(issues) == 0 {
+				return finish("modules", text, nil)
+			}
+			if !yesFlag {
+				return finish("modules", text+"\n\n"+T("Pass --yes to fix them."), errors.New("module mismatches found"))
+			}
+			var cmds []tea.Cmd
+			for _, issue := range issues {
+				cmds = append(cmds, fixModule(issue))
+			}
+			fmt.Println(text)
+			return runAction("modules", cmds...)
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "load and persist the modules as needed")
+	return cmd
+}
+
+func formatCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "format",
+		Short: "Show how the config would be formatted: indentation, quoting and section order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			status, err := runFormat(yesFlag)
+			return finish("format", status, err)
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "write the formatted config")
+	return cmd
+}
+
+func bindsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "binds",
+		Short: "Look for key combinations bound twice and essential actions without a key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			status, err := runKeyBindings(yesFlag)
+			return finish("binds", status, err)
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "bind the missing essential actions to free keys")
+	return cmd
+}
+
+func doctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Look for graphics errors in the kernel messages and setup problems, and suggest fixes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			status, err := runDoctor(yesFlag)
+			return finish("doctor", strings.TrimRight(status, "\n"), err)
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "fix the setup problems found")
+	return cmd
+}
+
+func conflictsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conflicts",
+		Short: "Look for display managers, other compositors and X11 settings that get in niri's way",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			conflicts := findConflicts()
+			if len(conflicts) == 0 {
+				return finish("conflicts", T("No other display manager, compositor or X11 session settings found"), nil)
+			}
+			text := describeConflicts(conflicts)
+			if !yesFlag {
+				for _, c := range conflicts {
+					if c.fix != nil {
+						text += "\n\n" + T("Pass --yes to set up niri to coexist with them.")
+						break
+					}
+				}
+				return finish("conflicts", text, errors.New("conflicts found"))
+			}
+			msg, _ := applyCoexistence(conflicts)().(statusMsg)
+			return finish("conflicts", text+"\n\n"+msg.status, msg.err)
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "set up niri to coexist with what was found")
+	return cmd
+}
+
+func configureCmd() *cobra.Command {
+	var scope string
+	var sectionIDs []string
+	cmd := &cobra.Command{
+		Use:   "configure",
+		Short: "Copy the config template to ~/.config/niri/config.kdl or " + systemConfigPath,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepare(); err != nil {
+				return err
+			}
+			var actions []tea.Cmd
+			switch {
+			case len(sectionIDs) > 0 && scope != "user":
+				err := fmt.Errorf("--section only applies to the user config, not --scope %s", scope)
+				return finish("configure", err.Error(), preflightFailure(err))
+			case len(sectionIDs) > 0:
+				var sections []configSection
+				for _, id := range sectionIDs {
+					sec, ok := configSectionByID(id)
+					if !ok {
+						err := fmt.Errorf("unknown section %q, the sections are: %s", id, strings.Join(configSectionIDs(), ", "))
+						return finish("configure", err.Error(), preflightFailure(err))
+					}
+					sections = append(sections, sec)
+				}
+				actions = []tea.Cmd{applyConfigSections(sections)}
+			case scope == "user":
+				actions = []tea.Cmd{configureNiri()}
+			case scope == "system":
+				actions = []tea.Cmd{installSystemConfig()}
+			case scope == "both":
+				actions = []tea.Cmd{configureNiri(), installSystemConfig()}
+			default:
+				err := fmt.Errorf("--scope must be user, system or both, not %q", scope)
+				return finish("configure", err.Error(), preflightFailure(err))
+			}
+			// Mirrors the confirmation the menu asks for in a running session.
+			if scope != "system" && !yesFlag && niriRunning() {
+				return finish("configure", "niri is running and would reload the replaced config; pass --yes to continue", preflightFailure(errors.New("niri is running")))
+			}
+			return runAction("configure", actions...)
+		},
+	}
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "replace the live config without asking when niri is running")
+	cmd.Flags().StringVar(&scope, "scope", "user", "where to install the config: user (~/.config/niri), system (the default for all users) or both")
+	cmd.RegisterFlagCompletionFunc("scope", cobra.FixedCompletions([]string{"user", "system", "both"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.Flags().StringSliceVar(&sectionIDs, "section", nil, "merge only these sections of the template into the existing config: "+strings.Join(configSectionIDs(), ", "))
+	cmd.RegisterFlagCompletionFunc("section", cobra.FixedCompletions(configSectionIDs(), cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+// prepare applies the global options before anything runs.
+func prepare() error {
+	if err := setTargetUser(userFlag); err != nil {
+		return err
+	}
+	setLanguage(langFlag)
+	if recordFlag != "" {
+		if err := startRecording(recordFlag); err != nil {
+			return fmt.Errorf("failed to create %s: %v", recordFlag, err)
+		}
+	}
+
+	// Inside a running session XDG_RUNTIME_DIR is already set up, and
+	// pointing it elsewhere would cut child processes off from the compositor.
+	if !insideNiriSession() {
+		setupEnvironment()
+	}
+	return nil
+}
+
+// runAction runs actions outside the interface, in order, and prints their
+// results. The worst failure decides the exit code.
+func runAction(name string, cmds ...tea.Cmd) error {
+	var statuses []string
+	var worst error
+	for _, cmd := range cmds {
+		msg, _ := cmd().(statusMsg)
+		statuses = append(statuses, msg.status)
+		if exitCode(msg.err) > exitCode(worst) {
+			worst = msg.err
+		}
+	}
+	return finish(name, strings.Join(statuses, "\n\n"), worst)
+}
+
+// finish prints the outcome of a subcommand and the summary line, and
+// returns the error carrying its exit code.
+func finish(name, status string, err error) error {
+	if status != "" {
+		fmt.Println(status)
+	}
+	if name != "history" {
+		recordHistory(historyCLI, name, status, err)
+	}
+	code := exitCode(err)
+	fmt.Printf("nirisetup: command=%s status=%s exit=%d\n", name, exitStatus[code], code)
+	if code != exitOK {
+		return &exitError{code}
+	}
+	return nil
+}
+
+// runInterface starts the full-screen interface, or plain mode when asked
+// for or when the terminal can't show it.
+func runInterface() error {
+	if plainFlag || plainTerminal() {
+		return runPlain(initialModel())
+	}
+
+	// Clear the terminal screen
+	clearScreen()
+	p := tea.NewProgram(initialModel())
+	program = p
+	if err := p.Start(); err != nil {
+		log.Fatalf("Alas, there's been an error: %v", err)
+	}
+	return nil
+}
+
+// execInteractive runs cmd on the terminal, suspending the full-screen
+// interface while it runs, and reports the result through done.
+func execInteractive(cmd *exec.Cmd, done func(err error) tea.Msg) tea.Cmd {
+	if program == nil {
+		return func() tea.Msg {
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			return done(runCommand(cmd))
+		}
+	}
+	traceStart(cmd)
+	return tea.ExecProcess(cmd, done)
+}
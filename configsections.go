@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// configSection is a part of the config template that Configure Niri can
+// apply on its own: the top-level nodes named in nodes. id names it on the
+// command line.
+type configSection struct {
+	id    string
+	name  string
+	nodes []string
+}
+
+// configSections are the parts of the template, in the order they are
+// offered. The nodes none of them names belong to the last one.
+var configSections = []configSection{
+	{"input", "Input", []string{"input"}},
+	{"outputs", "Outputs", []string{"output"}},
+	{"layout", "Layout", []string{"layout", "prefer-no-csd"}},
+	{"startup", "Startup programs", []string{"spawn-at-startup"}},
+	{"environment", "Environment", []string{"environment"}},
+	{"animations", "Animations", []string{"animations"}},
+	{"window-rules", "Window rules", []string{"window-rule", "layer-rule"}},
+	{"binds", "Key bindings", []string{"binds"}},
+	{"other", "Other settings", nil},
+}
+
+// configSectionByID returns the section called id on the command line.
+func configSectionByID(id string) (configSection, bool) {
+	for _, sec := range configSections {
+		if strings.EqualFold(sec.id, id) {
+			return sec, true
+		}
+	}
+	return configSection{}, false
+}
+
+// configSectionIDs lists the command line names of the sections.
+func configSectionIDs() []string {
+	var ids []string
+	for _, sec := range configSections {
+		ids = append(ids, sec.id)
+	}
+	return ids
+}
+
+// holds reports whether the top-level node name belongs to sec.
+func (sec configSection) holds(name string) bool {
+	if sec.nodes != nil {
+		return containsString(sec.nodes, name)
+	}
+	for _, other := range configSections {
+		if containsString(other.nodes, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// fragment returns the top-level nodes of tmpl that belong to sec, leaving
+// out those commented out with /-.
+func (sec configSection) fragment(tmpl string) string {
+	var nodes []string
+	for _, sp := range kdlChildren(tmpl, 0, len(tmpl)) {
+		name := kdlNodeName(tmpl, sp)
+		if !strings.HasPrefix(name, "/-") && sec.holds(name) {
+			nodes = append(nodes, tmpl[sp.start:sp.end])
+		}
+	}
+	return strings.Join(nodes, "\n")
+}
+
+// mergeConfigSections applies sections of tmpl to cfg through the KDL
+// layer, like the config fragments of a manifest: each node they set
+// replaces the node of the same name in cfg, window rules are added unless
+// cfg has them already, and a startup program replaces the line that
+// starts the same program. Everything else in cfg stays as it is. It
+// returns the merged config and the nodes that changed it.
+func mergeConfigSections(cfg, tmpl string, sections []configSection) (string, []manifestEdit) {
+	var applied []manifestEdit
+	for _, sec := range sections {
+		for _, e := range fragmentEdits(sec.fragment(tmpl), nil) {
+			var next string
+			switch {
+			case len(e.path) == 0 && spawnArgs(e.node) != nil:
+				next = setSpawnAtStartup(cfg, spawnArgs(e.node)...)
+			case e.present(cfg):
+				continue
+			default:
+				next = e.apply(cfg)
+			}
+			if next != cfg {
+				cfg = next
+				applied = append(applied, e)
+			}
+		}
+	}
+	return cfg, applied
+}
+
+// newConfigSectionsForm lets the user pick the sections of the template to
+// merge into the existing config. Sections the template has nothing for
+// aren't offered.
+func newConfigSectionsForm(tmpl string, inSession bool) *form {
+	var offered []configSection
+	f := &form{title: "Configure Niri: Sections"}
+	for _, sec := range configSections {
+		if sec.fragment(tmpl) == "" {
+			continue
+		}
+		offered = append(offered, sec)
+		f.fields = append(f.fields, formField{
+			label:   sec.name,
+			value:   "Keep mine",
+			options: []string{"Keep mine", "Apply"},
+			help:    "Apply: merge the template's settings for this part into your config; your other settings stay. Keep mine: leave this part of your config alone.",
+		})
+	}
+	f.submit = func(values []string) (tea.Cmd, error) {
+		var chosen []configSection
+		for i, sec := range offered {
+			if values[i] == "Apply" {
+				chosen = append(chosen, sec)
+			}
+		}
+		if len(chosen) == 0 {
+			return nil, errors.New(T("Pick at least one section to apply"))
+		}
+		return previewConfigSections(chosen, inSession), nil
+	}
+	return f
+}
+
+// previewConfigSections shows the diff merging sections into the config
+// makes, and writes it when confirmed.
+func previewConfigSections(sections []configSection, inSession bool) tea.Cmd {
+	return func() tea.Msg {
+		tmpl, _, err := niriConfigTemplate()
+		if err != nil {
+			return statusMsg{status: err.Error(), err: preflightFailure(err)}
+		}
+		path, cfg, err := readNiriConfig()
+		if err != nil {
+			return statusMsg{status: err.Error(), err: err}
+		}
+		merged, applied := mergeConfigSections(cfg, tmpl, sections)
+		r := &report{title: "Configure Niri: Sections"}
+		if len(applied) == 0 {
+			r.body = T("Your config already has everything these sections of the template set.")
+			return reportMsg{r}
+		}
+		r.body = unifiedDiff(cfg, merged, path, path)
+		write := applyConfigSections(sections)
+		if inSession {
+			write = func() tea.Msg {
+				return confirmMsg{&confirmation{
+					prompt:    "niri is running and will reload the changed config immediately.\n\nWrite the changes?",
+					actionMsg: "Configuring Niri...",
+					yes:       applyConfigSections(sections),
+				}}
+			}
+		}
+		r.actions = []reportAction{{label: T("Write these changes"), cmd: write}}
+		return reportMsg{r}
+	}
+}
+
+// applyConfigSections merges sections of the template into the config.
+func applyConfigSections(sections []configSection) tea.Cmd {
+	return func() tea.Msg {
+		tmpl, _, err := niriConfigTemplate()
+		if err != nil {
+			return statusMsg{status: err.Error(), err: preflightFailure(err)}
+		}
+		var applied []manifestEdit
+		path, err := updateNiriConfig(func(cfg string) string {
+			merged, edits := mergeConfigSections(cfg, tmpl, sections)
+			applied = edits
+			return merged
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to update %s: %v", path, err), err: err}
+		}
+		var names []string
+		for _, sec := range sections {
+			names = append(names, T(sec.name))
+		}
+		if len(applied) == 0 {
+			return statusMsg{status: Tf("%s already has everything these sections set: %s", path, strings.Join(names, ", "))}
+		}
+		lines := []string{Tf("Merged %s from the template into %s:", strings.Join(names, ", "), path)}
+		for _, e := range applied {
+			lines = append(lines, "  "+e.String())
+		}
+		return statusMsg{status: strings.Join(lines, "\n")}
+	}
+}
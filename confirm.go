@@ -0,0 +1,62 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmation asks a yes/no question before running an action. If no is
+// set, answering no runs it instead of cancelling, and esc cancels.
+type confirmation struct {
+	prompt    string
+	actionMsg string
+	yes       tea.Cmd
+	no        tea.Cmd
+}
+
+// confirmMsg opens a confirmation prepared by a background command.
+type confirmMsg struct {
+	confirmation *confirmation
+}
+
+var warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00")).Padding(1, 2).Width(viewWidth)
+
+func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.confirm
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "y", "Y":
+		m.state = actionView
+		m.actionMsg = c.actionMsg
+		m.confirm = nil
+		return m, c.yes
+	case "n", "N":
+		if c.no != nil {
+			m.state = actionView
+			m.actionMsg = c.actionMsg
+			m.confirm = nil
+			return m, c.no
+		}
+		m.state = menuView
+		m.confirm = nil
+		m.isProcessing = false
+	case "esc", "q", "backspace":
+		m.state = menuView
+		m.confirm = nil
+		m.isProcessing = false
+	}
+	return m, nil
+}
+
+func (m model) renderConfirmView() string {
+	help := "y continue • n cancel"
+	if m.confirm.no != nil {
+		help = "y yes • n no • esc cancel"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(T("Please confirm")),
+		warningStyle.Render(T(m.confirm.prompt)),
+		formHelpStyle.Render(T(help)),
+	)
+}
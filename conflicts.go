@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// conflict is another desktop setup found on this machine that can get in
+// the way of niri. fix, if set, applies a configuration that lets both
+// live side by side and describes what it did.
+type conflict struct {
+	what string
+	why  string
+	fix  func() (string, error)
+}
+
+// displayManagers are the login managers whose rc.conf switch is checked.
+var displayManagers = []string{"lightdm", "sddm", "gdm", "slim", "xdm"}
+
+// otherCompositors are Wayland compositors that use the same seat and
+// login setup as niri.
+var otherCompositors = []string{"sway", "hyprland", "wayfire", "labwc", "river", "hikari"}
+
+// x11Env matches exports that force applications onto X11 or pose as
+// another desktop. In ~/.profile they end up in the niri session too.
+var x11Env = regexp.MustCompile(`^\s*(export\s+)?(GDK_BACKEND=x11|QT_QPA_PLATFORM=xcb|SDL_VIDEODRIVER=x11|CLUTTER_BACKEND=x11|XDG_SESSION_TYPE=x11|MOZ_ENABLE_WAYLAND=0|XDG_CURRENT_DESKTOP=|XDG_SESSION_DESKTOP=)`)
+
+// sessionAutostart matches a login script line that starts a graphical
+// session by itself.
+var sessionAutostart = regexp.MustCompile(`^\s*[^#]*\b(startx|xinit|exec\s+(sway|Hyprland|wayfire|labwc|river|hikari))\b`)
+
+const (
+	sessionScriptPath = "/usr/local/bin/nirisetup-session"
+	sessionEntryPath  = "/usr/local/share/wayland-sessions/nirisetup-niri.desktop"
+)
+
+// sessionScript starts niri the way NiriSetup sets it up, for display
+// managers that start Wayland sessions.
+const sessionScript = `#!/bin/sh
+# Generated by NiriSetup: start niri from a display manager.
+export LIBSEAT_BACKEND=consolekit2
+exec dbus-launch --exit-with-session niri --session
+`
+
+const sessionEntry = `[Desktop Entry]
+Name=Niri
+Comment=Scrollable-tiling Wayland compositor (set up by NiriSetup)
+Exec=` + sessionScriptPath + `
+Type=Application
+DesktopNames=niri
+`
+
+// enabledDisplayManager returns the display manager started at boot, if
+// any.
+func enabledDisplayManager() string {
+	for _, dm := range displayManagers {
+		out, err := commandOutput(exec.Command("sysrc", "-n", dm+"_enable"))
+		if err == nil && strings.EqualFold(strings.TrimSpace(string(out)), "YES") {
+			return dm
+		}
+	}
+	return ""
+}
+
+// findConflicts looks for display managers, other compositors and login
+// settings that would fight with niri over the seat or its environment.
+func findConflicts() []conflict {
+	var conflicts []conflict
+	if dm := enabledDisplayManager(); dm != "" {
+		conflicts = append(conflicts, conflict{
+			what: Tf("The %s display manager starts at boot", dm),
+			why:  T("It runs Xorg on its own virtual terminal and opens a ConsoleKit session there. Starting niri from another TTY works, but niri won't be offered at the login screen."),
+			fix:  installSessionEntry,
+		})
+	}
+	for _, name := range otherCompositors {
+		if isPackageInstalled(name) {
+			conflicts = append(conflicts, conflict{
+				what: Tf("%s is installed", name),
+				why:  T("It uses the same seat and login environment as niri. That works as long as only one of them is started at a time and its settings don't leak into ~/.profile."),
+			})
+		}
+	}
+
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return conflicts
+	}
+	for _, name := range []string{".profile", ".login"} {
+		data, err := os.ReadFile(filepath.Join(homeDir, name))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			// A line that checks the TTY already leaves the others free.
+			if sessionAutostart.MatchString(line) && !strings.Contains(line, "tty") {
+				conflicts = append(conflicts, conflict{
+					what: Tf("~/%s starts a session: %s", name, strings.TrimSpace(line)),
+					why:  T("Logging in on a TTY starts that session, so there is no shell left to start niri from. Limit it to one TTY, e.g. with [ \"$(tty)\" = /dev/ttyv1 ] &&, and start niri from another."),
+				})
+			}
+		}
+	}
+
+	profile := filepath.Join(homeDir, ".profile")
+	data, _ := os.ReadFile(profile)
+	var x11Lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if x11Env.MatchString(line) {
+			x11Lines = append(x11Lines, strings.TrimSpace(line))
+		} else if strings.Contains(line, "LIBSEAT_BACKEND=") && !strings.Contains(line, "consolekit2") && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			conflicts = append(conflicts, conflict{
+				what: Tf("~/.profile sets %s", strings.TrimSpace(line)),
+				why:  T("NiriSetup starts niri through ConsoleKit2 (LIBSEAT_BACKEND=consolekit2), and Setup System keeps an existing setting. If niri can't open the seat, change it to consolekit2."),
+			})
+		}
+	}
+	if len(x11Lines) > 0 {
+		conflicts = append(conflicts, conflict{
+			what: Tf("~/.profile sets X11 or desktop variables: %s", strings.Join(x11Lines, "; ")),
+			why:  T("~/.profile is read before niri starts, so these make applications in niri use X11 or believe they run in another desktop."),
+			fix:  moveX11Env,
+		})
+	}
+	return conflicts
+}
+
+// installSessionEntry adds niri to the sessions display managers offer.
+func installSessionEntry() (string, error) {
+	dir := filepath.Dir(sessionEntryPath)
+	if out, err := commandCombinedOutput(exec.Command("sudo", "mkdir", "-p", dir)); err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	if err := writeRootFile(sessionScriptPath, sessionScript, 0755); err != nil {
+		return "", err
+	}
+	if err := writeRootFile(sessionEntryPath, sessionEntry, 0644); err != nil {
+		return "", err
+	}
+	return Tf("Added niri to the display manager's sessions (%s)", sessionEntryPath), nil
+}
+
+// moveX11Env moves the X11 and desktop exports from ~/.profile to
+// ~/.xprofile, which display managers read for X sessions only.
+func moveX11Env() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	profile := filepath.Join(homeDir, ".profile")
+	data, err := os.ReadFile(profile)
+	if err != nil {
+		return "", err
+	}
+	var keep, moved []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if x11Env.MatchString(line) {
+			moved = append(moved, line)
+		} else {
+			keep = append(keep, line)
+		}
+	}
+	if len(moved) == 0 {
+		return "", nil
+	}
+	if err := writeFile(profile+".bak", data, 0644); err != nil {
+		return "", err
+	}
+	xprofile := filepath.Join(homeDir, ".xprofile")
+	text := "\n# Moved from ~/.profile by NiriSetup, X sessions only\n" + strings.Join(moved, "\n") + "\n"
+	if err := appendFile(xprofile, text); err != nil {
+		return "", err
+	}
+	if err := writeFile(profile, []byte(strings.Join(keep, "\n")), 0644); err != nil {
+		return "", err
+	}
+	return Tf("Moved %d lines from %s to %s (backup in %s.bak)", len(moved), profile, xprofile, profile), nil
+}
+
+// describeConflicts explains the conflicts, one paragraph each.
+func describeConflicts(conflicts []conflict) string {
+	var b strings.Builder
+	for _, c := range conflicts {
+		fmt.Fprintf(&b, "• %s\n  %s\n", c.what, c.why)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// applyCoexistence applies the fixes of conflicts.
+func applyCoexistence(conflicts []conflict) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		var failed error
+		for _, c := range conflicts {
+			if c.fix == nil {
+				continue
+			}
+			line, err := c.fix()
+			if err != nil {
+				logs = append(logs, Tf("Warning: %s: %v", c.what, err))
+				failed = err
+			} else if line != "" {
+				logs = append(logs, line)
+			}
+		}
+		return statusMsg{status: strings.Join(logs, "\n"), err: failed}
+	}
+}
+
+// checkConflicts explains what other desktop setups are found and, when
+// some of them can be reconciled, asks whether to do that.
+func checkConflicts() tea.Cmd {
+	return func() tea.Msg {
+		conflicts := findConflicts()
+		if len(conflicts) == 0 {
+			return statusMsg{status: T("No other display manager, compositor or X11 session settings found")}
+		}
+		text := Tf("Found %d other desktop setups:", len(conflicts)) + "\n\n" + describeConflicts(conflicts)
+		for _, c := range conflicts {
+			if c.fix != nil {
+				return confirmMsg{&confirmation{
+					prompt:    text + "\n\n" + T("Set up niri to coexist with them? Nothing is uninstalled or disabled."),
+					actionMsg: "Setting up coexistence...",
+					yes:       applyCoexistence(conflicts),
+				}}
+			}
+		}
+		return reportMsg{&report{title: "Desktop Conflicts", body: text, refresh: checkConflicts()}}
+	}
+}
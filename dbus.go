@@ -0,0 +1,577 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dbusConn is a minimal D-Bus client for the portal requests that can't be
+// made with dbus-send: a portal session belongs to the connection that
+// created it and answers through signals, so the whole exchange has to
+// happen on one connection. It covers the basic types, variants, arrays,
+// dicts and structs, which is all the portals use apart from file
+// descriptors.
+type dbusConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	serial  uint32
+	name    string
+	signals []*dbusMessage
+}
+
+// dbusVariant is a value of type v with its signature.
+type dbusVariant struct {
+	sig   string
+	value any
+}
+
+type dbusMessage struct {
+	kind    byte
+	serial  uint32
+	replyTo uint32
+	path    string
+	iface   string
+	member  string
+	errName string
+	sender  string
+	body    []any
+}
+
+const (
+	dbusMethodCall   = 1
+	dbusMethodReturn = 2
+	dbusError        = 3
+	dbusSignal       = 4
+)
+
+// dialSessionBus connects to the session bus and says hello.
+func dialSessionBus() (*dbusConn, error) {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		return nil, errors.New("DBUS_SESSION_BUS_ADDRESS is not set")
+	}
+	var network, path string
+	for _, a := range strings.Split(addr, ";") {
+		if rest, ok := strings.CutPrefix(a, "unix:"); ok {
+			for _, kv := range strings.Split(rest, ",") {
+				if v, ok := strings.CutPrefix(kv, "path="); ok {
+					network, path = "unix", v
+				} else if v, ok := strings.CutPrefix(kv, "abstract="); ok {
+					network, path = "unix", "@"+v
+				}
+			}
+			break
+		}
+	}
+	if path == "" {
+		return nil, fmt.Errorf("unsupported bus address %s", addr)
+	}
+	conn, err := net.Dial(network, path)
+	if err != nil {
+		return nil, err
+	}
+	c := &dbusConn{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.auth(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reply, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", "")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.name, _ = reply[0].(string)
+	return c, nil
+}
+
+func (c *dbusConn) Close() error {
+	return c.conn.Close()
+}
+
+// auth authenticates as the owner of the process with SASL EXTERNAL.
+func (c *dbusConn) auth() error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := fmt.Fprintf(c.conn, "\x00AUTH EXTERNAL %s\r\n", uid); err != nil {
+		return err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("bus refused authentication: %s", strings.TrimSpace(line))
+	}
+	_, err = io.WriteString(c.conn, "BEGIN\r\n")
+	return err
+}
+
+// call invokes a method and waits for its reply. sig is the signature of
+// args; signals arriving meanwhile are kept for waitSignal.
+func (c *dbusConn) call(dest, path, iface, member, sig string, args ...any) ([]any, error) {
+	c.serial++
+	serial := c.serial
+	fields := []any{
+		[]any{byte(1), dbusVariant{"o", path}},
+		[]any{byte(2), dbusVariant{"s", iface}},
+		[]any{byte(3), dbusVariant{"s", member}},
+		[]any{byte(6), dbusVariant{"s", dest}},
+	}
+	body := &dbusEncoder{}
+	if sig != "" {
+		fields = append(fields, []any{byte(8), dbusVariant{"g", sig}})
+		types, err := splitDBusSignature(sig)
+		if err != nil {
+			return nil, err
+		}
+		if len(types) != len(args) {
+			return nil, fmt.Errorf("%s: signature %s doesn't match %d arguments", member, sig, len(args))
+		}
+		for i, t := range types {
+			body.value(t, args[i])
+		}
+	}
+	head := &dbusEncoder{}
+	head.value("y", byte('l'))
+	head.value("y", byte(dbusMethodCall))
+	head.value("y", byte(0))
+	head.value("y", byte(1))
+	head.value("u", uint32(len(body.buf)))
+	head.value("u", serial)
+	head.value("a(yv)", fields)
+	head.align(8)
+	if _, err := c.conn.Write(append(head.buf, body.buf...)); err != nil {
+		return nil, err
+	}
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case msg.kind == dbusSignal:
+			c.signals = append(c.signals, msg)
+		case msg.replyTo != serial:
+		case msg.kind == dbusError:
+			text := ""
+			if len(msg.body) > 0 {
+				text, _ = msg.body[0].(string)
+			}
+			return nil, fmt.Errorf("%s: %s", msg.errName, text)
+		default:
+			return msg.body, nil
+		}
+	}
+}
+
+// waitSignal waits for a signal from path with the given member.
+func (c *dbusConn) waitSignal(path, member string, timeout time.Duration) (*dbusMessage, error) {
+	c.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+	for {
+		for i, s := range c.signals {
+			if s.path == path && s.member == member {
+				c.signals = append(c.signals[:i], c.signals[i+1:]...)
+				return s, nil
+			}
+		}
+		msg, err := c.read()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				return nil, fmt.Errorf("no answer within %s", timeout)
+			}
+			return nil, err
+		}
+		if msg.kind == dbusSignal {
+			c.signals = append(c.signals, msg)
+		}
+	}
+}
+
+// read reads the next message from the bus.
+func (c *dbusConn) read() (*dbusMessage, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(c.r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if fixed[0] == 'B' {
+		order = binary.BigEndian
+	}
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+	headLen := 16 + int(fieldsLen)
+	headLen += (8 - headLen%8) % 8
+	rest := make([]byte, headLen-16+int(bodyLen))
+	if _, err := io.ReadFull(c.r, rest); err != nil {
+		return nil, err
+	}
+	head := append(fixed, rest[:headLen-16]...)
+
+	msg := &dbusMessage{kind: fixed[1], serial: order.Uint32(fixed[8:])}
+	d := &dbusDecoder{buf: head, pos: 12, order: order}
+	fields, err := d.value("a(yv)")
+	if err != nil {
+		return nil, err
+	}
+	sig := ""
+	for _, f := range fields.([]any) {
+		f := f.([]any)
+		switch f[0].(byte) {
+		case 1:
+			msg.path, _ = f[1].(string)
+		case 2:
+			msg.iface, _ = f[1].(string)
+		case 3:
+			msg.member, _ = f[1].(string)
+		case 4:
+			msg.errName, _ = f[1].(string)
+		case 5:
+			msg.replyTo, _ = f[1].(uint32)
+		case 7:
+			msg.sender, _ = f[1].(string)
+		case 8:
+			sig, _ = f[1].(string)
+		}
+	}
+	types, err := splitDBusSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	d = &dbusDecoder{buf: rest[headLen-16:], order: order}
+	for _, t := range types {
+		v, err := d.value(t)
+		if err != nil {
+			return nil, err
+		}
+		msg.body = append(msg.body, v)
+	}
+	return msg, nil
+}
+
+// dbusBasicTypes are the type codes that may be dict keys.
+const dbusBasicTypes = "ybnqiuxtdhsog"
+
+// splitDBusSignature splits a signature into its single complete types.
+func splitDBusSignature(sig string) ([]string, error) {
+	var types []string
+	for rest := sig; rest != ""; {
+		n := dbusTypeLen(rest)
+		if n == 0 {
+			return nil, fmt.Errorf("malformed D-Bus signature %q", sig)
+		}
+		types = append(types, rest[:n])
+		rest = rest[n:]
+	}
+	return types, nil
+}
+
+// dbusTypeLen returns the length of the complete type sig starts with, or
+// 0 when it doesn't start with one.
+func dbusTypeLen(sig string) int {
+	if sig == "" {
+		return 0
+	}
+	switch sig[0] {
+	case 'a':
+		if n := dbusTypeLen(sig[1:]); n > 0 {
+			return 1 + n
+		}
+		return 0
+	case '(':
+		i := 1
+		for i < len(sig) && sig[i] != ')' {
+			n := dbusTypeLen(sig[i:])
+			if n == 0 {
+				return 0
+			}
+			i += n
+		}
+		if i == 1 || i == len(sig) {
+			return 0
+		}
+		return i + 1
+	case '{':
+		// A dict entry has a basic key and any value.
+		if len(sig) < 2 || !strings.Contains(dbusBasicTypes, sig[1:2]) {
+			return 0
+		}
+		n := dbusTypeLen(sig[2:])
+		if n == 0 || 2+n >= len(sig) || sig[2+n] != '}' {
+			return 0
+		}
+		return 3 + n
+	}
+	if strings.Contains(dbusBasicTypes+"v", sig[:1]) {
+		return 1
+	}
+	return 0
+}
+
+// dbusAlignment is the alignment of values of the type starting sig.
+func dbusAlignment(sig string) int {
+	switch sig[0] {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 4
+}
+
+// dbusEncoder writes messages in little-endian order.
+type dbusEncoder struct {
+	buf []byte
+}
+
+func (e *dbusEncoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// value appends v as the single complete type sig. Arrays are []any,
+// structs are []any of their fields and dicts are map[string]dbusVariant.
+func (e *dbusEncoder) value(sig string, v any) {
+	e.align(dbusAlignment(sig))
+	switch sig[0] {
+	case 'y':
+		e.buf = append(e.buf, v.(byte))
+	case 'b':
+		b := uint32(0)
+		if v.(bool) {
+			b = 1
+		}
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, b)
+	case 'u', 'h':
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, v.(uint32))
+	case 'i':
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v.(int32)))
+	case 'n':
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(v.(int16)))
+	case 'q':
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, v.(uint16))
+	case 'x':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(v.(int64)))
+	case 't':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, v.(uint64))
+	case 'd':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v.(float64)))
+	case 's', 'o':
+		s := v.(string)
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(len(s)))
+		e.buf = append(append(e.buf, s...), 0)
+	case 'g':
+		s := v.(string)
+		e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+	case 'v':
+		dv := v.(dbusVariant)
+		e.value("g", dv.sig)
+		e.value(dv.sig, dv.value)
+	case 'a':
+		elem := sig[1:]
+		lenAt := len(e.buf)
+		e.buf = append(e.buf, 0, 0, 0, 0)
+		e.align(dbusAlignment(elem))
+		start := len(e.buf)
+		if elem[0] == '{' {
+			m := v.(map[string]dbusVariant)
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				e.align(8)
+				e.value("s", k)
+				e.value("v", m[k])
+			}
+		} else {
+			for _, x := range v.([]any) {
+				e.value(elem, x)
+			}
+		}
+		binary.LittleEndian.PutUint32(e.buf[lenAt:], uint32(len(e.buf)-start))
+	case '(':
+		types, _ := splitDBusSignature(sig[1 : len(sig)-1])
+		for i, t := range types {
+			e.value(t, v.([]any)[i])
+		}
+	}
+}
+
+type dbusDecoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errDBusShort = errors.New("truncated D-Bus message")
+
+func (d *dbusDecoder) take(n int) ([]byte, error) {
+	if d.pos+n > len(d.buf) {
+		return nil, errDBusShort
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// value decodes the single complete type sig. Dicts with string keys
+// become map[string]any, other arrays and structs []any, and variants
+// their value.
+func (d *dbusDecoder) value(sig string) (any, error) {
+	if n := dbusTypeLen(sig); n == 0 || n != len(sig) {
+		return nil, fmt.Errorf("malformed D-Bus type %q", sig)
+	}
+	a := dbusAlignment(sig)
+	d.pos += (a - d.pos%a) % a
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'n':
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		return int16(d.order.Uint16(b)), nil
+	case 'q':
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint16(b), nil
+	case 'b':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b) != 0, nil
+	case 'u', 'h':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b), nil
+	case 'i':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return int32(d.order.Uint32(b)), nil
+	case 'x':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(d.order.Uint64(b)), nil
+	case 't':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint64(b), nil
+	case 'd':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(d.order.Uint64(b)), nil
+	case 's', 'o':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(d.order.Uint32(b)) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'g':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(b[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'v':
+		s, err := d.value("g")
+		if err != nil {
+			return nil, err
+		}
+		return d.value(s.(string))
+	case 'a':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		elem := sig[1:]
+		ea := dbusAlignment(elem)
+		d.pos += (ea - d.pos%ea) % ea
+		end := d.pos + int(d.order.Uint32(b))
+		if end > len(d.buf) {
+			return nil, errDBusShort
+		}
+		if strings.HasPrefix(elem, "{s") {
+			m := map[string]any{}
+			for d.pos < end {
+				d.pos += (8 - d.pos%8) % 8
+				k, err := d.value("s")
+				if err != nil {
+					return nil, err
+				}
+				v, err := d.value(elem[2 : len(elem)-1])
+				if err != nil {
+					return nil, err
+				}
+				m[k.(string)] = v
+			}
+			return m, nil
+		}
+		var list []any
+		for d.pos < end {
+			v, err := d.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case '(', '{':
+		types, err := splitDBusSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return nil, err
+		}
+		var fields []any
+		for _, t := range types {
+			v, err := d.value(t)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, v)
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("unsupported D-Bus type %s", sig)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDBusRoundTrip(t *testing.T) {
+	tests := []struct {
+		sig  string
+		in   any
+		want any
+	}{
+		{"y", byte(7), byte(7)},
+		{"b", true, true},
+		{"n", int16(-2), int16(-2)},
+		{"q", uint16(65535), uint16(65535)},
+		{"i", int32(-40), int32(-40)},
+		{"u", uint32(40), uint32(40)},
+		{"x", int64(-1 << 40), int64(-1 << 40)},
+		{"t", uint64(1 << 63), uint64(1 << 63)},
+		{"d", 1.5, 1.5},
+		{"d", math.Inf(-1), math.Inf(-1)},
+		{"s", "org.freedesktop.portal.Desktop", "org.freedesktop.portal.Desktop"},
+		{"o", "/org/freedesktop/portal/desktop", "/org/freedesktop/portal/desktop"},
+		{"g", "a{sv}", "a{sv}"},
+		{"v", dbusVariant{"d", 0.25}, 0.25},
+		{"as", []any{"a", "bc"}, []any{"a", "bc"}},
+		{"a{sv}", map[string]dbusVariant{"types": {"u", uint32(3)}, "multiple": {"b", false}},
+			map[string]any{"types": uint32(3), "multiple": false}},
+		{"(ysd)", []any{byte(1), "x", 2.0}, []any{byte(1), "x", 2.0}},
+		{"a(yv)", []any{[]any{byte(1), dbusVariant{"o", "/"}}, []any{byte(3), dbusVariant{"s", "Hello"}}},
+			[]any{[]any{byte(1), "/"}, []any{byte(3), "Hello"}}},
+		{"a(ua{sv})", []any{[]any{uint32(42), map[string]dbusVariant{"size": {"(ii)", []any{int32(1920), int32(1080)}}}}},
+			[]any{[]any{uint32(42), map[string]any{"size": []any{int32(1920), int32(1080)}}}}},
+	}
+	for _, tt := range tests {
+		e := &dbusEncoder{}
+		// Start unaligned so that the padding is exercised.
+		e.value("y", byte(0))
+		e.value(tt.sig, tt.in)
+		d := &dbusDecoder{buf: e.buf, order: binary.LittleEndian}
+		if _, err := d.value("y"); err != nil {
+			t.Fatalf("%s: %v", tt.sig, err)
+		}
+		got, err := d.value(tt.sig)
+		if err != nil {
+			t.Errorf("%s: decoding: %v", tt.sig, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.sig, got, tt.want)
+		}
+		if d.pos != len(e.buf) {
+			t.Errorf("%s: decoded %d of %d bytes", tt.sig, d.pos, len(e.buf))
+		}
+	}
+}
+
+func TestDBusMalformedSignature(t *testing.T) {
+	for _, sig := range []string{"", "a", "aa", "(", "()", "(s", "{sv", "{vs}", "a{s}", "z", "s)", "ss"} {
+		d := &dbusDecoder{buf: make([]byte, 64), order: binary.LittleEndian}
+		if _, err := d.value(sig); err == nil {
+			t.Errorf("value(%q) succeeded", sig)
+		}
+	}
+	for _, sig := range []string{"a", "s(", "sz", "a{s}"} {
+		if _, err := splitDBusSignature(sig); err == nil {
+			t.Errorf("splitDBusSignature(%q) succeeded", sig)
+		}
+	}
+	types, err := splitDBusSignature("sa{sv}(ii)d")
+	if err != nil || !reflect.DeepEqual(types, []string{"s", "a{sv}", "(ii)", "d"}) {
+		t.Errorf("splitDBusSignature: got %q, %v", types, err)
+	}
+}
+
+func TestDBusMalformedVariant(t *testing.T) {
+	// A variant whose signature is empty, then one holding two types.
+	for _, sig := range []string{"", "ss"} {
+		e := &dbusEncoder{}
+		e.value("g", sig)
+		d := &dbusDecoder{buf: append(e.buf, make([]byte, 16)...), order: binary.LittleEndian}
+		if _, err := d.value("v"); err == nil {
+			t.Errorf("variant of %q decoded", sig)
+		}
+	}
+}
+
+func TestDBusTruncated(t *testing.T) {
+	e := &dbusEncoder{}
+	e.value("as", []any{"portal"})
+	d := &dbusDecoder{buf: e.buf[:len(e.buf)-2], order: binary.LittleEndian}
+	if _, err := d.value("as"); !errors.Is(err, errDBusShort) {
+		t.Errorf("got %v, want %v", err, errDBusShort)
+	}
+}
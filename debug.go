@@ -0,0 +1,172 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// debugDmesgLines is how much of the end of dmesg goes into the bundle.
+const debugDmesgLines = 200
+
+// secretEnv matches the names of environment variables whose values are
+// left out of the bundle.
+var secretEnv = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASS|KEY|AUTH|COOKIE|CREDENTIAL|SESSION_BUS_ADDRESS)`)
+
+// macAddress matches hardware addresses, which dmesg prints for network
+// cards.
+var macAddress = regexp.MustCompile(`(?i)\b([0-9a-f]{2}:){5}[0-9a-f]{2}\b`)
+
+// debugFile is one file of the debug bundle.
+type debugFile struct {
+	name string
+	data string
+}
+
+// redactor removes the user name, home directory, host name and hardware
+// addresses from the bundle, so that it can be attached to a public bug
+// report.
+func redactor() func(string) string {
+	var pairs []string
+	if homeDir, err := userHomeDir(); err == nil && homeDir != "/" {
+		pairs = append(pairs, homeDir, "~")
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		pairs = append(pairs, host, "HOST")
+		if short, _, ok := strings.Cut(host, "."); ok {
+			pairs = append(pairs, short, "HOST")
+		}
+	}
+	if name := userName(); len(name) > 2 {
+		pairs = append(pairs, name, "USER")
+	}
+	replacer := strings.NewReplacer(pairs...)
+	return func(s string) string {
+		return macAddress.ReplaceAllString(replacer.Replace(s), "xx:xx:xx:xx:xx:xx")
+	}
+}
+
+// debugCommand runs a command for the bundle, keeping its error output.
+func debugCommand(name string, args ...string) string {
+	out, err := commandCombinedOutput(exec.Command(name, args...))
+	if err != nil {
+		return string(out) + fmt.Sprintf("\n(%s failed: %v)\n", name, err)
+	}
+	return string(out)
+}
+
+// debugEnvironment lists the environment, leaving out secret values.
+func debugEnvironment() string {
+	env := os.Environ()
+	sort.Strings(env)
+	for i, kv := range env {
+		if key, _, _ := strings.Cut(kv, "="); secretEnv.MatchString(key) {
+			env[i] = key + "=[redacted]"
+		}
+	}
+	return strings.Join(env, "\n") + "\n"
+}
+
+// debugFiles gathers what bug reports about niri on GhostBSD usually need.
+func debugFiles(logs []string) []debugFile {
+	var files []debugFile
+	if len(logs) > 0 {
+		files = append(files, debugFile{"session-log.txt", strings.Join(logs, "\n") + "\n"})
+	}
+	if data, err := os.ReadFile(logFilePath()); err == nil {
+		files = append(files, debugFile{"nirisetup.log", string(data)})
+	}
+	if path, err := niriConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			files = append(files, debugFile{"config.kdl", string(data)})
+		}
+	}
+	if pkgs := installedNiriPackages(); len(pkgs) > 0 {
+		files = append(files, debugFile{"pkg-info.txt", debugCommand("pkg", append([]string{"info"}, pkgs...)...)})
+	}
+	dmesg := strings.Split(strings.TrimRight(debugCommand("dmesg"), "\n"), "\n")
+	dmesg = dmesg[max(0, len(dmesg)-debugDmesgLines):]
+	files = append(files,
+		debugFile{"uname.txt", debugCommand("uname", "-a")},
+		debugFile{"pciconf.txt", debugCommand("pciconf", "-lv")},
+		debugFile{"kldstat.txt", debugCommand("kldstat", "-v")},
+		debugFile{"dmesg.txt", strings.Join(dmesg, "\n") + "\n"},
+		debugFile{"env.txt", debugEnvironment()},
+	)
+	return files
+}
+
+// writeDebugBundle packs files into a gzipped tarball, redacted, in the
+// user's home directory and returns its path.
+func writeDebugBundle(files []debugFile) (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	stamp := time.Now().Format("20060102-150405")
+	dir := "nirisetup-debug-" + stamp
+	path := filepath.Join(homeDir, dir+".tar.gz")
+
+	redact := redactor()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		data := []byte(redact(f.data))
+		hdr := &tar.Header{Name: dir + "/" + f.name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	// Not writeFile: the tarball has no place in a --record script.
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	tracef("wrote %s (%d bytes, mode %o)", path, buf.Len(), 0600)
+	chownToUser(path)
+	return path, nil
+}
+
+// runDebugBundle collects the debug bundle and describes it.
+func runDebugBundle(logs []string) (string, error) {
+	files := debugFiles(logs)
+	path, err := writeDebugBundle(files)
+	if err != nil {
+		return Tf("Failed to write the debug bundle: %v", err), err
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.name
+	}
+	return Tf("Wrote %s with %s.", path, strings.Join(names, ", ")) + "\n" +
+		T("Your user name, home directory, host name, hardware addresses and secret environment variables were replaced. Look through it before attaching it to a niri or GhostBSD bug report."), nil
+}
+
+// collectDebugInfo writes the debug bundle, including the logs of this
+// session.
+func collectDebugInfo(m model) tea.Cmd {
+	logs := m.logs
+	return func() tea.Msg {
+		status, err := runDebugBundle(logs)
+		return statusMsg{status: status, err: err}
+	}
+}
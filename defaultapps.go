@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultAppKind is a row of the Default Apps screen. Programs whose
+// desktop file handles probe (a MIME type, or a category for the
+// terminal) are offered, and the chosen one is made the default for all
+// of types.
+type defaultAppKind struct {
+	label string
+	probe string
+	types []string
+}
+
+var defaultAppKinds = []defaultAppKind{
+	{"Browser", "x-scheme-handler/https", []string{"text/html", "x-scheme-handler/http", "x-scheme-handler/https", "application/xhtml+xml"}},
+	{"File manager", "inode/directory", []string{"inode/directory"}},
+	{"Image viewer", "image/png", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "image/bmp", "image/svg+xml"}},
+	{"PDF viewer", "application/pdf", []string{"application/pdf"}},
+	{"Terminal", "TerminalEmulator", nil},
+}
+
+// desktopEntry is the part of a .desktop file the screen needs.
+type desktopEntry struct {
+	id         string
+	mimeTypes  []string
+	categories []string
+}
+
+// applicationDirs are searched for .desktop files, later ones taking
+// precedence like in the XDG data dirs.
+func applicationDirs() []string {
+	dirs := []string{"/usr/local/share/applications"}
+	if homeDir, err := userHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, ".local", "share", "applications"))
+	}
+	return dirs
+}
+
+// desktopEntries reads the installed applications, skipping hidden ones.
+func desktopEntries() map[string]desktopEntry {
+	entries := map[string]desktopEntry{}
+	for _, dir := range applicationDirs() {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.desktop"))
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			e := desktopEntry{id: filepath.Base(file)}
+			hidden := false
+			inEntry := false
+			for _, line := range strings.Split(string(data), "\n") {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "[") {
+					inEntry = line == "[Desktop Entry]"
+					continue
+				}
+				key, value, ok := strings.Cut(line, "=")
+				if !inEntry || !ok {
+					continue
+				}
+				switch strings.TrimSpace(key) {
+				case "MimeType":
+					e.mimeTypes = strings.FieldsFunc(value, func(r rune) bool { return r == ';' })
+				case "Categories":
+					e.categories = strings.FieldsFunc(value, func(r rune) bool { return r == ';' })
+				case "NoDisplay", "Hidden":
+					hidden = hidden || strings.TrimSpace(value) == "true"
+				}
+			}
+			if hidden {
+				delete(entries, e.id)
+				continue
+			}
+			entries[e.id] = e
+		}
+	}
+	return entries
+}
+
+// candidates returns the IDs of the applications that fit kind.
+func (kind defaultAppKind) candidates(entries map[string]desktopEntry) []string {
+	var ids []string
+	for id, e := range entries {
+		list := e.mimeTypes
+		if kind.types == nil {
+			list = e.categories
+		}
+		if containsString(list, kind.probe) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// mimeappsPath and terminalsPath return the files the defaults are kept in.
+// The terminal isn't a MIME type; xdg-terminal-exec reads it from
+// xdg-terminals.list.
+func mimeappsPath(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "mimeapps.list")
+}
+
+func terminalsPath(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "xdg-terminals.list")
+}
+
+// currentDefault returns the application set for kind, or "".
+func currentDefault(homeDir string, kind defaultAppKind) string {
+	if kind.types == nil {
+		data, _ := os.ReadFile(terminalsPath(homeDir))
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				return line
+			}
+		}
+		return ""
+	}
+	data, _ := os.ReadFile(mimeappsPath(homeDir))
+	inDefaults := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inDefaults = line == "[Default Applications]"
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); inDefaults && ok && key == kind.probe {
+			return strings.SplitN(value, ";", 2)[0]
+		}
+	}
+	return ""
+}
+
+func newDefaultAppsForm() *form {
+	homeDir, _ := userHomeDir()
+	entries := desktopEntries()
+	var fields []formField
+	for _, kind := range defaultAppKinds {
+		options := append([]string{"Unchanged"}, kind.candidates(entries)...)
+		value := "Unchanged"
+		if current := currentDefault(homeDir, kind); containsString(options, current) {
+			value = current
+		} else if len(options) > 1 {
+			value = options[1]
+		}
+		fields = append(fields, formField{label: kind.label, value: value, options: options})
+	}
+	return &form{
+		title:  "Default Apps",
+		fields: fields,
+		submit: func(values []string) (tea.Cmd, error) {
+			return writeDefaultApps(values), nil
+		},
+	}
+}
+
+// setMimeDefaults sets the [Default Applications] entries of a
+// mimeapps.list, keeping the other entries and sections.
+func setMimeDefaults(list string, defaults map[string]string) string {
+	var out []string
+	inDefaults, found := false, false
+	flush := func() {
+		var types []string
+		for t := range defaults {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			out = append(out, t+"="+defaults[t]+";")
+		}
+	}
+	for _, line := range strings.Split(strings.TrimRight(list, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if inDefaults {
+				flush()
+				out = append(out, "")
+			}
+			inDefaults = trimmed == "[Default Applications]"
+			found = found || inDefaults
+		} else if key, _, ok := strings.Cut(trimmed, "="); inDefaults && ok {
+			if _, replaced := defaults[key]; replaced {
+				continue
+			}
+		}
+		if inDefaults && trimmed == "" {
+			continue
+		}
+		out = append(out, line)
+	}
+	if inDefaults {
+		flush()
+	}
+	if !found {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+		out = append(out, "[Default Applications]")
+		flush()
+	}
+	return strings.TrimLeft(strings.Join(out, "\n"), "\n") + "\n"
+}
+
+// writeDefaultApps writes the chosen applications to mimeapps.list and the
+// terminal to xdg-terminals.list.
+func writeDefaultApps(values []string) tea.Cmd {
+	return func() tea.Msg {
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		if err := os.MkdirAll(filepath.Join(homeDir, ".config"), 0755); err != nil {
+			return statusMsg{status: Tf("Failed to create %s: %v", filepath.Join(homeDir, ".config"), err), err: err}
+		}
+		var logs []string
+		defaults := map[string]string{}
+		for i, kind := range defaultAppKinds {
+			if values[i] == "Unchanged" {
+				continue
+			}
+			logs = append(logs, fmt.Sprintf("%s: %s", T(kind.label), values[i]))
+			if kind.types == nil {
+				path := terminalsPath(homeDir)
+				if err := writeFile(path, []byte(values[i]+"\n"), 0644); err != nil {
+					return statusMsg{status: Tf("Failed to write %s: %v", path, err), err: err}
+				}
+				continue
+			}
+			for _, t := range kind.types {
+				defaults[t] = values[i]
+			}
+		}
+		if len(logs) == 0 {
+			return statusMsg{status: T("Nothing to change.")}
+		}
+		if len(defaults) > 0 {
+			path := mimeappsPath(homeDir)
+			data, _ := os.ReadFile(path)
+			if err := writeFile(path, []byte(setMimeDefaults(string(data), defaults)), 0644); err != nil {
+				return statusMsg{status: Tf("Failed to write %s: %v", path, err), err: err}
+			}
+			logs = append(logs, Tf("Default applications written to %s", path))
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
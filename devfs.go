@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	devfsRulesPath    = "/etc/devfs.rules"
+	devfsDefaultRules = "/etc/defaults/devfs.rules"
+	// devfsRuleset is the name of the ruleset NiriSetup adds.
+	devfsRuleset = "nirisetup"
+)
+
+// devfsSection matches the header of a ruleset, e.g. [devfsrules_common=7].
+var devfsSection = regexp.MustCompile(`^\s*\[([\w-]+)=(\d+)\]`)
+
+// devfsRulesets returns the numbers of the rulesets defined in the
+// devfs.rules files, by name.
+func devfsRulesets() map[string]int {
+	rulesets := map[string]int{}
+	for _, path := range []string{devfsDefaultRules, devfsRulesPath} {
+		data, _ := os.ReadFile(path)
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := devfsSection.FindStringSubmatch(line); m != nil {
+				rulesets[m[1]], _ = strconv.Atoi(m[2])
+			}
+		}
+	}
+	return rulesets
+}
+
+// devfsComment heads the NiriSetup ruleset in /etc/devfs.rules.
+const devfsComment = "# Added by NiriSetup: device access for the desktop session."
+
+// driRules give the video group access to the DRM devices.
+var driRules = []string{
+	"add path 'dri/*' mode 0660 group video",
+	"add path 'drm/*' mode 0660 group video",
+}
+
+// devfsRules returns rules, the content of /etc/devfs.rules, with the
+// NiriSetup ruleset added or replaced. The ruleset keeps the rules it
+// already had and gains add; it includes the ruleset that was active
+// before, so nothing else changes.
+func devfsRules(rules string, rulesets map[string]int, current string, add []string) string {
+	number, ok := rulesets[devfsRuleset]
+	if !ok {
+		number = 10
+		for _, n := range rulesets {
+			number = max(number, n+1)
+		}
+	}
+
+	var kept, paths []string
+	include := ""
+	if current != "" && current != devfsRuleset {
+		include = "add include $" + current
+	}
+	inOurs := false
+	for _, line := range strings.Split(strings.TrimRight(rules, "\n"), "\n") {
+		if m := devfsSection.FindStringSubmatch(line); m != nil {
+			inOurs = m[1] == devfsRuleset
+			if inOurs && len(kept) > 0 && strings.HasPrefix(kept[len(kept)-1], "# Added by NiriSetup") {
+				kept = kept[:len(kept)-1]
+			}
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inOurs:
+			kept = append(kept, line)
+		case strings.HasPrefix(trimmed, "add include") && include == "":
+			// Keep the ruleset that was active before NiriSetup's.
+			include = trimmed
+		case strings.HasPrefix(trimmed, "add path") && !containsString(paths, trimmed):
+			paths = append(paths, trimmed)
+		}
+	}
+	for _, rule := range add {
+		if !containsString(paths, rule) {
+			paths = append(paths, rule)
+		}
+	}
+	content := strings.TrimRight(strings.Join(kept, "\n"), "\n")
+	if content != "" {
+		content += "\n\n"
+	}
+
+	content += fmt.Sprintf("%s\n[%s=%d]\n", devfsComment, devfsRuleset, number)
+	if include != "" {
+		content += include + "\n"
+	}
+	for _, rule := range paths {
+		content += rule + "\n"
+	}
+	return content
+}
+
+// renderDeviceIssue reports a DRM render node the user can't open.
+func renderDeviceIssue() (doctorIssue, bool) {
+	dev := findRenderDevice()
+	if dev == "" {
+		return doctorIssue{}, false
+	}
+	f, err := os.OpenFile(dev, os.O_RDWR, 0)
+	if err == nil {
+		f.Close()
+		return doctorIssue{}, false
+	}
+	if !os.IsPermission(err) {
+		return doctorIssue{}, false
+	}
+	return doctorIssue{
+		what:   Tf("You can't open the GPU render node %s: %v", dev, err),
+		remedy: T("Add a devfs.rules ruleset that gives the video group access to /dev/dri and /dev/drm, make it the system ruleset and restart devfs."),
+		label:  "Give the video group access to the GPU",
+		fix:    grantDRIAccess,
+	}, true
+}
+
+// applyDevfsRules adds rules to the NiriSetup ruleset in /etc/devfs.rules,
+// makes it the system ruleset and restarts devfs.
+func applyDevfsRules(add []string) (string, error) {
+	out, _ := commandOutput(exec.Command("sysrc", "-n", "devfs_system_ruleset"))
+	current := strings.TrimSpace(string(out))
+	rules, err := os.ReadFile(devfsRulesPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := writeRootFile(devfsRulesPath, devfsRules(string(rules), devfsRulesets(), current, add), 0644); err != nil {
+		return "", err
+	}
+	for _, args := range [][]string{
+		{"sysrc", "devfs_system_ruleset=" + devfsRuleset},
+		{"service", "devfs", "restart"},
+	} {
+		if out, err := commandCombinedOutput(exec.Command("sudo", args...)); err != nil {
+			return "", fmt.Errorf("%s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return Tf("Added the %s ruleset to %s and made it the system ruleset", devfsRuleset, devfsRulesPath), nil
+}
+
+// grantDRIAccess gives the video group access to the DRM devices through
+// /etc/devfs.rules, and adds the user to the group.
+func grantDRIAccess() (string, error) {
+	status, err := applyDevfsRules(driRules)
+	if err != nil {
+		return "", err
+	}
+	if user := userName(); user != "" {
+		if out, err := commandCombinedOutput(exec.Command("sudo", "pw", "groupmod", "video", "-m", user)); err != nil {
+			return status, fmt.Errorf("pw groupmod video: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		status += "\n" + Tf("Added user '%s' to video group: OK", user)
+	}
+	return status + "\n" + T("Log out and back in for the group change to take effect."), nil
+}
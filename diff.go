@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround each change.
+const diffContext = 3
+
+// unifiedDiff returns the changes from a to b in unified diff format, or
+// "" if they are the same.
+func unifiedDiff(a, b, nameA, nameB string) string {
+	if a == b {
+		return ""
+	}
+	x, y := diffLines(a), diffLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// ops are the edit script: ' ' keeps, '-' deletes and '+' inserts a
+	// line; ai and bi are its line indexes in a and b.
+	type op struct {
+		kind   byte
+		ai, bi int
+		text   string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, op{' ', i, j, x[i]})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', i, j, x[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', i, j, y[j]})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// Grow the hunk while the next change is within twice the context.
+		from := max(0, start-diffContext)
+		end := start
+		for k := start; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k
+			} else if k-end > 2*diffContext {
+				break
+			}
+		}
+		to := min(len(ops), end+diffContext+1)
+		var countA, countB int
+		for _, o := range ops[from:to] {
+			if o.kind != '+' {
+				countA++
+			}
+			if o.kind != '-' {
+				countB++
+			}
+		}
+		// An empty side starts at the line before, as in diff -u.
+		startA, startB := ops[from].ai+1, ops[from].bi+1
+		if countA == 0 {
+			startA--
+		}
+		if countB == 0 {
+			startB--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", startA, countA, startB, countB)
+		for _, o := range ops[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", o.kind, o.text)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// diffLines splits s into lines; an empty s has none.
+func diffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
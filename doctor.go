@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dmesgBootPath keeps the boot messages after the kernel buffer wraps.
+const dmesgBootPath = "/var/run/dmesg.boot"
+
+// logPattern is a known log message and what to do about it.
+type logPattern struct {
+	match   *regexp.Regexp
+	problem string
+	remedy  string
+}
+
+// firmwareFailure matches the kernel messages of a GPU driver that failed
+// to load firmware.
+var firmwareFailure = regexp.MustCompile(`(?i)(could not load firmware|failed to load (dmc )?firmware|firmware .*not found|direct firmware load .* failed)`)
+
+// dmesgPatterns are the known kernel messages about the GPU. Patterns are
+// checked in order; a line counts for the first one it matches.
+var dmesgPatterns = []logPattern{
+	{
+		niriCrashed,
+		"niri crashed",
+		"Report it to niri with Report a Bug, which prefills an issue with these lines.",
+	},
+	{
+		firmwareFailure,
+		"The GPU driver could not load its firmware",
+		"GPU Firmware in the System menu installs the package for your GPU, e.g. gpu-firmware-intel-kmod-kabylake; or install all firmware with: sudo pkg install gpu-firmware-kmod. Then reboot.",
+	},
+	{
+		regexp.MustCompile(`(?i)KLD (drm|i915kms|amdgpu|radeonkms)\.ko: depends on .*(not available|version mismatch)|(drm|i915kms|amdgpu|radeonkms)\.ko.*version mismatch`),
+		"The drm-kmod modules were built for another FreeBSD version",
+		"Reinstall them for this kernel: sudo pkg install -f drm-kmod, or build graphics/drm-kmod from ports after a kernel update.",
+	},
+	{
+		regexp.MustCompile(`(?i)NVRM: API mismatch`),
+		"The NVIDIA kernel module and driver versions differ",
+		"Upgrade nvidia-driver and nvidia-drm-kmod together: sudo pkg upgrade nvidia-driver nvidia-drm-kmod, then reboot.",
+	},
+	{
+		regexp.MustCompile(`(?i)(GPU HANG|ring \S+ timeout|GPU reset|gpu fault)`),
+		"The GPU hung or was reset",
+		"Update drm-kmod and the GPU firmware. If it keeps happening, try disabling power saving for the GPU (e.g. compat.linuxkpi.i915_enable_dc=0 in /boot/loader.conf) and report it to drm-kmod.",
+	},
+	{
+		regexp.MustCompile(`(?i)(unsupported|unknown) (gpu|device|chip)|is not supported by this driver|no drm device found`),
+		"The GPU driver does not support this GPU",
+		"Install a newer drm-kmod, such as the drm-61-kmod package, and load it from kld_list (see Kernel Modules).",
+	},
+	{
+		regexp.MustCompile(`(?i)\[drm[^\]]*\] \*ERROR\*`),
+		"The DRM driver reported errors",
+		"Check the lines above. Most errors at boot come from a driver that doesn't match the GPU or the kernel; see Kernel Modules.",
+	},
+}
+
+// logFinding is a logPattern found in a log.
+type logFinding struct {
+	pattern *logPattern
+	lines   []string
+}
+
+// kernelMessages returns the boot messages and the current kernel
+// buffer, without repeated lines.
+func kernelMessages() ([]string, error) {
+	boot, _ := os.ReadFile(dmesgBootPath)
+	out, err := commandOutput(exec.Command("dmesg"))
+	if err != nil && len(boot) == 0 {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var lines []string
+	for _, line := range strings.Split(string(boot)+"\n"+string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// scanLog matches the lines of a log against patterns.
+func scanLog(lines []string, patterns []logPattern) []logFinding {
+	found := map[int]*logFinding{}
+	var order []int
+	for _, line := range lines {
+		for i := range patterns {
+			if !patterns[i].match.MatchString(line) {
+				continue
+			}
+			if found[i] == nil {
+				found[i] = &logFinding{pattern: &patterns[i]}
+				order = append(order, i)
+			}
+			found[i].lines = append(found[i].lines, line)
+			break
+		}
+	}
+	var findings []logFinding
+	for _, i := range order {
+		findings = append(findings, *found[i])
+	}
+	return findings
+}
+
+// describeFindings explains each finding with up to three of its lines.
+func describeFindings(findings []logFinding) string {
+	var b strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&b, "• %s\n", T(f.pattern.problem))
+		for _, line := range f.lines[:min(len(f.lines), 3)] {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+		if len(f.lines) > 3 {
+			fmt.Fprintf(&b, "    %s\n", Tf("(%d more)", len(f.lines)-3))
+		}
+		fmt.Fprintf(&b, "  %s %s\n", T("Fix:"), T(f.pattern.remedy))
+	}
+	return b.String()
+}
+
+// doctorIssue is a problem with the system setup that Doctor can fix.
+// fix is what doctor --yes runs; the report also offers the alternatives.
+type doctorIssue struct {
+	what         string
+	remedy       string
+	label        string
+	fix          func() (string, error)
+	alternatives []doctorFix
+}
+
+// doctorFix is another way to fix a doctorIssue.
+type doctorFix struct {
+	label string
+	fix   func() (string, error)
+}
+
+// systemChecks look for setup problems that don't show in the kernel
+// messages.
+var systemChecks = []func() (doctorIssue, bool){
+	noRenderNodeIssue,
+	renderDeviceIssue,
+	runtimeDirIssue,
+}
+
+func systemIssues() []doctorIssue {
+	var issues []doctorIssue
+	for _, check := range systemChecks {
+		if issue, ok := check(); ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// doctorReport checks the kernel messages for graphics problems and the
+// system for setup problems.
+func doctorReport() (string, []doctorIssue, error) {
+	lines, err := kernelMessages()
+	if err != nil {
+		return Tf("Failed to read the kernel messages: %v", err), nil, preflightFailure(err)
+	}
+	findings := scanLog(lines, dmesgPatterns)
+	issues := systemIssues()
+	var b strings.Builder
+	fmt.Fprintln(&b, T("Graphics errors in dmesg:"))
+	if len(findings) == 0 {
+		fmt.Fprintln(&b, T("  None found."))
+	}
+	b.WriteString(describeFindings(findings))
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, T("Setup problems:"))
+	if len(issues) == 0 {
+		fmt.Fprintln(&b, T("  None found."))
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "• %s\n  %s %s\n", issue.what, T("Fix:"), issue.remedy)
+	}
+	if len(findings) > 0 || len(issues) > 0 {
+		err = errors.New("problems found")
+	}
+	return b.String(), issues, err
+}
+
+// runDoctor returns the doctor report and, with fix set, fixes the setup
+// problems it can.
+func runDoctor(fix bool) (string, error) {
+	text, issues, err := doctorReport()
+	if !fix || len(issues) == 0 {
+		return text, err
+	}
+	var b strings.Builder
+	b.WriteString(text)
+	var failed []string
+	for _, issue := range issues {
+		status, ferr := issue.fix()
+		if ferr != nil {
+			failed = append(failed, issue.what)
+			status = Tf("Failed to fix %s: %v", issue.what, ferr)
+		}
+		fmt.Fprintf(&b, "\n%s\n", status)
+	}
+	if len(failed) > 0 {
+		return b.String(), fmt.Errorf("%d fixes failed", len(failed))
+	}
+	return b.String(), nil
+}
+
+// fixDoctorIssue runs fix, one of the fixes of issue.
+func fixDoctorIssue(issue doctorIssue, fix func() (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		status, err := fix()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to fix %s: %v", issue.what, err), err: err}
+		}
+		return statusMsg{status: status}
+	}
+}
+
+// doctor shows the doctor report.
+func doctor() tea.Cmd {
+	return func() tea.Msg {
+		body, issues, err := doctorReport()
+		if exitCode(err) == exitPreflight {
+			return statusMsg{status: body, err: err}
+		}
+		r := &report{title: "Doctor", body: body, refresh: doctor()}
+		for _, issue := range issues {
+			r.actions = append(r.actions, reportAction{label: issue.label, cmd: fixDoctorIssue(issue, issue.fix)})
+			for _, alt := range issue.alternatives {
+				r.actions = append(r.actions, reportAction{label: alt.label, cmd: fixDoctorIssue(issue, alt.fix)})
+			}
+		}
+		return reportMsg{r}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultDotfilesPackages are the niri-related stow packages, or the
+// directories under ~/.config applied with chezmoi.
+const defaultDotfilesPackages = "niri waybar fuzzel mako"
+
+// dotfiles is the dotfiles workflow saved in the settings: a git repository
+// applied with stow or chezmoi. When one is set up, Configure Niri applies
+// it instead of copying the template.
+type dotfiles struct {
+	repo     string
+	tool     string
+	packages []string
+}
+
+// loadDotfiles returns the dotfiles workflow from the settings, or nil if
+// there is none.
+func loadDotfiles() *dotfiles {
+	settings := loadSettings()
+	if settings["dotfiles_repo"] == "" {
+		return nil
+	}
+	d := &dotfiles{repo: settings["dotfiles_repo"], tool: settings["dotfiles_tool"], packages: strings.Fields(settings["dotfiles_packages"])}
+	if d.tool != "chezmoi" {
+		d.tool = "stow"
+	}
+	if len(d.packages) == 0 {
+		d.packages = strings.Fields(defaultDotfilesPackages)
+	}
+	return d
+}
+
+func newDotfilesForm() *form {
+	d := loadDotfiles()
+	if d == nil {
+		d = &dotfiles{tool: "stow", packages: strings.Fields(defaultDotfilesPackages)}
+	}
+	return &form{
+		title: "Dotfiles",
+		fields: []formField{
+			{label: "Git repository", value: d.repo},
+			{label: "Tool", value: d.tool, options: []string{"stow", "chezmoi"}},
+			{label: "Packages", value: strings.Join(d.packages, " "),
+				help: "For stow, the package directories of the repository to link. For chezmoi, the directories under ~/.config to apply."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			repo := strings.TrimSpace(values[0])
+			if repo == "" {
+				return nil, errors.New(T("enter the URL or path of your dotfiles repository"))
+			}
+			packages := strings.Fields(values[2])
+			if len(packages) == 0 {
+				return nil, errors.New(T("name at least one package"))
+			}
+			d := &dotfiles{repo: repo, tool: values[1], packages: packages}
+			if err := saveSettings(map[string]string{
+				"dotfiles_repo":     d.repo,
+				"dotfiles_tool":     d.tool,
+				"dotfiles_packages": strings.Join(d.packages, " "),
+			}); err != nil {
+				return nil, err
+			}
+			return applyDotfilesCmd(d), nil
+		},
+	}
+}
+
+// dotfilesRun runs a dotfiles command and describes the result.
+func dotfilesRun(name string, args ...string) (string, error) {
+	out, err := commandCombinedOutput(exec.Command(name, args...))
+	line := name + " " + strings.Join(args, " ")
+	if err != nil {
+		return Tf("%s failed: %s", line, strings.TrimSpace(string(out))), err
+	}
+	return Tf("%s: OK", line), nil
+}
+
+// apply clones or updates the repository and applies the packages.
+func (d *dotfiles) apply() ([]string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	var logs []string
+	for _, pkg := range []string{"git", d.tool} {
+		line, err := installPackage(pkg)
+		logs = append(logs, line)
+		if err != nil {
+			return logs, err
+		}
+	}
+
+	if d.tool == "chezmoi" {
+		source := filepath.Join(homeDir, ".local", "share", "chezmoi")
+		args := []string{"init", d.repo}
+		if _, err := os.Stat(source); err == nil {
+			args = []string{"update", "--apply=false"}
+		}
+		line, err := dotfilesRun("chezmoi", args...)
+		logs = append(logs, line)
+		if err != nil {
+			return logs, err
+		}
+		targets := []string{"apply", "--force"}
+		for _, pkg := range d.packages {
+			targets = append(targets, filepath.Join(homeDir, ".config", pkg))
+		}
+		line, err = dotfilesRun("chezmoi", targets...)
+		return append(logs, line), err
+	}
+
+	dir := filepath.Join(homeDir, ".dotfiles")
+	args := []string{"clone", d.repo, dir}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		args = []string{"-C", dir, "pull", "--ff-only"}
+	}
+	line, err := dotfilesRun("git", args...)
+	logs = append(logs, line)
+	if err != nil {
+		return logs, err
+	}
+	var failed []string
+	for _, pkg := range d.packages {
+		if info, err := os.Stat(filepath.Join(dir, pkg)); err != nil || !info.IsDir() {
+			logs = append(logs, Tf("Warning: %s has no %s package", dir, pkg))
+			continue
+		}
+		line, err := dotfilesRun("stow", "-d", dir, "-t", homeDir, "--restow", pkg)
+		logs = append(logs, line)
+		if err != nil {
+			failed = append(failed, pkg)
+		}
+	}
+	if len(failed) > 0 {
+		logs = append(logs, T("stow won't replace files that aren't its links; move them into the repository or out of the way and try again."))
+		return logs, fmt.Errorf("stow failed for %s", strings.Join(failed, ", "))
+	}
+	return logs, nil
+}
+
+// applyDotfilesCmd applies the dotfiles workflow from the menu.
+func applyDotfilesCmd(d *dotfiles) tea.Cmd {
+	return func() tea.Msg {
+		logs, err := d.apply()
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Applied the dotfiles from %s with %s", d.repo, d.tool))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// syncDotfile records a file NiriSetup changed in the dotfiles workflow.
+// Files linked by stow already live in the repository; chezmoi keeps
+// copies, so the change is added to its source state or the next apply
+// would undo it.
+func syncDotfile(path string) error {
+	d := loadDotfiles()
+	if d == nil || d.tool != "chezmoi" {
+		return nil
+	}
+	if _, err := commandOutput(exec.Command("chezmoi", "source-path", path)); err != nil {
+		// Not managed by chezmoi.
+		return nil
+	}
+	if out, err := commandCombinedOutput(exec.Command("chezmoi", "re-add", path)); err != nil {
+		return fmt.Errorf("chezmoi re-add %s: %s", path, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
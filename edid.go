@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// edidInfo is what NiriSetup reads from a display's EDID: its name, the
+// modes it advertises, the first of which is the preferred one, and the
+// vertical refresh range it accepts (0 if the EDID doesn't say).
+type edidInfo struct {
+	name       string
+	modes      []niriMode
+	minRefresh int
+	maxRefresh int
+}
+
+var edidHeader = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+
+// parseEDID decodes the base block of an EDID: the detailed timings, the
+// standard timings and the display name and range descriptors.
+func parseEDID(data []byte) (*edidInfo, error) {
+	if len(data) < 128 || !bytes.Equal(data[:8], edidHeader) {
+		return nil, fmt.Errorf("not an EDID")
+	}
+	info := &edidInfo{}
+	for off := 54; off <= 108; off += 18 {
+		d := data[off : off+18]
+		clock := int(d[0]) | int(d[1])<<8
+		if clock != 0 {
+			width := int(d[2]) | int(d[4]&0xf0)<<4
+			hblank := int(d[3]) | int(d[4]&0x0f)<<8
+			height := int(d[5]) | int(d[7]&0xf0)<<4
+			vblank := int(d[6]) | int(d[7]&0x0f)<<8
+			if d[17]&0x80 != 0 || width == 0 || height == 0 {
+				// Interlaced modes aren't offered by niri.
+				continue
+			}
+			// clock is in units of 10 kHz; the refresh rate in mHz.
+			refresh := int(math.Round(float64(clock) * 1e7 / float64((width+hblank)*(height+vblank))))
+			info.modes = append(info.modes, niriMode{Width: width, Height: height, RefreshRate: refresh, IsPreferred: len(info.modes) == 0})
+			continue
+		}
+		switch d[3] {
+		case 0xfc:
+			name := string(d[5:])
+			if i := strings.IndexByte(name, '\n'); i >= 0 {
+				name = name[:i]
+			}
+			info.name = strings.TrimSpace(name)
+		case 0xfd:
+			info.minRefresh, info.maxRefresh = int(d[5]), int(d[6])
+			if d[4]&0x01 != 0 {
+				info.minRefresh += 255
+			}
+			if d[4]&0x02 != 0 {
+				info.maxRefresh += 255
+			}
+		}
+	}
+	for off := 38; off < 54; off += 2 {
+		b0, b1 := data[off], data[off+1]
+		if b0 == 0x01 && b1 == 0x01 || b0 == 0 {
+			continue
+		}
+		width := (int(b0) + 31) * 8
+		var height int
+		switch b1 >> 6 {
+		case 0:
+			height = width * 10 / 16
+		case 1:
+			height = width * 3 / 4
+		case 2:
+			height = width * 4 / 5
+		case 3:
+			height = width * 9 / 16
+		}
+		info.modes = append(info.modes, niriMode{Width: width, Height: height, RefreshRate: (int(b1&0x3f) + 60) * 1000})
+	}
+	return info, nil
+}
+
+// readEDID reads the EDID of an output from the DRM connectors in sysfs,
+// which FreeBSD provides through linsysfs.
+func readEDID(output string) (*edidInfo, error) {
+	var lastErr error = os.ErrNotExist
+	for _, root := range []string{"/compat/linux/sys/class/drm", "/sys/class/drm"} {
+		paths, _ := filepath.Glob(filepath.Join(root, "card*-"+output, "edid"))
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if info, err := parseEDID(data); err == nil {
+				return info, nil
+			} else {
+				lastErr = err
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+// modeString renders a mode the way the mode node of an output block takes
+// it.
+func modeString(m niriMode) string {
+	return fmt.Sprintf("%dx%d@%.3f", m.Width, m.Height, float64(m.RefreshRate)/1000)
+}
+
+// parseModeString splits a configured mode into its size and refresh rate
+// in mHz, which is 0 when the config leaves the rate to niri.
+func parseModeString(s string) (int, int, int, bool) {
+	size, rate, hasRate := strings.Cut(kdlUnquote(s), "@")
+	w, h, ok := strings.Cut(size, "x")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	width, err1 := strconv.Atoi(w)
+	height, err2 := strconv.Atoi(h)
+	if err1 != nil || err2 != nil {
+		return 0, 0, 0, false
+	}
+	refresh := 0
+	if hasRate {
+		hz, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		refresh = int(math.Round(hz * 1000))
+	}
+	return width, height, refresh, true
+}
+
+// advertisesMode reports whether a configured mode is one of modes. Like
+// niri, it compares refresh rates to the millihertz.
+func advertisesMode(modes []niriMode, mode string) bool {
+	width, height, refresh, ok := parseModeString(mode)
+	if !ok {
+		return false
+	}
+	for _, m := range modes {
+		if m.Width == width && m.Height == height && (refresh == 0 || abs(m.RefreshRate-refresh) <= 1) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestMode picks the mode to suggest for an output: the preferred
+// resolution at the highest refresh rate the display offers for it and
+// its EDID range allows.
+func suggestMode(modes []niriMode, edid *edidInfo) (niriMode, bool) {
+	var preferred *niriMode
+	for i := range modes {
+		if modes[i].IsPreferred {
+			preferred = &modes[i]
+			break
+		}
+	}
+	if preferred == nil && edid != nil && len(edid.modes) > 0 && edid.modes[0].IsPreferred {
+		preferred = &edid.modes[0]
+	}
+	if preferred == nil {
+		return niriMode{}, false
+	}
+	best := *preferred
+	for _, m := range modes {
+		if m.Width != best.Width || m.Height != best.Height || m.RefreshRate <= best.RefreshRate {
+			continue
+		}
+		if edid != nil && edid.maxRefresh > 0 && m.RefreshRate > edid.maxRefresh*1000+500 {
+			continue
+		}
+		best = m
+	}
+	return best, true
+}
+
+// modeChoices lists the modes offered for an output: every refresh rate of
+// the suggested resolution, then the other resolutions at their highest
+// rate, largest first.
+func modeChoices(modes []niriMode, suggested niriMode) []string {
+	highest := map[[2]int]niriMode{}
+	var native []niriMode
+	for _, m := range modes {
+		if m.Width == suggested.Width && m.Height == suggested.Height {
+			native = append(native, m)
+			continue
+		}
+		key := [2]int{m.Width, m.Height}
+		if m.RefreshRate > highest[key].RefreshRate {
+			highest[key] = m
+		}
+	}
+	sort.Slice(native, func(i, j int) bool { return native[i].RefreshRate > native[j].RefreshRate })
+	var others []niriMode
+	for _, m := range highest {
+		others = append(others, m)
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if others[i].Width*others[i].Height != others[j].Width*others[j].Height {
+			return others[i].Width*others[i].Height > others[j].Width*others[j].Height
+		}
+		return others[i].Width > others[j].Width
+	})
+	var choices []string
+	for _, m := range append(native, others...) {
+		if s := modeString(m); !containsString(choices, s) {
+			choices = append(choices, s)
+		}
+	}
+	return choices
+}
+
+// modeSetting offers the modes an output advertises, suggests one from
+// its EDID and warns when config.kdl asks for a mode the display doesn't
+// have.
+func modeSetting(o niriOutput, cfg string) outputSetting {
+	block := []string{"output " + o.Name}
+	edid, _ := readEDID(o.Name)
+	modes := o.Modes
+	if len(modes) == 0 && edid != nil {
+		modes = edid.modes
+	}
+
+	var help []string
+	if edid != nil {
+		line := "EDID"
+		if edid.name != "" {
+			line += ": " + edid.name
+		}
+		if len(edid.modes) > 0 && edid.modes[0].IsPreferred {
+			line += ", " + Tf("preferred %s", modeString(edid.modes[0]))
+		}
+		if edid.maxRefresh > 0 {
+			line += ", " + fmt.Sprintf("%d–%d Hz", edid.minRefresh, edid.maxRefresh)
+		}
+		help = append(help, line)
+	}
+	options := []string{"Automatic"}
+	if suggested, ok := suggestMode(modes, edid); ok {
+		options = append(options, modeChoices(modes, suggested)...)
+		help = append(help, Tf("Suggested: %s", modeString(suggested)))
+	}
+
+	value := "Automatic"
+	if current, ok := kdlGet(cfg, block, "mode"); ok {
+		value = kdlUnquote(current)
+		if !advertisesMode(modes, value) {
+			help = append(help, Tf("Warning: config.kdl asks for %s, which %s doesn't advertise; niri falls back to the preferred mode.", value, o.Name))
+			options = append(options, value)
+		} else if !containsString(options, value) {
+			options = append(options, value)
+		}
+	}
+
+	return outputSetting{
+		output: o.Name,
+		field:  formField{label: o.Name + " mode", value: value, options: options, help: strings.Join(help, "\n")},
+		apply: func(cfg, output, value string) string {
+			if value == "Automatic" {
+				return removeKDLNode(cfg, []string{"output " + output}, "mode")
+			}
+			return setKDLNode(cfg, []string{"output " + output}, "mode "+kdlQuote(value))
+		},
+	}
+}
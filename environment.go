@@ -0,0 +1,321 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// An environment snapshot is a gzipped tarball holding the explicitly
+// installed packages, the rc.conf service and kernel module settings, and
+// the configs below under config/, relative to ~/.config.
+var environmentConfigs = []string{"niri", "waybar", "mako", "foot"}
+
+const (
+	snapshotPackages = "packages.txt"
+	snapshotSysrc    = "sysrc.conf"
+	snapshotConfig   = "config/"
+)
+
+// snapshotSetting reports whether an rc.conf variable belongs in a
+// snapshot. Host specific settings such as the hostname or network setup
+// are left out; services and kernel modules carry over.
+func snapshotSetting(key string) bool {
+	return strings.HasSuffix(key, "_enable") || key == "kld_list"
+}
+
+func defaultSnapshotPath() string {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "nirisetup-environment.tar.gz"
+	}
+	return filepath.Join(homeDir, "nirisetup-environment.tar.gz")
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if homeDir, err := userHomeDir(); err == nil {
+			return filepath.Join(homeDir, rest)
+		}
+	}
+	return path
+}
+
+func newExportEnvironmentForm() *form {
+	return &form{
+		title:  "Export Environment",
+		fields: []formField{{label: "Archive", value: defaultSnapshotPath()}},
+		submit: func(values []string) (tea.Cmd, error) {
+			path := strings.TrimSpace(values[0])
+			if path == "" {
+				return nil, errors.New(T("enter a file name for the archive"))
+			}
+			return exportEnvironment(expandHome(path)), nil
+		},
+	}
+}
+
+func newImportEnvironmentForm() *form {
+	return &form{
+		title:  "Import Environment",
+		fields: []formField{{label: "Archive", value: defaultSnapshotPath()}},
+		submit: func(values []string) (tea.Cmd, error) {
+			path := expandHome(strings.TrimSpace(values[0]))
+			if _, err := os.Stat(path); err != nil {
+				return nil, errors.New(Tf("cannot read %s", path))
+			}
+			return importEnvironment(path), nil
+		},
+	}
+}
+
+// exportEnvironment writes a snapshot of this machine to path.
+func exportEnvironment(path string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := commandOutput(exec.Command("pkg", "query", "-e", "%a = 0", "%n"))
+		if err != nil {
+			return statusMsg{status: Tf("Failed to list installed packages: %v", err), err: preflightFailure(err)}
+		}
+		packages := strings.Fields(string(out))
+
+		out, err = commandOutput(exec.Command("sysrc", "-a"))
+		if err != nil {
+			return statusMsg{status: Tf("Failed to read rc.conf settings: %v", err), err: preflightFailure(err)}
+		}
+		var settings []string
+		for _, line := range strings.Split(string(out), "\n") {
+			key, value, ok := strings.Cut(line, ": ")
+			if ok && snapshotSetting(key) {
+				settings = append(settings, fmt.Sprintf("%s=%q", key, value))
+			}
+		}
+
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: preflightFailure(err)}
+		}
+		configDir := filepath.Join(homeDir, ".config")
+
+		f, err := os.Create(path)
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write %s: %v", path, err), err: preflightFailure(err)}
+		}
+		defer f.Close()
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		add := func(name string, data []byte, mode fs.FileMode) error {
+			hdr := &tar.Header{Name: name, Mode: int64(mode.Perm()), Size: int64(len(data)), ModTime: time.Now()}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			_, err := tw.Write(data)
+			return err
+		}
+
+		err = add(snapshotPackages, []byte(strings.Join(packages, "\n")+"\n"), 0644)
+		if err == nil {
+			err = add(snapshotSysrc, []byte(strings.Join(settings, "\n")+"\n"), 0644)
+		}
+		files := 0
+		for _, dir := range environmentConfigs {
+			if err != nil {
+				break
+			}
+			err = filepath.WalkDir(filepath.Join(configDir, dir), func(p string, d fs.DirEntry, err error) error {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				data, err := os.ReadFile(p)
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(configDir, p)
+				files++
+				return add(snapshotConfig+filepath.ToSlash(rel), data, info.Mode())
+			})
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		if err == nil {
+			err = gz.Close()
+		}
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write %s: %v", path, err), err: err}
+		}
+		tracef("wrote %s", path)
+		chownToUser(path)
+		return statusMsg{status: Tf("Exported %d packages, %d rc.conf settings and %d config files to %s", len(packages), len(settings), files, path)}
+	}
+}
+
+type snapshotFile struct {
+	name string // relative to ~/.config
+	data []byte
+	mode fs.FileMode
+}
+
+// readSnapshot unpacks the snapshot at path. Config files outside the
+// directories a snapshot may carry are rejected.
+func readSnapshot(path string) (packages, settings []string, files []snapshotFile, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		switch {
+		case hdr.Name == snapshotPackages:
+			packages = strings.Fields(string(data))
+		case hdr.Name == snapshotSysrc:
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.TrimSpace(line) != "" {
+					settings = append(settings, strings.TrimSpace(line))
+				}
+			}
+		case strings.HasPrefix(hdr.Name, snapshotConfig):
+			name := filepath.Clean(strings.TrimPrefix(hdr.Name, snapshotConfig))
+			top, _, _ := strings.Cut(filepath.ToSlash(name), "/")
+			if !filepath.IsLocal(name) || !containsString(environmentConfigs, top) {
+				return nil, nil, nil, fmt.Errorf("unexpected file in snapshot: %s", hdr.Name)
+			}
+			files = append(files, snapshotFile{name, data, fs.FileMode(hdr.Mode).Perm()})
+		}
+	}
+	return packages, settings, files, nil
+}
+
+// importEnvironment applies the snapshot at path: it installs the
+// packages, sets the rc.conf settings and restores the config files,
+// keeping a .bak of any file it replaces.
+func importEnvironment(path string) tea.Cmd {
+	return func() tea.Msg {
+		packages, settings, files, err := readSnapshot(path)
+		if err != nil {
+			return statusMsg{status: Tf("Failed to read %s: %v", path, err), err: preflightFailure(err)}
+		}
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: preflightFailure(err)}
+		}
+
+		if len(packages) > 0 && !pkgBootstrapped() {
+			return statusMsg{status: Tf("Failed to install %s: pkg isn't bootstrapped yet, run pkg bootstrap first", T("the packages of the snapshot")), err: preflightFailure(errPkgNotBootstrapped)}
+		}
+
+		var logs []string
+		failures := 0
+		if len(packages) > 0 {
+			// One transaction: pkg resolves the dependencies of all of them
+			// at once and skips those already installed.
+			args := append([]string{"pkg", "install", "-y"}, packages...)
+			if out, err := commandCombinedOutput(exec.Command("sudo", args...)); err != nil {
+				logs = append(logs, Tf("Failed to install the packages of the snapshot: %s", strings.TrimSpace(string(out))))
+				failures++
+			} else {
+				logs = append(logs, Tf("Installed the %d packages of the snapshot: OK", len(packages)))
+			}
+		}
+
+		applied := 0
+		for _, setting := range settings {
+			key, value, _ := strings.Cut(setting, "=")
+			if !snapshotSetting(key) {
+				// Only a snapshot edited by hand, or not made by NiriSetup,
+				// holds other variables; they may well be host specific.
+				logs = append(logs, Tf("Warning: Skipping %s: snapshots only carry *_enable and kld_list", key))
+				continue
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			arg := key + "=" + value
+			if key == "kld_list" {
+				// Add the modules rather than replacing what this machine loads.
+				arg = key + "+=" + value
+			}
+			if out, err := commandCombinedOutput(exec.Command("sudo", "sysrc", arg)); err != nil {
+				logs = append(logs, Tf("Warning: Setting %s: %s", key, strings.TrimSpace(string(out))))
+				failures++
+			} else {
+				logs = append(logs, Tf("Setting %s: OK", key))
+				applied++
+			}
+		}
+
+		for _, file := range files {
+			dest := filepath.Join(homeDir, ".config", file.name)
+			if err := restoreConfigFile(dest, file); err != nil {
+				logs = append(logs, Tf("Warning: Writing %s: %v", dest, err))
+				failures++
+			} else {
+				logs = append(logs, Tf("Restored %s", dest))
+			}
+		}
+
+		summary := Tf("Imported %d packages, %d rc.conf settings and %d config files from %s", len(packages), applied, len(files), path)
+		logs = append(logs, summary)
+		if failures > 0 {
+			return statusMsg{status: strings.Join(logs, "\n"), err: fmt.Errorf("%d steps failed", failures)}
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+func restoreConfigFile(dest string, file snapshotFile) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(dest); err == nil {
+		if bytes.Equal(old, file.data) {
+			return nil
+		}
+		if err := writeFile(dest+".bak", old, 0644); err != nil {
+			return err
+		}
+	}
+	if file.name == filepath.Join("niri", "config.kdl") {
+		// Goes live right away in a running session.
+		return writeNiriConfig(dest, string(file.data))
+	}
+	return writeFile(dest, file.data, file.mode)
+}
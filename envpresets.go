@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// envPreset is a variable in the environment block of config.kdl that makes
+// a toolkit behave under niri. help says what it fixes and is shown on the
+// form.
+type envPreset struct {
+	name  string
+	value string
+	help  string
+}
+
+var envPresets = []envPreset{
+	{"ELECTRON_OZONE_PLATFORM_HINT", "auto", "Electron programs such as VS Code, Discord and Signal run as Wayland clients instead of through Xwayland, so they are sharp on scaled outputs."},
+	{"QT_QPA_PLATFORM", "wayland", "Qt programs use Wayland instead of X11. Needs the Qt Wayland plugins (qt5-wayland, qt6-wayland), or Qt programs won't start."},
+	{"_JAVA_AWT_WM_NONREPARENTING", "1", "Java Swing and AWT programs such as IntelliJ show their content instead of blank grey windows, which they do under window managers that don't reparent."},
+}
+
+// newEnvPresetsForm prefills the presets from the environment block: a
+// preset is on when its variable is set there.
+func newEnvPresetsForm() *form {
+	cfg := ""
+	if path, err := niriConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			cfg = string(data)
+		}
+	}
+	var fields []formField
+	for _, p := range envPresets {
+		value := "Off"
+		if _, ok := kdlGet(cfg, []string{"environment"}, p.name); ok {
+			value = "On"
+		}
+		fields = append(fields, formField{label: p.name, value: value, options: []string{"On", "Off"}, help: p.help})
+	}
+	return &form{
+		title:  "App Environment",
+		fields: fields,
+		submit: func(values []string) (tea.Cmd, error) {
+			return writeEnvPresets(values), nil
+		},
+	}
+}
+
+// writeEnvPresets sets the presets that are on in the environment block and
+// removes the ones that are off.
+func writeEnvPresets(values []string) tea.Cmd {
+	return func() tea.Msg {
+		var on []string
+		path, err := updateNiriConfig(func(cfg string) string {
+			block := []string{"environment"}
+			for i, p := range envPresets {
+				if values[i] == "On" {
+					cfg = setKDLNode(cfg, block, p.name+" "+kdlQuote(p.value))
+					on = append(on, p.name+"="+p.value)
+				} else {
+					cfg = removeKDLNode(cfg, block, p.name)
+				}
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to update config: %v", err), err: err}
+		}
+		status := Tf("Environment presets written to %s", path)
+		if len(on) > 0 {
+			status += "\n" + Tf("Set: %s", strings.Join(on, " "))
+		}
+		return statusMsg{status: status + "\n" + T("Programs started from now on pick up the change.")}
+	}
+}
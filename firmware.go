@@ -0,0 +1,456 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// firmwareSettle is how long the GPU driver gets to load its firmware
+// after kldload returns.
+const firmwareSettle = 3 * time.Second
+
+// firmwareFile picks the firmware a driver asked for out of a kernel
+// message, either as a path (i915/kbl_dmc_ver1_04.bin) or as the name of
+// the FreeBSD firmware module (i915_kbl_dmc_ver1_04_bin).
+var firmwareFile = regexp.MustCompile(`\b(i915|amdgpu|radeon)[/_]([A-Za-z0-9_.-]+?)(?:\.bin|_bin)\b`)
+
+// firmwareVendors maps the firmware directories to the PCI vendor of the
+// GPUs whose driver loads from them.
+var firmwareVendors = map[string]string{
+	"i915":   "0x8086",
+	"amdgpu": "0x1002",
+	"radeon": "0x1002",
+}
+
+// vendorFirmware is the package with all the firmware for a vendor's GPUs,
+// for when the driver hasn't said yet which files it needs.
+var vendorFirmware = map[string]string{
+	"0x8086": "gpu-firmware-intel-kmod",
+	"0x1002": "gpu-firmware-amd-kmod",
+}
+
+// intelPlatforms maps the prefixes of Intel firmware files to the platform
+// their gpu-firmware-intel-kmod package is named after.
+var intelPlatforms = map[string]string{
+	"skl": "skylake", "bxt": "broxton", "kbl": "kabylake", "glk": "geminilake",
+	"cml": "cometlake", "cnl": "cannonlake", "icl": "icelake", "ehl": "elkhartlake",
+	"tgl": "tigerlake", "rkl": "rocketlake", "adlp": "alderlake", "adls": "alderlake",
+	"dg1": "dg1", "dg2": "dg2", "mtl": "meteorlake",
+}
+
+// amdChips are the chip names AMD firmware files start with, each packaged
+// as gpu-firmware-amd-kmod-<chip>. Newer files are named after the IP
+// block version instead, like gc_11_0_1.
+var amdChips = []string{
+	"aldebaran", "arcturus", "banks", "beige_goby", "bonaire", "carrizo", "cyan_skillfish2",
+	"dimgrey_cavefish", "fiji", "green_sardine", "hainan", "hawaii", "kabini", "kaveri",
+	"mullins", "navi10", "navi12", "navi14", "navy_flounder", "oland", "picasso", "pitcairn",
+	"polaris10", "polaris11", "polaris12", "raven", "raven2", "renoir", "sienna_cichlid",
+	"stoney", "tahiti", "tonga", "topaz", "vangogh", "vega10", "vega12", "vega20", "vegam",
+	"verde", "yellow_carp",
+}
+
+// amdIPBlock matches AMD firmware named after an IP block version.
+var amdIPBlock = regexp.MustCompile(`^([a-z]+_\d+_\d+_\d+)_`)
+
+// missingFirmware is a firmware file a driver failed to load.
+type missingFirmware struct {
+	driver string // i915, amdgpu or radeon
+	file   string // without .bin
+}
+
+func (f missingFirmware) String() string {
+	return f.driver + "/" + f.file + ".bin"
+}
+
+// modulePath is where the FreeBSD firmware module for f is installed.
+// The radeon ones keep the name of the older radeonkms firmware ports.
+func (f missingFirmware) modulePath() string {
+	if f.driver == "radeon" {
+		return filepath.Join("/boot/modules", "radeonkmsfw_"+f.file+".ko")
+	}
+	return filepath.Join("/boot/modules", f.driver+"_"+strings.NewReplacer(".", "_", "-", "_").Replace(f.file)+"_bin.ko")
+}
+
+// installed reports whether the firmware module for f is there now.
+func (f missingFirmware) installed() bool {
+	_, err := os.Stat(f.modulePath())
+	return err == nil
+}
+
+// firmwarePackage names the package holding f.
+func firmwarePackage(f missingFirmware) string {
+	switch f.driver {
+	case "i915":
+		prefix, _, _ := strings.Cut(f.file, "_")
+		if platform, ok := intelPlatforms[prefix]; ok {
+			return "gpu-firmware-intel-kmod-" + platform
+		}
+		return "gpu-firmware-intel-kmod"
+	case "radeon":
+		prefix, _, _ := strings.Cut(f.file, "_")
+		return "gpu-firmware-radeon-kmod-" + strings.ToLower(prefix)
+	}
+	if m := amdIPBlock.FindStringSubmatch(f.file); m != nil {
+		return "gpu-firmware-amd-kmod-" + strings.ReplaceAll(m[1], "_", "-")
+	}
+	chip := ""
+	for _, c := range amdChips {
+		if strings.HasPrefix(f.file, c+"_") && len(c) > len(chip) {
+			chip = c
+		}
+	}
+	if chip == "" {
+		return "gpu-firmware-amd-kmod"
+	}
+	return "gpu-firmware-amd-kmod-" + strings.ReplaceAll(chip, "_", "-")
+}
+
+// firmwareFailures returns the firmware that lines report as failing to
+// load. A failure that doesn't name its file is returned with an empty
+// file.
+func firmwareFailures(lines []string) []missingFirmware {
+	var missing []missingFirmware
+	seen := map[missingFirmware]bool{}
+	for _, line := range lines {
+		if !firmwareFailure.MatchString(line) {
+			continue
+		}
+		f := missingFirmware{}
+		if m := firmwareFile.FindStringSubmatch(line); m != nil {
+			f = missingFirmware{m[1], m[2]}
+		}
+		if !seen[f] {
+			seen[f] = true
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// gpuDevice is a display controller pciconf lists.
+type gpuDevice struct {
+	selector string
+	vendor   string
+	device   string
+	model    string
+}
+
+// detectGPUs returns the display controllers with their vendor and model.
+func detectGPUs() ([]gpuDevice, error) {
+	out, err := commandOutput(exec.Command("pciconf", "-lv"))
+	if err != nil {
+		return nil, err
+	}
+	var gpus []gpuDevice
+	var gpu *gpuDevice
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			gpu = nil
+			if !strings.HasPrefix(line, "vgapci") {
+				continue
+			}
+			gpus = append(gpus, gpuDevice{})
+			gpu = &gpus[len(gpus)-1]
+			selector, _, _ := strings.Cut(line, ":")
+			gpu.selector = selector
+			for _, field := range strings.Fields(line) {
+				if v, ok := strings.CutPrefix(field, "vendor="); ok {
+					gpu.vendor = v
+				} else if v, ok := strings.CutPrefix(field, "device="); ok {
+					gpu.device = v
+				}
+			}
+			continue
+		}
+		if gpu == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), "'")
+		switch strings.TrimSpace(key) {
+		case "vendor":
+			gpu.model = strings.TrimSpace(value + " " + gpu.model)
+		case "device":
+			gpu.model = strings.TrimSpace(gpu.model + " " + value)
+		}
+	}
+	return gpus, nil
+}
+
+// gpuFirmware is what a GPU needs in firmware.
+type gpuFirmware struct {
+	gpu      gpuDevice
+	module   string            // the driver, "" if NiriSetup doesn't know it
+	loaded   bool              // whether the driver is loaded
+	missing  []missingFirmware // what the driver failed to load since boot
+	packages []string          // what to install, installed or not
+}
+
+// pending returns the packages of g that aren't installed yet.
+func (g gpuFirmware) pending() []string {
+	var pending []string
+	for _, pkg := range g.packages {
+		if !isPackageInstalled(pkg) {
+			pending = append(pending, pkg)
+		}
+	}
+	return pending
+}
+
+// checkGPUFirmware works out for each GPU which firmware its driver failed
+// to load and which packages hold it. For a driver that isn't loaded yet
+// it can't tell which files it will ask for, so it picks the package with
+// all of the vendor's firmware.
+func checkGPUFirmware() ([]gpuFirmware, error) {
+	gpus, err := detectGPUs()
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := loadedModules()
+	if err != nil {
+		return nil, err
+	}
+	lines, _ := kernelMessages()
+	failures := firmwareFailures(lines)
+
+	var result []gpuFirmware
+	for _, gpu := range gpus {
+		g := gpuFirmware{gpu: gpu, module: gpuModules[gpu.vendor]}
+		g.loaded = loaded[g.module] || (gpu.vendor == "0x1002" && loaded["radeonkms"])
+		for _, f := range failures {
+			if f.driver == "" || firmwareVendors[f.driver] == gpu.vendor {
+				g.missing = append(g.missing, f)
+			}
+		}
+		for _, f := range g.missing {
+			pkg := vendorFirmware[gpu.vendor]
+			if f.file != "" {
+				pkg = firmwarePackage(f)
+			}
+			if pkg != "" && !containsString(g.packages, pkg) {
+				g.packages = append(g.packages, pkg)
+			}
+		}
+		if !g.loaded && len(g.packages) == 0 && vendorFirmware[gpu.vendor] != "" {
+			g.packages = []string{vendorFirmware[gpu.vendor]}
+		}
+		sort.Strings(g.packages)
+		result = append(result, g)
+	}
+	return result, nil
+}
+
+// describeGPUFirmware lays out what each GPU needs.
+func describeGPUFirmware(gpus []gpuFirmware) string {
+	if len(gpus) == 0 {
+		return T("pciconf lists no display controller.")
+	}
+	var b strings.Builder
+	for _, g := range gpus {
+		fmt.Fprintf(&b, "%s: %s (%s %s)\n", g.gpu.selector, g.gpu.model, g.gpu.vendor, g.gpu.device)
+		switch {
+		case g.module == "":
+			fmt.Fprintln(&b, "  "+T("NiriSetup doesn't know the driver for this GPU."))
+			continue
+		case g.gpu.vendor == "0x10de":
+			fmt.Fprintln(&b, "  "+T("The NVIDIA driver brings its own firmware."))
+			continue
+		case g.loaded:
+			fmt.Fprintln(&b, "  "+Tf("Driver: %s, loaded", g.module))
+		default:
+			fmt.Fprintln(&b, "  "+Tf("Driver: %s, not loaded yet", g.module))
+		}
+		for _, f := range g.missing {
+			name := f.String()
+			if f.file == "" {
+				name = T("firmware not named in the message")
+			}
+			state := T("missing")
+			if f.file != "" && f.installed() {
+				state = Tf("installed since, %s loads it after a reboot", g.module)
+			}
+			fmt.Fprintln(&b, "  ✗ "+name+": "+state)
+		}
+		pending := g.pending()
+		switch {
+		case len(pending) > 0:
+			fmt.Fprintln(&b, "  "+Tf("Needs: %s", strings.Join(pending, ", ")))
+		case g.loaded && len(g.missing) == 0:
+			fmt.Fprintln(&b, "  ✓ "+T("The driver loaded all the firmware it asked for."))
+		case len(g.packages) > 0:
+			fmt.Fprintln(&b, "  "+Tf("Installed: %s", strings.Join(g.packages, ", ")))
+		}
+	}
+	return b.String()
+}
+
+// dmesgLines splits the output of dmesg into its lines.
+func dmesgLines(out []byte) []string {
+	if len(out) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+}
+
+// dmesgAdded returns the lines of after that follow those of before. They
+// are told by position, not by content, since a driver that fails twice
+// logs the same line twice. The kernel message buffer is a ring, so when
+// old lines dropped off its front meanwhile, the new ones start after the
+// last lines of before, wherever those ended up.
+func dmesgAdded(before, after []string) []string {
+	n := len(before)
+	if n == 0 {
+		return after
+	}
+	if n <= len(after) && after[n-1] == before[n-1] {
+		return after[n:]
+	}
+	for k := min(n, 3); k > 0; k-- {
+		tail := before[n-k:]
+		for end := len(after); end >= k; end-- {
+			if slices.Equal(after[end-k:end], tail) {
+				return after[end:]
+			}
+		}
+	}
+	// All of before has dropped off: everything is new.
+	return after
+}
+
+// loadAndCheckFirmware loads module and returns the firmware its new
+// kernel messages say it failed to load.
+func loadAndCheckFirmware(module string) ([]missingFirmware, error) {
+	out, _ := commandOutput(exec.Command("dmesg"))
+	before := dmesgLines(out)
+	if _, err := loadModule(module); err != nil {
+		return nil, err
+	}
+	time.Sleep(firmwareSettle)
+	out, err := commandOutput(exec.Command("dmesg"))
+	if err != nil {
+		return nil, err
+	}
+	return firmwareFailures(dmesgAdded(before, dmesgLines(out))), nil
+}
+
+// installGPUFirmware installs the firmware packages g needs. If its driver
+// isn't loaded yet, it loads it and checks the kernel messages for
+// firmware that still fails to load; a loaded driver only picks up the new
+// firmware at the next boot.
+func installGPUFirmware(g gpuFirmware) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		for _, pkg := range g.pending() {
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		if g.loaded {
+			for _, f := range g.missing {
+				if f.file != "" && !f.installed() {
+					err := errors.New(Tf("%s is still missing: %s isn't installed", f, f.modulePath()))
+					logs = append(logs, err.Error())
+					return statusMsg{status: strings.Join(logs, "\n"), err: err}
+				}
+			}
+			logs = append(logs, Tf("%s is already loaded and loads the new firmware at the next boot. Reboot, then check GPU Firmware again.", g.module))
+			return statusMsg{status: strings.Join(logs, "\n")}
+		}
+
+		missing, err := loadAndCheckFirmware(g.module)
+		if err != nil {
+			logs = append(logs, Tf("Failed to load %s: %v", g.module, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Loaded %s", g.module))
+		if len(missing) > 0 {
+			var names []string
+			for _, f := range missing {
+				names = append(names, f.String())
+			}
+			err := errors.New(Tf("%s still fails to load firmware: %s", g.module, strings.Join(names, ", ")))
+			logs = append(logs, err.Error(), T("Open GPU Firmware again to install the packages for these files."))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("%s loaded without firmware errors.", g.module))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// firmwareLabel names the fix for g on the GPU Firmware screen.
+func firmwareLabel(g gpuFirmware) string {
+	if g.loaded {
+		return Tf("Install %s", strings.Join(g.pending(), ", "))
+	}
+	if len(g.pending()) == 0 {
+		return Tf("Load %s and check its firmware", g.module)
+	}
+	return Tf("Install %s and load %s", strings.Join(g.pending(), ", "), g.module)
+}
+
+// needsFirmwareFix reports whether GPU Firmware offers a fix for g.
+func needsFirmwareFix(g gpuFirmware) bool {
+	if g.module == "" || g.gpu.vendor == "0x10de" {
+		return false
+	}
+	return !g.loaded || len(g.pending()) > 0
+}
+
+// gpuFirmwareView shows the GPUs and the firmware they need, with a fix
+// for each GPU missing some.
+func gpuFirmwareView() tea.Cmd {
+	return func() tea.Msg {
+		gpus, err := checkGPUFirmware()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to check the GPU firmware: %v", err), err: preflightFailure(err)}
+		}
+		r := &report{title: "GPU Firmware", body: describeGPUFirmware(gpus), refresh: gpuFirmwareView()}
+		for _, g := range gpus {
+			if needsFirmwareFix(g) {
+				r.actions = append(r.actions, reportAction{label: firmwareLabel(g), cmd: installGPUFirmware(g)})
+			}
+		}
+		return reportMsg{r}
+	}
+}
+
+// setupGPUFirmware is nirisetup firmware: it fixes the firmware of every
+// GPU that needs it.
+func setupGPUFirmware() tea.Cmd {
+	return func() tea.Msg {
+		gpus, err := checkGPUFirmware()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to check the GPU firmware: %v", err), err: preflightFailure(err)}
+		}
+		logs := []string{strings.TrimRight(describeGPUFirmware(gpus), "\n")}
+		var worst error
+		for _, g := range gpus {
+			if !needsFirmwareFix(g) {
+				continue
+			}
+			msg := installGPUFirmware(g)().(statusMsg)
+			logs = append(logs, msg.status)
+			if exitCode(msg.err) > exitCode(worst) {
+				worst = msg.err
+			}
+		}
+		return statusMsg{status: strings.Join(logs, "\n"), err: worst}
+	}
+}
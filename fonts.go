@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fontPackages are the Noto fonts covering Chinese, Japanese and Korean
+// text and color emoji.
+var fontPackages = []string{"noto-sans-cjk", "noto-serif-cjk", "noto-emoji"}
+
+// cjkRegions picks the regional glyph shapes of Noto CJK from the input
+// method in use; Han characters are drawn differently in each.
+var cjkRegions = map[string]string{
+	"pinyin": "SC",
+	"rime":   "SC",
+	"mozc":   "JP",
+	"anthy":  "JP",
+	"hangul": "KR",
+}
+
+// fontconfigFallbacks accepts Noto CJK and Noto Color Emoji right after the
+// generic families, so Latin text keeps its usual fonts and only the
+// characters they lack fall back.
+func fontconfigFallbacks(region string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?>
+<!DOCTYPE fontconfig SYSTEM "fonts.dtd">
+<!-- Generated by NiriSetup: fall back to Noto CJK and Noto Color Emoji. -->
+<fontconfig>
+`)
+	for _, f := range [][2]string{
+		{"sans-serif", "Noto Sans CJK " + region},
+		{"serif", "Noto Serif CJK " + region},
+		{"monospace", "Noto Sans Mono CJK " + region},
+	} {
+		fmt.Fprintf(&b, "  <alias>\n    <family>%s</family>\n    <accept>\n      <family>%s</family>\n      <family>Noto Color Emoji</family>\n    </accept>\n  </alias>\n", f[0], f[1])
+	}
+	b.WriteString("</fontconfig>\n")
+	return b.String()
+}
+
+// installFonts installs the fonts, writes the fontconfig fallbacks for the
+// region and rebuilds the font cache.
+func installFonts(region string) ([]string, error) {
+	var logs []string
+	for _, pkg := range fontPackages {
+		line, err := installPackage(pkg)
+		logs = append(logs, line)
+		if err != nil {
+			return logs, err
+		}
+	}
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return logs, err
+	}
+	path := filepath.Join(homeDir, ".config", "fontconfig", "conf.d", "60-nirisetup-fallbacks.conf")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return logs, err
+	}
+	if err := writeFile(path, []byte(fontconfigFallbacks(region)), 0644); err != nil {
+		logs = append(logs, Tf("Failed to write %s: %v", path, err))
+		return logs, err
+	}
+	logs = append(logs, Tf("Wrote %s: OK", path))
+	if out, err := commandCombinedOutput(exec.Command("fc-cache", "-f")); err != nil {
+		logs = append(logs, Tf("Warning: fc-cache: %s", strings.TrimSpace(string(out))))
+	} else {
+		logs = append(logs, T("Rebuilt the font cache: OK"))
+	}
+	logs = append(logs, T("Restart waybar, foot and your browser to pick up the fonts."))
+	return logs, nil
+}
+
+// setupFonts installs the fonts on their own, as a wizard component.
+func setupFonts() tea.Cmd {
+	return func() tea.Msg {
+		logs, err := installFonts("SC")
+		return statusMsg{status: strings.Join(logs, "\n"), err: err}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// formField is a single editable value on a form screen. Fields with
+// options are cycled with left/right instead of being typed into. Swatch
+// fields hold a hex color that is previewed next to the value. help, if
+// set, is shown below the form while the field is selected.
+type formField struct {
+	label   string
+	value   string
+	options []string
+	swatch  bool
+	help    string
+}
+
+// form is a simple screen of labelled fields. submit receives the field
+// values in order and either returns the command to run or a validation
+// error that is shown on the form.
+type form struct {
+	title  string
+	fields []formField
+	cursor int
+	err    string
+	submit func(values []string) (tea.Cmd, error)
+}
+
+var (
+	formLabelStyle = lipgloss.NewStyle().Width(menuItemWidth)
+	formErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5f5f")).Width(viewWidth)
+	formHelpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Width(viewWidth)
+)
+
+func (f *form) values() []string {
+	values := make([]string, len(f.fields))
+	for i, field := range f.fields {
+		values[i] = field.value
+	}
+	return values
+}
+
+// cycle moves the current option field by delta, wrapping around.
+func (f *form) cycle(delta int) {
+	field := &f.fields[f.cursor]
+	if len(field.options) == 0 {
+		return
+	}
+	idx := 0
+	for i, opt := range field.options {
+		if opt == field.value {
+			idx = i
+		}
+	}
+	idx = (idx + delta + len(field.options)) % len(field.options)
+	field.value = field.options[idx]
+}
+
+// updateForm handles key input while a form is shown. It returns the model
+// and, once the form has been submitted, the command to run.
+func (m model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.form
+	field := &f.fields[f.cursor]
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.state = menuView
+		if m.wizard != nil {
+			m.state = wizardView
+		}
+		m.form = nil
+		m.isProcessing = false
+	case "up", "shift+tab":
+		if f.cursor > 0 {
+			f.cursor--
+		}
+	case "down", "tab":
+		if f.cursor < len(f.fields)-1 {
+			f.cursor++
+		}
+	case "left":
+		f.cycle(-1)
+	case "right":
+		f.cycle(1)
+	case "backspace":
+		if len(field.options) == 0 && len(field.value) > 0 {
+			runes := []rune(field.value)
+			field.value = string(runes[:len(runes)-1])
+		}
+	case "enter":
+		cmd, err := f.submit(f.values())
+		if err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+		m.state = actionView
+		m.actionMsg = Tf("Applying %s...", T(f.title))
+		m.form = nil
+		return m, cmd
+	default:
+		if msg.Type == tea.KeyRunes && len(field.options) == 0 {
+			field.value += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderFormView() string {
+	f := m.form
+	title := titleStyle.Render(T(f.title))
+
+	s := strings.Builder{}
+	for i, field := range f.fields {
+		value := field.value
+		if len(field.options) > 0 {
+			value = "< " + T(value) + " >"
+		}
+		line := formLabelStyle.Render(T(field.label)) + value
+		if field.swatch && isHexColor(field.value) {
+			// Terminals can't show alpha, so preview just the RGB part.
+			rgb := field.value
+			if len(rgb) == 9 {
+				rgb = rgb[:7]
+			} else if len(rgb) == 5 {
+				rgb = rgb[:4]
+			}
+			line += " " + lipgloss.NewStyle().Foreground(lipgloss.Color(rgb)).Render("███")
+		}
+		if f.cursor == i {
+			s.WriteString(cursorStyle.Render("> "+line) + "\n")
+		} else {
+			s.WriteString(disabledStyle.Render("  "+line) + "\n")
+		}
+	}
+
+	parts := []string{title, menuStyle.Render(s.String())}
+	if help := f.fields[f.cursor].help; help != "" {
+		parts = append(parts, formHelpStyle.Render(T(help)))
+	}
+	if f.err != "" {
+		parts = append(parts, formErrorStyle.Render(f.err))
+	}
+	parts = append(parts, formHelpStyle.Render(T("↑/↓ move • ←/→ choose • enter apply • esc cancel")))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// formMsg opens a form that was prepared by a background command, e.g. one
+// prefilled from detected hardware.
+type formMsg struct {
+	form *form
+}
+
+// isHexColor reports whether s is a "#rgb", "#rgba", "#rrggbb" or
+// "#rrggbbaa" color as accepted by niri.
+func isHexColor(s string) bool {
+	if !strings.HasPrefix(s, "#") {
+		return false
+	}
+	switch len(s) {
+	case 4, 5, 7, 9:
+	default:
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
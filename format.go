@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// kdlIndentUnit is one level of indentation in a formatted config.
+const kdlIndentUnit = "    "
+
+// kdlNodeOrder is the order of the top-level sections in a formatted
+// config, following niri's default config. Other nodes go last.
+var kdlNodeOrder = []string{
+	"input", "output", "layout", "spawn-at-startup", "spawn-sh-at-startup",
+	"environment", "cursor", "hotkey-overlay", "prefer-no-csd", "screenshot-path",
+	"clipboard", "config-notification", "animations", "gestures", "overview",
+	"xwayland-satellite", "workspace", "window-rule", "layer-rule", "binds",
+	"switch-events", "debug",
+}
+
+// kdlChunk is a top-level node with the comments above it and the rest
+// of its last line.
+type kdlChunk struct {
+	name string
+	text string
+}
+
+func kdlNodeRank(name string) int {
+	for i, n := range kdlNodeOrder {
+		if n == strings.TrimPrefix(name, "/-") {
+			return i
+		}
+	}
+	return len(kdlNodeOrder)
+}
+
+// reorderKDL sorts the top-level nodes of cfg by kdlNodeOrder, keeping
+// nodes of the same kind in their order, since that matters for window
+// rules, and moving the comments above a node with it. Configs that
+// include other files are left alone because there order decides which
+// setting wins.
+func reorderKDL(cfg string) string {
+	spans := kdlChildren(cfg, 0, len(cfg))
+	if len(spans) == 0 {
+		return cfg
+	}
+	var chunks []kdlChunk
+	header, prev := "", 0
+	for _, sp := range spans {
+		name := kdlNodeName(cfg, sp)
+		if strings.TrimPrefix(name, "/-") == "include" {
+			return cfg
+		}
+		if sp.start < prev {
+			// Another node on the same line as the last one.
+			chunks[len(chunks)-1].text += cfg[prev:sp.end]
+			prev = sp.end
+			continue
+		}
+		end := len(cfg)
+		if i := strings.IndexByte(cfg[sp.end:], '\n'); i >= 0 {
+			end = sp.end + i + 1
+		}
+		if len(chunks) == 0 {
+			// A comment at the top of the file, separated from the first
+			// node by a blank line, stays at the top.
+			if i := strings.LastIndex(cfg[:sp.start], "\n\n"); i >= 0 {
+				header, prev = cfg[:i+2], i+2
+			}
+		}
+		chunks = append(chunks, kdlChunk{name, cfg[prev:end]})
+		prev = end
+	}
+	rest := cfg[prev:]
+
+	sorted := append([]kdlChunk(nil), chunks...)
+	sort.SliceStable(sorted, func(i, j int) bool { return kdlNodeRank(sorted[i].name) < kdlNodeRank(sorted[j].name) })
+	moved := false
+	for i := range chunks {
+		moved = moved || sorted[i] != chunks[i]
+	}
+	if !moved {
+		return cfg
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	for i, c := range sorted {
+		text := strings.TrimRight(c.text, " \t") + "\n"
+		// Keep a blank line between sections that now meet.
+		if i > 0 && kdlNodeRank(c.name) != kdlNodeRank(sorted[i-1].name) && !strings.HasPrefix(strings.TrimLeft(text, " \t"), "\n") {
+			text = "\n" + text
+		}
+		b.WriteString(text)
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// rawToQuoted rewrites a raw string as a plain quoted one when it has
+// nothing that would need escaping.
+func rawToQuoted(tok string) string {
+	body := strings.TrimLeft(strings.TrimPrefix(tok, "r"), "#")
+	body = strings.TrimRight(body, "#")
+	if len(body) < 2 || body[0] != '"' || body[len(body)-1] != '"' {
+		return tok
+	}
+	inner := body[1 : len(body)-1]
+	if strings.ContainsAny(inner, "\\\"\n") {
+		return tok
+	}
+	return body
+}
+
+// formatKDL normalizes cfg: top-level nodes in the usual order, four
+// spaces per level, plain quotes where raw strings aren't needed, no
+// trailing whitespace and no runs of blank lines. Spacing inside a line
+// is kept, since binds are often aligned, as are comments and the
+// insides of strings.
+func formatKDL(cfg string) string {
+	s := reorderKDL(strings.ReplaceAll(cfg, "\r\n", "\n"))
+	var b strings.Builder
+	depth := 0
+	blank := false
+	var last byte // the last character written on a content line
+	lineStart := true
+	for i := 0; i < len(s); {
+		if lineStart {
+			for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+				i++
+			}
+			if i == len(s) {
+				break
+			}
+			if s[i] == '\n' {
+				blank = true
+				i++
+				continue
+			}
+			closing := s[i] == '}'
+			if blank && b.Len() > 0 && last != '{' && !closing {
+				b.WriteByte('\n')
+			}
+			blank = false
+			level := depth
+			if closing {
+				level--
+			}
+			b.WriteString(strings.Repeat(kdlIndentUnit, max(0, level)))
+			lineStart = false
+		}
+
+		if j := kdlSkip(s, i); j != i {
+			tok := s[i:j]
+			if s[i] == 'r' {
+				tok = rawToQuoted(tok)
+			}
+			b.WriteString(tok)
+			last = tok[len(tok)-1]
+			i = j
+			continue
+		}
+		switch c := s[i]; c {
+		case ' ', '\t':
+			j := i
+			for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+				j++
+			}
+			if j < len(s) && s[j] != '\n' {
+				b.WriteString(s[i:j])
+			}
+			i = j
+		case '\n':
+			b.WriteByte('\n')
+			lineStart = true
+			i++
+		default:
+			switch c {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			b.WriteByte(c)
+			last = c
+			i++
+		}
+	}
+	out := strings.TrimRight(b.String(), "\n")
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
+
+// formatDiff returns the path of the user's config.kdl and what
+// formatting would change in it.
+func formatDiff() (string, string, error) {
+	path, cfg, err := readNiriConfig()
+	if err != nil {
+		return path, err.Error(), err
+	}
+	return path, unifiedDiff(cfg, formatKDL(cfg), path, T("formatted")), nil
+}
+
+// writeFormattedConfig formats config.kdl in place.
+func writeFormattedConfig() tea.Cmd {
+	return func() tea.Msg {
+		path, err := updateNiriConfig(formatKDL)
+		if err != nil {
+			return statusMsg{status: Tf("Failed to format %s: %v", path, err), err: err}
+		}
+		return statusMsg{status: Tf("Formatted %s", path)}
+	}
+}
+
+// formatNiriConfig shows what formatting would change in config.kdl and
+// offers to write it.
+func formatNiriConfig() tea.Cmd {
+	return func() tea.Msg {
+		path, diff, err := formatDiff()
+		if err != nil {
+			return statusMsg{status: diff, err: err}
+		}
+		if diff == "" {
+			return statusMsg{status: Tf("%s is already formatted", path)}
+		}
+		r := &report{title: "Format Config", body: strings.TrimRight(diff, "\n"), refresh: formatNiriConfig()}
+		r.actions = []reportAction{{label: "Write the formatted config", cmd: writeFormattedConfig()}}
+		return reportMsg{r}
+	}
+}
+
+// runFormat prints what formatting would change in config.kdl and
+// writes it when write is set.
+func runFormat(write bool) (string, error) {
+	path, diff, err := formatDiff()
+	if err != nil {
+		return diff, err
+	}
+	if diff == "" {
+		return Tf("%s is already formatted", path), nil
+	}
+	diff = strings.TrimRight(diff, "\n")
+	if !write {
+		return diff + "\n\n" + T("Pass --yes to write it."), errors.New("config not formatted")
+	}
+	if _, err := updateNiriConfig(formatKDL); err != nil {
+		return diff + "\n\n" + Tf("Failed to format %s: %v", path, err), err
+	}
+	return diff + "\n\n" + Tf("Formatted %s", path), nil
+}
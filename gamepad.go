@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gamepadGroup owns the input devices, so games can read controllers
+// without the user being able to open every device as root.
+const gamepadGroup = "input"
+
+// gamepadRules give the gamepad group the evdev, uhid and hidraw devices
+// controllers show up as.
+var gamepadRules = []string{
+	"add path 'input/*' mode 0660 group " + gamepadGroup,
+	"add path 'uhid*' mode 0660 group " + gamepadGroup,
+	"add path 'hidraw*' mode 0660 group " + gamepadGroup,
+}
+
+// gamepadModules are the HID drivers that turn generic, Xbox 360 and
+// PlayStation 4 controllers into evdev devices.
+var gamepadModules = []string{"hgame", "xb360gp", "ps4dshock"}
+
+// usbhidTunable switches USB HID devices to the hidbus stack the gamepad
+// drivers attach to. It is a loader tunable and takes a reboot, and it
+// moves every USB HID device, keyboards and mice included, not only the
+// controllers.
+const usbhidTunable = "hw.usb.usbhid.enable"
+
+// usbhidEnabled reports whether USB HID devices use the hidbus stack.
+func usbhidEnabled() bool {
+	out, _ := commandOutput(exec.Command("sysctl", "-n", usbhidTunable))
+	return strings.TrimSpace(string(out)) == "1"
+}
+
+// setupGamepads asks before switching USB HID devices to the hidbus stack,
+// then sets up the game controllers either way.
+func setupGamepads() tea.Cmd {
+	return func() tea.Msg {
+		if usbhidEnabled() {
+			return setupGamepadsWith(false)()
+		}
+		return confirmMsg{&confirmation{
+			prompt:    Tf("Most controllers need %s=1 in %s. It moves every USB keyboard, mouse and other input device to the hidbus stack at the next boot, not only the controllers. Set it?", usbhidTunable, loaderConfPath),
+			actionMsg: "Setting up gamepads...",
+			yes:       setupGamepadsWith(true),
+			no:        setupGamepadsWith(false),
+		}}
+	}
+}
+
+// setupGamepadsWith gives the session access to game controllers: devfs
+// rules for the input devices, the group and the HID drivers. With usbhid
+// set it also switches USB HID devices to the hidbus stack.
+func setupGamepadsWith(usbhid bool) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		if out, err := commandOutput(exec.Command("pw", "groupshow", gamepadGroup)); err != nil || len(out) == 0 {
+			if out, err := commandCombinedOutput(exec.Command("sudo", "pw", "groupadd", gamepadGroup)); err != nil {
+				logs = append(logs, Tf("Failed to create the %s group: %s", gamepadGroup, strings.TrimSpace(string(out))))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, Tf("Created the %s group: OK", gamepadGroup))
+		}
+
+		status, err := applyDevfsRules(gamepadRules)
+		if err != nil {
+			logs = append(logs, Tf("Failed to update %s: %v", devfsRulesPath, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, status)
+		logs = append(logs, Tf("Gave the %s group access to /dev/input, /dev/uhid* and /dev/hidraw*: OK", gamepadGroup))
+
+		if user := userName(); user != "" {
+			if out, err := commandCombinedOutput(exec.Command("sudo", "pw", "groupmod", gamepadGroup, "-m", user)); err != nil {
+				logs = append(logs, Tf("Warning: Adding user '%s' to the %s group: %s", user, gamepadGroup, strings.TrimSpace(string(out))))
+			} else {
+				logs = append(logs, Tf("Added user '%s' to the %s group: OK", user, gamepadGroup))
+			}
+		}
+
+		reboot := false
+		switch {
+		case usbhidEnabled():
+		case !usbhid:
+			logs = append(logs, Tf("Left %s alone: controllers that need the hidbus stack won't show up as gamepads.", usbhidTunable))
+		default:
+			if out, err := commandCombinedOutput(exec.Command("sudo", "sysrc", "-f", loaderConfPath, usbhidTunable+"=1")); err != nil {
+				logs = append(logs, Tf("Warning: Setting %s in %s: %s", usbhidTunable, loaderConfPath, strings.TrimSpace(string(out))))
+			} else {
+				logs = append(logs, Tf("Set %s=1 in %s: OK. From the next boot every USB input device, keyboards and mice too, uses the hidbus stack.", usbhidTunable, loaderConfPath))
+				reboot = true
+			}
+		}
+
+		kld := kldList()
+		for _, name := range gamepadModules {
+			if _, err := os.Stat("/boot/kernel/" + name + ".ko"); err != nil {
+				continue
+			}
+			if line, err := loadModule(name); err != nil {
+				logs = append(logs, Tf("Warning: Loading %s: %v", name, err))
+			} else {
+				logs = append(logs, line)
+			}
+			if !kld[name] {
+				if line, err := persistModule(name); err != nil {
+					logs = append(logs, Tf("Warning: Adding %s to kld_list: %v", name, err))
+				} else {
+					logs = append(logs, line)
+				}
+			}
+		}
+
+		if reboot {
+			logs = append(logs, T("Reboot so controllers attach to the new HID stack."))
+		} else {
+			logs = append(logs, T("Log out and back in for the group change to take effect."))
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
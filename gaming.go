@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gamingPackages are the tools for games under niri: MangoHud draws a
+// performance overlay and gamescope runs a game in its own nested
+// compositor, which helps games that misbehave with scaling or focus.
+var gamingPackages = []string{"mangohud", "gamescope"}
+
+// gamingEnvironment makes SDL games talk Wayland directly and fall back to
+// X11 through xwayland-satellite.
+var gamingEnvironment = [][2]string{
+	{"SDL_VIDEODRIVER", "wayland,x11"},
+	{"SDL_VIDEO_DRIVER", "wayland,x11"},
+}
+
+// gamingWindowRule opens games fullscreen and lets them drive the refresh
+// rate on outputs with variable-refresh-rate on-demand=true. Steam games
+// run through Proton get the steam_app_<id> app ID.
+const gamingWindowRule = `window-rule {
+    match app-id=r#"^steam_app_\d+$"#
+    match app-id="gamescope"
+    open-fullscreen true
+    variable-refresh-rate true
+}`
+
+// packageAvailable reports whether the package repository has pkg.
+func packageAvailable(pkg string) bool {
+	out, err := commandOutput(exec.Command("pkg", "rquery", "%n", pkg))
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
+// gamingConfig sets the SDL environment and adds the window rule for games
+// to cfg.
+func gamingConfig(cfg string) string {
+	for _, kv := range gamingEnvironment {
+		cfg = setKDLNode(cfg, []string{"environment"}, kv[0]+" "+kdlQuote(kv[1]))
+	}
+	if !strings.Contains(cfg, `app-id=r#"^steam_app_\d+$"#`) {
+		cfg = addKDLNode(cfg, nil, gamingWindowRule)
+	}
+	return cfg
+}
+
+// setupGaming installs the gaming tools the repository has, sets the SDL
+// environment and adds the window rule for games.
+func setupGaming() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		for _, pkg := range gamingPackages {
+			if !isPackageInstalled(pkg) && !packageAvailable(pkg) {
+				logs = append(logs, Tf("%s isn't available for this system, skipping", pkg))
+				continue
+			}
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		path, err := updateNiriConfig(gamingConfig)
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to %s: OK", "SDL_VIDEODRIVER=wayland,x11", path))
+		logs = append(logs, Tf("Added a window rule opening games fullscreen to %s: OK", path))
+		if hasCommand("mangohud") {
+			logs = append(logs, T("Start a game with mangohud <game> for the overlay, or add mangohud %command% to its Steam launch options."))
+		}
+		if hasCommand("gamescope") {
+			logs = append(logs, T("Run a game with gamescope -f -- <game> if it has trouble with scaling or focus."))
+		}
+		logs = append(logs, T("For smooth frame pacing, set VRR to On demand in Output Settings."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// renderNodeWait is how long GPU Driver waits for a render node to appear
+// after loading a driver.
+const renderNodeWait = 5 * time.Second
+
+// gpuDriverPackages are the packages holding the GPU drivers, by module.
+var gpuDriverPackages = map[string]string{
+	"i915kms":    "drm-kmod",
+	"amdgpu":     "drm-kmod",
+	"radeonkms":  "drm-kmod",
+	"nvidia-drm": "nvidia-drm-kmod",
+}
+
+// gpuDriver is what a GPU needs to get a render node: its driver, the
+// package holding it and the firmware the driver loads.
+type gpuDriver struct {
+	gpuFirmware
+	pkg        string // "" if NiriSetup knows no driver for the GPU
+	pkgPresent bool
+}
+
+// scanGPUDrivers checks the driver of each GPU.
+func scanGPUDrivers() ([]gpuDriver, error) {
+	gpus, err := checkGPUFirmware()
+	if err != nil {
+		return nil, err
+	}
+	var drivers []gpuDriver
+	for _, g := range gpus {
+		d := gpuDriver{gpuFirmware: g, pkg: gpuDriverPackages[g.module]}
+		d.pkgPresent = d.pkg != "" && isPackageInstalled(d.pkg)
+		drivers = append(drivers, d)
+	}
+	return drivers, nil
+}
+
+// needsFix reports whether GPU Driver can do anything for d: install or
+// load a driver it knows.
+func (d gpuDriver) needsFix() bool {
+	return d.pkg != "" && (!d.pkgPresent || !d.loaded)
+}
+
+// label names the fix for d.
+func (d gpuDriver) label() string {
+	if d.pkgPresent {
+		return Tf("Load %s", d.module)
+	}
+	return Tf("Install %s and load %s", d.pkg, d.module)
+}
+
+// explain says why d has no render node.
+func (d gpuDriver) explain() string {
+	switch {
+	case d.pkg == "":
+		return Tf("NiriSetup knows no DRM driver for this GPU (vendor %s), so it gets no render node. niri needs one to draw.", d.gpu.vendor)
+	case !d.pkgPresent:
+		return Tf("%s isn't installed. It holds %s, the driver that creates the render node for this GPU.", d.pkg, d.module)
+	case !d.loaded:
+		return Tf("%s is installed, but %s isn't loaded.", d.pkg, d.module)
+	case len(d.missing) > 0:
+		return Tf("%s is loaded but failed to load its firmware; GPU Firmware installs it.", d.module)
+	}
+	return Tf("%s is loaded but created no render node; Doctor shows what the kernel said about it.", d.module)
+}
+
+// describeGPUDrivers lays out the render node and the driver of each GPU.
+func describeGPUDrivers(node string, drivers []gpuDriver) string {
+	var b strings.Builder
+	if node != "" {
+		fmt.Fprintf(&b, "%s %s\n", T("Render node:"), node)
+	} else {
+		fmt.Fprintf(&b, "%s %s\n", T("Render node:"), T("none, /dev/dri/renderD* is missing"))
+	}
+	if len(drivers) == 0 {
+		fmt.Fprintln(&b, T("pciconf lists no display controller."))
+	}
+	for _, d := range drivers {
+		fmt.Fprintf(&b, "\n%s (%s)\n", d.gpu.model, d.gpu.selector)
+		if d.pkg != "" {
+			state := T("not installed")
+			switch {
+			case d.pkgPresent && d.loaded:
+				state = T("loaded")
+			case d.pkgPresent:
+				state = T("installed, not loaded")
+			}
+			fmt.Fprintf(&b, "  %s %s (%s): %s\n", T("Driver:"), d.module, d.pkg, state)
+		}
+		if pending := d.pending(); len(pending) > 0 {
+			fmt.Fprintf(&b, "  %s %s\n", T("Firmware to install:"), strings.Join(pending, ", "))
+		}
+		if node == "" {
+			fmt.Fprintf(&b, "  %s\n", d.explain())
+		}
+	}
+	return b.String()
+}
+
+// waitRenderNode waits for a render node to show up in /dev/dri.
+func waitRenderNode() string {
+	deadline := time.Now().Add(renderNodeWait)
+	for {
+		if node := findRenderDevice(); node != "" || time.Now().After(deadline) {
+			return node
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// setUpGPUDriver installs the driver of d and its firmware, loads it now
+// and at boot, and waits for the render node.
+func setUpGPUDriver(d gpuDriver) (string, error) {
+	var logs []string
+	for _, pkg := range append([]string{d.pkg}, d.pending()...) {
+		line, err := installPackage(pkg)
+		logs = append(logs, line)
+		if err != nil {
+			return strings.Join(logs, "\n"), err
+		}
+	}
+	if d.module == "nvidia-drm" {
+		// Without modesetting the NVIDIA driver creates no DRM devices.
+		for _, args := range [][]string{
+			{"sudo", "sysrc", "-f", loaderConfPath, "hw.nvidiadrm.modeset=1"},
+			{"sudo", "kenv", "hw.nvidiadrm.modeset=1"},
+		} {
+			if out, err := commandCombinedOutput(exec.Command(args[0], args[1:]...)); err != nil {
+				logs = append(logs, Tf("Warning: %s: %s", strings.Join(args[1:], " "), strings.TrimSpace(string(out))))
+			}
+		}
+	}
+	line, err := loadModule(d.module)
+	if err != nil {
+		logs = append(logs, Tf("Failed to load %s: %v", d.module, err))
+		return strings.Join(logs, "\n"), err
+	}
+	logs = append(logs, line)
+	if !kldList()[d.module] {
+		line, err := persistModule(d.module)
+		if err != nil {
+			return strings.Join(logs, "\n"), err
+		}
+		logs = append(logs, line)
+	}
+	node := waitRenderNode()
+	if node == "" {
+		err := errors.New(Tf("%s is loaded, but no render node showed up in /dev/dri", d.module))
+		logs = append(logs, err.Error())
+		return strings.Join(logs, "\n"), err
+	}
+	logs = append(logs, Tf("Found DRM render device: %s", node))
+	return strings.Join(logs, "\n"), nil
+}
+
+// gpuDriverReport scans the GPUs and shows them, after what the last fix
+// printed.
+func gpuDriverReport(done string) tea.Msg {
+	drivers, err := scanGPUDrivers()
+	if err != nil {
+		return statusMsg{status: Tf("Failed to detect the GPU: %v", err), err: preflightFailure(err)}
+	}
+	node := findRenderDevice()
+	body := describeGPUDrivers(node, drivers)
+	if done != "" {
+		body = done + "\n\n" + body
+	}
+	r := &report{title: "GPU Driver", body: body, refresh: gpuDriverView()}
+	for _, d := range drivers {
+		if node == "" && d.needsFix() {
+			r.actions = append(r.actions, reportAction{label: d.label(), cmd: fixGPUDriver(d)})
+		}
+	}
+	return reportMsg{r}
+}
+
+// gpuDriverView shows the graphics hardware and, when there is no render
+// node, what each GPU is missing, with a fix for those NiriSetup knows a
+// driver for.
+func gpuDriverView() tea.Cmd {
+	return func() tea.Msg { return gpuDriverReport("") }
+}
+
+// fixGPUDriver sets up the driver of d and scans again, so the screen
+// shows whether the render node came up.
+func fixGPUDriver(d gpuDriver) tea.Cmd {
+	return func() tea.Msg {
+		status, err := setUpGPUDriver(d)
+		if err != nil {
+			return statusMsg{status: status, err: err}
+		}
+		return gpuDriverReport(status)
+	}
+}
+
+// noRenderNodeIssue reports a missing render node that GPU Driver can fix.
+func noRenderNodeIssue() (doctorIssue, bool) {
+	if findRenderDevice() != "" {
+		return doctorIssue{}, false
+	}
+	drivers, err := scanGPUDrivers()
+	if err != nil {
+		return doctorIssue{}, false
+	}
+	var fixable []gpuDriver
+	var explained []string
+	for _, d := range drivers {
+		if d.needsFix() {
+			fixable = append(fixable, d)
+			explained = append(explained, d.explain())
+		}
+	}
+	if len(fixable) == 0 {
+		return doctorIssue{}, false
+	}
+	return doctorIssue{
+		what:   T("There is no DRM render node in /dev/dri, so niri can't start"),
+		remedy: strings.Join(explained, " "),
+		label:  "Install and load the GPU driver",
+		fix: func() (string, error) {
+			var logs []string
+			for _, d := range fixable {
+				status, err := setUpGPUDriver(d)
+				logs = append(logs, status)
+				if err != nil {
+					return strings.Join(logs, "\n"), err
+				}
+			}
+			return strings.Join(logs, "\n"), nil
+		},
+	}, true
+}
+
+// setupGPUDriver is nirisetup gpu-driver: it sets up the driver of every
+// GPU that needs it when there is no render node.
+func setupGPUDriver() tea.Cmd {
+	return func() tea.Msg {
+		drivers, err := scanGPUDrivers()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to detect the GPU: %v", err), err: preflightFailure(err)}
+		}
+		node := findRenderDevice()
+		logs := []string{strings.TrimRight(describeGPUDrivers(node, drivers), "\n")}
+		if node != "" {
+			return statusMsg{status: strings.Join(logs, "\n")}
+		}
+		var failed error
+		for _, d := range drivers {
+			if !d.needsFix() {
+				continue
+			}
+			status, err := setUpGPUDriver(d)
+			logs = append(logs, status)
+			if err != nil {
+				failed = err
+				break
+			}
+		}
+		if failed == nil && findRenderDevice() == "" {
+			failed = errors.New(T("no render device"))
+		}
+		return statusMsg{status: strings.Join(logs, "\n"), err: failed}
+	}
+}
+
+// renderNodeHint points at GPU Driver when the render node is missing.
+func renderNodeHint() string {
+	if _, err := os.Stat("/dev/dri"); err != nil {
+		return T("  /dev/dri doesn't exist: no GPU driver is loaded. System › GPU Driver shows your GPU and installs and loads its driver.")
+	}
+	return T("  System › GPU Driver shows your GPU and installs and loads its driver.")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// softwareRenderers are the renderer names of Mesa's CPU drivers. Seeing
+// one of them means the GPU isn't used.
+var softwareRenderers = []string{"llvmpipe", "softpipe", "swrast", "lavapipe"}
+
+var (
+	eglPlatform     = regexp.MustCompile(`^(\w+) platform:`)
+	eglRenderer     = regexp.MustCompile(`OpenGL (?:core profile |ES profile )?renderer string: (.+)`)
+	vulkanDevice    = regexp.MustCompile(`deviceName\s*=\s*(.+)`)
+	vulkanType      = regexp.MustCompile(`deviceType\s*=\s*(\S+)`)
+	graphicsTimeout = 20 * time.Second
+)
+
+// isSoftwareRenderer reports whether a renderer name is a CPU driver.
+func isSoftwareRenderer(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range softwareRenderers {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// eglRenderers returns the first OpenGL renderer eglinfo reports for each
+// EGL platform, e.g. "wayland" or "gbm".
+func eglRenderers(out string) map[string]string {
+	renderers := map[string]string{}
+	platform := ""
+	for _, line := range strings.Split(out, "\n") {
+		if m := eglPlatform.FindStringSubmatch(line); m != nil {
+			platform = strings.ToLower(m[1])
+			continue
+		}
+		if m := eglRenderer.FindStringSubmatch(line); m != nil && platform != "" && renderers[platform] == "" {
+			renderers[platform] = strings.TrimSpace(m[1])
+		}
+	}
+	return renderers
+}
+
+// timedCommand runs a command, stopping it after graphicsTimeout so that a
+// hanging driver doesn't hang the test.
+func timedCommand(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), graphicsTimeout)
+	defer cancel()
+	out, err := commandCombinedOutput(exec.CommandContext(ctx, name, args...))
+	if ctx.Err() == context.DeadlineExceeded {
+		return out, fmt.Errorf("%s didn't finish within %s", name, graphicsTimeout)
+	}
+	return out, err
+}
+
+// testGraphics runs the EGL and Vulkan smoke tests. Inside niri it tests
+// the Wayland platform the session's programs use; elsewhere it goes
+// straight to the render node through GBM.
+func testGraphics() (string, bool) {
+	var b strings.Builder
+	ok := true
+	inside := insideNiri()
+	platform := "gbm"
+	if inside {
+		platform = "wayland"
+	}
+	tested := false
+	if dev := findRenderDevice(); dev != "" {
+		fmt.Fprintf(&b, "%-22s %s\n", T("Render node:"), dev)
+	} else {
+		ok = false
+		fmt.Fprintf(&b, "%-22s %s\n", T("Render node:"), T("none, /dev/dri/renderD* is missing"))
+	}
+
+	if !hasCommand("eglinfo") {
+		fmt.Fprintf(&b, "%-22s %s\n", "EGL:", T("eglinfo not found (install mesa-demos)"))
+	} else if out, err := timedCommand("eglinfo", "-B"); err != nil && len(out) == 0 {
+		ok = false
+		fmt.Fprintf(&b, "%-22s %s\n", "EGL:", Tf("eglinfo failed: %v", err))
+	} else {
+		tested = true
+		renderers := eglRenderers(string(out))
+		renderer := renderers[platform]
+		switch {
+		case renderer == "":
+			ok = false
+			fmt.Fprintf(&b, "%-22s %s\n", "EGL ("+platform+"):", T("no renderer: EGL couldn't initialize"))
+		case isSoftwareRenderer(renderer):
+			ok = false
+			fmt.Fprintf(&b, "%-22s %s\n", "EGL ("+platform+"):", Tf("%s — software rendering, the GPU isn't used", renderer))
+		default:
+			fmt.Fprintf(&b, "%-22s %s\n", "EGL ("+platform+"):", Tf("%s — hardware acceleration: OK", renderer))
+		}
+	}
+
+	if !hasCommand("vulkaninfo") {
+		fmt.Fprintf(&b, "%-22s %s\n", "Vulkan:", T("vulkaninfo not found (install vulkan-tools)"))
+	} else if out, err := timedCommand("vulkaninfo", "--summary"); err != nil {
+		fmt.Fprintf(&b, "%-22s %s\n", "Vulkan:", Tf("no Vulkan driver: %v", err))
+	} else {
+		tested = true
+		devices := vulkanDevice.FindAllStringSubmatch(string(out), -1)
+		types := vulkanType.FindAllStringSubmatch(string(out), -1)
+		for i, d := range devices {
+			name := strings.TrimSpace(d[1])
+			if (i < len(types) && strings.Contains(types[i][1], "CPU")) || isSoftwareRenderer(name) {
+				fmt.Fprintf(&b, "%-22s %s\n", "Vulkan:", Tf("%s — software rendering", name))
+			} else {
+				fmt.Fprintf(&b, "%-22s %s\n", "Vulkan:", Tf("%s — hardware acceleration: OK", name))
+			}
+		}
+		if len(devices) == 0 {
+			fmt.Fprintf(&b, "%-22s %s\n", "Vulkan:", T("no devices"))
+		}
+	}
+
+	if inside && hasCommand("vkcube") {
+		if out, err := timedCommand("vkcube", "--wayland", "--c", "120"); err != nil {
+			ok = false
+			fmt.Fprintf(&b, "%-22s %s\n", "vkcube:", Tf("failed: %s", lastLine(string(out), err)))
+		} else {
+			fmt.Fprintf(&b, "%-22s %s\n", "vkcube:", T("drew 120 frames on Wayland: OK"))
+		}
+	}
+
+	fmt.Fprintln(&b)
+	switch {
+	case !tested:
+		ok = false
+		fmt.Fprint(&b, T("Install mesa-demos or vulkan-tools to run the test."))
+	case ok:
+		fmt.Fprint(&b, T("The GPU renders for niri."))
+	default:
+		fmt.Fprint(&b, T("Programs fall back to software rendering or can't draw at all. Run Doctor and Kernel Modules to find out why."))
+	}
+	return b.String(), ok
+}
+
+// lastLine returns the last line of out, or err if there is no output.
+func lastLine(out string, err error) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		return last
+	}
+	return err.Error()
+}
+
+// runTestGraphics prints the graphics test for the command line.
+func runTestGraphics() (string, error) {
+	text, ok := testGraphics()
+	if !ok {
+		return text, errors.New("no hardware acceleration")
+	}
+	return text, nil
+}
+
+// graphicsTestPackages provide eglinfo, vulkaninfo and vkcube.
+var graphicsTestPackages = []string{"mesa-demos", "vulkan-tools"}
+
+// installGraphicsTools installs the test programs and runs the test again.
+func installGraphicsTools() tea.Msg {
+	var logs []string
+	for _, pkg := range graphicsTestPackages {
+		line, err := installPackage(pkg)
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+	}
+	return testGraphicsCmd()()
+}
+
+// testGraphicsCmd shows the graphics test.
+func testGraphicsCmd() tea.Cmd {
+	return func() tea.Msg {
+		text, _ := testGraphics()
+		r := &report{title: "Test Graphics", body: text, refresh: testGraphicsCmd()}
+		if !hasCommand("eglinfo") || !hasCommand("vulkaninfo") {
+			r.actions = []reportAction{{label: "Install mesa-demos and vulkan-tools", cmd: installGraphicsTools}}
+		}
+		return reportMsg{r}
+	}
+}
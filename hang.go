@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultCommandTimeout is how long a command may run before NiriSetup
+// asks whether to keep waiting for it. A pkg install behind a stuck lock
+// or a stalled download would otherwise keep the interface waiting
+// forever.
+const defaultCommandTimeout = 5 * time.Minute
+
+// commandTimeout is defaultCommandTimeout, or the command_timeout setting
+// in seconds; 0 turns the question off.
+var commandTimeout = sync.OnceValue(func() time.Duration {
+	if v, ok := loadSettings()["command_timeout"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultCommandTimeout
+})
+
+// hangChoice is what to do about a command that is taking too long.
+type hangChoice int
+
+const (
+	hangWait hangChoice = iota
+	hangRetry
+	hangKill
+)
+
+// hangPrompt asks about one command that is taking too long. The answer
+// goes to answer; finished is closed if the command ends first, which
+// drops the question.
+type hangPrompt struct {
+	command  string
+	elapsed  time.Duration
+	canRetry bool
+	answer   chan hangChoice
+	finished chan struct{}
+	prev     appState
+}
+
+// hangMsg shows a hang prompt in the full-screen interface, and
+// hangDoneMsg takes it down again when the command ends on its own.
+type hangMsg struct {
+	prompt *hangPrompt
+}
+
+type hangDoneMsg struct {
+	prompt *hangPrompt
+}
+
+// superviseCommand starts cmd and waits for it. Every commandTimeout it
+// asks whether to keep waiting, retry or kill it. reset clears the output
+// collected so far before a retry. Commands that read the terminal, such
+// as ssh -t or an editor, are never asked about: the question would read
+// keys meant for them.
+func superviseCommand(cmd *exec.Cmd, reset func()) (*exec.Cmd, error) {
+	for {
+		if err := cmd.Start(); err != nil {
+			return cmd, err
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+
+		timeout := commandTimeout()
+		if cmd.Stdin == os.Stdin {
+			timeout = 0
+		}
+		started := time.Now()
+		choice := hangWait
+		for choice == hangWait {
+			if timeout == 0 {
+				return cmd, <-done
+			}
+			timer := time.NewTimer(timeout)
+			select {
+			case err := <-done:
+				timer.Stop()
+				return cmd, err
+			case <-timer.C:
+			}
+			h := &hangPrompt{
+				command:  strings.Join(cmd.Args, " "),
+				elapsed:  time.Since(started).Round(time.Second),
+				canRetry: cmd.Stdin == nil,
+				answer:   make(chan hangChoice, 1),
+				finished: make(chan struct{}),
+			}
+			tracef("  %s still running after %s", cmd.Args[0], h.elapsed)
+			askHang(h)
+			select {
+			case err := <-done:
+				close(h.finished)
+				if program != nil {
+					go program.Send(hangDoneMsg{h})
+				}
+				return cmd, err
+			case choice = <-h.answer:
+			}
+		}
+
+		stopCommand(cmd, done)
+		if choice == hangKill {
+			return cmd, fmt.Errorf("%s was stopped after it stopped responding", cmd.Args[0])
+		}
+		tracef("  retrying %s", cmd.Args[0])
+		retry := exec.Command(cmd.Path, cmd.Args[1:]...)
+		retry.Env, retry.Dir = cmd.Env, cmd.Dir
+		retry.Stdout, retry.Stderr = cmd.Stdout, cmd.Stderr
+		retry.SysProcAttr = cmd.SysProcAttr
+		if reset != nil {
+			reset()
+		}
+		cmd = retry
+	}
+}
+
+// stopCommand asks cmd to terminate and kills it if it hasn't after a few
+// seconds. sudo passes SIGTERM on to the command it runs, but can't pass
+// on SIGKILL.
+func stopCommand(cmd *exec.Cmd, done chan error) {
+	cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		<-done
+	}
+}
+
+// askHang puts h to the user: on the full-screen interface if it runs,
+// otherwise on the terminal. Without a terminal to ask on, NiriSetup
+// keeps waiting and says so on stderr.
+func askHang(h *hangPrompt) {
+	if program != nil {
+		go program.Send(hangMsg{h})
+		return
+	}
+	fmt.Fprintln(os.Stderr, Tf("%s has been running for %s.", h.command, h.elapsed))
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		h.answer <- hangWait
+		return
+	}
+	prompt := T("w keep waiting • k kill")
+	if h.canRetry {
+		prompt = T("w keep waiting • r retry • k kill")
+	}
+	fmt.Fprint(os.Stderr, prompt+": ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.TrimSpace(line) {
+	case "k", "K":
+		h.answer <- hangKill
+	case "r", "R":
+		if h.canRetry {
+			h.answer <- hangRetry
+			return
+		}
+		h.answer <- hangWait
+	default:
+		h.answer <- hangWait
+	}
+}
+
+func (m model) showHang(h *hangPrompt) (tea.Model, tea.Cmd) {
+	select {
+	case <-h.finished:
+		return m, nil
+	default:
+	}
+	if m.hang != nil {
+		// One question at a time; ask about this one again later.
+		h.answer <- hangWait
+		return m, nil
+	}
+	h.prev = m.state
+	m.hang = h
+	m.state = hangView
+	return m, nil
+}
+
+func (m model) updateHang(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	h := m.hang
+	choice := hangWait
+	switch msg.String() {
+	case "ctrl+c":
+		h.answer <- hangKill
+		return m, tea.Quit
+	case "w", "W", "esc":
+	case "r", "R":
+		if !h.canRetry {
+			return m, nil
+		}
+		choice = hangRetry
+	case "k", "K":
+		choice = hangKill
+	default:
+		return m, nil
+	}
+	h.answer <- choice
+	m.state = h.prev
+	m.hang = nil
+	return m, nil
+}
+
+func (m model) renderHangView() string {
+	h := m.hang
+	help := "w keep waiting • k kill"
+	if h.canRetry {
+		help = "w keep waiting • r retry • k kill"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(T("A command isn't finishing")),
+		warningStyle.Render(Tf("%s has been running for %s.", h.command, h.elapsed)+"\n\n"+
+			T("It may be waiting on a lock held by another pkg, or on a stalled download. Keep waiting, or stop it and retry or give up.")),
+		formHelpStyle.Render(T(help)),
+	)
+}
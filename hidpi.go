@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Pixel densities that look right at scale 1. Laptop panels are viewed from
+// closer up than desktop monitors, so they can go denser before scaling.
+const (
+	targetDPIInternal = 130.0
+	targetDPIExternal = 110.0
+)
+
+// outputDPI computes the horizontal pixel density of o from its current
+// mode and EDID physical size. It returns 0 if either is unknown.
+func outputDPI(o niriOutput) float64 {
+	mode, ok := o.currentMode()
+	if !ok || o.PhysicalSize == nil || o.PhysicalSize[0] <= 0 {
+		return 0
+	}
+	return float64(mode.Width) / (float64(o.PhysicalSize[0]) / 25.4)
+}
+
+// suggestScale rounds the density ratio to the nearest quarter step, never
+// going below 1.
+func suggestScale(o niriOutput) float64 {
+	dpi := outputDPI(o)
+	if dpi == 0 {
+		return 1
+	}
+	target := targetDPIExternal
+	if o.isInternal() {
+		target = targetDPIInternal
+	}
+	return math.Max(1, math.Round(dpi/target*4)/4)
+}
+
+// loadScalingForm detects the connected outputs and opens a form prefilled
+// with suggested scale factors.
+func loadScalingForm() tea.Cmd {
+	return func() tea.Msg {
+		outputs, err := niriOutputs()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to query outputs, run this from inside a niri session: %v", err), err: err}
+		}
+
+		var names []string
+		var fields []formField
+		for _, o := range outputs {
+			label := o.Name
+			if dpi := outputDPI(o); dpi > 0 {
+				label = fmt.Sprintf("%s (%.0f dpi)", o.Name, dpi)
+			}
+			names = append(names, o.Name)
+			fields = append(fields, formField{label: label, value: strconv.FormatFloat(suggestScale(o), 'f', -1, 64)})
+		}
+		if len(names) == 0 {
+			return statusMsg{status: T("No outputs detected"), err: fmt.Errorf("no outputs")}
+		}
+		fields = append(fields,
+			formField{label: "Cursor size", value: "24"},
+			formField{label: "Toolkit env vars", value: "Yes", options: []string{"Yes", "No"}},
+		)
+
+		return formMsg{&form{
+			title:  "HiDPI Scaling",
+			fields: fields,
+			submit: func(values []string) (tea.Cmd, error) {
+				scales := make([]float64, len(names))
+				for i, name := range names {
+					s, err := strconv.ParseFloat(strings.TrimSpace(values[i]), 64)
+					if err != nil || s < 0.5 || s > 4 {
+						return nil, errors.New(Tf("scale for %s must be between 0.5 and 4", name))
+					}
+					scales[i] = s
+				}
+				cursor, err := strconv.Atoi(strings.TrimSpace(values[len(names)]))
+				if err != nil || cursor < 8 || cursor > 128 {
+					return nil, errors.New(T("cursor size must be between 8 and 128"))
+				}
+				return applyScaling(names, scales, cursor, values[len(names)+1] == "Yes"), nil
+			},
+		}}
+	}
+}
+
+// applyScaling writes per-output scale factors, the cursor size and, if
+// requested, the toolkit scaling variables for Qt and X11 GTK apps.
+func applyScaling(names []string, scales []float64, cursor int, env bool) tea.Cmd {
+	return func() tea.Msg {
+		// GDK_SCALE only takes integers, so it is set only when every output
+		// rounds up to the same factor of 2 or more.
+		gdkScale := 0
+		for i, s := range scales {
+			c := int(math.Ceil(s))
+			if i == 0 {
+				gdkScale = c
+			} else if c != gdkScale {
+				gdkScale = 0
+			}
+		}
+
+		var logs []string
+		path, err := updateNiriConfig(func(cfg string) string {
+			for i, name := range names {
+				scale := strconv.FormatFloat(scales[i], 'f', -1, 64)
+				cfg = setKDLNode(cfg, []string{"output " + name}, "scale "+scale)
+				logs = append(logs, Tf("%s: scale %s", name, scale))
+			}
+			cfg = setKDLNode(cfg, []string{"cursor"}, fmt.Sprintf("xcursor-size %d", cursor))
+			logs = append(logs, Tf("Cursor size: %d", cursor))
+			if env {
+				cfg = setKDLNode(cfg, []string{"environment"}, `QT_AUTO_SCREEN_SCALE_FACTOR "1"`)
+				cfg = setKDLNode(cfg, []string{"environment"}, `QT_ENABLE_HIGHDPI_SCALING "1"`)
+				if gdkScale >= 2 {
+					cfg = setKDLNode(cfg, []string{"environment"}, fmt.Sprintf("GDK_SCALE %q", strconv.Itoa(gdkScale)))
+					logs = append(logs, Tf("GDK_SCALE=%d for X11 GTK apps", gdkScale))
+				} else {
+					cfg = removeKDLNode(cfg, []string{"environment"}, "GDK_SCALE")
+				}
+				logs = append(logs, T("Enabled Qt high-DPI scaling"))
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write scaling settings: %v", err), err: err}
+		}
+		logs = append(logs, Tf("Updated %s", path))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
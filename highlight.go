@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Colors of the parts of a KDL document in the config preview.
+var (
+	kdlCommentStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	kdlNodeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#00afff")).Bold(true)
+	kdlPropertyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#af87ff"))
+	kdlStringStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#87d787"))
+	kdlValueStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00"))
+)
+
+// kdlStyled renders text with style one line at a time, so that a
+// multi-line comment or string keeps its color when the lines are shown
+// separately.
+func kdlStyled(style lipgloss.Style, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = style.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// kdlValue reports whether word is a number or a keyword value.
+func kdlValue(word string) bool {
+	switch strings.TrimPrefix(word, "#") {
+	case "true", "false", "null", "inf", "-inf", "nan":
+		return true
+	}
+	return word != "" && (word[0] >= '0' && word[0] <= '9' || (word[0] == '-' || word[0] == '+' || word[0] == '.') && len(word) > 1 && word[1] >= '0' && word[1] <= '9')
+}
+
+// highlightKDL colors the comments, node names, properties, strings and
+// values of cfg for the terminal. Nodes commented out with /- are shown
+// as comments.
+func highlightKDL(cfg string) string {
+	var b strings.Builder
+	nodeStart := true
+	for i := 0; i < len(cfg); {
+		if j := kdlSkip(cfg, i); j != i {
+			style := kdlStringStyle
+			if cfg[i] == '/' {
+				style = kdlCommentStyle
+			}
+			b.WriteString(kdlStyled(style, cfg[i:j]))
+			i = j
+			continue
+		}
+		c := cfg[i]
+		if !isKDLIdentChar(c) {
+			switch c {
+			case '\n', ';', '{', '}':
+				nodeStart = true
+			}
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		j := i
+		for j < len(cfg) && isKDLIdentChar(cfg[j]) {
+			j++
+		}
+		word := cfg[i:j]
+		switch {
+		case strings.HasPrefix(word, "/-") && nodeStart:
+			// Commented out up to the end of the node.
+			if spans := kdlChildren(cfg, i, len(cfg)); len(spans) > 0 {
+				j = spans[0].end
+			}
+			b.WriteString(kdlStyled(kdlCommentStyle, cfg[i:j]))
+		case strings.HasPrefix(word, "/-"):
+			b.WriteString(kdlCommentStyle.Render(word))
+		case nodeStart:
+			b.WriteString(kdlNodeStyle.Render(word))
+		case j < len(cfg) && cfg[j] == '=':
+			b.WriteString(kdlPropertyStyle.Render(word))
+		case kdlValue(word):
+			b.WriteString(kdlValueStyle.Render(word))
+		default:
+			b.WriteString(word)
+		}
+		nodeStart = false
+		i = j
+	}
+	return b.String()
+}
+
+// previewConfig shows the config Configure Niri is about to write, and
+// writes it when confirmed. Inside a niri session writing it still asks
+// first, since the running session reloads it at once.
+func previewConfig(inSession bool) tea.Cmd {
+	return func() tea.Msg {
+		cfg, _, err := niriConfigTemplate()
+		if err != nil {
+			return statusMsg{status: err.Error(), err: preflightFailure(err)}
+		}
+		write := configureNiri()
+		if inSession {
+			write = func() tea.Msg {
+				return confirmMsg{&confirmation{
+					prompt:    "niri is running. Configure Niri replaces the live config.kdl with the template, and the running session will reload it immediately.\n\nReplace the live config?",
+					actionMsg: "Configuring Niri...",
+					yes:       configureNiri(),
+				}}
+			}
+		}
+		r := &report{title: "Configure Niri", body: highlightKDL(cfg)}
+		r.actions = []reportAction{{label: "Write this config", cmd: write}}
+		if _, _, err := readNiriConfig(); err == nil {
+			// Rather than replace the config, merge parts of the template into it.
+			r.actions = append(r.actions, reportAction{
+				label: T("Apply only some sections to my config"),
+				cmd:   func() tea.Msg { return formMsg{newConfigSectionsForm(cfg, inSession)} },
+			})
+		}
+		return reportMsg{r}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyLimit is how many actions the history keeps; older ones are
+// dropped as new ones are recorded.
+const historyLimit = 500
+
+// historyMu serializes writes to the history file.
+var historyMu sync.Mutex
+
+// Where a historyEntry was run from.
+const (
+	historyMenu = "menu"
+	historyCLI  = "cli"
+)
+
+// historyEntry is one completed action: a menu entry or a subcommand, when
+// it finished, its exit status and the first line of what it reported.
+type historyEntry struct {
+	when    time.Time
+	source  string
+	name    string
+	result  string
+	summary string
+}
+
+func historyPath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "nirisetup", "history"), nil
+}
+
+// loadHistory reads the recorded actions, oldest first. A missing file
+// means there are none.
+func loadHistory() ([]historyEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 5 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		when, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			continue
+		}
+		summary, err := strconv.Unquote(fields[4])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, historyEntry{when, fields[1], fields[2], fields[3], summary})
+	}
+	return entries, scanner.Err()
+}
+
+// recordHistory adds a finished action to the history. Like the managed
+// state, failing to keep it doesn't fail the action, so it is only traced.
+func recordHistory(source, name, status string, err error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	path, perr := historyPath()
+	if perr != nil {
+		return
+	}
+	entries, lerr := loadHistory()
+	if lerr != nil {
+		tracef("reading %s failed: %v", path, lerr)
+		return
+	}
+	summary, _, _ := strings.Cut(strings.TrimSpace(status), "\n")
+	entries = append(entries, historyEntry{time.Now(), source, name, exitStatus[exitCode(err)], summary})
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+
+	var b strings.Builder
+	b.WriteString("# Actions run through NiriSetup, see History.\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\n", e.when.Format(time.RFC3339), e.source, e.name, e.result, strconv.Quote(e.summary))
+	}
+	werr := os.MkdirAll(filepath.Dir(path), 0755)
+	if werr == nil {
+		werr = os.WriteFile(path, []byte(b.String()), 0644)
+	}
+	if werr != nil {
+		tracef("write %s failed: %v", path, werr)
+		return
+	}
+	chownToUser(path)
+}
+
+// label names what e ran: the menu entry, or the subcommand.
+func (e historyEntry) label() string {
+	if e.source == historyCLI {
+		return "nirisetup " + e.name
+	}
+	return T(e.name)
+}
+
+// historyReport lists the recorded actions, newest first.
+func historyReport() (string, []historyEntry, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(entries) == 0 {
+		return T("No actions have been recorded yet."), nil, nil
+	}
+	var lines []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		mark := "✓"
+		if e.result != exitStatus[exitOK] {
+			mark = "✗"
+		}
+		line := fmt.Sprintf("%s %s %s", e.when.Format("2006-01-02 15:04"), mark, e.label())
+		if e.result != exitStatus[exitOK] {
+			line += " (" + e.result + ")"
+		}
+		if e.summary != "" {
+			line += ": " + e.summary
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), entries, nil
+}
+
+// rerunMsg starts a menu entry again from the History screen.
+type rerunMsg struct {
+	choice string
+}
+
+// historyView shows the history on a report screen, offering to run the
+// most recent menu entries again.
+func historyView() tea.Cmd {
+	return func() tea.Msg {
+		body, entries, err := historyReport()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to read the history: %v", err), err: err}
+		}
+		r := &report{title: "History", body: body, refresh: historyView()}
+		seen := map[string]bool{}
+		for i := len(entries) - 1; i >= 0 && len(r.actions) < 9; i-- {
+			e := entries[i]
+			if e.source != historyMenu || seen[e.name] || menuGroupOf(e.name) == "" {
+				continue
+			}
+			seen[e.name] = true
+			choice := e.name
+			r.actions = append(r.actions, reportAction{
+				label: Tf("Run %s again", T(choice)),
+				cmd:   func() tea.Msg { return rerunMsg{choice} },
+			})
+		}
+		return reportMsg{r}
+	}
+}
+
+// rerun starts choice as if it had been picked in its submenu.
+func (m model) rerun(choice string) (tea.Model, tea.Cmd) {
+	group := menuGroupOf(choice)
+	if !containsString(menuChoices(group, m.inSession), choice) {
+		m.selected = choice
+		m.isProcessing = false
+		return m, func() tea.Msg {
+			err := errors.New(Tf("%s needs a running niri session", T(choice)))
+			return statusMsg{status: err.Error(), err: preflightFailure(err)}
+		}
+	}
+	updated, _ := m.openMenu(group)
+	m = updated.(model)
+	m.selected = choice
+	m.isProcessing = true
+	return m.runChoice()
+}
+
+// runHistory is nirisetup history.
+func runHistory() (string, error) {
+	body, _, err := historyReport()
+	if err != nil {
+		return Tf("Failed to read the history: %v", err), preflightFailure(err)
+	}
+	return body, nil
+}
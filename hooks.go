@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hooksDir holds the site-specific scripts NiriSetup runs around its
+// steps, named after the hook: pre-install, post-install and
+// post-configure.
+func hooksDir() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "nirisetup", "hooks"), nil
+}
+
+// hookEnvironment describes the run to a hook: which hook it is, for
+// whom, and where niri's config lives. status is "ok" or "failed" for the
+// post- hooks and empty for the pre- hooks.
+func hookEnvironment(name, status string) []string {
+	env := append(os.Environ(),
+		"NIRISETUP_HOOK="+name,
+		"NIRISETUP_USER="+userName(),
+		"NIRISETUP_PACKAGES="+strings.Join(niriPackages, " "),
+	)
+	if homeDir, err := userHomeDir(); err == nil {
+		env = append(env, "NIRISETUP_HOME="+homeDir)
+	}
+	if path, err := niriConfigPath(); err == nil {
+		env = append(env, "NIRISETUP_CONFIG="+path)
+	}
+	if status != "" {
+		env = append(env, "NIRISETUP_STATUS="+status)
+	}
+	return env
+}
+
+// runHook runs the hook called name if there is an executable one. It
+// returns the lines to log, which are empty when there is no hook.
+func runHook(name, status string) ([]string, error) {
+	dir, err := hooksDir()
+	if err != nil {
+		return nil, nil
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return nil, nil
+	}
+	if info.Mode()&0111 == 0 {
+		return []string{Tf("Warning: Hook %s is not executable, skipping it", path)}, nil
+	}
+	cmd := exec.Command(path)
+	cmd.Env = hookEnvironment(name, status)
+	cmd.Dir = dir
+	out, err := commandCombinedOutput(cmd)
+	var lines []string
+	if text := strings.TrimSpace(string(out)); text != "" {
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+	if err != nil {
+		return append([]string{Tf("Hook %s failed: %v", name, err)}, lines...), err
+	}
+	return append([]string{Tf("Ran hook %s: OK", name)}, lines...), nil
+}
+
+// withHooks runs the pre hook, action and the post hook; either name may
+// be empty. A failing pre hook stops the action from running; a failing
+// post hook fails the action.
+func withHooks(pre, post string, action tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		var before []string
+		if pre != "" {
+			lines, err := runHook(pre, "")
+			if err != nil {
+				lines = append(lines, Tf("Stopped before %s because the hook failed", strings.TrimPrefix(pre, "pre-")))
+				return statusMsg{status: strings.Join(lines, "\n"), err: preflightFailure(err)}
+			}
+			before = lines
+		}
+		msg := action()
+		status, ok := msg.(statusMsg)
+		result := "ok"
+		if ok && status.err != nil {
+			result = "failed"
+		}
+		var after []string
+		if post != "" {
+			lines, err := runHook(post, result)
+			after = lines
+			if err != nil && ok && status.err == nil {
+				status.err = err
+			}
+		}
+		if !ok {
+			return msg
+		}
+		status.status = strings.Join(append(append(before, status.status), after...), "\n")
+		return status
+	}
+}
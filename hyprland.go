@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hyprModifiers maps Hyprland modifier names to niri's.
+var hyprModifiers = map[string]string{
+	"super": "Mod", "win": "Mod", "logo": "Mod", "mod4": "Mod",
+	"alt": "Alt", "mod1": "Alt", "shift": "Shift", "ctrl": "Ctrl", "control": "Ctrl",
+}
+
+// hyprKeys maps Hyprland key names that differ from XKB names.
+var hyprKeys = map[string]string{
+	"mouse_down": "WheelScrollDown", "mouse_up": "WheelScrollUp",
+	"mouse_left": "WheelScrollLeft", "mouse_right": "WheelScrollRight",
+}
+
+// hyprDirections maps the l/r/u/d arguments of Hyprland dispatchers.
+var hyprDirections = map[string]string{"l": "left", "r": "right", "u": "up", "d": "down", "left": "left", "right": "right", "up": "up", "down": "down"}
+
+// hyprDispatchers maps Hyprland dispatchers that take no arguments.
+var hyprDispatchers = map[string]string{
+	"killactive":        "close-window",
+	"exit":              "quit",
+	"togglefloating":    "toggle-window-floating",
+	"fullscreen":        "fullscreen-window",
+	"focusurgentorlast": "focus-window-previous",
+}
+
+// hyprUnsupported explains why common dispatchers have no translation.
+var hyprUnsupported = map[string]string{
+	"togglesplit":            "niri arranges windows in columns, there are no splits",
+	"pseudo":                 "niri arranges windows in columns, there are no splits",
+	"togglegroup":            "niri has tabbed columns instead of groups",
+	"changegroupactive":      "niri has tabbed columns instead of groups",
+	"pin":                    "niri has no sticky windows",
+	"togglespecialworkspace": "niri has no scratchpad",
+	"movetoworkspacesilent":  "niri always follows the window to its workspace",
+	"pass":                   "no niri equivalent",
+}
+
+// hyprTransforms maps Hyprland's numeric monitor transforms.
+var hyprTransforms = []string{"normal", "90", "180", "270", "flipped", "flipped-90", "flipped-180", "flipped-270"}
+
+// hyprSettings maps Hyprland settings to niri nodes. A node ending in =
+// takes the value as a string, one ending in a space takes it as is, and
+// anything else is a flag set when the value is true.
+var hyprSettings = map[string]struct {
+	path []string
+	node string
+}{
+	"input:kb_layout":                        {[]string{"input", "keyboard", "xkb"}, "layout="},
+	"input:kb_variant":                       {[]string{"input", "keyboard", "xkb"}, "variant="},
+	"input:kb_options":                       {[]string{"input", "keyboard", "xkb"}, "options="},
+	"input:kb_model":                         {[]string{"input", "keyboard", "xkb"}, "model="},
+	"input:repeat_rate":                      {[]string{"input", "keyboard"}, "repeat-rate "},
+	"input:repeat_delay":                     {[]string{"input", "keyboard"}, "repeat-delay "},
+	"input:sensitivity":                      {[]string{"input", "mouse"}, "accel-speed "},
+	"input:accel_profile":                    {[]string{"input", "mouse"}, "accel-profile="},
+	"input:natural_scroll":                   {[]string{"input", "mouse"}, "natural-scroll"},
+	"input:left_handed":                      {[]string{"input", "mouse"}, "left-handed"},
+	"input:touchpad:natural_scroll":          {[]string{"input", "touchpad"}, "natural-scroll"},
+	"input:touchpad:tap-to-click":            {[]string{"input", "touchpad"}, "tap"},
+	"input:touchpad:disable_while_typing":    {[]string{"input", "touchpad"}, "dwt"},
+	"input:touchpad:middle_button_emulation": {[]string{"input", "touchpad"}, "middle-emulation"},
+	"general:gaps_in":                        {[]string{"layout"}, "gaps "},
+	"general:border_size":                    {[]string{"layout", "focus-ring"}, "width "},
+	"cursor:inactive_timeout":                {[]string{"cursor"}, "hide-after-inactive-ms "},
+}
+
+var (
+	hyprAssignment = regexp.MustCompile(`^(\$?[\w.:-]+)\s*=\s*(.*)$`)
+	hyprSize       = regexp.MustCompile(`^(\d+)x(\d+)(@([\d.]+))?$`)
+)
+
+// parseHyprlandConfig translates a hyprland.conf.
+func parseHyprlandConfig(path, data string) *migration {
+	mg := &migration{from: path}
+	vars := map[string]string{}
+	var sections []string
+	for i, raw := range strings.Split(data, "\n") {
+		n := i + 1
+		line := hyprStripComment(raw)
+		switch {
+		case line == "":
+			continue
+		case line == "}":
+			if len(sections) > 0 {
+				sections = sections[:len(sections)-1]
+			}
+			continue
+		case strings.HasSuffix(line, "{"):
+			sections = append(sections, strings.TrimSpace(strings.TrimSuffix(line, "{")))
+			continue
+		}
+		m := hyprAssignment.FindStringSubmatch(line)
+		if m == nil {
+			mg.skip(n, line, "no niri equivalent")
+			continue
+		}
+		key, value := m[1], strings.TrimSpace(m[2])
+		if strings.HasPrefix(key, "$") {
+			vars[key] = expandHyprVars(value, vars)
+			continue
+		}
+		value = expandHyprVars(value, vars)
+		if len(sections) > 0 {
+			key = strings.Join(sections, ":") + ":" + key
+		}
+		translateHyprSetting(mg, n, raw, key, value)
+	}
+	return mg
+}
+
+// hyprStripComment removes a comment from line. ## is an escaped #.
+func hyprStripComment(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' {
+			if i+1 < len(line) && line[i+1] == '#' {
+				b.WriteByte('#')
+				i++
+				continue
+			}
+			break
+		}
+		b.WriteByte(line[i])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// expandHyprVars replaces $variables, longest names first.
+func expandHyprVars(s string, vars map[string]string) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		s = strings.ReplaceAll(s, name, vars[name])
+	}
+	return s
+}
+
+func hyprBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// hyprArgs splits a comma separated value, trimming each part.
+func hyprArgs(value string, n int) []string {
+	parts := strings.SplitN(value, ",", n)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func translateHyprSetting(mg *migration, n int, line, key, value string) {
+	switch {
+	case strings.HasPrefix(key, "bind"):
+		translateHyprBind(mg, n, line, strings.TrimPrefix(key, "bind"), value)
+		return
+	case key == "monitor":
+		translateHyprMonitor(mg, n, line, value)
+		return
+	case key == "exec-once" || key == "exec":
+		translateExec(mg, n, line, value)
+		return
+	case key == "windowrule" || key == "windowrulev2":
+		translateHyprWindowRule(mg, n, line, key == "windowrulev2", value)
+		return
+	case key == "env":
+		if args := hyprArgs(value, 2); len(args) == 2 {
+			mg.add(n, []string{"environment"}, args[0]+" "+kdlQuote(args[1]))
+			return
+		}
+	case key == "animations:enabled":
+		if hyprBool(value) {
+			mg.remove(n, []string{"animations"}, "off")
+		} else {
+			mg.add(n, []string{"animations"}, "off")
+		}
+		return
+	case key == "input:follow_mouse":
+		if value != "0" {
+			mg.add(n, []string{"input"}, "focus-follows-mouse")
+		}
+		return
+	case key == "source":
+		mg.skip(n, line, "included files aren't followed, import them separately")
+		return
+	}
+
+	setting, ok := hyprSettings[key]
+	if !ok {
+		mg.skip(n, line, "no niri equivalent")
+		return
+	}
+	switch node := setting.node; {
+	case strings.HasSuffix(node, "="):
+		mg.add(n, setting.path, strings.TrimSuffix(node, "=")+" "+kdlQuote(value))
+	case strings.HasSuffix(node, " "):
+		mg.add(n, setting.path, node+value)
+	case hyprBool(value):
+		mg.add(n, setting.path, node)
+	default:
+		mg.remove(n, setting.path, node)
+	}
+}
+
+// hyprKey converts the modifiers and key of a bind.
+func hyprKey(mods, key string) string {
+	var parts []string
+	for _, mod := range strings.FieldsFunc(mods, func(r rune) bool { return r == ' ' || r == '_' || r == '+' }) {
+		if m, ok := hyprModifiers[strings.ToLower(mod)]; ok {
+			parts = append(parts, m)
+		} else {
+			parts = append(parts, mod)
+		}
+	}
+	if k, ok := hyprKeys[strings.ToLower(key)]; ok {
+		key = k
+	} else if len(key) == 1 {
+		key = strings.ToUpper(key)
+	}
+	return strings.Join(append(parts, key), "+")
+}
+
+func translateHyprBind(mg *migration, n int, line, flags, value string) {
+	if strings.Contains(flags, "m") {
+		mg.skip(n, line, "niri moves and resizes windows with Mod and the mouse by itself")
+		return
+	}
+	args := hyprArgs(value, 4)
+	if len(args) < 3 {
+		mg.skip(n, line, "incomplete binding")
+		return
+	}
+	if strings.HasPrefix(args[1], "mouse:") || strings.HasPrefix(args[1], "code:") {
+		mg.skip(n, line, "key codes can't be translated, use key names")
+		return
+	}
+	param := ""
+	if len(args) == 4 {
+		param = args[3]
+	}
+	action, reason := hyprAction(strings.ToLower(args[2]), param)
+	if action == "" {
+		mg.skip(n, line, reason)
+		return
+	}
+	props := ""
+	if strings.Contains(flags, "l") && strings.HasPrefix(action, "spawn ") {
+		props = "allow-when-locked=true"
+	}
+	mg.bind(n, hyprKey(args[0], args[1]), props, action)
+}
+
+// hyprAction translates a dispatcher and its argument into a niri action,
+// or returns why it can't be.
+func hyprAction(dispatcher, param string) (string, string) {
+	if action, ok := hyprDispatchers[dispatcher]; ok {
+		if dispatcher == "fullscreen" && param == "1" {
+			return "maximize-column", ""
+		}
+		return action, ""
+	}
+	dir := hyprDirections[strings.ToLower(param)]
+	switch dispatcher {
+	case "exec":
+		return bindExec(param), ""
+	case "movefocus":
+		if dir == "left" || dir == "right" {
+			return "focus-column-" + dir, ""
+		}
+		if dir != "" {
+			return "focus-window-" + dir, ""
+		}
+	case "movewindow", "swapwindow":
+		if dir == "left" || dir == "right" {
+			return "move-column-" + dir, ""
+		}
+		if dir != "" {
+			return "move-window-" + dir, ""
+		}
+	case "focusmonitor":
+		if dir != "" {
+			return "focus-monitor-" + dir, ""
+		}
+	case "movecurrentworkspacetomonitor":
+		if dir != "" {
+			return "move-workspace-to-monitor-" + dir, ""
+		}
+	case "workspace", "movetoworkspace":
+		verb := "focus-workspace"
+		if dispatcher == "movetoworkspace" {
+			verb = "move-column-to-workspace"
+		}
+		switch param {
+		case "e+1", "r+1", "m+1", "+1":
+			return verb + "-down", ""
+		case "e-1", "r-1", "m-1", "-1":
+			return verb + "-up", ""
+		case "previous":
+			if dispatcher == "workspace" {
+				return "focus-workspace-previous", ""
+			}
+		}
+		if isDigits(param) {
+			return verb + " " + param, ""
+		}
+	case "resizeactive":
+		var x, y string
+		if _, err := fmt.Sscan(param, &x, &y); err == nil {
+			switch {
+			case y == "0" && x != "0":
+				return "set-column-width " + kdlQuote(signed(x)), ""
+			case x == "0" && y != "0":
+				return "set-window-height " + kdlQuote(signed(y)), ""
+			}
+		}
+	}
+	if reason, ok := hyprUnsupported[dispatcher]; ok {
+		return "", reason
+	}
+	return "", "no niri equivalent"
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// signed makes a resize amount relative, as niri expects.
+func signed(s string) string {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return s
+	}
+	return "+" + s
+}
+
+func translateHyprMonitor(mg *migration, n int, line, value string) {
+	args := hyprArgs(value, -1)
+	if len(args) < 2 || args[0] == "" {
+		mg.skip(n, line, "niri settings apply to one output at a time, repeat them per output")
+		return
+	}
+	name := strings.TrimPrefix(args[0], "desc:")
+	block := []string{"output " + name}
+	if args[1] == "disable" {
+		mg.add(n, block, "off")
+		return
+	}
+	if m := hyprSize.FindStringSubmatch(args[1]); m != nil {
+		mg.add(n, block, "mode "+kdlQuote(args[1]))
+	}
+	if len(args) > 2 {
+		if x, y, ok := strings.Cut(args[2], "x"); ok && isDigits(strings.TrimPrefix(x, "-")) && isDigits(strings.TrimPrefix(y, "-")) {
+			mg.add(n, block, fmt.Sprintf("position x=%s y=%s", x, y))
+		}
+	}
+	if len(args) > 3 && args[3] != "auto" {
+		mg.add(n, block, "scale "+args[3])
+	}
+	for i := 4; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case "transform":
+			var t int
+			if _, err := fmt.Sscan(args[i+1], &t); err == nil && t >= 0 && t < len(hyprTransforms) {
+				mg.add(n, block, "transform "+kdlQuote(hyprTransforms[t]))
+			}
+		case "vrr":
+			if args[i+1] != "0" {
+				mg.add(n, block, "variable-refresh-rate")
+			}
+		default:
+			mg.skip(n, args[i]+", "+args[i+1], "no niri equivalent")
+		}
+	}
+}
+
+// hyprRuleEffects maps Hyprland window rule effects to window-rule nodes.
+var hyprRuleEffects = map[string]string{
+	"float":      "open-floating true",
+	"tile":       "open-floating false",
+	"fullscreen": "open-fullscreen true",
+	"maximize":   "open-maximized true",
+	"nofocus":    "open-focused false",
+}
+
+func translateHyprWindowRule(mg *migration, n int, line string, v2 bool, value string) {
+	args := hyprArgs(value, 2)
+	if len(args) < 2 {
+		mg.skip(n, line, "incomplete window rule")
+		return
+	}
+	effect, matcher := args[0], args[1]
+	var rule string
+	if e, ok := hyprRuleEffects[effect]; ok {
+		rule = e
+	} else if op, ok := strings.CutPrefix(effect, "opacity "); ok {
+		rule = "opacity " + strings.Fields(op)[0]
+	} else {
+		mg.skip(n, line, "no niri equivalent")
+		return
+	}
+
+	var match []string
+	if !v2 {
+		// windowrule = float, ^(pavucontrol)$ matches the class.
+		match = append(match, "app-id="+kdlQuote(matcher))
+	} else {
+		for _, cond := range hyprArgs(matcher, -1) {
+			field, pattern, _ := strings.Cut(cond, ":")
+			switch field {
+			case "class", "initialClass":
+				match = append(match, "app-id="+kdlQuote(pattern))
+			case "title", "initialTitle":
+				match = append(match, "title="+kdlQuote(pattern))
+			default:
+				mg.skip(n, line, "only class and title matches can be translated")
+				return
+			}
+		}
+	}
+	mg.addOnce(n, nil, "window-rule {\n    match "+strings.Join(match, " ")+"\n    "+rule+"\n}")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// catalogs maps a language code to its translations. Messages are keyed by
+// their English text, so anything missing from a catalog shows in English.
+var catalogs = map[string]map[string]string{
+	"fr": frMessages,
+}
+
+// messages is the catalog of the selected language, nil for English.
+var messages map[string]string
+
+// T translates msg into the selected language.
+func T(msg string) string {
+	if t, ok := messages[msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Tf translates format and then formats it with args like fmt.Sprintf.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// languageCode reduces a locale such as "fr_CA.UTF-8" to "fr".
+func languageCode(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	lang, _, _ := strings.Cut(locale, "_")
+	return strings.ToLower(lang)
+}
+
+// setLanguage selects the UI language. An explicit choice (flag or setting)
+// wins over the locale environment variables.
+func setLanguage(explicit string) {
+	candidates := []string{explicit, loadSettings()["language"], os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		messages = catalogs[languageCode(c)]
+		return
+	}
+}
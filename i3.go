@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// xinitFiles are the X11 startup scripts read along with an i3 config,
+// relative to the home directory.
+var xinitFiles = []string{".xinitrc", ".xsession", ".xprofile"}
+
+// parseI3Config translates an i3 config and the X11 startup scripts next
+// to it: their autostarts, xrandr layout and keyboard settings.
+func parseI3Config(path, data string) *migration {
+	mg := parseI3Style(path, data)
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return mg
+	}
+	for _, name := range xinitFiles {
+		data, err := os.ReadFile(filepath.Join(homeDir, name))
+		if err != nil {
+			continue
+		}
+		mg.file = "~/" + name
+		parseXinit(mg, string(data))
+	}
+	mg.file = ""
+	return mg
+}
+
+// xinitSession matches the line of a startup script that starts the
+// window manager or desktop, which niri replaces.
+var xinitSession = regexp.MustCompile(`^exec\s|^(i3|dbus-launch|ck-launch-session|startxfce4|startplasma|openbox|xmonad|dwm|bspwm|awesome)\b`)
+
+// parseXinit translates the commands of an X11 startup script.
+func parseXinit(mg *migration, data string) {
+	for i, raw := range strings.Split(data, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if xinitSession.MatchString(line) {
+			continue
+		}
+		command := strings.TrimSpace(strings.TrimSuffix(line, "&"))
+		first, _, _ := strings.Cut(command, " ")
+		if strings.ContainsAny(first, "=[") || first == "if" || first == "fi" || first == "export" || first == "." || first == "source" {
+			mg.skip(i+1, line, "shell code isn't translated")
+			continue
+		}
+		translateExec(mg, i+1, line, command)
+	}
+}
+
+// translateExec handles a program started with the session. X11 tools
+// that set up the display or keyboard become niri settings, and others
+// are replaced by their Wayland counterparts.
+func translateExec(mg *migration, n int, line, command string) {
+	command = stripExecFlags(command)
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return
+	}
+	switch filepath.Base(fields[0]) {
+	case "xrandr":
+		translateXrandr(mg, n, line, fields[1:])
+		return
+	case "setxkbmap":
+		translateSetxkbmap(mg, n, line, fields[1:])
+		return
+	case "xset":
+		if len(fields) == 5 && fields[1] == "r" && fields[2] == "rate" {
+			mg.add(n, []string{"input", "keyboard"}, "repeat-delay "+fields[3])
+			mg.add(n, []string{"input", "keyboard"}, "repeat-rate "+fields[4])
+		} else {
+			mg.skip(n, line, "X11 only, not needed under niri")
+		}
+		return
+	case "unclutter":
+		mg.add(n, []string{"cursor"}, "hide-after-inactive-ms 3000")
+		return
+	}
+	argv, reason := x11Equivalent(fields)
+	switch {
+	case reason != "":
+		mg.skip(n, line, reason)
+	case argv != nil:
+		mg.addOnce(n, nil, "spawn-at-startup "+kdlArgs(argv...))
+	default:
+		mg.spawnAtStartup(n, command)
+	}
+}
+
+// x11Only are X11 programs that have no use under niri.
+var x11Only = map[string]string{
+	"picom":        "niri draws its own shadows and animations",
+	"compton":      "niri draws its own shadows and animations",
+	"xcompmgr":     "niri draws its own shadows and animations",
+	"xrdb":         "X11 only, not needed under niri",
+	"xmodmap":      "X11 only, set xkb options instead",
+	"xsetroot":     "X11 only, not needed under niri",
+	"xinput":       "X11 only, set the input block instead",
+	"nitrogen":     "set the wallpaper with swaybg instead",
+	"i3status":     "use waybar, which NiriSetup sets up, instead",
+	"clipmenud":    "X11 only, use a Wayland clipboard manager such as cliphist",
+	"autocutsel":   "X11 only, not needed under niri",
+	"xscreensaver": "use swayidle and swaylock instead",
+}
+
+// x11Replacements are X11 programs with a Wayland counterpart that
+// NiriSetup installs or that is packaged for GhostBSD.
+var x11Replacements = map[string][]string{
+	"dunst":     {"mako"},
+	"polybar":   {"waybar"},
+	"i3bar":     {"waybar"},
+	"redshift":  {"wlsunset"},
+	"rofi":      {"fuzzel"},
+	"dmenu_run": {"fuzzel"},
+	"dmenu":     {"fuzzel", "--dmenu"},
+	"i3lock":    {"swaylock"},
+	"xss-lock":  {"swayidle", "-w", "before-sleep", "swaylock"},
+}
+
+// fehModes maps feh background options to swaybg modes.
+var fehModes = map[string]string{
+	"--bg-scale": "stretch", "--bg-fill": "fill", "--bg-center": "center", "--bg-max": "fit", "--bg-tile": "tile",
+}
+
+// x11Equivalent returns the Wayland replacement for an X11 command, or
+// why it's dropped. Both are empty when the command can run as is.
+func x11Equivalent(fields []string) ([]string, string) {
+	name := filepath.Base(fields[0])
+	if reason, ok := x11Only[name]; ok {
+		return nil, reason
+	}
+	if name == "feh" {
+		for i, arg := range fields[1:] {
+			if mode, ok := fehModes[arg]; ok && i+2 < len(fields) {
+				return []string{"swaybg", "-i", expandHome(fields[i+2]), "-m", mode}, ""
+			}
+		}
+		return nil, ""
+	}
+	if name == "xbacklight" && len(fields) == 3 {
+		switch fields[1] {
+		case "-inc", "+":
+			return []string{"backlight", "incr", fields[2]}, ""
+		case "-dec", "-":
+			return []string{"backlight", "decr", fields[2]}, ""
+		}
+	}
+	if argv, ok := x11Replacements[name]; ok {
+		return argv, ""
+	}
+	return nil, ""
+}
+
+// x11Screenshot are screenshot tools whose bindings become niri's built-in
+// screenshot action.
+var x11Screenshot = map[string]bool{"scrot": true, "maim": true, "flameshot": true, "xfce4-screenshooter": true, "import": true}
+
+// bindExec translates the program run by a key binding.
+func bindExec(command string) string {
+	command = stripExecFlags(command)
+	fields := strings.Fields(command)
+	if len(fields) > 0 && x11Screenshot[filepath.Base(fields[0])] {
+		return "screenshot"
+	}
+	if len(fields) > 0 {
+		if argv, _ := x11Equivalent(fields); argv != nil {
+			return "spawn " + kdlArgs(argv...)
+		}
+	}
+	return "spawn " + kdlArgs(commandArgv(command)...)
+}
+
+// xrandrRotations maps xrandr rotations to niri transforms, which count
+// counter-clockwise.
+var xrandrRotations = map[string]string{"normal": "normal", "left": "90", "inverted": "180", "right": "270"}
+
+var x11OutputName = regexp.MustCompile(`^([A-Za-z]+(-[A-Za-z])?)-?(\d+)$`)
+
+// waylandOutputName converts an X11 output name to the name the DRM
+// connector has under Wayland: HDMI1 and HDMI-1 are HDMI-A-1, eDP1 is
+// eDP-1, and amdgpu's DisplayPort-0 is DP-1.
+func waylandOutputName(name string) string {
+	m := x11OutputName.FindStringSubmatch(name)
+	if m == nil {
+		return name
+	}
+	kind, index := m[1], m[3]
+	switch kind {
+	case "HDMI":
+		kind = "HDMI-A"
+	case "DisplayPort":
+		kind = "DP"
+		if i, err := strconv.Atoi(index); err == nil {
+			index = strconv.Itoa(i + 1)
+		}
+	}
+	return kind + "-" + index
+}
+
+func translateXrandr(mg *migration, n int, line string, args []string) {
+	var block []string
+	var mode, rate string
+	flushMode := func() {
+		if block != nil && mode != "" {
+			if rate != "" {
+				mode += "@" + rate
+			}
+			mg.add(n, block, "mode "+kdlQuote(mode))
+		}
+		mode, rate = "", ""
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch arg {
+		case "--output":
+			flushMode()
+			name := waylandOutputName(value)
+			if name != value {
+				mg.note(Tf("xrandr names outputs differently; %s was taken to be %s. Check the names with niri msg outputs.", value, name))
+			}
+			block = []string{"output " + name}
+			i++
+		case "--auto", "--primary", "--noprimary":
+			// niri picks the preferred mode itself and has no primary output.
+		case "--off":
+			if block != nil {
+				mg.add(n, block, "off")
+			}
+		case "--mode":
+			mode = value
+			i++
+		case "--rate", "--refresh":
+			rate = value
+			i++
+		case "--pos":
+			if x, y, ok := strings.Cut(value, "x"); ok && block != nil {
+				mg.add(n, block, fmt.Sprintf("position x=%s y=%s", x, y))
+			}
+			i++
+		case "--rotate":
+			if t, ok := xrandrRotations[value]; ok && block != nil {
+				mg.add(n, block, "transform "+kdlQuote(t))
+			}
+			i++
+		case "--scale":
+			// xrandr --scale 0.5x0.5 makes everything twice as big.
+			sx, sy, _ := strings.Cut(value, "x")
+			f, err := strconv.ParseFloat(sx, 64)
+			if err == nil && f > 0 && (sy == "" || sy == sx) && block != nil {
+				mg.add(n, block, "scale "+strconv.FormatFloat(1/f, 'f', -1, 64))
+			} else {
+				mg.skip(n, arg+" "+value, "unrecognized scale")
+			}
+			i++
+		case "--left-of", "--right-of", "--above", "--below", "--same-as":
+			mg.skip(n, arg+" "+value, "relative positions can't be translated, use Arrange Monitors")
+			i++
+		default:
+			mg.skip(n, line, "no niri equivalent")
+			return
+		}
+	}
+	flushMode()
+}
+
+func translateSetxkbmap(mg *migration, n int, line string, args []string) {
+	xkb := []string{"input", "keyboard", "xkb"}
+	options := []string{}
+	for i := 0; i < len(args); i++ {
+		value := ""
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch args[i] {
+		case "-layout":
+			mg.add(n, xkb, "layout "+kdlQuote(value))
+		case "-variant":
+			mg.add(n, xkb, "variant "+kdlQuote(value))
+		case "-model":
+			mg.add(n, xkb, "model "+kdlQuote(value))
+		case "-option":
+			if value != "" {
+				options = append(options, value)
+			}
+		default:
+			if i == 0 && !strings.HasPrefix(args[i], "-") {
+				// setxkbmap de
+				mg.add(n, xkb, "layout "+kdlQuote(args[i]))
+				continue
+			}
+			mg.skip(n, line, "no niri equivalent")
+			return
+		}
+		i++
+	}
+	if len(options) > 0 {
+		mg.add(n, xkb, "options "+kdlQuote(strings.Join(options, ",")))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fcitxEngine is a language engine for fcitx5: its package and the name of
+// the input method it adds.
+type fcitxEngine struct {
+	label string
+	pkg   string
+	im    string
+}
+
+var fcitxEngines = []fcitxEngine{
+	{"Chinese (Pinyin)", "zh-fcitx5-chinese-addons", "pinyin"},
+	{"Chinese (Rime)", "zh-fcitx5-rime", "rime"},
+	{"Japanese (Mozc)", "ja-fcitx5-mozc", "mozc"},
+	{"Japanese (Anthy)", "ja-fcitx5-anthy", "anthy"},
+	{"Korean (Hangul)", "ko-fcitx5-hangul", "hangul"},
+	{"Vietnamese (Unikey)", "vi-fcitx5-unikey", "unikey"},
+}
+
+// fcitxPackages are fcitx5 itself and the modules that let GTK and Qt
+// programs type through it.
+var fcitxPackages = []string{"fcitx5", "fcitx5-gtk", "fcitx5-qt5", "fcitx5-qt6", "fcitx5-configtool"}
+
+// fcitxEnvironment points GTK, Qt, SDL and X11 programs at fcitx5.
+var fcitxEnvironment = [][2]string{
+	{"GTK_IM_MODULE", "fcitx"},
+	{"QT_IM_MODULE", "fcitx"},
+	{"SDL_IM_MODULE", "fcitx"},
+	{"XMODIFIERS", "@im=fcitx"},
+}
+
+func newInputMethodForm() *form {
+	var fields []formField
+	for _, e := range fcitxEngines {
+		value := "Off"
+		if isPackageInstalled(e.pkg) {
+			value = "On"
+		}
+		fields = append(fields, formField{label: e.label, value: value, options: []string{"On", "Off"}})
+	}
+	fields[0].help = "Ctrl+Space switches between the keyboard layout and the input methods. Fine-tune them later with fcitx5-configtool."
+	fields = append(fields, formField{label: "CJK and emoji fonts", value: "On", options: []string{"On", "Off"},
+		help: "Installs Noto CJK and Noto Color Emoji and makes fontconfig fall back to them, so East Asian text and emoji render in waybar, foot and browsers."})
+	return &form{
+		title:  "Input Method",
+		fields: fields,
+		submit: func(values []string) (tea.Cmd, error) {
+			var engines []fcitxEngine
+			for i, e := range fcitxEngines {
+				if values[i] == "On" {
+					engines = append(engines, e)
+				}
+			}
+			fonts := values[len(fcitxEngines)] == "On"
+			if len(engines) == 0 && !fonts {
+				return nil, errors.New(T("turn on a language engine or the fonts"))
+			}
+			return setupInputMethod(engines, fonts), nil
+		},
+	}
+}
+
+// fcitxProfile is a first fcitx5 profile with the keyboard layout and the
+// chosen input methods in one group, so they work without opening the
+// configuration tool.
+func fcitxProfile(layout string, engines []fcitxEngine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Groups/0]\nName=Default\nDefault Layout=%s\nDefaultIM=%s\n\n", layout, engines[0].im)
+	items := []string{"keyboard-" + layout}
+	for _, e := range engines {
+		items = append(items, e.im)
+	}
+	for i, item := range items {
+		fmt.Fprintf(&b, "[Groups/0/Items/%d]\nName=%s\nLayout=\n\n", i, item)
+	}
+	b.WriteString("[GroupOrder]\n0=Default\n")
+	return b.String()
+}
+
+// setupInputMethod installs fcitx5 with the engines, sets the input method
+// variables in the environment block and starts fcitx5 with niri. With
+// fonts, it installs the CJK and emoji fonts first; without engines, only
+// them.
+func setupInputMethod(engines []fcitxEngine, fonts bool) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		if fonts {
+			region := "SC"
+			for _, e := range engines {
+				if r, ok := cjkRegions[e.im]; ok {
+					region = r
+					break
+				}
+			}
+			lines, err := installFonts(region)
+			logs = append(logs, lines...)
+			if err != nil || len(engines) == 0 {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+		pkgs := append([]string{}, fcitxPackages...)
+		for _, e := range engines {
+			pkgs = append(pkgs, e.pkg)
+		}
+		for _, pkg := range pkgs {
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			for _, kv := range fcitxEnvironment {
+				cfg = setKDLNode(cfg, []string{"environment"}, kv[0]+" "+kdlQuote(kv[1]))
+			}
+			return setSpawnAtStartup(cfg, "fcitx5", "-d", "--replace")
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to %s: OK", "GTK_IM_MODULE, QT_IM_MODULE, SDL_IM_MODULE, XMODIFIERS", path))
+		logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "fcitx5", path))
+
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		profile := filepath.Join(homeDir, ".config", "fcitx5", "profile")
+		if _, err := os.Stat(profile); err == nil {
+			logs = append(logs, Tf("Kept the existing %s; add the new input methods with fcitx5-configtool", profile))
+		} else {
+			_, cfg, _ := readNiriConfig()
+			layout := "us"
+			if v, ok := kdlGet(cfg, []string{"input", "keyboard", "xkb"}, "layout"); ok && kdlUnquote(v) != "" {
+				// fcitx5 takes the first of several layouts.
+				layout = strings.Split(kdlUnquote(v), ",")[0]
+			}
+			if err := os.MkdirAll(filepath.Dir(profile), 0755); err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			if err := writeFile(profile, []byte(fcitxProfile(layout, engines)), 0644); err != nil {
+				logs = append(logs, Tf("Failed to write %s: %v", profile, err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, Tf("Wrote %s: OK", profile))
+		}
+		logs = append(logs, T("Log out and back in; then Ctrl+Space switches input methods."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// niriMode is one video mode advertised by an output. Refresh rates are in
+// millihertz.
+type niriMode struct {
+	Width       int  `json:"width"`
+	Height      int  `json:"height"`
+	RefreshRate int  `json:"refresh_rate"`
+	IsPreferred bool `json:"is_preferred"`
+}
+
+// niriLogical is where an enabled output sits in the global coordinate space.
+type niriLogical struct {
+	X         int     `json:"x"`
+	Y         int     `json:"y"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Scale     float64 `json:"scale"`
+	Transform string  `json:"transform"`
+}
+
+// niriOutput mirrors the output objects reported by `niri msg --json outputs`.
+type niriOutput struct {
+	Name         string       `json:"name"`
+	Make         string       `json:"make"`
+	Model        string       `json:"model"`
+	Serial       *string      `json:"serial"`
+	PhysicalSize *[2]int      `json:"physical_size"`
+	Modes        []niriMode   `json:"modes"`
+	CurrentMode  *int         `json:"current_mode"`
+	VrrSupported bool         `json:"vrr_supported"`
+	VrrEnabled   bool         `json:"vrr_enabled"`
+	Logical      *niriLogical `json:"logical"`
+}
+
+// isInternal reports whether the output is a built-in laptop panel.
+func (o niriOutput) isInternal() bool {
+	for _, prefix := range []string{"eDP", "LVDS", "DSI"} {
+		if strings.HasPrefix(o.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// description returns the "make model serial" string that identifies the
+// monitor regardless of which connector it is plugged into.
+func (o niriOutput) description() string {
+	parts := []string{o.Make, o.Model}
+	if o.Serial != nil {
+		parts = append(parts, *o.Serial)
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// currentMode returns the mode the output is driven at, if any.
+func (o niriOutput) currentMode() (niriMode, bool) {
+	if o.CurrentMode == nil || *o.CurrentMode >= len(o.Modes) {
+		return niriMode{}, false
+	}
+	return o.Modes[*o.CurrentMode], true
+}
+
+// niriMsg runs `niri msg --json` with args against the running session and
+// decodes the reply into v.
+func niriMsg(v any, args ...string) error {
+	cmd := exec.Command("niri", append([]string{"msg", "--json"}, args...)...)
+	out, err := commandOutput(cmd)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("niri msg %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return fmt.Errorf("niri msg %s: %v", strings.Join(args, " "), err)
+	}
+	return json.Unmarshal(out, v)
+}
+
+// niriOutputs returns the outputs connected to the running niri session,
+// sorted by name.
+func niriOutputs() ([]niriOutput, error) {
+	byName := map[string]niriOutput{}
+	if err := niriMsg(&byName, "outputs"); err != nil {
+		return nil, err
+	}
+	outputs := make([]niriOutput, 0, len(byName))
+	for _, o := range byName {
+		outputs = append(outputs, o)
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+	return outputs, nil
+}
+
+// niriSocketAlive reports whether a niri instance accepts connections on
+// socket. A niri that crashed leaves its socket file behind.
+func niriSocketAlive(socket string) bool {
+	conn, err := net.DialTimeout("unix", socket, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// findNiriSocket returns the IPC socket of a running niri instance. Inside a
+// session niri exports NIRI_SOCKET; from another TTY or over SSH the socket
+// is looked up in the runtime directory instead.
+func findNiriSocket() string {
+	if socket := os.Getenv("NIRI_SOCKET"); socket != "" && niriSocketAlive(socket) {
+		return socket
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" || targetUser != nil {
+		// With --user, look for that user's session rather than ours.
+		runtimeDir = runtimeDirPath()
+	}
+	matches, _ := filepath.Glob(filepath.Join(runtimeDir, "niri.*.sock"))
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.Mode()&os.ModeSocket != 0 && niriSocketAlive(m) {
+			return m
+		}
+	}
+	return ""
+}
+
+// niriRunning reports whether a running niri session can be reached over
+// IPC. A socket found outside the environment is exported as NIRI_SOCKET so
+// that `niri msg` uses it too.
+func niriRunning() bool {
+	socket := findNiriSocket()
+	if socket == "" {
+		return false
+	}
+	setenv("NIRI_SOCKET", socket)
+	return true
+}
+
+// insideNiriSession reports whether this process was started from within a
+// niri session, as opposed to merely being able to reach one.
+func insideNiriSession() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" && os.Getenv("NIRI_SOCKET") != ""
+}
+
+// reloadNiriConfig asks the running niri to load its config file and waits
+// for the ConfigLoaded event to find out whether the new config was accepted.
+// If niri does not report back in time, `niri validate` decides instead.
+func reloadNiriConfig(path string) error {
+	stream := exec.Command("niri", "msg", "--json", "event-stream")
+	stdout, err := stream.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	traceStart(stream)
+	if err := stream.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		stream.Process.Kill()
+		stream.Wait()
+	}()
+
+	events := make(chan map[string]json.RawMessage)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			ev := map[string]json.RawMessage{}
+			if json.Unmarshal(scanner.Bytes(), &ev) != nil {
+				continue
+			}
+			select {
+			case events <- ev:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// The stream starts with a burst describing the current state; skip it
+	// so that only the reload we trigger is looked at.
+	settle := time.After(300 * time.Millisecond)
+drain:
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				break drain
+			}
+		case <-settle:
+			break drain
+		}
+	}
+
+	if out, err := commandCombinedOutput(exec.Command("niri", "msg", "action", "load-config-file")); err != nil {
+		return fmt.Errorf("niri did not accept the reload request: %s", strings.TrimSpace(string(out)))
+	}
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return validateConfigFile(path)
+			}
+			raw, ok := ev["ConfigLoaded"]
+			if !ok {
+				continue
+			}
+			var loaded struct {
+				Failed bool `json:"failed"`
+			}
+			if err := json.Unmarshal(raw, &loaded); err != nil || !loaded.Failed {
+				return nil
+			}
+			if err := validateConfigFile(path); err != nil {
+				return err
+			}
+			return fmt.Errorf("niri failed to reload %s", path)
+		case <-timeout:
+			return validateConfigFile(path)
+		}
+	}
+}
+
+// validateConfigFile runs `niri validate` on path and returns its complaints
+// as an error.
+func validateConfigFile(path string) error {
+	out, err := commandCombinedOutput(exec.Command("niri", "validate", "-c", path))
+	if err != nil {
+		return fmt.Errorf("niri rejected %s: %s", path, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
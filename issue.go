@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	nirisetupIssues = "https://github.com/xcrsz/NiriSetup/issues/new"
+	niriIssues      = "https://github.com/YaLTeR/niri/issues/new"
+)
+
+// maxIssueURL keeps prefilled issue URLs short enough for browsers and
+// GitHub; older log lines are dropped to fit.
+const maxIssueURL = 7000
+
+// issueLogLines is how much of a log goes into an issue.
+const issueLogLines = 40
+
+// niriCrashed matches the kernel message logged when niri dies on a
+// signal.
+var niriCrashed = regexp.MustCompile(`pid \d+ \(niri\).* exited on signal \d+`)
+
+// issueDraft is a GitHub issue ready to be filled in.
+type issueDraft struct {
+	repo    string
+	title   string
+	excerpt []string
+}
+
+// issueSummary describes the system for a bug report.
+func issueSummary() string {
+	var b strings.Builder
+	if out, err := commandOutput(exec.Command("uname", "-srm")); err == nil {
+		fmt.Fprintf(&b, "- OS: %s\n", strings.TrimSpace(string(out)))
+	}
+	for _, pkg := range []string{"niri", "drm-kmod", "mesa-dri", "xwayland-satellite"} {
+		if out, err := commandOutput(exec.Command("pkg", "query", "%v", pkg)); err == nil {
+			fmt.Fprintf(&b, "- %s: %s\n", pkg, strings.TrimSpace(string(out)))
+		}
+	}
+	if out, err := commandOutput(exec.Command("pciconf", "-lv")); err == nil {
+		inGPU := false
+		for _, line := range strings.Split(string(out), "\n") {
+			if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				inGPU = strings.HasPrefix(line, "vgapci")
+			} else if field := strings.TrimSpace(line); inGPU && strings.HasPrefix(field, "device") {
+				_, name, _ := strings.Cut(field, "=")
+				fmt.Fprintf(&b, "- GPU: %s\n", strings.Trim(strings.TrimSpace(name), "'"))
+			}
+		}
+	}
+	return b.String()
+}
+
+// url builds the prefilled issue URL, redacted like the debug bundle.
+func (d issueDraft) url() string {
+	redact := redactor()
+	summary := issueSummary()
+	excerpt := d.excerpt[max(0, len(d.excerpt)-issueLogLines):]
+	for {
+		body := "### What happened\n\n(Describe what you did and what you expected.)\n\n" +
+			"### System\n\n" + summary + "\n" +
+			"### Log\n\n```\n" + strings.Join(excerpt, "\n") + "\n```\n\n" +
+			"Prefilled by NiriSetup.\n"
+		u := d.repo + "?" + url.Values{"title": {d.title}, "body": {redact(body)}}.Encode()
+		if len(u) <= maxIssueURL || len(excerpt) <= 1 {
+			return u
+		}
+		excerpt = excerpt[1:]
+	}
+}
+
+// failureIssue drafts an issue about an action of NiriSetup that failed.
+func failureIssue(action, log string) issueDraft {
+	return issueDraft{
+		repo:    nirisetupIssues,
+		title:   fmt.Sprintf("%s fails", action),
+		excerpt: strings.Split(strings.TrimRight(log, "\n"), "\n"),
+	}
+}
+
+// crashIssue drafts an issue about niri crashing, if the kernel messages
+// show that it did.
+func crashIssue() (issueDraft, bool) {
+	lines, err := kernelMessages()
+	if err != nil {
+		return issueDraft{}, false
+	}
+	var crashes []string
+	for _, line := range lines {
+		if niriCrashed.MatchString(line) {
+			crashes = append(crashes, line)
+		}
+	}
+	if len(crashes) == 0 {
+		return issueDraft{}, false
+	}
+	if homeDir, err := userHomeDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(homeDir, "niri.core")); err == nil {
+			crashes = append(crashes, "(a core dump was left in ~/niri.core)")
+		}
+	}
+	return issueDraft{repo: niriIssues, title: "niri crashes on FreeBSD (GhostBSD)", excerpt: crashes}, true
+}
+
+// openIssue opens url in the browser of the running session.
+func openIssue(u string) tea.Cmd {
+	return func() tea.Msg {
+		if err := runCommand(exec.Command("xdg-open", u)); err != nil {
+			return statusMsg{status: Tf("Failed to open the browser: %v", err), err: err}
+		}
+		return statusMsg{status: T("Opened the issue in the browser")}
+	}
+}
+
+// issueReport shows the URL of d, with an action to open it when there
+// is a browser to open it in.
+func issueReport(d issueDraft) tea.Msg {
+	u := d.url()
+	r := &report{
+		title: "Report a Bug",
+		body: Tf("This issue for %s is prefilled with the log and a summary of this system. Check it for anything private before submitting it:", strings.TrimSuffix(d.repo, "/issues/new")) +
+			"\n\n" + u,
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" && hasCommand("xdg-open") {
+		r.actions = []reportAction{{label: "Open in the browser", cmd: openIssue(u)}}
+	}
+	return reportMsg{r}
+}
+
+// reportBug drafts an issue about the last action that failed, or else
+// about a niri crash, or else about NiriSetup with the logs so far.
+func reportBug(m model) tea.Cmd {
+	action, log, logs := m.failedAction, m.failedLog, m.logs
+	return func() tea.Msg {
+		if action != "" {
+			return issueReport(failureIssue(action, log))
+		}
+		if d, ok := crashIssue(); ok {
+			return issueReport(d)
+		}
+		return issueReport(issueDraft{repo: nirisetupIssues, excerpt: strings.Split(strings.Join(logs, "\n"), "\n")})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// kanshiOutput renders a kanshi output directive that reproduces the current
+// state of o. External monitors are matched by description so the profile
+// still applies when a dock hands out different connector names.
+func kanshiOutput(o niriOutput) string {
+	criteria := o.Name
+	if !o.isInternal() && o.Make != "" {
+		criteria = strconv.Quote(o.description())
+	}
+	if o.Logical == nil {
+		return fmt.Sprintf("    output %s disable", criteria)
+	}
+	line := fmt.Sprintf("    output %s enable", criteria)
+	if mode, ok := o.currentMode(); ok {
+		line += fmt.Sprintf(" mode %dx%d@%.3fHz", mode.Width, mode.Height, float64(mode.RefreshRate)/1000)
+	}
+	line += fmt.Sprintf(" position %d,%d scale %s", o.Logical.X, o.Logical.Y, strconv.FormatFloat(o.Logical.Scale, 'f', -1, 64))
+	return line
+}
+
+// kanshiConfig builds "laptop" and "docked" profiles from the outputs that
+// are connected right now.
+func kanshiConfig(outputs []niriOutput) (string, []string) {
+	var notes []string
+	var internal *niriOutput
+	var external []niriOutput
+	for i, o := range outputs {
+		if o.isInternal() {
+			internal = &outputs[i]
+		} else {
+			external = append(external, o)
+		}
+	}
+
+	s := strings.Builder{}
+	s.WriteString("# Generated by NiriSetup from the outputs connected at the time.\n")
+	if internal != nil {
+		laptop := *internal
+		if laptop.Logical != nil {
+			l := *laptop.Logical
+			l.X, l.Y = 0, 0
+			laptop.Logical = &l
+		}
+		fmt.Fprintf(&s, "\nprofile laptop {\n%s\n}\n", kanshiOutput(laptop))
+	} else {
+		notes = append(notes, "No built-in panel detected, skipping the laptop profile")
+	}
+	if len(external) > 0 {
+		s.WriteString("\nprofile docked {\n")
+		if internal != nil {
+			s.WriteString(kanshiOutput(*internal) + "\n")
+		}
+		for _, o := range external {
+			s.WriteString(kanshiOutput(o) + "\n")
+		}
+		s.WriteString("}\n")
+	} else {
+		notes = append(notes, "No external monitors connected, skipping the docked profile (connect your dock and run this again)")
+	}
+	return s.String(), notes
+}
+
+// setupKanshi installs kanshi, writes profiles for the current outputs and
+// starts it with niri.
+func setupKanshi() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		outputs, err := niriOutputs()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to query outputs, run this from inside a niri session: %v", err), err: err}
+		}
+
+		line, err := installPackage("kanshi")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		config, notes := kanshiConfig(outputs)
+		logs = append(logs, notes...)
+
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		path := filepath.Join(homeDir, ".config", "kanshi", "config")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return statusMsg{status: Tf("Failed to create kanshi config directory: %v", err), err: err}
+		}
+		if old, err := os.ReadFile(path); err == nil && string(old) != config {
+			if err := writeFile(path+".bak", old, 0644); err == nil {
+				logs = append(logs, Tf("Backed up existing kanshi config to %s.bak", path))
+			}
+		}
+		if err := writeFile(path, []byte(config), 0644); err != nil {
+			logs = append(logs, Tf("Failed to write %s: %v", path, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Wrote kanshi profiles to %s", path))
+
+		cfgPath, err := updateNiriConfig(func(cfg string) string {
+			return setSpawnAtStartup(cfg, "kanshi")
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added kanshi to spawn-at-startup in %s", cfgPath))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// niriConfigPath returns the location of the user's niri config file.
+func niriConfigPath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "niri", "config.kdl"), nil
+}
+
+// writeNiriConfig writes config content to path. All config writes go
+// through here so that they are handled consistently. The content is
+// validated first and a config niri rejects is not written, leaving the
+// previous file intact. When niri is running, the new config is reloaded
+// and any reload error is returned.
+func writeNiriConfig(path, content string) error {
+	if err := validateConfigContent(filepath.Dir(path), path, content); err != nil {
+		return validationFailure(err)
+	}
+	if err := writeFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	if err := syncDotfile(path); err != nil {
+		return fmt.Errorf("config written but not added to the dotfiles: %v", err)
+	}
+	if niriRunning() {
+		if err := reloadNiriConfig(path); err != nil {
+			return validationFailure(fmt.Errorf("config written but not applied: %v", err))
+		}
+	}
+	return nil
+}
+
+// validateConfigContent runs niri validate on content before it is written to
+// path, from a temporary file in dir so that relative includes resolve. It
+// does nothing when niri isn't installed.
+func validateConfigContent(dir, path, content string) error {
+	if !hasCommand("niri") {
+		return nil
+	}
+	tmp, err := os.CreateTemp(dir, ".nirisetup-*.kdl")
+	if err != nil {
+		tmp, err = os.CreateTemp("", "nirisetup-*.kdl")
+		if err != nil {
+			return err
+		}
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateConfigFile(tmp.Name()); err != nil {
+		return errors.New(strings.ReplaceAll(err.Error(), tmp.Name(), path))
+	}
+	return nil
+}
+
+// readNiriConfig reads the user's config.kdl. Failures are preflight
+// failures with a message for the user.
+func readNiriConfig() (string, string, error) {
+	path, err := niriConfigPath()
+	if err != nil {
+		return "", "", preflightFailure(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path, "", preflightFailure(errors.New(Tf("Failed to read %s: %v", path, err)))
+	}
+	return path, string(data), nil
+}
+
+// updateNiriConfig applies edit to the user's existing config.kdl and writes
+// the result back, returning the path that was written.
+func updateNiriConfig(edit func(cfg string) string) (string, error) {
+	path, err := niriConfigPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return path, preflightFailure(fmt.Errorf("%s not found, run Configure Niri first", path))
+	}
+	if err != nil {
+		return path, err
+	}
+	return path, writeNiriConfig(path, edit(string(data)))
+}
+
+// kdlQuote renders s as a quoted KDL string.
+func kdlQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// kdlArgs quotes each argument and joins them with spaces.
+func kdlArgs(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = kdlQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// kdlStringPattern matches a quoted KDL string.
+var kdlStringPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// spawnArgs returns the arguments of a spawn-at-startup line, or nil if the
+// line is not one.
+func spawnArgs(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "spawn-at-startup ") {
+		return nil
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "spawn-at-startup"))
+	if !strings.HasPrefix(rest, `"`) {
+		return nil
+	}
+	var args []string
+	for _, quoted := range kdlStringPattern.FindAllString(rest, -1) {
+		args = append(args, kdlUnquote(quoted))
+	}
+	return args
+}
+
+// spawnProgram returns the program name of a spawn-at-startup line, or ""
+// if the line is not one. For programs run through the supervisor it is
+// the supervised program.
+func spawnProgram(line string) string {
+	args := spawnArgs(line)
+	if len(args) > 1 && isSupervisor(args[0]) {
+		return args[1]
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// setSpawnAtStartup makes cfg launch args at startup. An existing
+// spawn-at-startup line for the same program is replaced, staying under
+// the supervisor if it was; otherwise the new line is added after the last
+// spawn-at-startup line, or at the end.
+func setSpawnAtStartup(cfg string, args ...string) string {
+	newLine := "spawn-at-startup " + kdlArgs(args...)
+	lines := strings.Split(cfg, "\n")
+	var out []string
+	replaced := false
+	lastSpawn := -1
+	for _, line := range lines {
+		prog := spawnProgram(line)
+		if prog == args[0] {
+			if replaced {
+				continue
+			}
+			if old := spawnArgs(line); isSupervisor(old[0]) {
+				line = "spawn-at-startup " + kdlArgs(append([]string{old[0]}, args...)...)
+			} else {
+				line = newLine
+			}
+			replaced = true
+		}
+		if prog != "" {
+			lastSpawn = len(out)
+		}
+		out = append(out, line)
+	}
+	if replaced {
+		return strings.Join(out, "\n")
+	}
+	if lastSpawn < 0 {
+		return strings.TrimRight(cfg, "\n") + "\n\n" + newLine + "\n"
+	}
+	out = append(out[:lastSpawn+1], append([]string{newLine}, out[lastSpawn+1:]...)...)
+	return strings.Join(out, "\n")
+}
+
+// removeSpawnAtStartup drops the spawn-at-startup lines for program.
+func removeSpawnAtStartup(cfg, program string) string {
+	lines := strings.Split(cfg, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if spawnProgram(line) != program {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// kdlSpan is the byte range of a single node, from the start of its name to
+// the end of its arguments or closing brace.
+type kdlSpan struct {
+	start, end int
+}
+
+func isKDLIdentChar(c byte) bool {
+	return c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ';' &&
+		c != '{' && c != '}' && c != '(' && c != ')' && c != '=' && c != '"'
+}
+
+// kdlSkip returns the index just past the comment or string that starts at
+// i, or i itself if there is none. Line comments stop before the newline so
+// that it still terminates the node.
+func kdlSkip(s string, i int) int {
+	switch {
+	case strings.HasPrefix(s[i:], "//"):
+		if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+			return i + j
+		}
+		return len(s)
+	case strings.HasPrefix(s[i:], "/*"):
+		depth := 0
+		for j := i; j < len(s)-1; j++ {
+			switch s[j : j+2] {
+			case "/*":
+				depth++
+				j++
+			case "*/":
+				depth--
+				j++
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+		return len(s)
+	case s[i] == '"':
+		for j := i + 1; j < len(s); j++ {
+			if s[j] == '\\' {
+				j++
+				continue
+			}
+			if s[j] == '"' {
+				return j + 1
+			}
+		}
+		return len(s)
+	case s[i] == 'r' && (i == 0 || !isKDLIdentChar(s[i-1])):
+		j := i + 1
+		for j < len(s) && s[j] == '#' {
+			j++
+		}
+		if j >= len(s) || s[j] != '"' {
+			return i
+		}
+		closing := `"` + strings.Repeat("#", j-i-1)
+		if k := strings.Index(s[j+1:], closing); k >= 0 {
+			return j + 1 + k + len(closing)
+		}
+		return len(s)
+	}
+	return i
+}
+
+// kdlChildren returns the spans of the nodes between from and to, which
+// must cover a whole document or the inside of a children block. Commented
+// out ("/-") nodes are returned too; their names start with "/-".
+func kdlChildren(s string, from, to int) []kdlSpan {
+	var spans []kdlSpan
+	i := from
+	for i < to {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+			i++
+			continue
+		case c == '/' && kdlSkip(s, i) != i:
+			i = kdlSkip(s, i)
+			continue
+		case c == '}':
+			return spans
+		}
+
+		start := i
+		depth := 0
+	node:
+		for i < to {
+			if j := kdlSkip(s, i); j != i {
+				if s[i] == '/' && s[i+1] == '/' && depth == 0 {
+					// A trailing line comment isn't part of the node.
+					break
+				}
+				i = j
+				continue
+			}
+			switch s[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					i++
+					break node
+				}
+			case '\n', ';':
+				if depth == 0 {
+					break node
+				}
+			}
+			i++
+		}
+		end := i
+		for end > start && (s[end-1] == ' ' || s[end-1] == '\t' || s[end-1] == '\r') {
+			end--
+		}
+		spans = append(spans, kdlSpan{start, end})
+	}
+	return spans
+}
+
+// kdlNodeName returns the name of the node at sp.
+func kdlNodeName(s string, sp kdlSpan) string {
+	i := sp.start
+	for i < sp.end && isKDLIdentChar(s[i]) {
+		i++
+	}
+	return s[sp.start:i]
+}
+
+// kdlFirstArg returns the first argument of the node at sp if it is a plain
+// quoted string.
+func kdlFirstArg(s string, sp kdlSpan) string {
+	rest := strings.TrimLeft(s[sp.start+len(kdlNodeName(s, sp)):sp.end], " \t")
+	if !strings.HasPrefix(rest, `"`) {
+		return ""
+	}
+	end := kdlSkip(rest, 0)
+	if end < 2 {
+		return ""
+	}
+	return kdlUnquote(rest[:end])
+}
+
+// kdlBody returns the range inside the braces of the node at sp.
+func kdlBody(s string, sp kdlSpan) (int, int, bool) {
+	for i := sp.start; i < sp.end; {
+		if j := kdlSkip(s, i); j != i {
+			i = j
+			continue
+		}
+		if s[i] == '{' {
+			return i + 1, sp.end - 1, true
+		}
+		i++
+	}
+	return 0, 0, false
+}
+
+// kdlFind returns the first node among the children in [from, to) that is
+// called name and, if arg is non-empty, whose first argument is arg.
+func kdlFind(s string, from, to int, name, arg string) (kdlSpan, bool) {
+	for _, sp := range kdlChildren(s, from, to) {
+		if kdlNodeName(s, sp) == name && (arg == "" || kdlFirstArg(s, sp) == arg) {
+			return sp, true
+		}
+	}
+	return kdlSpan{}, false
+}
+
+// kdlPathElem splits a path element like `output eDP-1` into the node name
+// and the first argument it must have.
+func kdlPathElem(elem string) (string, string) {
+	name, arg, _ := strings.Cut(elem, " ")
+	return name, arg
+}
+
+// kdlIndent prefixes every line of text after the first with indent.
+func kdlIndent(text, indent string) string {
+	return strings.ReplaceAll(text, "\n", "\n"+indent)
+}
+
+// kdlInsert inserts node as the last child of the block whose body ends at
+// close, which lives at the given nesting depth.
+func kdlInsert(s string, close int, depth int, node string) string {
+	indent := strings.Repeat("    ", depth+1)
+	lineStart := strings.LastIndexByte(s[:close], '\n') + 1
+	if strings.TrimSpace(s[lineStart:close]) == "" {
+		return s[:lineStart] + indent + kdlIndent(node, indent) + "\n" + s[lineStart:]
+	}
+	closeIndent := strings.Repeat("    ", depth)
+	return s[:close] + "\n" + indent + kdlIndent(node, indent) + "\n" + closeIndent + s[close:]
+}
+
+// setKDLNode makes node (the full text of a node, e.g. `gaps 16`) a child
+// of the block at path, replacing the first existing child with the same
+// name. Missing blocks along path are created. Path elements are node names,
+// optionally followed by a space and a required first argument.
+func setKDLNode(cfg string, path []string, node string) string {
+	return putKDLNode(cfg, path, node, true)
+}
+
+// addKDLNode appends node to the block at path even if a child with the
+// same name exists, creating missing blocks like setKDLNode.
+func addKDLNode(cfg string, path []string, node string) string {
+	return putKDLNode(cfg, path, node, false)
+}
+
+func putKDLNode(cfg string, path []string, node string, replace bool) string {
+	from, to := 0, len(cfg)
+	for depth, elem := range path {
+		name, arg := kdlPathElem(elem)
+		sp, ok := kdlFind(cfg, from, to, name, arg)
+		if !ok {
+			block := buildKDLPath(path[depth:], node)
+			if depth == 0 {
+				return strings.TrimRight(cfg, "\n") + "\n\n" + block + "\n"
+			}
+			return kdlInsert(cfg, to, depth-1, block)
+		}
+		open, close, ok := kdlBody(cfg, sp)
+		if !ok {
+			// Turn a childless node into a block.
+			cfg = cfg[:sp.end] + " {\n}" + cfg[sp.end:]
+			sp.end += 4
+			open, close, _ = kdlBody(cfg, sp)
+		}
+		from, to = open, close
+	}
+
+	name := strings.TrimSpace(node)
+	if i := strings.IndexFunc(name, func(r rune) bool { return r < 128 && !isKDLIdentChar(byte(r)) }); i >= 0 {
+		name = name[:i]
+	}
+	if sp, ok := kdlFind(cfg, from, to, name, ""); ok && replace {
+		indent := strings.Repeat("    ", len(path))
+		return cfg[:sp.start] + kdlIndent(node, indent) + cfg[sp.end:]
+	}
+	if len(path) == 0 {
+		return strings.TrimRight(cfg, "\n") + "\n\n" + node + "\n"
+	}
+	return kdlInsert(cfg, to, len(path)-1, node)
+}
+
+// buildKDLPath nests node inside new blocks named by path.
+func buildKDLPath(path []string, node string) string {
+	if len(path) == 0 {
+		return node
+	}
+	name, arg := kdlPathElem(path[0])
+	header := name
+	if arg != "" {
+		header += " " + kdlQuote(arg)
+	}
+	return header + " {\n    " + kdlIndent(buildKDLPath(path[1:], node), "    ") + "\n}"
+}
+
+// kdlLocate returns the body range of the block at path. An empty path is
+// the whole document.
+func kdlLocate(cfg string, path []string) (int, int, bool) {
+	from, to := 0, len(cfg)
+	for _, elem := range path {
+		name, arg := kdlPathElem(elem)
+		sp, ok := kdlFind(cfg, from, to, name, arg)
+		if !ok {
+			return 0, 0, false
+		}
+		if from, to, ok = kdlBody(cfg, sp); !ok {
+			return 0, 0, false
+		}
+	}
+	return from, to, true
+}
+
+// kdlGet returns what follows the name of the first child called name in the
+// block at path, e.g. `16` for `gaps 16`.
+func kdlGet(cfg string, path []string, name string) (string, bool) {
+	from, to, ok := kdlLocate(cfg, path)
+	if !ok {
+		return "", false
+	}
+	sp, ok := kdlFind(cfg, from, to, name, "")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(cfg[sp.start+len(name) : sp.end]), true
+}
+
+// kdlUnquote returns the contents of a quoted KDL string, or s unchanged if
+// it isn't quoted.
+func kdlUnquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	r := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\t`, "\t")
+	return r.Replace(s[1 : len(s)-1])
+}
+
+// removeKDLNode deletes the children called name from the block at path,
+// along with the lines they were on if nothing else is left there.
+func removeKDLNode(cfg string, path []string, name string) string {
+	from, to, ok := kdlLocate(cfg, path)
+	if !ok {
+		return cfg
+	}
+	spans := kdlChildren(cfg, from, to)
+	for i := len(spans) - 1; i >= 0; i-- {
+		sp := spans[i]
+		if kdlNodeName(cfg, sp) != name {
+			continue
+		}
+		start, end := sp.start, sp.end
+		lineStart := strings.LastIndexByte(cfg[:start], '\n') + 1
+		lineEnd := strings.IndexByte(cfg[end:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(cfg) - end
+		}
+		if strings.TrimSpace(cfg[lineStart:start]) == "" && strings.TrimSpace(cfg[end:end+lineEnd]) == "" {
+			start, end = lineStart, end+lineEnd
+			if end < len(cfg) {
+				end++
+			}
+		}
+		cfg = cfg[:start] + cfg[end:]
+	}
+	return cfg
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// essentialBind is an action every setup needs a key for, with the
+// keys tried in order when adding it.
+type essentialBind struct {
+	what  string
+	keys  []string
+	found func(action, program string) bool
+	node  func() string
+}
+
+var (
+	terminals = []string{"alacritty", "foot", "kitty", "wezterm", "sakura", "xfce4-terminal", "xterm"}
+	launchers = []string{"fuzzel", "wofi", "rofi", "bemenu-run", "tofi-drun", "anyrun", "walker"}
+)
+
+// firstInstalled returns the first of programs that is installed, or the
+// first one if none is.
+func firstInstalled(programs []string) string {
+	for _, p := range programs {
+		if hasCommand(p) {
+			return p
+		}
+	}
+	return programs[0]
+}
+
+var essentialBinds = []essentialBind{
+	{
+		what:  "close the focused window",
+		keys:  []string{"Mod+Q", "Mod+Shift+Q"},
+		found: func(action, _ string) bool { return action == "close-window" },
+		node:  func() string { return "{ close-window; }" },
+	},
+	{
+		what:  "open the application launcher",
+		keys:  []string{"Mod+D", "Mod+Space", "Mod+R"},
+		found: func(_, program string) bool { return containsString(launchers, program) },
+		node: func() string {
+			if launcher := firstInstalled(launchers); launcher != "wofi" {
+				return "{ spawn " + kdlArgs(launcher) + "; }"
+			}
+			return "{ spawn " + kdlArgs("wofi", "--show", "drun") + "; }"
+		},
+	},
+	{
+		what:  "open a terminal",
+		keys:  []string{"Mod+T", "Mod+Return"},
+		found: func(_, program string) bool { return containsString(terminals, program) },
+		node:  func() string { return "{ spawn " + kdlArgs(firstInstalled(terminals)) + "; }" },
+	},
+	{
+		what:  "quit niri",
+		keys:  []string{"Mod+Shift+E", "Ctrl+Alt+Delete"},
+		found: func(action, _ string) bool { return action == "quit" },
+		node:  func() string { return "{ quit; }" },
+	},
+}
+
+// bindAction returns the action a bind runs and, for spawn actions, the
+// program it starts.
+func bindAction(cfg string, sp kdlSpan) (string, string) {
+	from, to, ok := kdlBody(cfg, sp)
+	if !ok {
+		return "", ""
+	}
+	children := kdlChildren(cfg, from, to)
+	if len(children) == 0 {
+		return "", ""
+	}
+	action := kdlNodeName(cfg, children[0])
+	program := ""
+	switch action {
+	case "spawn":
+		program = kdlFirstArg(cfg, children[0])
+	case "spawn-sh":
+		if fields := strings.Fields(kdlFirstArg(cfg, children[0])); len(fields) > 0 {
+			program = fields[0]
+		}
+	}
+	return action, program
+}
+
+// missingEssentials returns the essential actions no bind in cfg runs.
+func missingEssentials(cfg string) []essentialBind {
+	binds := kdlBlockChildren(cfg, []string{"binds"})
+	var missing []essentialBind
+	for _, e := range essentialBinds {
+		found := false
+		for _, sp := range binds {
+			action, program := bindAction(cfg, sp)
+			found = found || e.found(action, program)
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// freeKey returns the first of e's keys that cfg doesn't bind yet.
+func (e essentialBind) freeKey(cfg string) (string, bool) {
+	bound := map[string]bool{}
+	for _, sp := range kdlBlockChildren(cfg, []string{"binds"}) {
+		bound[normalizeKey(kdlNodeName(cfg, sp))] = true
+	}
+	for _, key := range e.keys {
+		if !bound[normalizeKey(key)] {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// addEssentialBinds binds each missing essential action to a free key.
+func addEssentialBinds(cfg string) string {
+	for _, e := range missingEssentials(cfg) {
+		if key, ok := e.freeKey(cfg); ok {
+			cfg = addKDLNode(cfg, []string{"binds"}, key+" "+e.node())
+		}
+	}
+	return cfg
+}
+
+// describeBinds lists the duplicate chords and missing essential actions
+// in cfg.
+func describeBinds(cfg string) string {
+	var b strings.Builder
+	duplicates := duplicateBinds(cfg)
+	if len(duplicates) == 0 {
+		fmt.Fprintln(&b, T("No key combination is bound twice."))
+	} else {
+		fmt.Fprintln(&b, T("Key combinations bound more than once:"))
+		for _, issue := range duplicates {
+			fmt.Fprintf(&b, "  %s %s\n", Tf("line %d:", issue.line), issue.text)
+		}
+	}
+	fmt.Fprintln(&b)
+	missing := missingEssentials(cfg)
+	if len(missing) == 0 {
+		fmt.Fprint(&b, T("Every essential action has a key."))
+		return b.String()
+	}
+	fmt.Fprintln(&b, T("No key is bound to:"))
+	for _, e := range missing {
+		if key, ok := e.freeKey(cfg); ok {
+			fmt.Fprintf(&b, "  %s (%s)\n", T(e.what), Tf("can be added as %s", key+" "+e.node()))
+		} else {
+			fmt.Fprintf(&b, "  %s (%s)\n", T(e.what), Tf("%s are all taken", strings.Join(e.keys, ", ")))
+		}
+	}
+	return b.String()
+}
+
+// runKeyBindings checks the binds of the user's config.kdl and, with
+// fix set, adds the missing essentials.
+func runKeyBindings(fix bool) (string, error) {
+	path, cfg, err := readNiriConfig()
+	if err != nil {
+		return err.Error(), err
+	}
+	text := strings.TrimRight(describeBinds(cfg), "\n")
+	unfixed := len(missingEssentials(cfg)) > 0
+	if fix && addEssentialBinds(cfg) != cfg {
+		if _, err := updateNiriConfig(addEssentialBinds); err != nil {
+			return text + "\n\n" + Tf("Failed to add the key bindings: %v", err), err
+		}
+		text += "\n\n" + Tf("Added the missing key bindings to %s", path)
+		unfixed = len(missingEssentials(addEssentialBinds(cfg))) > 0
+	}
+	if unfixed || len(duplicateBinds(cfg)) > 0 {
+		return text, errors.New("key binding problems found")
+	}
+	return text, nil
+}
+
+// addMissingBinds adds the missing essential bindings to config.kdl.
+func addMissingBinds() tea.Cmd {
+	return func() tea.Msg {
+		path, err := updateNiriConfig(addEssentialBinds)
+		if err != nil {
+			return statusMsg{status: Tf("Failed to add the key bindings: %v", err), err: err}
+		}
+		return statusMsg{status: Tf("Added the missing key bindings to %s", path)}
+	}
+}
+
+// keyBindingsView shows the binding conflicts and missing essentials,
+// offering to add the missing ones.
+func keyBindingsView() tea.Cmd {
+	return func() tea.Msg {
+		_, cfg, err := readNiriConfig()
+		if err != nil {
+			return statusMsg{status: err.Error(), err: err}
+		}
+		r := &report{title: "Key Bindings", body: describeBinds(cfg), refresh: keyBindingsView()}
+		if addEssentialBinds(cfg) != cfg {
+			r.actions = []reportAction{{label: "Add the missing key bindings", cmd: addMissingBinds()}}
+		}
+		return reportMsg{r}
+	}
+}
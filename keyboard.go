@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// xkbPath is where config.kdl keeps the keyboard layout.
+var xkbPath = []string{"input", "keyboard", "xkb"}
+
+// xkbSettings are the xkb settings the Keyboard form edits, in the order of
+// its fields.
+var xkbSettings = []string{"layout", "variant", "options"}
+
+// newKeyboardForm edits the keyboard layout in config.kdl, starting from the
+// one set there.
+func newKeyboardForm() *form {
+	_, cfg, _ := readNiriConfig()
+	xkb := func(name string) string {
+		v, _ := kdlGet(cfg, xkbPath, name)
+		return kdlUnquote(v)
+	}
+	return &form{
+		title: "Keyboard",
+		fields: []formField{
+			{label: "Layout", value: xkb("layout"),
+				help: "xkb layouts, comma-separated, e.g. us or us,de. Empty keeps the layout the system picks."},
+			{label: "Variant", value: xkb("variant"),
+				help: "xkb variants for the layouts, comma-separated, e.g. colemak or ,nodeadkeys."},
+			{label: "Options", value: xkb("options"),
+				help: "xkb options, e.g. grp:alt_shift_toggle to switch layouts or ctrl:nocaps."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			for i, v := range values {
+				values[i] = strings.TrimSpace(v)
+				if strings.ContainsAny(values[i], " \"") {
+					return nil, errors.New(T("xkb settings can't contain spaces or quotes"))
+				}
+			}
+			return writeKeyboard(values), nil
+		},
+	}
+}
+
+// writeKeyboard sets the xkb settings in config.kdl, removing those left
+// empty.
+func writeKeyboard(values []string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := updateNiriConfig(func(cfg string) string {
+			for i, name := range xkbSettings {
+				if values[i] == "" {
+					cfg = removeKDLNode(cfg, xkbPath, name)
+				} else {
+					cfg = setKDLNode(cfg, xkbPath, name+" "+kdlQuote(values[i]))
+				}
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write the keyboard layout: %v", err), err: err}
+		}
+		if values[0] == "" {
+			return statusMsg{status: Tf("Keyboard settings written to %s", path)}
+		}
+		return statusMsg{status: Tf("Keyboard layout %s written to %s", values[0], path)}
+	}
+}
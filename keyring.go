@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	pamLoginPath = "/etc/pam.d/login"
+	// pamGnomeKeyring is the PAM module of gnome-keyring. It unlocks the
+	// login keyring with the password typed at the TTY login.
+	pamGnomeKeyring = "/usr/local/lib/pam_gnome_keyring.so"
+)
+
+func newKeyringForm() *form {
+	return &form{
+		title: "Keyring",
+		fields: []formField{
+			{label: "Secret service", value: "gnome-keyring", options: []string{"gnome-keyring", "KeePassXC"},
+				help: "Browsers, mail programs and network tools store their passwords in the secret service. gnome-keyring keeps them in a keyring unlocked at login; KeePassXC serves them from a database you unlock yourself."},
+			{label: "Unlock at login", value: "Yes", options: []string{"Yes", "No"},
+				help: "gnome-keyring only: unlock the keyring with your login password through PAM, so you aren't asked for it again."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			if values[0] == "KeePassXC" {
+				return setupKeePassXC(), nil
+			}
+			return setupGnomeKeyring(values[1] == "Yes"), nil
+		},
+	}
+}
+
+// pamWithKeyring adds the gnome-keyring module to a PAM service file: to
+// the auth stack to get the password, and to the session stack to start
+// the daemon with it. Each line goes after the last line of its stack.
+func pamWithKeyring(pam string) string {
+	if strings.Contains(pam, "pam_gnome_keyring.so") {
+		return pam
+	}
+	lines := strings.Split(strings.TrimRight(pam, "\n"), "\n")
+	added := map[string]string{
+		"auth":    "auth\t\toptional\t" + pamGnomeKeyring,
+		"session": "session\t\toptional\t" + pamGnomeKeyring + " auto_start",
+	}
+	last := map[string]int{"auth": -1, "session": -1}
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			if _, ok := last[fields[0]]; ok {
+				last[fields[0]] = i
+			}
+		}
+	}
+	var out []string
+	for i, line := range lines {
+		out = append(out, line)
+		for stack, n := range last {
+			if n == i {
+				out = append(out, added[stack])
+			}
+		}
+	}
+	for _, stack := range []string{"auth", "session"} {
+		if last[stack] < 0 {
+			out = append(out, added[stack])
+		}
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// setupGnomeKeyring installs gnome-keyring, starts its secret service with
+// niri and optionally unlocks it at the TTY login.
+func setupGnomeKeyring(pam bool) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		line, err := installPackage("gnome-keyring")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			return setSpawnAtStartup(cfg, "gnome-keyring-daemon", "--start", "--components=secrets")
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "gnome-keyring-daemon", path))
+
+		if pam {
+			status, err := unlockKeyringAtLogin()
+			if err != nil {
+				logs = append(logs, Tf("Failed to set up %s: %v", pamLoginPath, err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, status)
+		}
+		logs = append(logs, T("Log out and back in for the keyring to start."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// unlockKeyringAtLogin adds gnome-keyring to /etc/pam.d/login, keeping a
+// backup of the file.
+func unlockKeyringAtLogin() (string, error) {
+	if _, err := os.Stat(pamGnomeKeyring); err != nil {
+		return "", fmt.Errorf("%s not found: %v", pamGnomeKeyring, err)
+	}
+	data, err := os.ReadFile(pamLoginPath)
+	if err != nil {
+		return "", err
+	}
+	updated := pamWithKeyring(string(data))
+	if updated == string(data) {
+		return Tf("%s already unlocks the keyring: OK", pamLoginPath), nil
+	}
+	if err := writeRootFile(pamLoginPath+".bak", string(data), 0644); err != nil {
+		return "", err
+	}
+	if err := writeRootFile(pamLoginPath, updated, 0644); err != nil {
+		return "", err
+	}
+	return Tf("Added gnome-keyring to %s (backup in %s.bak): OK", pamLoginPath, pamLoginPath), nil
+}
+
+// setupKeePassXC installs KeePassXC, turns on its secret service
+// integration and starts it minimized with niri.
+func setupKeePassXC() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		line, err := installPackage("keepassxc")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		ini := filepath.Join(homeDir, ".config", "keepassxc", "keepassxc.ini")
+		if err := updateINIFile(ini, "FdoSecrets", [][2]string{{"Enabled", "true"}}); err != nil {
+			logs = append(logs, Tf("Failed to write %s: %v", ini, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Enabled the secret service in %s: OK", ini))
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			return setSpawnAtStartup(cfg, "keepassxc", "--minimized")
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "keepassxc", path))
+		logs = append(logs, T("In KeePassXC, pick the database to expose under Database Settings → Secret Service Integration."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
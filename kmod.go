@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// kernelModule is a kernel module niri depends on. required modules are
+// needed on every machine, the others only for the matching GPU.
+type kernelModule struct {
+	name     string
+	purpose  string
+	required bool
+}
+
+// kernelModules are the modules shown by Kernel Modules.
+var kernelModules = []kernelModule{
+	{"drm", "DRM core, used by every GPU driver", true},
+	{"i915kms", "Intel graphics", false},
+	{"amdgpu", "AMD graphics (GCN and newer)", false},
+	{"radeonkms", "older AMD and ATI graphics", false},
+	{"nvidia-drm", "NVIDIA graphics (nvidia-drm-kmod)", false},
+	{"evdev", "input events for libinput", true},
+}
+
+// gpuModules maps PCI vendor IDs of display controllers to the module
+// that drives them.
+var gpuModules = map[string]string{"0x8086": "i915kms", "0x1002": "amdgpu", "0x10de": "nvidia-drm"}
+
+const loaderConfPath = "/boot/loader.conf"
+
+// loaderLoad matches a loader.conf line that loads a module at boot.
+var loaderLoad = regexp.MustCompile(`^\s*([\w-]+)_load\s*=\s*"?(?i:yes)"?`)
+
+// moduleStatus is where a kernel module is loaded from, if anywhere.
+type moduleStatus struct {
+	kernelModule
+	loaded       bool
+	inKldList    bool
+	inLoaderConf bool
+}
+
+// moduleIssue is a mismatch between the loaded and configured modules,
+// and the fix for it.
+type moduleIssue struct {
+	what  string
+	label string
+	fix   func() (string, error)
+}
+
+// loadedModules parses kldstat -v into the names of the loaded files and
+// the modules they contain, which includes those built into the kernel.
+func loadedModules() (map[string]bool, error) {
+	out, err := commandOutput(exec.Command("kldstat", "-v"))
+	if err != nil {
+		return nil, err
+	}
+	loaded := map[string]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 5 && isDigits(fields[0]):
+			loaded[strings.TrimSuffix(fields[4], ".ko")] = true
+		case len(fields) == 2 && isDigits(fields[0]):
+			loaded[fields[1]] = true
+		}
+	}
+	return loaded, nil
+}
+
+// kldList returns the modules in rc.conf's kld_list, by name.
+func kldList() map[string]bool {
+	out, _ := commandOutput(exec.Command("sysrc", "-n", "kld_list"))
+	list := map[string]bool{}
+	for _, entry := range strings.Fields(string(out)) {
+		list[strings.TrimSuffix(filepath.Base(entry), ".ko")] = true
+	}
+	return list
+}
+
+// loaderModules returns the modules loader.conf loads.
+func loaderModules() map[string]bool {
+	list := map[string]bool{}
+	f, err := os.Open(loaderConfPath)
+	if err != nil {
+		return list
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := loaderLoad.FindStringSubmatch(scanner.Text()); m != nil {
+			list[m[1]] = true
+		}
+	}
+	return list
+}
+
+// detectedGPUModules returns the GPU modules for the display controllers
+// pciconf lists.
+func detectedGPUModules() []string {
+	out, err := commandOutput(exec.Command("pciconf", "-l"))
+	if err != nil {
+		return nil
+	}
+	var modules []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "vgapci") {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if vendor, ok := strings.CutPrefix(field, "vendor="); ok && gpuModules[vendor] != "" && !containsString(modules, gpuModules[vendor]) {
+				modules = append(modules, gpuModules[vendor])
+			}
+		}
+	}
+	return modules
+}
+
+// moduleStatuses returns the status of the modules that are required,
+// loaded, configured or wanted by the GPU, and the GPU modules wanted.
+func moduleStatuses() ([]moduleStatus, []string, error) {
+	loaded, err := loadedModules()
+	if err != nil {
+		return nil, nil, err
+	}
+	kld, loader := kldList(), loaderModules()
+	gpus := detectedGPUModules()
+	var statuses []moduleStatus
+	for _, km := range kernelModules {
+		s := moduleStatus{kernelModule: km, loaded: loaded[km.name], inKldList: kld[km.name], inLoaderConf: loader[km.name]}
+		if km.required || s.loaded || s.inKldList || s.inLoaderConf || containsString(gpus, km.name) {
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses, gpus, nil
+}
+
+func loadModule(name string) (string, error) {
+	out, err := commandCombinedOutput(exec.Command("sudo", "kldload", "-n", name))
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return Tf("Loaded %s", name), nil
+}
+
+func persistModule(name string) (string, error) {
+	out, err := commandCombinedOutput(exec.Command("sudo", "sysrc", "kld_list+="+name))
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return Tf("Added %s to kld_list", name), nil
+}
+
+// moveToKldList stops loader.conf from loading a module and loads it from
+// kld_list instead.
+func moveToKldList(name string) (string, error) {
+	out, err := commandCombinedOutput(exec.Command("sudo", "sysrc", "-f", loaderConfPath, "-x", name+"_load"))
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	if _, err := persistModule(name); err != nil {
+		return "", err
+	}
+	return Tf("Moved %s from %s to kld_list", name, loaderConfPath), nil
+}
+
+// moduleIssues compares the loaded modules with the configured ones.
+func moduleIssues(statuses []moduleStatus, gpus []string) []moduleIssue {
+	var issues []moduleIssue
+	gpuLoaded := false
+	for _, s := range statuses {
+		name := s.name
+		if !s.required && s.loaded {
+			gpuLoaded = true
+		}
+		switch {
+		case !s.loaded && (s.required || s.inKldList || s.inLoaderConf):
+			issues = append(issues, moduleIssue{
+				what:  Tf("%s is not loaded", name),
+				label: Tf("Load %s now", name),
+				fix:   func() (string, error) { return loadModule(name) },
+			})
+		case s.loaded && !s.required && !s.inKldList && !s.inLoaderConf:
+			issues = append(issues, moduleIssue{
+				what:  Tf("%s is loaded but won't be after a reboot", name),
+				label: Tf("Add %s to kld_list", name),
+				fix:   func() (string, error) { return persistModule(name) },
+			})
+		}
+		if s.inLoaderConf && !s.required {
+			issues = append(issues, moduleIssue{
+				what:  Tf("%s is loaded from %s, which can hang the boot; GPU drivers belong in kld_list", name, loaderConfPath),
+				label: Tf("Move %s to kld_list", name),
+				fix:   func() (string, error) { return moveToKldList(name) },
+			})
+		}
+	}
+	if !gpuLoaded {
+		for _, name := range gpus {
+			issues = append(issues, moduleIssue{
+				what:  Tf("No GPU driver is loaded, and this machine's GPU uses %s", name),
+				label: Tf("Load %s and add it to kld_list", name),
+				fix: func() (string, error) {
+					loaded, err := loadModule(name)
+					if err != nil {
+						return "", err
+					}
+					persisted, err := persistModule(name)
+					return loaded + "\n" + persisted, err
+				},
+			})
+		}
+	}
+	return issues
+}
+
+// describeModules lays out the module table and the issues found.
+func describeModules(statuses []moduleStatus, issues []moduleIssue) string {
+	yesNo := func(b bool) string {
+		if b {
+			return T("yes")
+		}
+		return "-"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-11s %-7s %-9s %-12s %s\n", T("Module"), T("Loaded"), "kld_list", "loader.conf", T("Purpose"))
+	for _, s := range statuses {
+		fmt.Fprintf(&b, "%-11s %-7s %-9s %-12s %s\n", s.name, yesNo(s.loaded), yesNo(s.inKldList), yesNo(s.inLoaderConf), T(s.purpose))
+	}
+	fmt.Fprintln(&b)
+	if len(issues) == 0 {
+		fmt.Fprintln(&b, T("The loaded modules match the boot configuration."))
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "• %s\n", issue.what)
+	}
+	return b.String()
+}
+
+// fixModule runs the fix of one issue.
+func fixModule(issue moduleIssue) tea.Cmd {
+	return func() tea.Msg {
+		status, err := issue.fix()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to fix %s: %v", issue.what, err), err: err}
+		}
+		return statusMsg{status: status}
+	}
+}
+
+// kernelModulesView shows which DRM, GPU and input modules are loaded and
+// configured, with a fix for each mismatch.
+func kernelModulesView() tea.Cmd {
+	return func() tea.Msg {
+		statuses, gpus, err := moduleStatuses()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to run kldstat: %v", err), err: preflightFailure(err)}
+		}
+		issues := moduleIssues(statuses, gpus)
+		r := &report{title: "Kernel Modules", body: describeModules(statuses, issues), refresh: kernelModulesView()}
+		for _, issue := range issues {
+			r.actions = append(r.actions, reportAction{label: issue.label, cmd: fixModule(issue)})
+		}
+		return reportMsg{r}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const suspendSudoersPath = "/usr/local/etc/sudoers.d/nirisetup-suspend"
+
+// batteryNotifyScript polls the ACPI battery and raises a mako notification
+// once per discharge when the charge drops below the threshold.
+const batteryNotifyScript = `#!/bin/sh
+# Generated by NiriSetup: notify when the battery runs low.
+threshold=%d
+warned=0
+while sleep 60; do
+    life=$(sysctl -n hw.acpi.battery.life 2>/dev/null) || continue
+    acline=$(sysctl -n hw.acpi.acline 2>/dev/null)
+    if [ "$acline" = "0" ] && [ "$life" -le "$threshold" ]; then
+        if [ "$warned" = "0" ]; then
+            notify-send -u critical "Battery low" "${life}%% remaining"
+            warned=1
+        fi
+    else
+        warned=0
+    fi
+done
+`
+
+func newLaptopForm() *form {
+	return &form{
+		title: "Laptop Power",
+		fields: []formField{
+			{label: "Lock after (s)", value: "300"},
+			{label: "Suspend after (s, 0=off)", value: "900"},
+			{label: "On lid close", value: "Suspend", options: []string{"Suspend", "Nothing"}},
+			{label: "Low battery warning (%)", value: "15"},
+			{label: "Suspend key", value: "XF86Sleep", help: "Locks the screen and suspends. Leave it empty for no key."},
+		},
+		submit: submitLaptop,
+	}
+}
+
+func submitLaptop(values []string) (tea.Cmd, error) {
+	lock, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil || lock < 30 {
+		return nil, errors.New(T("lock timeout must be at least 30 seconds"))
+	}
+	suspend, err := strconv.Atoi(strings.TrimSpace(values[1]))
+	if err != nil || suspend < 0 || (suspend > 0 && suspend <= lock) {
+		return nil, errors.New(T("suspend timeout must be 0 or longer than the lock timeout"))
+	}
+	threshold, err := strconv.Atoi(strings.TrimSpace(values[3]))
+	if err != nil || threshold < 1 || threshold > 50 {
+		return nil, errors.New(T("battery warning must be between 1 and 50 percent"))
+	}
+	key := strings.TrimSpace(values[4])
+	if strings.ContainsAny(key, " \"{}") {
+		return nil, errors.New(T("the suspend key must be a key combination like Mod+Shift+S"))
+	}
+	return setupLaptop(lock, suspend, values[2] == "Suspend", threshold, key), nil
+}
+
+// hasBattery reports whether ACPI knows about at least one battery.
+func hasBattery() bool {
+	out, err := commandOutput(exec.Command("sysctl", "-n", "hw.acpi.battery.units"))
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	return err == nil && n > 0
+}
+
+// setupLaptop configures lid-close suspend, swayidle lock/suspend timers, a
+// suspend key and battery monitoring in waybar and mako.
+func setupLaptop(lock, suspend int, lidSuspend bool, threshold int, suspendKey string) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+
+		// Step 1: Lid close behaviour via ACPI
+		lidState := "NONE"
+		if lidSuspend {
+			lidState = "S3"
+		}
+		setting := "hw.acpi.lid_switch_state=" + lidState
+		if out, err := commandCombinedOutput(exec.Command("sudo", "sysctl", setting)); err != nil {
+			logs = append(logs, Tf("Warning: Setting %s: %s", setting, strings.TrimSpace(string(out))))
+		} else {
+			logs = append(logs, Tf("Setting %s: OK", setting))
+		}
+		if out, err := commandCombinedOutput(exec.Command("sudo", "sysrc", "-f", "/etc/sysctl.conf", setting)); err != nil {
+			logs = append(logs, Tf("Warning: Persisting %s: %s", setting, strings.TrimSpace(string(out))))
+		} else {
+			logs = append(logs, Tf("Persisting %s to /etc/sysctl.conf: OK", setting))
+		}
+
+		// Step 2: Let the video group suspend without a password so swayidle
+		// and the suspend key can
+		if suspend > 0 || suspendKey != "" {
+			if err := writeRootFile(suspendSudoersPath, "%video ALL=(root) NOPASSWD: /usr/sbin/zzz\n", 0440); err != nil {
+				logs = append(logs, Tf("Warning: Writing %s: %v", suspendSudoersPath, err))
+			} else {
+				logs = append(logs, Tf("Allowed video group to suspend via %s: OK", suspendSudoersPath))
+			}
+		}
+
+		// Step 3: Battery notifications
+		var notifier string
+		battery := hasBattery()
+		if battery {
+			line, err := installPackage("libnotify")
+			logs = append(logs, line)
+			if err == nil {
+				notifier, err = writeBatteryNotifier(threshold)
+				if err != nil {
+					logs = append(logs, Tf("Warning: Writing battery notifier: %v", err))
+				} else {
+					logs = append(logs, Tf("Wrote battery notifier to %s: OK", notifier))
+				}
+			}
+
+			path, err := updateWaybarConfig(func(cfg map[string]any) {
+				addWaybarModule(cfg, "modules-right", "battery")
+				// Plain text: battery glyphs need an icon font NiriSetup
+				// doesn't install.
+				cfg["battery"] = map[string]any{
+					"states":          map[string]any{"warning": threshold * 2, "critical": threshold},
+					"format":          "{capacity}%",
+					"format-charging": "{capacity}% charging",
+				}
+			})
+			if err != nil {
+				logs = append(logs, Tf("Warning: Adding waybar battery module: %v", err))
+			} else {
+				logs = append(logs, Tf("Added battery module to %s: OK", path))
+			}
+		} else {
+			logs = append(logs, T("No battery detected, skipping battery monitoring"))
+		}
+
+		// Step 4: Idle timers and session autostart
+		idle := []string{"swayidle", "-w",
+			"timeout", strconv.Itoa(lock), "swaylock -f",
+		}
+		if suspend > 0 {
+			idle = append(idle, "timeout", strconv.Itoa(suspend), strings.Join(suspendCommand, " "))
+		}
+		idle = append(idle, "before-sleep", "swaylock -f")
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			cfg = setSpawnAtStartup(cfg, idle...)
+			if suspendKey != "" {
+				cfg = setKDLNode(cfg, []string{"binds"}, suspendKey+" allow-when-locked=true { spawn "+kdlArgs(suspendBindAction...)+"; }")
+			}
+			if battery {
+				cfg = setSpawnAtStartup(cfg, "waybar")
+				if notifier != "" {
+					cfg = setSpawnAtStartup(cfg, notifier)
+				}
+			}
+			return cfg
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added swayidle timers to %s: OK", path))
+		if suspendKey != "" {
+			logs = append(logs, Tf("%s locks the screen and suspends", suspendKey))
+		}
+		if insideNiri() {
+			logs = append(logs, T("Run Test Suspend to check that the session survives a suspend."))
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// writeBatteryNotifier installs the low battery script in ~/.local/bin and
+// returns its path.
+func writeBatteryNotifier(threshold int) (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(homeDir, ".local", "bin")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "nirisetup-battery-notify")
+	return path, writeFile(path, []byte(fmt.Sprintf(batteryNotifyScript, threshold)), 0755)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionLauncherName is the script in ~/.local/bin that starts niri from
+// a TTY and keeps its log.
+const sessionLauncherName = "start-niri"
+
+// launchCommand is how niri is started from a TTY without the launcher.
+const launchCommand = "LIBSEAT_BACKEND=consolekit2 ck-launch-session dbus-launch niri --session"
+
+// sessionLauncherScript starts niri the way launchCommand does and copies
+// what it prints to niri.log, where Session Log and Last Session Log read
+// it. The logs of the 4 sessions before are kept as niri.log.1 to
+// niri.log.4.
+const sessionLauncherScript = `#!/bin/sh
+# Generated by NiriSetup: start niri from a TTY and keep its log.
+dir="${XDG_STATE_HOME:-$HOME/.local/state}/niri"
+log="$dir/niri.log"
+mkdir -p "$dir"
+i=4
+while [ "$i" -gt 1 ]; do
+	[ -f "$log.$((i - 1))" ] && mv -f "$log.$((i - 1))" "$log.$i"
+	i=$((i - 1))
+done
+[ -f "$log" ] && mv -f "$log" "$log.1"
+echo "$(date): starting niri" >"$log"
+# niri logs to its standard error; it still shows on the TTY once niri exits.
+export LIBSEAT_BACKEND=consolekit2
+ck-launch-session dbus-launch niri --session 2>&1 | tee -a "$log"
+`
+
+// sessionLogsKept is how many rotated logs the launcher keeps besides
+// niri.log.
+const sessionLogsKept = 4
+
+// sessionLogLines is how many lines Last Session Log shows at most.
+const sessionLogLines = 1000
+
+// sessionLauncherPath returns where the launcher is written.
+func sessionLauncherPath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "bin", sessionLauncherName), nil
+}
+
+// writeSessionLauncher writes the launcher, replacing one written by an
+// older NiriSetup.
+func writeSessionLauncher() ([]string, error) {
+	path, err := sessionLauncherPath()
+	if err != nil {
+		return []string{T("Failed to determine home directory")}, err
+	}
+	if data, err := os.ReadFile(path); err == nil && string(data) == sessionLauncherScript {
+		return []string{Tf("%s is up to date: OK", path)}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return []string{Tf("Failed to create %s: %v", filepath.Dir(path), err)}, err
+	}
+	if err := writeFile(path, []byte(sessionLauncherScript), 0755); err != nil {
+		return []string{Tf("Failed to write %s: %v", path, err)}, err
+	}
+	return []string{Tf("Wrote %s, which starts niri and saves its log: OK", path)}, nil
+}
+
+// startCommand is what to run on a TTY to start niri: the launcher once
+// Setup System has written it.
+func startCommand() string {
+	if path, err := sessionLauncherPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			return "~/.local/bin/" + sessionLauncherName
+		}
+	}
+	return launchCommand
+}
+
+// sessionLogPath returns the log of the session back sessions ago:
+// niri.log for the last one, niri.log.1 for the one before.
+func sessionLogPath(back int) (string, error) {
+	dir, err := niriLogDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "niri.log")
+	if back > 0 {
+		path += fmt.Sprintf(".%d", back)
+	}
+	return path, nil
+}
+
+// lastSessionLog lays out the end of the session log at path.
+func lastSessionLog(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(ansiEscape.ReplaceAllString(string(data), ""), "\n"), "\n")
+	var b strings.Builder
+	fmt.Fprintln(&b, Tf("Log: %s (%d lines)", path, len(lines)))
+	if info, err := os.Stat(path); err == nil {
+		fmt.Fprintln(&b, Tf("Last written: %s", info.ModTime().Format("2006-01-02 15:04:05")))
+	}
+	if len(lines) > sessionLogLines {
+		fmt.Fprintln(&b, Tf("Showing the last %d lines.", sessionLogLines))
+		lines = lines[len(lines)-sessionLogLines:]
+	}
+	fmt.Fprintln(&b)
+	b.WriteString(strings.Join(lines, "\n"))
+	return b.String(), nil
+}
+
+// lastSessionLogView shows the log the launcher saved of the session back
+// sessions ago, with the session before and the analysis of its errors a
+// key away.
+func lastSessionLogView(back int) tea.Cmd {
+	return func() tea.Msg {
+		path, err := sessionLogPath(back)
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		body, err := lastSessionLog(path)
+		if err != nil {
+			body = Tf("No session log in %s.", path) + "\n\n" +
+				Tf("Start niri from a TTY with %s, which Setup System writes, to save its log there.", "~/.local/bin/"+sessionLauncherName)
+		}
+		r := &report{title: "Last Session Log", body: body, refresh: lastSessionLogView(back)}
+		if err == nil {
+			r.actions = append(r.actions, reportAction{label: "Explain the errors", cmd: explainSessionLog(path)})
+		}
+		if before, err := sessionLogPath(back + 1); err == nil && back < sessionLogsKept {
+			if _, err := os.Stat(before); err == nil {
+				r.actions = append(r.actions, reportAction{label: "Show the session before", cmd: lastSessionLogView(back + 1)})
+			}
+		}
+		if back > 0 {
+			r.actions = append(r.actions, reportAction{label: "Show the last session", cmd: lastSessionLogView(0)})
+		}
+		return reportMsg{r}
+	}
+}
+
+// explainSessionLog shows the analysis of the session log at path.
+func explainSessionLog(path string) tea.Cmd {
+	return func() tea.Msg {
+		body, _ := runSessionLogAnalysis(path)
+		return reportMsg{&report{title: "Session Log", body: body, refresh: explainSessionLog(path)}}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// layoutValue reads the first argument of a layout setting from cfg,
+// falling back to def when it isn't set.
+func layoutValue(cfg string, path []string, name, def string) string {
+	v, ok := kdlGet(cfg, append([]string{"layout"}, path...), name)
+	if !ok || v == "" {
+		return def
+	}
+	if fields := strings.Fields(v); len(fields) > 0 {
+		return kdlUnquote(fields[0])
+	}
+	return def
+}
+
+// layoutEnabled reports whether the decoration block at path is not turned
+// off.
+func layoutEnabled(cfg string, block string) string {
+	if _, off := kdlGet(cfg, []string{"layout", block}, "off"); off {
+		return "Off"
+	}
+	return "On"
+}
+
+// columnWidths lists the proportion and fixed widths in the block at path,
+// space separated, as the layout form shows them: 0.5 for a proportion and
+// fixed 1280 for a width in pixels.
+func columnWidths(cfg string, path []string) (string, bool) {
+	from, to, ok := kdlLocate(cfg, path)
+	if !ok {
+		return "", false
+	}
+	var widths []string
+	for _, sp := range kdlChildren(cfg, from, to) {
+		switch name := kdlNodeName(cfg, sp); name {
+		case "proportion":
+			widths = append(widths, strings.TrimSpace(cfg[sp.start+len(name):sp.end]))
+		case "fixed":
+			widths = append(widths, "fixed "+strings.TrimSpace(cfg[sp.start+len(name):sp.end]))
+		}
+	}
+	return strings.Join(widths, " "), true
+}
+
+// presetWidths returns the widths of preset-column-widths.
+func presetWidths(cfg string) string {
+	if widths, ok := columnWidths(cfg, []string{"layout", "preset-column-widths"}); ok {
+		return widths
+	}
+	return "0.33333 0.5 0.66667"
+}
+
+// defaultColumnWidth returns default-column-width. Empty braces, which let
+// windows pick their width, show as an empty field.
+func defaultColumnWidth(cfg string) string {
+	if width, ok := columnWidths(cfg, []string{"layout", "default-column-width"}); ok {
+		return width
+	}
+	return "0.5"
+}
+
+// newLayoutForm prefills the layout form from the user's current config.
+func newLayoutForm() *form {
+	cfg := ""
+	if path, err := niriConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			cfg = string(data)
+		}
+	}
+	f := &form{
+		title: "Layout",
+		fields: []formField{
+			{label: "Gaps", value: layoutValue(cfg, nil, "gaps", "16")},
+			{label: "Center focused column", value: layoutValue(cfg, nil, "center-focused-column", "never"), options: []string{"never", "always", "on-overflow"}},
+			{label: "Column presets", value: presetWidths(cfg)},
+			{label: "Default column width", value: defaultColumnWidth(cfg)},
+			{label: "Focus ring", value: layoutEnabled(cfg, "focus-ring"), options: []string{"On", "Off"}},
+			{label: "Focus ring width", value: layoutValue(cfg, []string{"focus-ring"}, "width", "4")},
+			{label: "Focus ring active", value: layoutValue(cfg, []string{"focus-ring"}, "active-color", "#7fc8ff"), swatch: true},
+			{label: "Focus ring inactive", value: layoutValue(cfg, []string{"focus-ring"}, "inactive-color", "#505050"), swatch: true},
+			{label: "Border", value: layoutEnabled(cfg, "border"), options: []string{"On", "Off"}},
+			{label: "Border width", value: layoutValue(cfg, []string{"border"}, "width", "4")},
+			{label: "Border active", value: layoutValue(cfg, []string{"border"}, "active-color", "#ffc87f"), swatch: true},
+			{label: "Border inactive", value: layoutValue(cfg, []string{"border"}, "inactive-color", "#505050"), swatch: true},
+		},
+	}
+	initial := f.values()
+	f.submit = func(values []string) (tea.Cmd, error) {
+		return submitLayout(initial, values)
+	}
+	return f
+}
+
+// parseProportion checks that s is a column width fraction.
+func parseProportion(s string) (string, error) {
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || p <= 0 || p > 1 {
+		return "", fmt.Errorf("column widths must be fractions between 0 and 1 or fixed widths like fixed 1280, got %q", s)
+	}
+	return s, nil
+}
+
+// parseColumnWidths turns widths as the layout form shows them into the
+// proportion and fixed nodes niri reads.
+func parseColumnWidths(s string) ([]string, error) {
+	var nodes []string
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "fixed" {
+			if i+1 == len(fields) {
+				return nil, errors.New(T("fixed needs a width in pixels, like fixed 1280"))
+			}
+			i++
+			if n, err := strconv.Atoi(fields[i]); err != nil || n <= 0 || n > 20000 {
+				return nil, fmt.Errorf("fixed widths must be whole numbers of pixels, got %q", fields[i])
+			}
+			nodes = append(nodes, "fixed "+fields[i])
+			continue
+		}
+		if _, err := parseProportion(fields[i]); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, "proportion "+fields[i])
+	}
+	return nodes, nil
+}
+
+// submitLayout checks the form values and writes them. The column widths
+// are only written when they differ from initial, the values the form
+// started with.
+func submitLayout(initial, values []string) (tea.Cmd, error) {
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	if n, err := strconv.Atoi(values[0]); err != nil || n < 0 || n > 200 {
+		return nil, errors.New(T("gaps must be a whole number between 0 and 200"))
+	}
+	presets, err := parseColumnWidths(values[2])
+	if err != nil {
+		return nil, err
+	}
+	if len(presets) == 0 {
+		return nil, errors.New(T("enter at least one column preset"))
+	}
+	width, err := parseColumnWidths(values[3])
+	if err != nil {
+		return nil, err
+	}
+	if len(width) > 1 {
+		return nil, errors.New(T("enter one default column width"))
+	}
+	for _, i := range []int{5, 9} {
+		if n, err := strconv.Atoi(values[i]); err != nil || n < 0 || n > 50 {
+			return nil, errors.New(T("ring and border widths must be whole numbers between 0 and 50"))
+		}
+	}
+	for _, i := range []int{6, 7, 10, 11} {
+		if !isHexColor(values[i]) {
+			return nil, errors.New(Tf("colors must be hex values like #7fc8ff, got %q", values[i]))
+		}
+	}
+	if strings.Join(strings.Fields(values[2]), " ") == strings.Join(strings.Fields(initial[2]), " ") {
+		presets = nil
+	}
+	if values[3] == strings.TrimSpace(initial[3]) {
+		width = nil
+	} else if width == nil {
+		width = []string{}
+	}
+	return writeLayout(values, presets, width), nil
+}
+
+// writeLayout updates the layout block with the form values. presets and
+// width hold the nodes of preset-column-widths and default-column-width;
+// nil leaves them as they are, and an empty width lets windows pick theirs.
+func writeLayout(values []string, presets, width []string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := updateNiriConfig(func(cfg string) string {
+			layout := []string{"layout"}
+			cfg = setKDLNode(cfg, layout, "gaps "+values[0])
+			cfg = setKDLNode(cfg, layout, "center-focused-column "+kdlQuote(values[1]))
+
+			if presets != nil {
+				widths := []string{"layout", "preset-column-widths"}
+				cfg = removeKDLNode(cfg, widths, "proportion")
+				cfg = removeKDLNode(cfg, widths, "fixed")
+				for _, p := range presets {
+					cfg = addKDLNode(cfg, widths, p)
+				}
+			}
+			switch {
+			case width == nil:
+			case len(width) == 0:
+				cfg = setKDLNode(cfg, layout, "default-column-width {}")
+			default:
+				cfg = setKDLNode(cfg, layout, fmt.Sprintf("default-column-width { %s; }", width[0]))
+			}
+
+			for i, block := range []string{"focus-ring", "border"} {
+				base := 4 + i*4
+				path := []string{"layout", block}
+				if values[base] == "Off" {
+					cfg = setKDLNode(cfg, path, "off")
+				} else {
+					cfg = removeKDLNode(cfg, path, "off")
+				}
+				cfg = setKDLNode(cfg, path, "width "+values[base+1])
+				cfg = setKDLNode(cfg, path, "active-color "+kdlQuote(values[base+2]))
+				cfg = setKDLNode(cfg, path, "inactive-color "+kdlQuote(values[base+3]))
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write layout: %v", err), err: err}
+		}
+		return statusMsg{status: Tf("Layout settings written to %s", path)}
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lintIssue is a problem in config.kdl that niri validate accepts.
+type lintIssue struct {
+	line int
+	text string
+}
+
+// profileAssignment matches a variable set in ~/.profile.
+var profileAssignment = regexp.MustCompile(`^\s*(export\s+)?([A-Za-z_][A-Za-z0-9_]*)=("([^"]*)"|'([^']*)'|(\S*))`)
+
+// kdlLine returns the line number of byte offset i of s.
+func kdlLine(s string, i int) int {
+	return strings.Count(s[:i], "\n") + 1
+}
+
+// kdlBlockChildren returns the nodes in the block at path, leaving out
+// those commented out with /-.
+func kdlBlockChildren(cfg string, path []string) []kdlSpan {
+	from, to, ok := kdlLocate(cfg, path)
+	if !ok {
+		return nil
+	}
+	var spans []kdlSpan
+	for _, sp := range kdlChildren(cfg, from, to) {
+		if !strings.HasPrefix(kdlNodeName(cfg, sp), "/-") {
+			spans = append(spans, sp)
+		}
+	}
+	return spans
+}
+
+// duplicateBinds reports key combinations bound more than once, such as
+// Mod+Shift+Q and Shift+Mod+q.
+func duplicateBinds(cfg string) []lintIssue {
+	var issues []lintIssue
+	seen := map[string]int{}
+	for _, sp := range kdlBlockChildren(cfg, []string{"binds"}) {
+		name := kdlNodeName(cfg, sp)
+		key := normalizeKey(name)
+		line := kdlLine(cfg, sp.start)
+		if first, ok := seen[key]; ok {
+			issues = append(issues, lintIssue{line, Tf("%s is already bound on line %d", name, first)})
+			continue
+		}
+		seen[key] = line
+	}
+	return issues
+}
+
+// startupProgram returns the program a spawn-at-startup node runs,
+// looking inside sh -c and spawn-sh-at-startup commands.
+func startupProgram(cfg string, sp kdlSpan) string {
+	arg := kdlFirstArg(cfg, sp)
+	if kdlNodeName(cfg, sp) == "spawn-sh-at-startup" {
+		fields := strings.Fields(arg)
+		if len(fields) == 0 {
+			return ""
+		}
+		return fields[0]
+	}
+	if arg == "sh" {
+		rest := cfg[sp.start:sp.end]
+		if i := strings.Index(rest, `"-c" "`); i >= 0 {
+			end := kdlSkip(rest, i+5)
+			if fields := strings.Fields(kdlUnquote(rest[i+5 : end])); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return arg
+}
+
+// missingStartupPrograms reports spawn-at-startup programs that aren't
+// installed.
+func missingStartupPrograms(cfg string) []lintIssue {
+	var issues []lintIssue
+	for _, sp := range kdlBlockChildren(cfg, nil) {
+		if name := kdlNodeName(cfg, sp); name != "spawn-at-startup" && name != "spawn-sh-at-startup" {
+			continue
+		}
+		prog := expandHome(startupProgram(cfg, sp))
+		if prog == "" || strings.ContainsAny(prog, "$=") {
+			continue
+		}
+		found := hasCommand(prog)
+		if filepath.IsAbs(prog) {
+			_, err := os.Stat(prog)
+			found = err == nil
+		}
+		if !found {
+			issues = append(issues, lintIssue{kdlLine(cfg, sp.start), Tf("%s is started at login but is not installed", prog)})
+		}
+	}
+	return issues
+}
+
+// unknownOutputs reports output blocks that match none of the connected
+// displays, by connector name or by make, model and serial.
+func unknownOutputs(cfg string, connected []niriOutput) []lintIssue {
+	var names []string
+	for _, o := range connected {
+		names = append(names, strings.ToLower(o.Name), strings.ToLower(o.Make+" "+o.Model))
+		if o.Serial != nil {
+			names = append(names, strings.ToLower(o.Make+" "+o.Model+" "+*o.Serial))
+		}
+	}
+	var issues []lintIssue
+	for _, sp := range kdlBlockChildren(cfg, nil) {
+		if kdlNodeName(cfg, sp) != "output" {
+			continue
+		}
+		name := kdlFirstArg(cfg, sp)
+		if name != "" && !containsString(names, strings.ToLower(name)) {
+			var current []string
+			for _, o := range connected {
+				current = append(current, o.Name)
+			}
+			issues = append(issues, lintIssue{kdlLine(cfg, sp.start), Tf("output %q doesn't match any connected display (connected: %s)", name, strings.Join(current, ", "))})
+		}
+	}
+	return issues
+}
+
+// profileVariables returns the variables ~/.profile sets.
+func profileVariables(profile string) map[string]string {
+	vars := map[string]string{}
+	for _, line := range strings.Split(profile, "\n") {
+		if m := profileAssignment.FindStringSubmatch(line); m != nil {
+			vars[m[2]] = m[4] + m[5] + m[6]
+		}
+	}
+	return vars
+}
+
+// conflictingEnvironment reports variables that ~/.profile and the
+// environment block of config.kdl set to different values.
+func conflictingEnvironment(cfg, profile string) []lintIssue {
+	vars := profileVariables(profile)
+	var issues []lintIssue
+	for _, sp := range kdlBlockChildren(cfg, []string{"environment"}) {
+		name := kdlNodeName(cfg, sp)
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if kdlValue := kdlFirstArg(cfg, sp); kdlValue != value {
+			issues = append(issues, lintIssue{kdlLine(cfg, sp.start), Tf("%s is %q here but %q in ~/.profile; programs niri starts get %q", name, kdlValue, value, kdlValue)})
+		}
+	}
+	return issues
+}
+
+// lintConfig runs every check on cfg. connected is nil outside a niri
+// session, when the displays can't be listed.
+func lintConfig(cfg, profile string, connected []niriOutput) []lintIssue {
+	var issues []lintIssue
+	issues = append(issues, duplicateBinds(cfg)...)
+	issues = append(issues, missingStartupPrograms(cfg)...)
+	if connected != nil {
+		issues = append(issues, unknownOutputs(cfg, connected)...)
+	}
+	issues = append(issues, conflictingEnvironment(cfg, profile)...)
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].line < issues[j].line })
+	return issues
+}
+
+// runLint lints the user's config.kdl.
+func runLint() (string, error) {
+	path, err := niriConfigPath()
+	if err != nil {
+		return err.Error(), preflightFailure(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Tf("Failed to read %s: %v", path, err), preflightFailure(err)
+	}
+	var profile []byte
+	if homeDir, err := userHomeDir(); err == nil {
+		profile, _ = os.ReadFile(filepath.Join(homeDir, ".profile"))
+	}
+	var connected []niriOutput
+	if niriRunning() {
+		connected, _ = niriOutputs()
+	}
+
+	issues := lintConfig(string(data), string(profile), connected)
+	var b strings.Builder
+	if connected == nil {
+		fmt.Fprintln(&b, T("Output names are only checked inside a niri session."))
+	}
+	if len(issues) == 0 {
+		fmt.Fprint(&b, Tf("No problems found in %s", path))
+		return b.String(), nil
+	}
+	fmt.Fprintln(&b, Tf("%d problems found in %s:", len(issues), path))
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "  %s %s\n", Tf("line %d:", issue.line), issue.text)
+	}
+	return strings.TrimRight(b.String(), "\n"), errors.New("lint problems found")
+}
+
+// lintNiriConfig shows the lint report.
+func lintNiriConfig() tea.Cmd {
+	return func() tea.Msg {
+		body, err := runLint()
+		if exitCode(err) == exitPreflight {
+			return statusMsg{status: body, err: err}
+		}
+		return reportMsg{&report{title: "Lint Config", body: body, refresh: lintNiriConfig()}}
+	}
+}
@@ -0,0 +1,1293 @@
+package main
+
+// frMessages is the French translation of the UI.
+var frMessages = map[string]string{
+	// Menu and common screens
+	"Niri Setup Assistant for GhostBSD":              "Assistant de configuration Niri pour GhostBSD",
+	"niri session detected: session actions enabled": "session niri détectée : actions de session activées",
+	"Unknown state!":        "État inconnu !",
+	"Installing Niri...":    "Installation de Niri...",
+	"Please wait...":        "Veuillez patienter...",
+	"First-run Wizard":      "Assistant de premier démarrage",
+	"Install Niri":          "Installer Niri",
+	"Setup System":          "Configurer le système",
+	"Configure Niri":        "Configurer Niri",
+	"Layout":                "Disposition",
+	"Animations":            "Animations",
+	"Night Light":           "Éclairage nocturne",
+	"Function Keys":         "Touches de fonction",
+	"Media Keys":            "Touches multimédia",
+	"Laptop Power":          "Alimentation portable",
+	"Monitor Profiles":      "Profils d'écrans",
+	"Arrange Monitors":      "Disposer les écrans",
+	"HiDPI Scaling":         "Mise à l'échelle HiDPI",
+	"Output Settings":       "Réglages des sorties",
+	"Active Session":        "Session active",
+	"Validate Config":       "Valider la configuration",
+	"Save Logs":             "Enregistrer le journal",
+	"Exit":                  "Quitter",
+	"Please confirm":        "Veuillez confirmer",
+	"y continue • n cancel": "y continuer • n annuler",
+	"Applying %s...":        "Application de %s...",
+	"↑/↓ move • ←/→ choose • enter apply • esc cancel": "↑/↓ déplacer • ←/→ choisir • entrée appliquer • échap annuler",
+	"↑/↓ scroll • esc back":                            "↑/↓ défiler • échap retour",
+	"↑/↓ scroll • r refresh • esc back":                "↑/↓ défiler • r actualiser • échap retour",
+	"lines %d-%d of %d":                                "lignes %d-%d sur %d",
+	"On":                                               "Activé",
+	"Off":                                              "Désactivé",
+	"Yes":                                              "Oui",
+	"No":                                               "Non",
+	"none":                                             "aucun",
+
+	// Action messages
+	"Configuring Niri...":                      "Configuration de Niri...",
+	"Setting up brightness and volume keys...": "Configuration des touches de luminosité et de volume...",
+	"Setting up media player keys...":          "Configuration des touches multimédia...",
+	"Generating kanshi monitor profiles...":    "Génération des profils d'écrans kanshi...",
+	"Detecting outputs...":                     "Détection des sorties...",
+	"Detecting displays...":                    "Détection des écrans...",
+	"Querying niri...":                         "Interrogation de niri...",
+	"Validating Niri config...":                "Validation de la configuration Niri...",
+	"Saving logs...":                           "Enregistrement du journal...",
+	"Writing output positions...":              "Écriture des positions des sorties...",
+	"niri is running. Configure Niri replaces the live config.kdl with the template, and the running session will reload it immediately.\n\nReplace the live config?": "niri est en cours d'exécution. Configurer Niri remplace le config.kdl actif par le modèle, et la session en cours le rechargera immédiatement.\n\nRemplacer la configuration active ?",
+
+	// Packages and system setup
+	"Already installed: %s":                                        "Déjà installé : %s",
+	"Failed to install %s: %s":                                     "Échec de l'installation de %s : %s",
+	"Successfully installed %s":                                    "%s installé avec succès",
+	"\nFailed packages (%d): %s":                                   "\nPaquets en échec (%d) : %s",
+	"Warning: %s: %s":                                              "Avertissement : %s : %s",
+	"%s: already running":                                          "%s : déjà en cours d'exécution",
+	"%s: OK":                                                       "%s : OK",
+	"Warning: Adding user to video group: %s":                      "Avertissement : ajout de l'utilisateur au groupe video : %s",
+	"Added user '%s' to video group: OK":                           "Utilisateur « %s » ajouté au groupe video : OK",
+	"Warning: Could not determine current user for group setup":    "Avertissement : impossible de déterminer l'utilisateur courant pour les groupes",
+	"Loading DRM kernel module: already loaded":                    "Chargement du module noyau DRM : déjà chargé",
+	"Warning: Loading DRM kernel module: %s":                       "Avertissement : chargement du module noyau DRM : %s",
+	"Loading DRM kernel module: OK":                                "Chargement du module noyau DRM : OK",
+	"Warning: Persisting DRM module to boot: %s":                   "Avertissement : ajout du module DRM au démarrage : %s",
+	"Persisting DRM module to boot: OK":                            "Ajout du module DRM au démarrage : OK",
+	"Warning: Could not write to %s: %v":                           "Avertissement : impossible d'écrire dans %s : %v",
+	"Added XDG_RUNTIME_DIR to %s: OK":                              "XDG_RUNTIME_DIR ajouté à %s : OK",
+	"XDG_RUNTIME_DIR already in .profile: OK":                      "XDG_RUNTIME_DIR déjà présent dans .profile : OK",
+	"Added LIBSEAT_BACKEND=consolekit2 to .profile: OK":            "LIBSEAT_BACKEND=consolekit2 ajouté à .profile : OK",
+	"LIBSEAT_BACKEND already in .profile: OK":                      "LIBSEAT_BACKEND déjà présent dans .profile : OK",
+	"Found DRM render device: %s":                                  "Périphérique de rendu DRM trouvé : %s",
+	"Warning: Cannot access %s: %v (check video group membership)": "Avertissement : accès impossible à %s : %v (vérifiez l'appartenance au groupe video)",
+	"DRM render device %s is accessible: OK":                       "Le périphérique de rendu DRM %s est accessible : OK",
+	"Warning: No DRM render device found in /dev/dri/":             "Avertissement : aucun périphérique de rendu DRM dans /dev/dri/",
+	"System setup complete. You may need to log out and back in for group changes to take effect.": "Configuration du système terminée. Déconnectez-vous puis reconnectez-vous pour que les changements de groupe prennent effet.",
+	"To start niri, switch to a TTY (Ctrl+Alt+F2) and run:":                                        "Pour lancer niri, passez sur un TTY (Ctrl+Alt+F2) et exécutez :",
+
+	// Configuration
+	"Failed to determine home directory":                              "Impossible de déterminer le répertoire personnel",
+	"Failed to create config directory: %v":                           "Impossible de créer le répertoire de configuration : %v",
+	"Failed to determine executable path":                             "Impossible de déterminer le chemin de l'exécutable",
+	"config.kdl not found next to executable or in current directory": "config.kdl introuvable à côté de l'exécutable ou dans le répertoire courant",
+	"Failed to read source config: %v":                                "Impossible de lire la configuration source : %v",
+	"Failed to write config: %v":                                      "Impossible d'écrire la configuration : %v",
+	"Validation failed: %s":                                           "Échec de la validation : %s",
+	"Niri configuration is valid.":                                    "La configuration Niri est valide.",
+	"Failed to open log file for writing":                             "Impossible d'ouvrir le journal en écriture",
+	"Failed to write to log file":                                     "Impossible d'écrire dans le journal",
+	"Logs saved to %s":                                                "Journal enregistré dans %s",
+	"Failed to update config: %v":                                     "Impossible de mettre à jour la configuration : %v",
+	"Updated %s":                                                      "%s mis à jour",
+	"Failed to write %s: %v":                                          "Impossible d'écrire %s : %v",
+
+	// Layout
+	"Gaps":                  "Espacement",
+	"Center focused column": "Centrer la colonne active",
+	"Column presets":        "Largeurs prédéfinies",
+	"Default column width":  "Largeur de colonne par défaut",
+	"Focus ring":            "Anneau de focus",
+	"Focus ring width":      "Largeur de l'anneau",
+	"Focus ring active":     "Anneau actif",
+	"Focus ring inactive":   "Anneau inactif",
+	"Border":                "Bordure",
+	"Border width":          "Largeur de bordure",
+	"Border active":         "Bordure active",
+	"Border inactive":       "Bordure inactive",
+	"never":                 "jamais",
+	"always":                "toujours",
+	"on-overflow":           "si débordement",
+	"gaps must be a whole number between 0 and 200":                 "l'espacement doit être un entier entre 0 et 200",
+	"enter at least one column preset":                              "indiquez au moins une largeur prédéfinie",
+	"ring and border widths must be whole numbers between 0 and 50": "les largeurs d'anneau et de bordure doivent être des entiers entre 0 et 50",
+	"colors must be hex values like #7fc8ff, got %q":                "les couleurs doivent être hexadécimales comme #7fc8ff, reçu %q",
+	"Failed to write layout: %v":                                    "Impossible d'écrire la disposition : %v",
+	"Layout settings written to %s":                                 "Réglages de disposition écrits dans %s",
+
+	// Animations
+	"Slowdown factor":      "Facteur de ralentissement",
+	"Easing duration (ms)": "Durée d'accélération (ms)",
+	"slowdown must be a number above 0 and at most 20": "le ralentissement doit être un nombre supérieur à 0 et au plus 20",
+	"easing duration must be between 10 and 5000 ms":   "la durée d'accélération doit être entre 10 et 5000 ms",
+	"Failed to write animation settings: %v":           "Impossible d'écrire les réglages d'animation : %v",
+	"Animation settings written to %s":                 "Réglages d'animation écrits dans %s",
+
+	// Night light
+	"Night Light (wlsunset)": "Éclairage nocturne (wlsunset)",
+	"City":                   "Ville",
+	"Custom":                 "Personnalisé",
+	"Latitude (custom)":      "Latitude (perso.)",
+	"Longitude (custom)":     "Longitude (perso.)",
+	"Day temperature (K)":    "Température de jour (K)",
+	"Night temperature (K)":  "Température de nuit (K)",
+	"latitude must be a number between -90 and 90":         "la latitude doit être un nombre entre -90 et 90",
+	"longitude must be a number between -180 and 180":      "la longitude doit être un nombre entre -180 et 180",
+	"day temperature must be between 1000 and 10000":       "la température de jour doit être entre 1000 et 10000",
+	"night temperature must be between 1000 and 10000":     "la température de nuit doit être entre 1000 et 10000",
+	"night temperature must be lower than day temperature": "la température de nuit doit être inférieure à celle de jour",
+	"Failed to configure wlsunset: %v":                     "Impossible de configurer wlsunset : %v",
+	"wlsunset will start with: %s\nUpdated %s":             "wlsunset démarrera avec : %s\n%s mis à jour",
+
+	// Keys
+	"No backlight device in /dev/backlight, skipping brightness keys": "Aucun rétroéclairage dans /dev/backlight, touches de luminosité ignorées",
+	"Using backlight(8) for brightness keys":                          "Utilisation de backlight(8) pour la luminosité",
+	"Warning: No brightness tool available, skipping brightness keys": "Avertissement : aucun outil de luminosité, touches de luminosité ignorées",
+	"Using wpctl (PipeWire) for volume keys":                          "Utilisation de wpctl (PipeWire) pour le volume",
+	"Using mixer(8) for volume keys":                                  "Utilisation de mixer(8) pour le volume",
+	"Warning: Neither mixer nor wpctl found, skipping volume keys":    "Avertissement : ni mixer ni wpctl trouvés, touches de volume ignorées",
+	"Wrote %d key bindings to %s":                                     "%d raccourcis écrits dans %s",
+	"Bound media player keys to playerctl in %s":                      "Touches multimédia associées à playerctl dans %s",
+
+	// Laptop power
+	"Lock after (s)":           "Verrouiller après (s)",
+	"Suspend after (s, 0=off)": "Mettre en veille après (s, 0=jamais)",
+	"On lid close":             "À la fermeture du capot",
+	"Low battery warning (%)":  "Alerte batterie faible (%)",
+	"Suspend":                  "Mise en veille",
+	"Nothing":                  "Rien",
+	"lock timeout must be at least 30 seconds":                  "le délai de verrouillage doit être d'au moins 30 secondes",
+	"suspend timeout must be 0 or longer than the lock timeout": "le délai de mise en veille doit être 0 ou plus long que celui du verrouillage",
+	"battery warning must be between 1 and 50 percent":          "l'alerte batterie doit être entre 1 et 50 pour cent",
+	"Warning: Setting %s: %s":                                   "Avertissement : réglage de %s : %s",
+	"Setting %s: OK":                                            "Réglage de %s : OK",
+	"Warning: Persisting %s: %s":                                "Avertissement : enregistrement de %s : %s",
+	"Persisting %s to /etc/sysctl.conf: OK":                     "Enregistrement de %s dans /etc/sysctl.conf : OK",
+	"Warning: Writing %s: %v":                                   "Avertissement : écriture de %s : %v",
+	"Allowed video group to suspend via %s: OK":                 "Mise en veille autorisée au groupe video via %s : OK",
+	"Warning: Writing battery notifier: %v":                     "Avertissement : écriture de l'alerte batterie : %v",
+	"Wrote battery notifier to %s: OK":                          "Alerte batterie écrite dans %s : OK",
+	"Warning: Adding waybar battery module: %v":                 "Avertissement : ajout du module batterie de waybar : %v",
+	"Added battery module to %s: OK":                            "Module batterie ajouté à %s : OK",
+	"No battery detected, skipping battery monitoring":          "Aucune batterie détectée, surveillance de la batterie ignorée",
+	"Added swayidle timers to %s: OK":                           "Minuteries swayidle ajoutées à %s : OK",
+
+	// Outputs
+	"Failed to query outputs, run this from inside a niri session: %v": "Impossible d'interroger les sorties, lancez ceci depuis une session niri : %v",
+	"At least two enabled outputs are needed to arrange them":          "Il faut au moins deux sorties actives pour les disposer",
+	"Select another output to move it around the primary":              "Sélectionnez une autre sortie pour la déplacer autour de la principale",
+	"Failed to write output positions: %v":                             "Impossible d'écrire les positions des sorties : %v",
+	"%s: position x=%d y=%d":                                           "%s : position x=%d y=%d",
+	"Primary output: %s":                                               "Sortie principale : %s",
+	"primary":                                                          "principale",
+	"%s primary":                                                       "%s la principale",
+	"right of":                                                         "à droite de",
+	"left of":                                                          "à gauche de",
+	"above":                                                            "au-dessus de",
+	"below":                                                            "au-dessous de",
+	"tab select • arrows place • r rotate • f flip • p primary • enter save • esc cancel": "tab choisir • flèches placer • r pivoter • f retourner • p principale • entrée enregistrer • échap annuler",
+	"Failed to create kanshi config directory: %v":                                        "Impossible de créer le répertoire de configuration kanshi : %v",
+	"Backed up existing kanshi config to %s.bak":                                          "Configuration kanshi existante sauvegardée dans %s.bak",
+	"Wrote kanshi profiles to %s":                                                         "Profils kanshi écrits dans %s",
+	"Added kanshi to spawn-at-startup in %s":                                              "kanshi ajouté au démarrage dans %s",
+	"No outputs detected":                                                                 "Aucune sortie détectée",
+	"scale for %s must be between 0.5 and 4":                                              "l'échelle de %s doit être entre 0,5 et 4",
+	"cursor size must be between 8 and 128":                                               "la taille du curseur doit être entre 8 et 128",
+	"%s: scale %s":                                                                        "%s : échelle %s",
+	"Cursor size: %d":                                                                     "Taille du curseur : %d",
+	"Cursor size":                                                                         "Taille du curseur",
+	"Toolkit env vars":                                                                    "Variables des toolkits",
+	"GDK_SCALE=%d for X11 GTK apps":                                                       "GDK_SCALE=%d pour les applications GTK X11",
+	"Enabled Qt high-DPI scaling":                                                         "Mise à l'échelle haute densité de Qt activée",
+	"Failed to write scaling settings: %v":                                                "Impossible d'écrire les réglages d'échelle : %v",
+	"Failed to write output settings: %v":                                                 "Impossible d'écrire les réglages des sorties : %v",
+	"On demand":                                                                           "À la demande",
+
+	// Active session
+	"Cannot reach niri, is a session running? %v": "Impossible de joindre niri, une session est-elle lancée ? %v",
+	"Failed to query workspaces: %v":              "Impossible d'interroger les espaces de travail : %v",
+	"Failed to query windows: %v":                 "Impossible d'interroger les fenêtres : %v",
+	"Focused window:":                             "Fenêtre active :",
+	"(none)":                                      "(aucune)",
+	"Outputs:":                                    "Sorties :",
+	"%s: disabled":                                "%s : désactivée",
+	"Workspaces:":                                 "Espaces de travail :",
+	"%s on %s":                                    "%s sur %s",
+	"%d windows, * focused, + active on its output": "%d fenêtres, * active, + visible sur sa sortie",
+
+	// First-run wizard
+	"First-run Wizard (%d/%d)": "Assistant de premier démarrage (%d/%d)",
+	"%s (skipped)":             "%s (ignoré)",
+	"Install packages":         "Installer les paquets",
+	"Installs niri, the Wayland helpers and the desktop components with pkg, bootstrapping pkg first on a fresh system.": "Installe niri, les outils Wayland et les composants du bureau avec pkg, en installant d'abord pkg sur un système neuf.",
+	"Set up the system": "Configurer le système",
+	"Enables dbus and seatd, loads the DRM module, adds you to the video group and prepares your login environment.": "Active dbus et seatd, charge le module DRM, vous ajoute au groupe video et prépare votre environnement de connexion.",
+	"Choose components": "Choisir les composants",
+	"Pick optional extras to set up along with the config.": "Choisissez les extras facultatifs à configurer avec la configuration.",
+	"Write the config": "Écrire la configuration",
+	"Copies the niri config template to ~/.config/niri/config.kdl and applies the chosen components.": "Copie le modèle de configuration niri dans ~/.config/niri/config.kdl et applique les composants choisis.",
+	"Validate the config":                        "Valider la configuration",
+	"Checks the new config with niri validate.":  "Vérifie la nouvelle configuration avec niri validate.",
+	"How to log in":                              "Comment se connecter",
+	"Shows how to start your new niri session.":  "Explique comment lancer votre nouvelle session niri.",
+	"Function keys":                              "Touches de fonction",
+	"Media keys":                                 "Touches multimédia",
+	"Laptop power":                               "Alimentation portable",
+	"Selected components: %s":                    "Composants choisis : %s",
+	"components failed: %s":                      "composants en échec : %s",
+	"enter run step • s skip • esc leave wizard": "entrée lancer l'étape • s ignorer • échap quitter l'assistant",
+	"enter retry • s skip • esc leave wizard":    "entrée réessayer • s ignorer • échap quitter l'assistant",
+	"enter finish":                               "entrée terminer",
+	"Setup is complete. To start niri:\n\n  1. Log out and back in so group changes take effect.\n  2. Switch to a TTY (Ctrl+Alt+F2) and log in.\n  3. Run:\n     %s\n\nInside niri, press Mod+Shift+/ for a list of important hotkeys.": "La configuration est terminée. Pour lancer niri :\n\n  1. Déconnectez-vous puis reconnectez-vous pour appliquer les changements de groupe.\n  2. Passez sur un TTY (Ctrl+Alt+F2) et connectez-vous.\n  3. Exécutez :\n     %s\n\nDans niri, appuyez sur Mod+Maj+/ pour la liste des raccourcis importants.",
+
+	// Plain mode
+	"(current)":               "(actuel)",
+	"(y/n)":                   "(o/n)",
+	"Error:":                  "Erreur :",
+	"Continue?":               "Continuer ?",
+	"Choose 1-%d":             "Choisissez 1-%d",
+	"pending":                 "à faire",
+	"done":                    "terminé",
+	"failed":                  "échec",
+	"skipped":                 "ignoré",
+	"next":                    "suivant",
+	"Place %s:":               "Placer %s :",
+	"Primary output:":         "Sortie principale :",
+	"Choose an option (1-%d)": "Choisissez une option (1-%d)",
+	"Please enter a number between 1 and %d.":                         "Saisissez un nombre entre 1 et %d.",
+	"Press enter to keep the value shown in brackets.":                "Appuyez sur entrée pour garder la valeur entre crochets.",
+	"Apply these settings?":                                           "Appliquer ces réglages ?",
+	"Write these positions?":                                          "Écrire ces positions ?",
+	"Press enter to go back.":                                         "Appuyez sur entrée pour revenir.",
+	"Type r to refresh, or press enter to go back:":                   "Tapez r pour actualiser, ou appuyez sur entrée pour revenir :",
+	"Press enter to finish.":                                          "Appuyez sur entrée pour terminer.",
+	"Press enter to run this step, s to skip, q to leave the wizard:": "Appuyez sur entrée pour lancer cette étape, s pour l'ignorer, q pour quitter l'assistant :",
+	"Press enter to retry, s to skip, q to leave the wizard:":         "Appuyez sur entrée pour réessayer, s pour ignorer, q pour quitter l'assistant :",
+
+	// Export
+	"Failed to create %s: %v":               "Impossible de créer %s : %v",
+	"Wrote Ansible playbook to %s":          "Playbook Ansible écrit dans %s",
+	"Export Environment":                    "Exporter l'environnement",
+	"Import Environment":                    "Importer l'environnement",
+	"Archive":                               "Archive",
+	"enter a file name for the archive":     "indiquez un nom de fichier pour l'archive",
+	"cannot read %s":                        "impossible de lire %s",
+	"Failed to list installed packages: %v": "Impossible de lister les paquets installés : %v",
+	"Failed to read rc.conf settings: %v":   "Impossible de lire les réglages de rc.conf : %v",
+	"Exported %d packages, %d rc.conf settings and %d config files to %s": "%d paquets, %d réglages rc.conf et %d fichiers de configuration exportés dans %s",
+	"Failed to read %s: %v": "Impossible de lire %s : %v",
+	"Restored %s":           "%s restauré",
+	"Imported %d packages, %d rc.conf settings and %d config files from %s": "%d paquets, %d réglages rc.conf et %d fichiers de configuration importés depuis %s",
+
+	// Remote setup
+	"Remote Setup":     "Installation à distance",
+	"Host (user@host)": "Hôte (utilisateur@hôte)",
+	"ssh and scp are needed to set up a remote machine":                                                        "ssh et scp sont nécessaires pour configurer une machine distante",
+	"NiriSetup copies itself to the remote machine, so it must be built for FreeBSD (GOOS=freebsd) to do that": "NiriSetup se copie sur la machine distante et doit donc être compilé pour FreeBSD (GOOS=freebsd)",
+	"Checking %s...":                                         "Vérification de %s...",
+	"Failed to connect to %s: %v":                            "Impossible de se connecter à %s : %v",
+	"%s runs %s, but this NiriSetup is built for FreeBSD %s": "%s fonctionne sous %s, mais ce NiriSetup est compilé pour FreeBSD %s",
+	"Copying NiriSetup to %s:%s...":                          "Copie de NiriSetup vers %s:%s...",
+	"Failed to prepare %s: %s":                               "Impossible de préparer %s : %s",
+	"Failed to copy NiriSetup to %s: %s":                     "Impossible de copier NiriSetup vers %s : %s",
+	"Finished setting up %s":                                 "Configuration de %s terminée",
+	"Lost the connection to %s":                              "Connexion à %s perdue",
+	"Setting up %s failed":                                   "Échec de la configuration de %s",
+	"Failed to run ssh: %v":                                  "Impossible de lancer ssh : %v",
+	"enter the machine to set up as user@host":               "indiquez la machine à configurer sous la forme utilisateur@hôte",
+	"Setting up %s failed (%s)":                              "Échec de la configuration de %s (%s)",
+
+	// Configuration template
+	"Niri configuration copied to %s":                "Configuration Niri copiée dans %s",
+	"DRM render device set to: %s":                   "Périphérique de rendu DRM défini : %s",
+	"System-wide Config":                             "Configuration système",
+	"Installing the system-wide config...":           "Installation de la configuration système...",
+	"Failed to back up %s: %s":                       "Impossible de sauvegarder %s : %s",
+	"Backed up the previous default to %s.bak":       "Configuration par défaut précédente sauvegardée dans %s.bak",
+	"Installed the system-wide default config to %s": "Configuration par défaut du système installée dans %s",
+	"Users without their own ~/.config/niri/config.kdl now start with this config.": "Les utilisateurs sans ~/.config/niri/config.kdl démarrent désormais avec cette configuration.",
+
+	// Boot environments
+	"Setting up the system...":                                      "Configuration du système...",
+	"Rollback Setup":                                                "Annuler l'installation",
+	"Looking for boot environments...":                              "Recherche des environnements de démarrage...",
+	"Activating boot environment...":                                "Activation de l'environnement de démarrage...",
+	"y yes • n no • esc cancel":                                     "y oui • n non • échap annuler",
+	"Pass --yes to go ahead.":                                       "Ajoutez --yes pour continuer.",
+	"Failed to create boot environment %s, nothing was changed: %v": "Impossible de créer l'environnement de démarrage %s, rien n'a été modifié : %v",
+	"Created boot environment %s (undo with Rollback Setup)":        "Environnement de démarrage %s créé (annulable avec Annuler l'installation)",
+	"This system runs on ZFS. Create a boot environment first, so the whole setup can be undone with Rollback Setup?":                            "Ce système utilise ZFS. Créer d'abord un environnement de démarrage, pour pouvoir tout annuler avec Annuler l'installation ?",
+	"Boot environments need a ZFS root file system and bectl":                                                                                    "Les environnements de démarrage nécessitent une racine ZFS et bectl",
+	"Failed to list boot environments: %v":                                                                                                       "Impossible de lister les environnements de démarrage : %v",
+	"There is no boot environment from before a NiriSetup run":                                                                                   "Aucun environnement de démarrage antérieur à NiriSetup",
+	"Activate boot environment %s? After a reboot the system is back to how it was before that setup, and all changes made since then are gone.": "Activer l'environnement de démarrage %s ? Après un redémarrage, le système revient à son état d'avant l'installation et toutes les modifications faites depuis sont perdues.",
+	"Failed to activate %s: %s": "Impossible d'activer %s : %s",
+	"Boot environment %s will be used from the next boot. Reboot to finish the rollback.": "L'environnement de démarrage %s sera utilisé au prochain démarrage. Redémarrez pour terminer l'annulation.",
+
+	// Security audit
+	"Security Audit":                                                          "Audit de sécurité",
+	"Checking for known vulnerabilities...":                                   "Recherche de vulnérabilités connues...",
+	"pkg is needed to audit the installed packages":                           "pkg est nécessaire pour auditer les paquets installés",
+	"None of the niri packages are installed yet, there is nothing to audit":  "Aucun paquet niri n'est encore installé, il n'y a rien à auditer",
+	"Failed to run pkg audit: %v":                                             "Échec de pkg audit : %v",
+	"Audited %d packages: niri, its desktop packages and their dependencies.": "%d paquets audités : niri, ses paquets de bureau et leurs dépendances.",
+	"%s %s is vulnerable:":                                                    "%s %s est vulnérable :",
+	"Affected:":                                                               "Concerné :",
+	"Fixed by updating to %s: sudo pkg upgrade %s":                            "Corrigé par la mise à jour vers %s : sudo pkg upgrade %s",
+	"The repository has %s, but it is still affected":                         "Le dépôt propose %s, mais cette version est aussi concernée",
+	"No fixed version in the repository yet":                                  "Pas encore de version corrigée dans le dépôt",
+	"No known vulnerabilities.":                                               "Aucune vulnérabilité connue.",
+	"%d vulnerable packages, %d without a fixed version yet.":                 "%d paquets vulnérables, dont %d sans version corrigée pour l'instant.",
+
+	// Package locks
+	"Update Niri":      "Mettre à jour Niri",
+	"Package Lock":     "Verrouillage des paquets",
+	"Updating Niri...": "Mise à jour de Niri...",
+	"niri packages":    "Paquets niri",
+	"Locked":           "Verrouillés",
+	"Unlocked":         "Déverrouillés",
+	"None of the niri packages are installed yet":                                "Aucun paquet niri n'est encore installé",
+	"Failed to change the package locks: %v":                                     "Impossible de modifier le verrouillage des paquets : %v",
+	"Locked %d packages, pkg upgrade now leaves them alone: %s":                  "%d paquets verrouillés, pkg upgrade ne les modifie plus : %s",
+	"Unlocked %d packages: %s":                                                   "%d paquets déverrouillés : %s",
+	"Failed to unlock the niri packages: %v":                                     "Impossible de déverrouiller les paquets niri : %v",
+	"Unlocked %d packages for the update and locked them again":                  "%d paquets déverrouillés pour la mise à jour puis verrouillés à nouveau",
+	"Warning: Failed to lock the niri packages again: %v":                        "Avertissement : impossible de verrouiller à nouveau les paquets niri : %v",
+	"Failed to update the niri packages: %s":                                     "Échec de la mise à jour des paquets niri : %s",
+	"Updated the niri packages (%d checked)":                                     "Paquets niri mis à jour (%d vérifiés)",
+	"Warning: Failed to lock the niri packages: %v":                              "Avertissement : impossible de verrouiller les paquets niri : %v",
+	"Locked the niri packages, pkg upgrade leaves them alone (see Package Lock)": "Paquets niri verrouillés, pkg upgrade ne les modifie plus (voir Verrouillage des paquets)",
+
+	// Desktop conflicts
+	"Desktop Conflicts":                     "Conflits de bureau",
+	"Looking for other desktop setups...":   "Recherche d'autres environnements de bureau...",
+	"Setting up coexistence...":             "Configuration de la cohabitation...",
+	"The %s display manager starts at boot": "Le gestionnaire de connexion %s démarre au boot",
+	"It runs Xorg on its own virtual terminal and opens a ConsoleKit session there. Starting niri from another TTY works, but niri won't be offered at the login screen.": "Il lance Xorg sur son propre terminal virtuel et y ouvre une session ConsoleKit. Démarrer niri depuis un autre TTY fonctionne, mais niri n'est pas proposé sur l'écran de connexion.",
+	"%s is installed": "%s est installé",
+	"It uses the same seat and login environment as niri. That works as long as only one of them is started at a time and its settings don't leak into ~/.profile.": "Il utilise le même seat et le même environnement de connexion que niri. Cela fonctionne tant qu'un seul des deux est lancé à la fois et que ses réglages ne se retrouvent pas dans ~/.profile.",
+	"~/%s starts a session: %s": "~/%s lance une session : %s",
+	"Logging in on a TTY starts that session, so there is no shell left to start niri from. Limit it to one TTY, e.g. with [ \"$(tty)\" = /dev/ttyv1 ] &&, and start niri from another.": "Se connecter sur un TTY lance cette session, il ne reste donc aucun shell pour démarrer niri. Limitez-la à un seul TTY, par exemple avec [ \"$(tty)\" = /dev/ttyv1 ] &&, et démarrez niri depuis un autre.",
+	"~/.profile sets %s": "~/.profile définit %s",
+	"NiriSetup starts niri through ConsoleKit2 (LIBSEAT_BACKEND=consolekit2), and Setup System keeps an existing setting. If niri can't open the seat, change it to consolekit2.": "NiriSetup démarre niri via ConsoleKit2 (LIBSEAT_BACKEND=consolekit2) et Configurer le système conserve un réglage existant. Si niri ne peut pas ouvrir le seat, remplacez-le par consolekit2.",
+	"~/.profile sets X11 or desktop variables: %s": "~/.profile définit des variables X11 ou de bureau : %s",
+	"~/.profile is read before niri starts, so these make applications in niri use X11 or believe they run in another desktop.": "~/.profile est lu avant le démarrage de niri, ces variables font donc utiliser X11 aux applications ou leur font croire qu'elles tournent dans un autre bureau.",
+	"Added niri to the display manager's sessions (%s)":                                                                         "niri ajouté aux sessions du gestionnaire de connexion (%s)",
+	"Moved %d lines from %s to %s (backup in %s.bak)":                                                                           "%d lignes déplacées de %s vers %s (sauvegarde dans %s.bak)",
+	"Warning: %s: %v": "Avertissement : %s : %v",
+	"No other display manager, compositor or X11 session settings found":                       "Aucun autre gestionnaire de connexion, compositeur ou réglage de session X11 trouvé",
+	"Found %d other desktop setups:":                                                           "%d autres environnements de bureau trouvés :",
+	"Set up niri to coexist with them? Nothing is uninstalled or disabled.":                    "Configurer niri pour cohabiter avec eux ? Rien n'est désinstallé ni désactivé.",
+	"Warning: Found %d other desktop setups that may get in niri's way, see Desktop Conflicts": "Avertissement : %d autres environnements de bureau pourraient gêner niri, voir Conflits de bureau",
+	"Pass --yes to set up niri to coexist with them.":                                          "Ajoutez --yes pour configurer niri afin qu'il cohabite avec eux.",
+
+	// Config import
+	"Import Config":                     "Importer une configuration",
+	"From":                              "Depuis",
+	"Config file (empty: default)":      "Fichier (vide : par défaut)",
+	"line %d:":                          "ligne %d :",
+	"remove %s":                         "supprimer %s",
+	"Translated %d settings from %s:":   "%d réglages traduits depuis %s :",
+	"Not translated (%d):":              "Non traduits (%d) :",
+	"Nothing in %s could be translated": "Rien dans %s n'a pu être traduit",
+	"Failed to import %s: %v":           "Échec de l'import de %s : %v",
+	"Imported %s into %s (the previous config is in %s.bak)":              "%s importé dans %s (l'ancienne configuration est dans %s.bak)",
+	"cannot read the config: %v":                                          "impossible de lire la configuration : %v",
+	"a niri binding runs a single action":                                 "un raccourci niri n'exécute qu'une seule action",
+	"incomplete binding":                                                  "raccourci incomplet",
+	"incomplete input setting":                                            "réglage d'entrée incomplet",
+	"incomplete output setting":                                           "réglage d'écran incomplet",
+	"key codes can't be translated, use bindsym":                          "les codes de touche ne peuvent pas être traduits, utilisez bindsym",
+	"niri configures input per device type, not per device":               "niri configure les entrées par type de périphérique, pas par périphérique",
+	"niri has a single gap size, set with gaps inner":                     "niri n'a qu'une taille d'espacement, définie par gaps inner",
+	"niri has no binding modes":                                           "niri n'a pas de modes de raccourcis",
+	"niri settings apply to one output at a time, repeat them per output": "les réglages niri s'appliquent à un écran à la fois, répétez-les pour chaque écran",
+	"no niri equivalent":                                                  "pas d'équivalent niri",
+	"relative transforms can't be translated":                             "les rotations relatives ne peuvent pas être traduites",
+	"unrecognized mode":                                                   "mode non reconnu",
+	"unrecognized transform":                                              "rotation non reconnue",
+	"use waybar, which NiriSetup sets up, instead":                        "utilisez plutôt waybar, que NiriSetup configure",
+	"window rules aren't translated, see window-rule in the niri wiki":    "les règles de fenêtre ne sont pas traduites, voir window-rule dans le wiki niri",
+	"niri reloads its config by itself":                                   "niri recharge sa configuration tout seul",
+	"niri arranges windows in columns, there are no splits":               "niri range les fenêtres en colonnes, il n'y a pas de divisions",
+	"niri arranges windows in columns, there are no layouts":              "niri range les fenêtres en colonnes, il n'y a pas de dispositions",
+	"niri has no scratchpad":                                              "niri n'a pas de scratchpad",
+	"niri has no sticky windows":                                          "niri n'a pas de fenêtres épinglées",
+
+	// X11 import
+	"%s line %d:":                                                "%s ligne %d :",
+	"Notes:":                                                     "Remarques :",
+	"shell code isn't translated":                                "le code shell n'est pas traduit",
+	"X11 only, not needed under niri":                            "propre à X11, inutile sous niri",
+	"niri draws its own shadows and animations":                  "niri dessine lui-même ombres et animations",
+	"X11 only, set xkb options instead":                          "propre à X11, utilisez plutôt les options xkb",
+	"X11 only, set the input block instead":                      "propre à X11, utilisez plutôt le bloc input",
+	"set the wallpaper with swaybg instead":                      "définissez plutôt le fond d'écran avec swaybg",
+	"X11 only, use a Wayland clipboard manager such as cliphist": "propre à X11, utilisez un gestionnaire de presse-papiers Wayland comme cliphist",
+	"use swayidle and swaylock instead":                          "utilisez plutôt swayidle et swaylock",
+	"xrandr names outputs differently; %s was taken to be %s. Check the names with niri msg outputs.": "xrandr nomme les écrans autrement ; %s a été interprété comme %s. Vérifiez les noms avec niri msg outputs.",
+	"unrecognized scale": "échelle non reconnue",
+	"relative positions can't be translated, use Arrange Monitors": "les positions relatives ne peuvent pas être traduites, utilisez Disposer les écrans",
+
+	// Hyprland import
+	"niri has tabbed columns instead of groups":                       "niri a des colonnes à onglets au lieu de groupes",
+	"niri always follows the window to its workspace":                 "niri suit toujours la fenêtre vers son espace de travail",
+	"included files aren't followed, import them separately":          "les fichiers inclus ne sont pas suivis, importez-les séparément",
+	"incomplete window rule":                                          "règle de fenêtre incomplète",
+	"key codes can't be translated, use key names":                    "les codes de touche ne peuvent pas être traduits, utilisez les noms de touche",
+	"niri moves and resizes windows with Mod and the mouse by itself": "niri déplace et redimensionne les fenêtres avec Mod et la souris de lui-même",
+	"only class and title matches can be translated":                  "seules les correspondances de classe et de titre peuvent être traduites",
+
+	// Kernel modules
+	"Kernel Modules":                           "Modules du noyau",
+	"Checking kernel modules...":               "Vérification des modules du noyau...",
+	"DRM core, used by every GPU driver":       "cœur DRM, utilisé par tous les pilotes graphiques",
+	"Intel graphics":                           "graphique Intel",
+	"AMD graphics (GCN and newer)":             "graphique AMD (GCN et plus récent)",
+	"older AMD and ATI graphics":               "anciens graphiques AMD et ATI",
+	"NVIDIA graphics (nvidia-drm-kmod)":        "graphique NVIDIA (nvidia-drm-kmod)",
+	"input events for libinput":                "événements d'entrée pour libinput",
+	"Loaded %s":                                "%s chargé",
+	"Added %s to kld_list":                     "%s ajouté à kld_list",
+	"Moved %s from %s to kld_list":             "%s déplacé de %s vers kld_list",
+	"%s is not loaded":                         "%s n'est pas chargé",
+	"Load %s now":                              "Charger %s maintenant",
+	"%s is loaded but won't be after a reboot": "%s est chargé mais ne le sera plus après un redémarrage",
+	"Add %s to kld_list":                       "Ajouter %s à kld_list",
+	"%s is loaded from %s, which can hang the boot; GPU drivers belong in kld_list": "%s est chargé depuis %s, ce qui peut bloquer le démarrage ; les pilotes graphiques vont dans kld_list",
+	"Move %s to kld_list": "Déplacer %s vers kld_list",
+	"No GPU driver is loaded, and this machine's GPU uses %s": "Aucun pilote graphique n'est chargé, et le GPU de cette machine utilise %s",
+	"Load %s and add it to kld_list":                          "Charger %s et l'ajouter à kld_list",
+	"yes":                                                     "oui",
+	"Module":                                                  "Module",
+	"Loaded":                                                  "Chargé",
+	"Purpose":                                                 "Rôle",
+	"The loaded modules match the boot configuration.":                 "Les modules chargés correspondent à la configuration de démarrage.",
+	"Failed to fix %s: %v":                                             "Échec de la correction de %s : %v",
+	"Failed to run kldstat: %v":                                        "Échec de kldstat : %v",
+	"Pass --yes to fix them.":                                          "Passez --yes pour les corriger.",
+	"Type the number of a fix to apply it, or press enter to go back:": "Tapez le numéro d'une correction pour l'appliquer, ou appuyez sur Entrée pour revenir :",
+
+	// Doctor
+	"Doctor":                   "Diagnostic",
+	"Checking for problems...": "Recherche de problèmes...",
+	"The GPU driver could not load its firmware":                                                                                 "Le pilote graphique n'a pas pu charger son firmware",
+	"The drm-kmod modules were built for another FreeBSD version":                                                                "Les modules drm-kmod ont été compilés pour une autre version de FreeBSD",
+	"Reinstall them for this kernel: sudo pkg install -f drm-kmod, or build graphics/drm-kmod from ports after a kernel update.": "Réinstallez-les pour ce noyau : sudo pkg install -f drm-kmod, ou compilez graphics/drm-kmod depuis les ports après une mise à jour du noyau.",
+	"The NVIDIA kernel module and driver versions differ":                                                                        "Les versions du module noyau et du pilote NVIDIA diffèrent",
+	"Upgrade nvidia-driver and nvidia-drm-kmod together: sudo pkg upgrade nvidia-driver nvidia-drm-kmod, then reboot.":           "Mettez à jour nvidia-driver et nvidia-drm-kmod ensemble : sudo pkg upgrade nvidia-driver nvidia-drm-kmod, puis redémarrez.",
+	"The GPU hung or was reset": "Le GPU s'est bloqué ou a été réinitialisé",
+	"Update drm-kmod and the GPU firmware. If it keeps happening, try disabling power saving for the GPU (e.g. compat.linuxkpi.i915_enable_dc=0 in /boot/loader.conf) and report it to drm-kmod.": "Mettez à jour drm-kmod et le firmware du GPU. Si cela se reproduit, essayez de désactiver l'économie d'énergie du GPU (p. ex. compat.linuxkpi.i915_enable_dc=0 dans /boot/loader.conf) et signalez-le à drm-kmod.",
+	"The GPU driver does not support this GPU": "Le pilote graphique ne prend pas en charge ce GPU",
+	"Install a newer drm-kmod, such as the drm-61-kmod package, and load it from kld_list (see Kernel Modules).": "Installez un drm-kmod plus récent, comme le paquet drm-61-kmod, et chargez-le depuis kld_list (voir Modules du noyau).",
+	"The DRM driver reported errors": "Le pilote DRM a signalé des erreurs",
+	"Check the lines above. Most errors at boot come from a driver that doesn't match the GPU or the kernel; see Kernel Modules.": "Vérifiez les lignes ci-dessus. La plupart des erreurs au démarrage viennent d'un pilote qui ne correspond pas au GPU ou au noyau ; voir Modules du noyau.",
+	"(%d more)":                              "(%d de plus)",
+	"Fix:":                                   "Correction :",
+	"Failed to read the kernel messages: %v": "Impossible de lire les messages du noyau : %v",
+	"Graphics errors in dmesg:":              "Erreurs graphiques dans dmesg :",
+	"  None found.":                          "  Aucune trouvée.",
+
+	// Debug bundle
+	"Collect Debug Info":                   "Collecter les infos de débogage",
+	"Collecting debug info...":             "Collecte des infos de débogage...",
+	"Failed to write the debug bundle: %v": "Impossible d'écrire l'archive de débogage : %v",
+	"Wrote %s with %s.":                    "%s écrit avec %s.",
+	"Your user name, home directory, host name, hardware addresses and secret environment variables were replaced. Look through it before attaching it to a niri or GhostBSD bug report.": "Votre nom d'utilisateur, dossier personnel, nom d'hôte, adresses matérielles et variables d'environnement secrètes ont été remplacés. Relisez-la avant de la joindre à un rapport de bogue niri ou GhostBSD.",
+
+	// Bug reports
+	"Report a Bug":                             "Signaler un bogue",
+	"Preparing the issue...":                   "Préparation du ticket...",
+	"Press g to report this failure on GitHub": "Appuyez sur g pour signaler cet échec sur GitHub",
+	"Failed to open the browser: %v":           "Impossible d'ouvrir le navigateur : %v",
+	"Opened the issue in the browser":          "Ticket ouvert dans le navigateur",
+	"This issue for %s is prefilled with the log and a summary of this system. Check it for anything private before submitting it:": "Ce ticket pour %s est prérempli avec le journal et un résumé de ce système. Vérifiez qu'il ne contient rien de privé avant de l'envoyer :",
+	"Open in the browser": "Ouvrir dans le navigateur",
+	"niri crashed":        "niri a planté",
+	"Report it to niri with Report a Bug, which prefills an issue with these lines.": "Signalez-le à niri avec Signaler un bogue, qui préremplit un ticket avec ces lignes.",
+
+	// Session log
+	"Session Log":             "Journal de session",
+	"Reading the niri log...": "Lecture du journal de niri...",
+	"niri panicked":           "niri a paniqué",
+	"This is a bug in niri. Report it with Report a Bug, attaching this log.": "C'est un bogue de niri. Signalez-le avec Signaler un bogue, en joignant ce journal.",
+	"niri could not set up EGL on the GPU":                                    "niri n'a pas pu initialiser EGL sur le GPU",
+	"Make sure mesa-dri and mesa-libs are installed and the GPU driver is loaded (see Kernel Modules and Doctor). With an NVIDIA GPU, nvidia-drm must be loaded with modeset enabled.": "Vérifiez que mesa-dri et mesa-libs sont installés et que le pilote graphique est chargé (voir Modules du noyau et Diagnostic). Avec un GPU NVIDIA, nvidia-drm doit être chargé avec modeset activé.",
+	"niri could not take the seat": "niri n'a pas pu prendre le siège",
+	"Start niri from a text console (not from inside X), with LIBSEAT_BACKEND=consolekit2 set and dbus running, or run seatd (Setup System sets this up). Only one compositor can hold the seat at a time.": "Lancez niri depuis une console texte (pas depuis X), avec LIBSEAT_BACKEND=consolekit2 défini et dbus actif, ou lancez seatd (Configurer le système s'en charge). Un seul compositeur peut tenir le siège à la fois.",
+	"niri could not open the GPU or input devices": "niri n'a pas pu ouvrir le GPU ou les périphériques d'entrée",
+	"Add your user to the video group (Setup System does this), log out and back in, and check that the GPU driver is loaded.": "Ajoutez votre utilisateur au groupe video (Configurer le système s'en charge), reconnectez-vous et vérifiez que le pilote graphique est chargé.",
+	"XDG_RUNTIME_DIR is missing or unusable":                                               "XDG_RUNTIME_DIR est absent ou inutilisable",
+	"Run Setup System, which sets XDG_RUNTIME_DIR in ~/.profile, then log in again.":       "Lancez Configurer le système, qui définit XDG_RUNTIME_DIR dans ~/.profile, puis reconnectez-vous.",
+	"niri rejected its config":                                                             "niri a rejeté sa configuration",
+	"Check it with Validate Config and fix the reported lines, or restore config.kdl.bak.": "Vérifiez-la avec Valider la configuration et corrigez les lignes signalées, ou restaurez config.kdl.bak.",
+	"X11 applications can't start":                                                         "Les applications X11 ne peuvent pas démarrer",
+	"Install xwayland-satellite (Install Niri includes it).":                               "Installez xwayland-satellite (Installer Niri l'inclut).",
+	"Another compositor is already running":                                                "Un autre compositeur est déjà lancé",
+	"Quit the other Wayland session before starting niri, see Desktop Conflicts.":          "Quittez l'autre session Wayland avant de lancer niri, voir Conflits de bureau.",
+	"Log: %s (%d lines)":            "Journal : %s (%d lignes)",
+	"No errors found.":              "Aucune erreur trouvée.",
+	"Last error, at line %d:":       "Dernière erreur, ligne %d :",
+	"Known problems:":               "Problèmes connus :",
+	"Failed to find a niri log: %v": "Aucun journal de niri trouvé : %v",
+
+	// Log tail
+	"Watch Session Logs":                         "Suivre les journaux de session",
+	"Opening the session logs...":                "Ouverture des journaux de session...",
+	"Failed to find the session logs: %v":        "Journaux de session introuvables : %v",
+	"Waiting for lines in %s...":                 "En attente de lignes dans %s...",
+	"↑/↓ scroll • G newest • c clear • esc back": "↑/↓ défiler • G plus récent • c effacer • échap retour",
+	"paused": "en pause",
+	"Press enter to show new lines, or type q to go back:": "Appuyez sur Entrée pour afficher les nouvelles lignes, ou tapez q pour revenir :",
+
+	// Test drive
+	"Test Config":                       "Tester la configuration",
+	"Config file (empty: the template)": "Fichier de configuration (vide : le modèle)",
+	"Test Config opens niri in a window, so run it from a terminal inside niri or another graphical session": "Tester la configuration ouvre niri dans une fenêtre : lancez-le depuis un terminal dans niri ou une autre session graphique",
+	"niri is not installed, run Install Niri first":                                                          "niri n'est pas installé, lancez d'abord Installer Niri",
+	"the config template":                   "le modèle de configuration",
+	"Failed to write the test config: %v":   "Impossible d'écrire la configuration de test : %v",
+	"%s is not a valid niri config: %v":     "%s n'est pas une configuration niri valide : %v",
+	"niri exited with an error, see %s: %v": "niri s'est terminé avec une erreur, voir %s : %v",
+	"Tested %s; your config is unchanged":   "%s testé ; votre configuration est inchangée",
+	"Failed to start niri: %v":              "Impossible de lancer niri : %v",
+	"Started niri in a window with %s. Try the key bindings and layout there (Mod is Alt in a window), then close the window. Your config is unchanged; niri's output goes to %s.": "niri lancé dans une fenêtre avec %s. Essayez-y les raccourcis et la disposition (Mod est Alt dans une fenêtre), puis fermez la fenêtre. Votre configuration est inchangée ; la sortie de niri va dans %s.",
+
+	// Config lint
+	"Lint Config":                                                     "Analyser la configuration",
+	"Linting Niri config...":                                          "Analyse de la configuration de niri...",
+	"%s is already bound on line %d":                                  "%s est déjà associé à la ligne %d",
+	"%s is started at login but is not installed":                     "%s est lancé à la connexion mais n'est pas installé",
+	"output %q doesn't match any connected display (connected: %s)":   "output %q ne correspond à aucun écran connecté (connectés : %s)",
+	"%s is %q here but %q in ~/.profile; programs niri starts get %q": "%s vaut %q ici mais %q dans ~/.profile ; les programmes lancés par niri reçoivent %q",
+	"Output names are only checked inside a niri session.":            "Les noms d'écran ne sont vérifiés que dans une session niri.",
+	"No problems found in %s":                                         "Aucun problème trouvé dans %s",
+	"%d problems found in %s:":                                        "%d problèmes trouvés dans %s :",
+
+	// Config format
+	"Format Config":              "Formater la configuration",
+	"Formatting Niri config...":  "Formatage de la configuration Niri...",
+	"formatted":                  "formaté",
+	"Failed to format %s: %v":    "Échec du formatage de %s : %v",
+	"Formatted %s":               "%s formaté",
+	"%s is already formatted":    "%s est déjà formaté",
+	"Write the formatted config": "Écrire la configuration formatée",
+	"Pass --yes to write it.":    "Ajoutez --yes pour l'écrire.",
+
+	// Config preview
+	"Preparing the config...": "Préparation de la configuration...",
+	"Write this config":       "Écrire cette configuration",
+
+	// Key bindings
+	"Key Bindings":                           "Raccourcis clavier",
+	"Checking the key bindings...":           "Vérification des raccourcis clavier...",
+	"close the focused window":               "fermer la fenêtre active",
+	"open the application launcher":          "ouvrir le lanceur d'applications",
+	"open a terminal":                        "ouvrir un terminal",
+	"quit niri":                              "quitter niri",
+	"No key combination is bound twice.":     "Aucune combinaison de touches n'est attribuée deux fois.",
+	"Key combinations bound more than once:": "Combinaisons de touches attribuées plusieurs fois :",
+	"Every essential action has a key.":      "Chaque action essentielle a un raccourci.",
+	"No key is bound to:":                    "Aucune touche n'est attribuée à :",
+	"can be added as %s":                     "peut être ajouté comme %s",
+	"%s are all taken":                       "%s sont toutes prises",
+	"Failed to add the key bindings: %v":     "Échec de l'ajout des raccourcis : %v",
+	"Added the missing key bindings to %s":   "Raccourcis manquants ajoutés à %s",
+	"Add the missing key bindings":           "Ajouter les raccourcis manquants",
+
+	// Cheat sheet
+	"Cheat Sheet":                "Aide-mémoire",
+	"Writing the cheat sheet...": "Écriture de l'aide-mémoire...",
+	"Run %s":                     "Lancer %s",
+	"niri key bindings":          "Raccourcis clavier de niri",
+	"Mod is the Super (Windows) key, or Alt when niri runs in a window.": "Mod est la touche Super (Windows), ou Alt quand niri tourne dans une fenêtre.",
+	"Keys":          "Touches",
+	"Action":        "Action",
+	"Programs":      "Programmes",
+	"Windows":       "Fenêtres",
+	"Columns":       "Colonnes",
+	"Workspaces":    "Espaces de travail",
+	"Monitors":      "Écrans",
+	"Screenshots":   "Captures d'écran",
+	"Session":       "Session",
+	"Hardware keys": "Touches matérielles",
+	"Other":         "Autres",
+	"The config has no key bindings to put on a cheat sheet":             "La configuration n'a aucun raccourci à mettre dans un aide-mémoire",
+	"Cheat sheet saved to %s (install ImageMagick to also get an image)": "Aide-mémoire enregistré dans %s (installez ImageMagick pour obtenir aussi une image)",
+	"Cheat sheet saved to %s, but the image failed: %v":                  "Aide-mémoire enregistré dans %s, mais l'image a échoué : %v",
+	"Cheat sheet saved to %s and %s":                                     "Aide-mémoire enregistré dans %s et %s",
+
+	// XDG environment
+	"Warning: Could not create %s: %v": "Avertissement : impossible de créer %s : %v",
+	"%s already in .profile: OK":       "%s déjà présent dans .profile : OK",
+	"Added %s to %s: OK":               "%s ajouté à %s : OK",
+	"No config.kdl yet: Configure Niri sets XDG_CURRENT_DESKTOP and XDG_SESSION_TYPE": "Pas encore de config.kdl : Configurer Niri définit XDG_CURRENT_DESKTOP et XDG_SESSION_TYPE",
+	"XDG_CURRENT_DESKTOP and XDG_SESSION_TYPE already in config.kdl: OK":              "XDG_CURRENT_DESKTOP et XDG_SESSION_TYPE déjà présents dans config.kdl : OK",
+	"Added XDG_CURRENT_DESKTOP=niri and XDG_SESSION_TYPE=wayland to %s: OK":           "XDG_CURRENT_DESKTOP=niri et XDG_SESSION_TYPE=wayland ajoutés à %s : OK",
+
+	// Session info
+	"Session Info":                       "Infos de session",
+	"Reading the session environment...": "Lecture de l'environnement de session...",
+	"niri sets it for the programs it starts; run NiriSetup from a terminal in niri": "niri la définit pour les programmes qu'il lance ; lancez NiriSetup depuis un terminal dans niri",
+	"X11 programs can't start; check that xwayland-satellite runs":                   "les programmes X11 ne peuvent pas démarrer ; vérifiez que xwayland-satellite tourne",
+	"Setup System sets it in ~/.profile; log in again":                               "Configurer le système la définit dans ~/.profile ; reconnectez-vous",
+	"start niri with dbus-launch, as Setup System shows":                             "lancez niri avec dbus-launch, comme l'indique Configurer le système",
+	"Setup System sets it in the environment block of config.kdl":                    "Configurer le système la définit dans le bloc environment de config.kdl",
+	"%s doesn't exist":                                       "%s n'existe pas",
+	"%s belongs to UID %d, not to you":                       "%s appartient à l'UID %d, pas à vous",
+	"%s has mode %o instead of 700":                          "%s a le mode %o au lieu de 700",
+	"ck-list-sessions not found (is consolekit2 installed?)": "ck-list-sessions introuvable (consolekit2 est-il installé ?)",
+	"ck-list-sessions failed: %v":                            "ck-list-sessions a échoué : %v",
+	"no ConsoleKit2 sessions":                                "aucune session ConsoleKit2",
+	"NiriSetup doesn't run inside niri, so the session-only variables below are its own, not the session's.": "NiriSetup ne tourne pas dans niri : les variables de session ci-dessous sont les siennes, pas celles de la session.",
+	"(not set)":                           "(non définie)",
+	"%s is not set: %s":                   "%s n'est pas définie : %s",
+	"%s is %s instead of %s: %s":          "%s vaut %s au lieu de %s : %s",
+	"Seat:":                               "Siège :",
+	"%s is not running: run Setup System": "%s ne tourne pas : lancez Configurer le système",
+	"Everything matches what NiriSetup set up.": "Tout correspond à ce que NiriSetup a configuré.",
+	"Different from what NiriSetup set up:":     "Différent de ce que NiriSetup a configuré :",
+
+	// devfs rules
+	"Setup problems:": "Problèmes de configuration :",
+	"You can't open the GPU render node %s: %v": "Vous ne pouvez pas ouvrir le nœud de rendu GPU %s : %v",
+	"Add a devfs.rules ruleset that gives the video group access to /dev/dri and /dev/drm, make it the system ruleset and restart devfs.": "Ajouter à devfs.rules un jeu de règles donnant au groupe video l'accès à /dev/dri et /dev/drm, en faire le jeu de règles système et redémarrer devfs.",
+	"Give the video group access to the GPU":                                   "Donner au groupe video l'accès au GPU",
+	"Added the %s ruleset to %s and made it the system ruleset":                "Jeu de règles %s ajouté à %s et défini comme jeu de règles système",
+	"Log out and back in for the group change to take effect.":                 "Déconnectez-vous puis reconnectez-vous pour que le changement de groupe prenne effet.",
+	"  Doctor can give the video group access to it through /etc/devfs.rules.": "  Diagnostic peut donner au groupe video l'accès via /etc/devfs.rules.",
+
+	// Webcam
+	"Webcam":                                "Webcam",
+	"Setting up the webcam...":              "Configuration de la webcam...",
+	"Loading the cuse kernel module":        "Chargement du module noyau cuse",
+	"Loading cuse at boot":                  "Chargement de cuse au démarrage",
+	"Enabling webcamd service":              "Activation du service webcamd",
+	"Starting webcamd service":              "Démarrage du service webcamd",
+	"Adding user '%s' to the webcamd group": "Ajout de l'utilisateur '%s' au groupe webcamd",
+	"No camera found yet. Plug it in; devd starts webcamd for it and /dev/video0 appears.": "Aucune caméra trouvée pour l'instant. Branchez-la ; devd lance webcamd et /dev/video0 apparaît.",
+	"Cameras: %s": "Caméras : %s",
+
+	// Browser setup
+	"Browser Setup": "Configuration du navigateur",
+	"Browser":       "Navigateur",
+	"Firefox":       "Firefox",
+	"Chromium":      "Chromium",
+	"Both":          "Les deux",
+	"Restart the browser for the change to take effect.": "Redémarrez le navigateur pour que le changement prenne effet.",
+
+	// App environment
+	"App Environment": "Environnement des applications",
+	"Electron programs such as VS Code, Discord and Signal run as Wayland clients instead of through Xwayland, so they are sharp on scaled outputs.":          "Les programmes Electron comme VS Code, Discord et Signal s'exécutent comme clients Wayland au lieu de passer par Xwayland, ils restent donc nets sur les sorties mises à l'échelle.",
+	"Qt programs use Wayland instead of X11. Needs the Qt Wayland plugins (qt5-wayland, qt6-wayland), or Qt programs won't start.":                            "Les programmes Qt utilisent Wayland au lieu de X11. Nécessite les greffons Qt Wayland (qt5-wayland, qt6-wayland), sinon les programmes Qt ne démarrent pas.",
+	"Java Swing and AWT programs such as IntelliJ show their content instead of blank grey windows, which they do under window managers that don't reparent.": "Les programmes Java Swing et AWT comme IntelliJ affichent leur contenu au lieu de fenêtres grises vides, ce qu'ils font sous les gestionnaires de fenêtres qui ne reparentent pas.",
+	"Environment presets written to %s": "Préréglages d'environnement écrits dans %s",
+	"Set: %s":                           "Définies : %s",
+	"Programs started from now on pick up the change.": "Les programmes lancés à partir de maintenant prennent en compte le changement.",
+
+	// Qt Wayland
+	"Qt Wayland plugins":              "Greffons Qt Wayland",
+	"Looking for Qt programs...":      "Recherche des programmes Qt...",
+	"No programs use %s, skipping %s": "Aucun programme n'utilise %s, %s ignoré",
+	"Programs using %s: %s":           "Programmes utilisant %s : %s",
+
+	// Polkit agent
+	"Polkit Agent":         "Agent polkit",
+	"Agent":                "Agent",
+	"unknown polkit agent": "agent polkit inconnu",
+	"%s runs (PID %d) and is connected to the session bus: OK":                    "%s tourne (PID %d) et est connecté au bus de session : OK",
+	"Added %s to spawn-at-startup in %s":                                          "%s ajouté à spawn-at-startup dans %s",
+	"Run this again inside niri to check that the agent starts, or log in again.": "Relancez ceci dans niri pour vérifier que l'agent démarre, ou reconnectez-vous.",
+	"Warning: %v": "Avertissement : %v",
+
+	// Keyring
+	"Keyring":         "Trousseau",
+	"Secret service":  "Service de secrets",
+	"Unlock at login": "Déverrouiller à la connexion",
+	"Browsers, mail programs and network tools store their passwords in the secret service. gnome-keyring keeps them in a keyring unlocked at login; KeePassXC serves them from a database you unlock yourself.": "Les navigateurs, les clients de messagerie et les outils réseau rangent leurs mots de passe dans le service de secrets. gnome-keyring les garde dans un trousseau déverrouillé à la connexion ; KeePassXC les fournit depuis une base que vous déverrouillez vous-même.",
+	"gnome-keyring only: unlock the keyring with your login password through PAM, so you aren't asked for it again.":                                                                                             "gnome-keyring uniquement : déverrouiller le trousseau avec votre mot de passe de connexion via PAM, pour qu'il ne soit pas redemandé.",
+	"Failed to set up %s: %v":                          "Échec de la configuration de %s : %v",
+	"Log out and back in for the keyring to start.":    "Déconnectez-vous puis reconnectez-vous pour démarrer le trousseau.",
+	"%s already unlocks the keyring: OK":               "%s déverrouille déjà le trousseau : OK",
+	"Added gnome-keyring to %s (backup in %s.bak): OK": "gnome-keyring ajouté à %s (sauvegarde dans %s.bak) : OK",
+	"Enabled the secret service in %s: OK":             "Service de secrets activé dans %s : OK",
+	"In KeePassXC, pick the database to expose under Database Settings → Secret Service Integration.": "Dans KeePassXC, choisissez la base à exposer dans Paramètres de la base → Intégration du service de secrets.",
+
+	// SSH and GPG agents
+	"SSH and GPG Agents": "Agents SSH et GPG",
+	"SSH agent":          "Agent SSH",
+	"GPG pinentry":       "Pinentry GPG",
+	"Unchanged":          "Inchangé",
+	"Keeps your unlocked SSH keys for the session and exports SSH_AUTH_SOCK to the programs niri starts. gpg-agent can serve SSH keys too, including ones on a smartcard.": "Garde vos clés SSH déverrouillées pour la session et exporte SSH_AUTH_SOCK vers les programmes lancés par niri. gpg-agent peut aussi servir les clés SSH, y compris celles d'une carte à puce.",
+	"The password prompt gpg-agent shows. The default curses and GTK 2 prompts don't work in niri: they need a terminal or X11.":                                           "La demande de mot de passe affichée par gpg-agent. Les demandes curses et GTK 2 par défaut ne fonctionnent pas dans niri : elles ont besoin d'un terminal ou de X11.",
+	"Updated %s: OK":     "%s mis à jour : OK",
+	"Wrote %s: OK":       "%s écrit : OK",
+	"gpgconf failed: %v": "Échec de gpgconf : %v",
+	"SSH_AUTH_SOCK=%s":   "SSH_AUTH_SOCK=%s",
+	"Log out and back in, then add your keys with ssh-add.": "Déconnectez-vous puis reconnectez-vous, puis ajoutez vos clés avec ssh-add.",
+
+	// User directories
+	"Created the user directories and listed them in %s: OK": "Répertoires utilisateur créés et listés dans %s : OK",
+
+	// Default apps
+	"Default Apps":                       "Applications par défaut",
+	"File manager":                       "Gestionnaire de fichiers",
+	"Image viewer":                       "Visionneuse d'images",
+	"PDF viewer":                         "Lecteur PDF",
+	"Terminal":                           "Terminal",
+	"Nothing to change.":                 "Rien à changer.",
+	"Default applications written to %s": "Applications par défaut écrites dans %s",
+
+	// Appearance
+	"Appearance":   "Apparence",
+	"Color scheme": "Jeu de couleurs",
+	"Dark":         "Sombre",
+	"Light":        "Clair",
+	"Default":      "Par défaut",
+	"Programs that follow the freedesktop color-scheme preference switch through the settings portal; GTK programs read settings.ini and Qt programs follow the GTK theme.": "Les programmes qui suivent la préférence color-scheme de freedesktop basculent via le portail de paramètres ; les programmes GTK lisent settings.ini et les programmes Qt suivent le thème GTK.",
+	"Warning: gsettings: %s":     "Avertissement : gsettings : %s",
+	"Set color-scheme to %s: OK": "color-scheme réglé sur %s : OK",
+	"gsettings not found, skipping the portal preference (install glib)":             "gsettings introuvable, préférence du portail ignorée (installez glib)",
+	"Running programs pick up the portal preference right away; restart the others.": "Les programmes en cours prennent la préférence du portail immédiatement ; redémarrez les autres.",
+
+	// Appearance
+	"Icon theme": "Thème d'icônes",
+	"Installed if needed and used by GTK programs, fuzzel and the waybar taskbar.": "Installé si besoin et utilisé par les programmes GTK, fuzzel et la barre des tâches de waybar.",
+	"Warning: Could not update %s: %v":                                             "Avertissement : impossible de mettre à jour %s : %v",
+
+	// Dotfiles
+	"Dotfiles":       "Fichiers de configuration",
+	"Git repository": "Dépôt git",
+	"Tool":           "Outil",
+	"Packages":       "Paquets",
+	"For stow, the package directories of the repository to link. For chezmoi, the directories under ~/.config to apply.": "Pour stow, les répertoires de paquets du dépôt à lier. Pour chezmoi, les répertoires sous ~/.config à appliquer.",
+	"enter the URL or path of your dotfiles repository":                                                                   "indiquez l'URL ou le chemin de votre dépôt de fichiers de configuration",
+	"name at least one package":     "indiquez au moins un paquet",
+	"%s failed: %s":                 "Échec de %s : %s",
+	"Warning: %s has no %s package": "Avertissement : %s n'a pas de paquet %s",
+	"stow won't replace files that aren't its links; move them into the repository or out of the way and try again.": "stow ne remplace pas les fichiers qui ne sont pas ses liens ; déplacez-les dans le dépôt ou ailleurs puis réessayez.",
+	"Applied the dotfiles from %s with %s": "Fichiers de configuration de %s appliqués avec %s",
+	"Applying your dotfiles...":            "Application de vos fichiers de configuration...",
+
+	// Update check
+	"Update Check":        "Recherche de mises à jour",
+	"Weekly update check": "Recherche hebdomadaire",
+	"periodic(8) looks for newer niri packages once a week and a notification in your session lists them. Nothing is installed automatically.": "periodic(8) cherche chaque semaine des paquets niri plus récents et une notification dans votre session les liste. Rien n'est installé automatiquement.",
+	"Failed to remove %s: %s":                "Impossible de supprimer %s : %s",
+	"Removed %s":                             "%s supprimé",
+	"Removed %s from spawn-at-startup in %s": "%s retiré de spawn-at-startup dans %s",
+	"The first check runs with the next weekly periodic run; to check now, run: sudo %s": "La première recherche a lieu au prochain passage hebdomadaire de periodic ; pour vérifier maintenant, lancez : sudo %s",
+
+	// Supervision
+	"Supervision":                                        "Supervision",
+	"%s isn't started by niri, skipping":                 "%s n'est pas lancé par niri, ignoré",
+	"%s is restarted when it exits":                      "%s est relancé quand il se termine",
+	"%s runs without supervision":                        "%s tourne sans supervision",
+	"The change takes effect the next time niri starts.": "Le changement prend effet au prochain démarrage de niri.",
+
+	// Test Graphics
+	"Test Graphics":                                       "Tester le rendu graphique",
+	"Testing graphics...":                                 "Test du rendu graphique...",
+	"Render node:":                                        "Nœud de rendu :",
+	"none, /dev/dri/renderD* is missing":                  "aucun, /dev/dri/renderD* est absent",
+	"eglinfo not found (install mesa-demos)":              "eglinfo introuvable (installez mesa-demos)",
+	"eglinfo failed: %v":                                  "échec d'eglinfo : %v",
+	"no renderer: EGL couldn't initialize":                "aucun moteur de rendu : EGL n'a pas pu s'initialiser",
+	"%s — software rendering, the GPU isn't used":         "%s — rendu logiciel, le GPU n'est pas utilisé",
+	"%s — hardware acceleration: OK":                      "%s — accélération matérielle : OK",
+	"vulkaninfo not found (install vulkan-tools)":         "vulkaninfo introuvable (installez vulkan-tools)",
+	"no Vulkan driver: %v":                                "aucun pilote Vulkan : %v",
+	"%s — software rendering":                             "%s — rendu logiciel",
+	"no devices":                                          "aucun périphérique",
+	"failed: %s":                                          "échec : %s",
+	"drew 120 frames on Wayland: OK":                      "120 images dessinées sur Wayland : OK",
+	"Install mesa-demos or vulkan-tools to run the test.": "Installez mesa-demos ou vulkan-tools pour lancer le test.",
+	"The GPU renders for niri.":                           "Le GPU effectue le rendu pour niri.",
+	"Programs fall back to software rendering or can't draw at all. Run Doctor and Kernel Modules to find out why.": "Les programmes se rabattent sur le rendu logiciel ou ne peuvent rien dessiner. Lancez Diagnostic et Modules du noyau pour savoir pourquoi.",
+	"Install mesa-demos and vulkan-tools": "Installer mesa-demos et vulkan-tools",
+
+	// Wayland Protocols
+	"Wayland Protocols":          "Protocoles Wayland",
+	"Querying the compositor...": "Interrogation du compositeur...",
+	"No Wayland session found. Start niri and run the check from it.": "Aucune session Wayland trouvée. Démarrez niri et lancez la vérification depuis celle-ci.",
+	"Could not query %s: %v": "Impossible d'interroger %s : %v",
+	"Display:":               "Affichage :",
+	"interfaces":             "interfaces",
+	"MISSING, needed by %s":  "ABSENT, requis par %s",
+	"not advertised (no installed program needs it)":                                                                                 "non annoncé (aucun programme installé n'en a besoin)",
+	"The compositor offers every protocol the installed components need.":                                                            "Le compositeur offre tous les protocoles dont les composants installés ont besoin.",
+	"Programs that need a missing protocol won't start or fall back. Update niri, or replace them with programs that don't need it.": "Les programmes qui ont besoin d'un protocole absent ne démarrent pas ou se rabattent sur un autre mode. Mettez niri à jour, ou remplacez-les par des programmes qui n'en ont pas besoin.",
+
+	// Output modes
+	"Automatic":     "Automatique",
+	"preferred %s":  "préféré %s",
+	"Suggested: %s": "Suggéré : %s",
+	"Warning: config.kdl asks for %s, which %s doesn't advertise; niri falls back to the preferred mode.": "Attention : config.kdl demande %s, que %s n'annonce pas ; niri se rabat sur le mode préféré.",
+
+	// Output transforms
+	"Rotation clockwise; 90 and 270 turn a monitor to portrait and flipped mirrors it. Arrange Monitors previews the layout, r rotates and f flips there.": "Rotation dans le sens horaire ; 90 et 270 passent un écran en portrait et flipped le retourne en miroir. Disposer les écrans affiche un aperçu de la disposition, r y pivote et f y retourne.",
+	"%s: transform %s": "%s : transformation %s",
+
+	// Screen Sharing
+	"Screen Sharing":                                     "Partage d'écran",
+	"Checking screen sharing...":                         "Vérification du partage d'écran...",
+	"Log out and back in, then run the screencast test.": "Déconnectez-vous puis reconnectez-vous, puis lancez le test de capture d'écran.",
+	"running":     "en cours d'exécution",
+	"not running": "arrêté",
+	"%s isn't running; set up PipeWire and the portals to start it with niri": "%s ne tourne pas ; configurez PipeWire et les portails pour le démarrer avec niri",
+	"Portal routing:":      "Routage des portails :",
+	"no niri-portals.conf": "pas de niri-portals.conf",
+	"xdg-desktop-portal doesn't know which backend to use in niri":                      "xdg-desktop-portal ne sait pas quel moteur utiliser dans niri",
+	"XDG_CURRENT_DESKTOP isn't niri, so niri-portals.conf isn't used; run Setup System": "XDG_CURRENT_DESKTOP n'est pas niri, donc niri-portals.conf n'est pas utilisé ; lancez Configurer le système",
+	"no session bus; start niri with dbus-launch":                                       "pas de bus de session ; démarrez niri avec dbus-launch",
+	"not on the session bus":                                                    "absent du bus de session",
+	"xdg-desktop-portal isn't running or can't be started":                      "xdg-desktop-portal ne tourne pas ou ne peut pas démarrer",
+	"niri doesn't offer screencasting; it may be built without it":              "niri n'offre pas la capture d'écran ; il a peut-être été compilé sans",
+	"cancelled in the dialog":                                                   "annulé dans la boîte de dialogue",
+	"the portal reported a failure":                                             "le portail a signalé un échec",
+	"Screencast:":                                                               "Capture d'écran :",
+	"the screencast request failed":                                             "la demande de capture d'écran a échoué",
+	"PipeWire node %d":                                                          "nœud PipeWire %d",
+	"PipeWire node %d is missing or not a video stream":                         "le nœud PipeWire %d est absent ou n'est pas un flux vidéo",
+	"niri started the screencast but PipeWire has no video for it":              "niri a lancé la capture mais PipeWire n'a pas de vidéo",
+	"video stream on PipeWire node %d: OK":                                      "flux vidéo sur le nœud PipeWire %d : OK",
+	"Screen sharing won't work in video calls:":                                 "Le partage d'écran ne fonctionnera pas dans les appels vidéo :",
+	"Screen sharing works: portal, niri and PipeWire delivered a video stream.": "Le partage d'écran fonctionne : le portail, niri et PipeWire ont fourni un flux vidéo.",
+	"Every link is in place. Run the screencast test to share a screen for real; a dialog asks which one.": "Tous les maillons sont en place. Lancez le test de capture pour partager réellement un écran ; une boîte de dialogue demande lequel.",
+	"Set up PipeWire and the portals": "Configurer PipeWire et les portails",
+	"Run the screencast test":         "Lancer le test de capture d'écran",
+
+	// OBS Studio
+	"OBS Studio":               "OBS Studio",
+	"Setting up OBS Studio...": "Configuration d'OBS Studio...",
+	"%s is missing: this OBS build has no PipeWire capture; install a package built with the PIPEWIRE option": "%s est absent : cette version d'OBS n'a pas la capture PipeWire ; installez un paquet compilé avec l'option PIPEWIRE",
+	"PipeWire capture plugin: %s":                                                       "Greffon de capture PipeWire : %s",
+	"Warning: Could not ask the screencast portal: %v":                                  "Attention : impossible d'interroger le portail de capture : %v",
+	"Inside niri, check it with Screen Sharing.":                                        "Dans niri, vérifiez-le avec Partage d'écran.",
+	"The screencast portal can't capture monitors; check Screen Sharing.":               "Le portail de capture ne peut pas capturer les écrans ; vérifiez Partage d'écran.",
+	"Screen Capture (PipeWire)":                                                         "Capture d'écran (PipeWire)",
+	"Window Capture (PipeWire)":                                                         "Capture de fenêtre (PipeWire)",
+	"Capture sources available in OBS: %s":                                              "Sources de capture disponibles dans OBS : %s",
+	"Add a PipeWire capture source in OBS and pick the screen or window in the dialog.": "Ajoutez une source de capture PipeWire dans OBS et choisissez l'écran ou la fenêtre dans la boîte de dialogue.",
+
+	// Gaming
+	"Gaming":               "Jeux",
+	"Setting up gaming...": "Configuration pour les jeux...",
+	"%s isn't available for this system, skipping":                                                              "%s n'est pas disponible pour ce système, ignoré",
+	"Added a window rule opening games fullscreen to %s: OK":                                                    "Règle de fenêtre ouvrant les jeux en plein écran ajoutée à %s : OK",
+	"Start a game with mangohud <game> for the overlay, or add mangohud %command% to its Steam launch options.": "Lancez un jeu avec mangohud <jeu> pour l'incrustation, ou ajoutez mangohud %command% à ses options de lancement Steam.",
+	"Run a game with gamescope -f -- <game> if it has trouble with scaling or focus.":                           "Lancez un jeu avec gamescope -f -- <jeu> s'il a des problèmes de mise à l'échelle ou de focus.",
+	"For smooth frame pacing, set VRR to On demand in Output Settings.":                                         "Pour une cadence d'images régulière, réglez le VRR sur À la demande dans Réglages des sorties.",
+
+	// Input Method
+	"Input Method":        "Méthode de saisie",
+	"Chinese (Pinyin)":    "Chinois (Pinyin)",
+	"Chinese (Rime)":      "Chinois (Rime)",
+	"Japanese (Mozc)":     "Japonais (Mozc)",
+	"Japanese (Anthy)":    "Japonais (Anthy)",
+	"Korean (Hangul)":     "Coréen (Hangul)",
+	"Vietnamese (Unikey)": "Vietnamien (Unikey)",
+	"Ctrl+Space switches between the keyboard layout and the input methods. Fine-tune them later with fcitx5-configtool.": "Ctrl+Espace bascule entre la disposition du clavier et les méthodes de saisie. Affinez-les ensuite avec fcitx5-configtool.",
+	"Kept the existing %s; add the new input methods with fcitx5-configtool":                                              "%s existant conservé ; ajoutez les nouvelles méthodes de saisie avec fcitx5-configtool",
+	"Log out and back in; then Ctrl+Space switches input methods.":                                                        "Déconnectez-vous puis reconnectez-vous ; Ctrl+Espace bascule alors entre les méthodes de saisie.",
+
+	// Fonts
+	"CJK and emoji fonts": "Polices CJK et émojis",
+	"Installs Noto CJK and Noto Color Emoji and makes fontconfig fall back to them, so East Asian text and emoji render in waybar, foot and browsers.": "Installe Noto CJK et Noto Color Emoji et fait que fontconfig s'en serve en repli, pour que le texte d'Asie de l'Est et les émojis s'affichent dans waybar, foot et les navigateurs.",
+	"turn on a language engine or the fonts":                      "activez un moteur de langue ou les polices",
+	"Rebuilt the font cache: OK":                                  "Cache des polices reconstruit : OK",
+	"Restart waybar, foot and your browser to pick up the fonts.": "Redémarrez waybar, foot et votre navigateur pour qu'ils prennent en compte les polices.",
+	"Warning: fc-cache: %s":                                       "Attention : fc-cache : %s",
+
+	// On-screen Keyboard
+	"On-screen Keyboard":                                    "Clavier virtuel",
+	"On-screen keyboard":                                    "Clavier virtuel",
+	"Setting up the on-screen keyboard...":                  "Configuration du clavier virtuel...",
+	"%s shows and hides the keyboard":                       "%s affiche et masque le clavier",
+	"Added a keyboard button to %s: OK":                     "Bouton clavier ajouté à %s : OK",
+	"The keyboard starts hidden the next time niri starts.": "Le clavier démarre masqué au prochain démarrage de niri.",
+
+	// Tablet
+	"Tablet":        "Tablette",
+	"Tablet input":  "Entrée tablette",
+	"Map to output": "Associer à la sortie",
+	"Left-handed":   "Gaucher",
+	"All outputs":   "Toutes les sorties",
+	"No tablet detected; plug it in and reopen this screen, or add yourself to the video group so libinput can see it.": "Aucune tablette détectée ; branchez-la et rouvrez cet écran, ou ajoutez-vous au groupe video pour que libinput la voie.",
+	"Detected: %s": "Détecté : %s",
+	"Confines the tablet area to one monitor so the pen's aspect ratio matches the screen. All outputs spreads it over the whole desktop.": "Limite la surface de la tablette à un moniteur pour que les proportions du stylet correspondent à l'écran. Toutes les sorties l'étend sur tout le bureau.",
+	"Turns the tablet upside down for holding the pen in the left hand.":                                                                   "Retourne la tablette pour tenir le stylet de la main gauche.",
+	"Updated the tablet settings in %s: OK": "Réglages de la tablette mis à jour dans %s : OK",
+	"Set the pressure curve in your drawing program, e.g. Krita's Tablet settings; niri passes pressure through unchanged.": "Réglez la courbe de pression dans votre logiciel de dessin, par ex. les réglages Tablette de Krita ; niri transmet la pression telle quelle.",
+
+	// Gamepads
+	"Gamepads":                          "Manettes",
+	"Setting up gamepad permissions...": "Configuration des droits des manettes...",
+	"Failed to create the %s group: %s": "Impossible de créer le groupe %s : %s",
+	"Created the %s group: OK":          "Groupe %s créé : OK",
+	"Failed to update %s: %v":           "Impossible de mettre à jour %s : %v",
+	"Gave the %s group access to /dev/input, /dev/uhid* and /dev/hidraw*: OK": "Accès à /dev/input, /dev/uhid* et /dev/hidraw* donné au groupe %s : OK",
+	"Warning: Adding user '%s' to the %s group: %s":                           "Avertissement : ajout de l'utilisateur '%s' au groupe %s : %s",
+	"Added user '%s' to the %s group: OK":                                     "Utilisateur '%s' ajouté au groupe %s : OK",
+	"Warning: Setting %s in %s: %s":                                           "Avertissement : réglage de %s dans %s : %s",
+	"Warning: Loading %s: %v":                                                 "Avertissement : chargement de %s : %v",
+	"Warning: Adding %s to kld_list: %v":                                      "Avertissement : ajout de %s à kld_list : %v",
+	"Reboot so controllers attach to the new HID stack.":                      "Redémarrez pour que les manettes utilisent la nouvelle pile HID.",
+
+	// Test Suspend
+	"Suspend key": "Touche de mise en veille",
+	"Locks the screen and suspends. Leave it empty for no key.":      "Verrouille l'écran et met en veille. Laissez vide pour aucune touche.",
+	"the suspend key must be a key combination like Mod+Shift+S":     "la touche de mise en veille doit être une combinaison comme Mod+Shift+S",
+	"%s locks the screen and suspends":                               "%s verrouille l'écran et met en veille",
+	"Run Test Suspend to check that the session survives a suspend.": "Lancez Tester la veille pour vérifier que la session survit à une mise en veille.",
+	"Test Suspend": "Tester la veille",
+	"The machine suspends now. Wake it up again and NiriSetup checks that the GPU, niri and the outputs came back.\n\nSuspend?": "La machine va se mettre en veille. Réveillez-la et NiriSetup vérifiera que le GPU, niri et les sorties sont revenus.\n\nMettre en veille ?",
+	"Waiting for the machine to suspend and resume...":                                                                          "Attente de la mise en veille et de la reprise...",
+	"No running niri session to check after resuming":                                                                           "Aucune session niri en cours à vérifier après la reprise",
+	"Suspend failed: %s": "Échec de la mise en veille : %s",
+	"Let the video group suspend without a password with Laptop Power.":                    "Autorisez le groupe video à mettre en veille sans mot de passe avec Alimentation portable.",
+	"The machine didn't suspend within two minutes":                                        "La machine ne s'est pas mise en veille en deux minutes",
+	"DRM render node %s: OK":                                                               "Nœud de rendu DRM %s : OK",
+	"DRM render node %s can't be opened after resuming; check dmesg for GPU driver errors": "Le nœud de rendu DRM %s ne s'ouvre plus après la reprise ; cherchez des erreurs du pilote GPU dans dmesg",
+	"niri session: OK": "Session niri : OK",
+	"niri doesn't answer over IPC after resuming; check Session Log": "niri ne répond plus par IPC après la reprise ; consultez Journal de session",
+	"Outputs %s: OK":                     "Sorties %s : OK",
+	"Outputs missing after resuming: %s": "Sorties manquantes après la reprise : %s",
+	"Suspend and resume work.":           "La mise en veille et la reprise fonctionnent.",
+
+	// Boot Environments
+	"%d older boot environments can be deleted in Boot Environments to free space in the pool": "%d anciens environnements de démarrage peuvent être supprimés dans Environnements de démarrage pour libérer de l'espace dans le pool",
+	"Pool %s: %s used, %s free": "Pool %s : %s utilisé, %s libre",
+	"(in use)":                  "(utilisé)",
+	"Boot Environments":         "Environnements de démarrage",
+	"Delete the %d oldest, keeping the newest %d":                                    "Supprimer les %d plus anciens, en gardant les %d plus récents",
+	"Delete these boot environments? They can't be rolled back to afterwards.\n\n%s": "Supprimer ces environnements de démarrage ? Il ne sera plus possible d'y revenir.\n\n%s",
+	"Deleting boot environments...":                                                  "Suppression des environnements de démarrage...",
+	"Failed to delete %s: %s":                                                        "Impossible de supprimer %s : %s",
+	"Deleted boot environment %s: OK":                                                "Environnement de démarrage %s supprimé : OK",
+	"Nothing to delete: NiriSetup keeps the newest %d boot environments":             "Rien à supprimer : NiriSetup garde les %d environnements de démarrage les plus récents",
+
+	// Commands That Hang
+	"%s has been running for %s.":       "%s tourne depuis %s.",
+	"w keep waiting • k kill":           "w continuer d'attendre • k arrêter",
+	"w keep waiting • r retry • k kill": "w continuer d'attendre • r réessayer • k arrêter",
+	"A command isn't finishing":         "Une commande ne se termine pas",
+	"It may be waiting on a lock held by another pkg, or on a stalled download. Keep waiting, or stop it and retry or give up.": "Elle attend peut-être un verrou tenu par un autre pkg, ou un téléchargement bloqué. Continuez d'attendre, ou arrêtez-la pour réessayer ou abandonner.",
+
+	// Step checklist
+	"Install %s":                                "Installer %s",
+	"Enable and start dbus":                     "Activer et démarrer dbus",
+	"Enable and start seatd":                    "Activer et démarrer seatd",
+	"Add you to the video group":                "Vous ajouter au groupe video",
+	"Load the DRM kernel module":                "Charger le module noyau DRM",
+	"Prepare the login environment in .profile": "Préparer l'environnement de connexion dans .profile",
+	"Set the session environment in config.kdl": "Définir l'environnement de session dans config.kdl",
+	"Check the DRM render device":               "Vérifier le périphérique de rendu DRM",
+	"Look for other desktops":                   "Chercher d'autres bureaux",
+	"Enabling %s service":                       "Activation du service %s",
+	"Starting %s service":                       "Démarrage du service %s",
+	"↑/↓ select • enter details":                "↑/↓ choisir • entrée détails",
+	"g report on GitHub • esc back":             "g signaler sur GitHub • échap retour",
+	"esc back":                                  "échap retour",
+
+	// Step engine
+	"Not run: there is no step %q":              "Non exécutée : il n'y a pas d'étape %q",
+	"Not run: it needs \"%s\", which failed":    "Non exécutée : elle dépend de « %s », qui a échoué",
+	"Already done":                              "Déjà fait",
+	"Not run: its prerequisites never finished": "Non exécutée : ses prérequis ne se sont jamais terminés",
+
+	// Hooks
+	"Warning: Hook %s is not executable, skipping it": "Avertissement : le hook %s n'est pas exécutable, il est ignoré",
+	"Hook %s failed: %v":                              "Échec du hook %s : %v",
+	"Ran hook %s: OK":                                 "Hook %s exécuté : OK",
+	"Stopped before %s because the hook failed":       "Arrêt avant %s car le hook a échoué",
+
+	// Manifests
+	"Everything in %s is already in place (%d items)": "Tout ce que demande %s est déjà en place (%d éléments)",
+	"%s: %d items missing, %d already in place":       "%s : %d éléments manquants, %d déjà en place",
+	"install package %s":                              "installer le paquet %s",
+	"enable and start service %s":                     "activer et démarrer le service %s",
+	"Enable and start %s":                             "Activer et démarrer %s",
+	"Update config.kdl":                               "Mettre à jour config.kdl",
+	"Updated %s:":                                     "%s mis à jour :",
+	"Applying the manifest...":                        "Application du manifeste...",
+	"Failed (%d): %s":                                 "Échecs (%d) : %s",
+	"%d changes of the manifest failed":               "%d modifications du manifeste ont échoué",
+
+	// Managed state
+	"Managed State":                          "État géré",
+	"Checking the changes NiriSetup made...": "Vérification des modifications faites par NiriSetup...",
+	"missing":                                "absent",
+	"unreadable: %v":                         "illisible : %v",
+	"added lines present":                    "lignes ajoutées présentes",
+	"added lines removed":                    "lignes ajoutées supprimées",
+	"as written":                             "tel qu'écrit",
+	"changed since":                          "modifié depuis",
+	"member":                                 "membre",
+	"not a member":                           "non membre",
+	"unset":                                  "non défini",
+	"expected unset, now %q":                 "attendu non défini, maintenant %q",
+	"expected %q, now unset":                 "attendu %q, maintenant non défini",
+	"includes %q":                            "contient %q",
+	"expected to include %q, now %q":         "devrait contenir %q, maintenant %q",
+	"expected %q, now %q":                    "attendu %q, maintenant %q",
+	"%s (appended %s)":                       "%s (ajout de %s)",
+	"%s in group %s":                         "%s dans le groupe %s",
+	"Files":                                  "Fichiers",
+	"System settings":                        "Réglages système",
+	"Groups":                                 "Groupes",
+	"NiriSetup hasn't changed anything for this user yet":    "NiriSetup n'a encore rien modifié pour cet utilisateur",
+	"NiriSetup made %d changes; all are as it left them.":    "NiriSetup a fait %d modifications ; toutes sont telles qu'il les a laissées.",
+	"NiriSetup made %d changes; %d differ from what it set.": "NiriSetup a fait %d modifications ; %d diffèrent de ce qu'il a réglé.",
+	"Failed to read the managed state: %v":                   "Impossible de lire l'état géré : %v",
+	"%d changes differ from what NiriSetup set":              "%d modifications diffèrent de ce que NiriSetup a réglé",
+
+	// Setup System preview
+	"Working out what Setup System would change...": "Calcul des modifications de Configurer le système...",
+	"/etc/rc.conf after sysrc":                      "/etc/rc.conf après sysrc",
+	"Start the %s service":                          "Démarrer le service %s",
+	"Add %s to the video group":                     "Ajouter %s au groupe video",
+	"Load the drm kernel module":                    "Charger le module noyau drm",
+	"Setup System has nothing to change: everything it sets up is already in place. Running it again only checks the render device and looks for other desktops.": "Configurer le système n'a rien à modifier : tout ce qu'il met en place est déjà là. Le relancer ne fait que vérifier le périphérique de rendu et chercher d'autres bureaux.",
+	"It also does this:":            "Il fait aussi ceci :",
+	"Apply these changes":           "Appliquer ces modifications",
+	"Run Setup System anyway":       "Lancer Configurer le système quand même",
+	"Setup System: pending changes": "Configurer le système : modifications en attente",
+
+	// sudo
+	"sudo needs a password, but there is no terminal to ask for it on; run sudo -v first":                           "sudo a besoin d'un mot de passe, mais aucun terminal ne permet de le demander ; lancez d'abord sudo -v",
+	"NiriSetup needs administrator rights for the next steps. sudo asks for your password once, for the whole run.": "NiriSetup a besoin des droits d'administrateur pour les étapes suivantes. sudo demande votre mot de passe une seule fois, pour toute l'exécution.",
+	"password for %u: ":          "mot de passe de %u : ",
+	"sudo authentication failed": "échec de l'authentification sudo",
+
+	// pkg bootstrap
+	"Failed to bootstrap pkg: %s": "Échec de l'installation de pkg : %s",
+	"Bootstrapped pkg: OK":        "pkg installé : OK",
+	"pkg isn't set up on this system yet, so no package can be installed. Run pkg bootstrap to install it from the FreeBSD package servers first?": "pkg n'est pas encore installé sur ce système, aucun paquet ne peut donc être installé. Lancer d'abord pkg bootstrap pour l'installer depuis les serveurs de paquets FreeBSD ?",
+	"Bootstrapping pkg...": "Installation de pkg...",
+	"pkg isn't bootstrapped on this system yet, so nothing can be installed. Run pkg bootstrap first, or install --yes to have NiriSetup do it.": "pkg n'est pas encore installé sur ce système, rien ne peut donc être installé. Lancez d'abord pkg bootstrap, ou install --yes pour que NiriSetup le fasse.",
+	"Failed to install %s: pkg isn't bootstrapped yet, run pkg bootstrap first":                                                                  "Échec de l'installation de %s : pkg n'est pas encore installé, lancez d'abord pkg bootstrap",
+
+	// Navigation
+	"Main": "Accueil",
+
+	// Menu
+	"Install":   "Installer",
+	"System":    "Système",
+	"Configure": "Configurer",
+	"Diagnose":  "Diagnostiquer",
+	"Maintain":  "Entretenir",
+	"Back":      "Retour",
+
+	// History
+	"History":                            "Historique",
+	"Reading the history...":             "Lecture de l'historique...",
+	"No actions have been recorded yet.": "Aucune action n'a encore été enregistrée.",
+	"Run %s again":                       "Relancer %s",
+	"%s needs a running niri session":    "%s nécessite une session niri en cours",
+	"Failed to read the history: %v":     "Impossible de lire l'historique : %v",
+
+	// Update banner
+	"Updates available: %s.":                                "Mises à jour disponibles : %s.",
+	"Maintain › Update Niri installs the packages.":         "Entretenir › Mettre à jour Niri installe les paquets.",
+	"To update NiriSetup, run git pull and go build again.": "Pour mettre à jour NiriSetup, lancez git pull puis go build.",
+
+	// GPU firmware
+	"GPU Firmware":                                    "Firmware GPU",
+	"Checking the GPU firmware...":                    "Vérification du firmware GPU...",
+	"pciconf lists no display controller.":            "pciconf ne liste aucun contrôleur graphique.",
+	"NiriSetup doesn't know the driver for this GPU.": "NiriSetup ne connaît pas le pilote de ce GPU.",
+	"The NVIDIA driver brings its own firmware.":      "Le pilote NVIDIA fournit son propre firmware.",
+	"Driver: %s, loaded":                              "Pilote : %s, chargé",
+	"Driver: %s, not loaded yet":                      "Pilote : %s, pas encore chargé",
+	"firmware not named in the message":               "firmware non nommé dans le message",
+	"installed since, %s loads it after a reboot":     "installé depuis, %s le charge après un redémarrage",
+	"Needs: %s": "Nécessite : %s",
+	"The driver loaded all the firmware it asked for.": "Le pilote a chargé tout le firmware demandé.",
+	"Installed: %s": "Installé : %s",
+	"%s is still missing: %s isn't installed": "%s manque toujours : %s n'est pas installé",
+	"%s is already loaded and loads the new firmware at the next boot. Reboot, then check GPU Firmware again.": "%s est déjà chargé et chargera le nouveau firmware au prochain démarrage. Redémarrez, puis vérifiez à nouveau Firmware GPU.",
+	"Failed to load %s: %v":                                            "Impossible de charger %s : %v",
+	"%s still fails to load firmware: %s":                              "%s n'arrive toujours pas à charger le firmware : %s",
+	"Open GPU Firmware again to install the packages for these files.": "Rouvrez Firmware GPU pour installer les paquets de ces fichiers.",
+	"%s loaded without firmware errors.":                               "%s chargé sans erreur de firmware.",
+	"Load %s and check its firmware":                                   "Charger %s et vérifier son firmware",
+	"Install %s and load %s":                                           "Installer %s et charger %s",
+	"Failed to check the GPU firmware: %v":                             "Impossible de vérifier le firmware GPU : %v",
+	"GPU Firmware in the System menu installs the package for your GPU, e.g. gpu-firmware-intel-kmod-kabylake; or install all firmware with: sudo pkg install gpu-firmware-kmod. Then reboot.": "Firmware GPU, dans le menu Système, installe le paquet de votre GPU, p. ex. gpu-firmware-intel-kmod-kabylake ; ou installez tout le firmware avec : sudo pkg install gpu-firmware-kmod. Puis redémarrez.",
+
+	// Setup steps
+	"Skipped, as you chose":  "Ignorée, comme vous l'avez choisi",
+	"Run":                    "Lancer",
+	"Skip":                   "Ignorer",
+	"Force":                  "Forcer",
+	"dbus service":           "Service dbus",
+	"seatd service":          "Service seatd",
+	"Video group":            "Groupe video",
+	"DRM module":             "Module DRM",
+	".profile":               ".profile",
+	"config.kdl environment": "Environnement config.kdl",
+	"Render device check":    "Vérif. du périphérique de rendu",
+	"Other desktops":         "Autres bureaux",
+	"Setup System Steps":     "Étapes de Configurer le système",
+	"Run: run it. Skip: don't touch it, now or in later runs.":                                                                    "Lancer : l'exécuter. Ignorer : ne pas y toucher, maintenant ni plus tard.",
+	"Run: run it unless it is already done. Skip: don't touch it, now or in later runs. Force: run it even though it looks done.": "Lancer : l'exécuter sauf si c'est déjà fait. Ignorer : ne pas y toucher, maintenant ni plus tard. Forcer : l'exécuter même si cela semble déjà fait.",
+	"Skipped, as you chose: %s":              "Ignorées, comme vous l'avez choisi : %s",
+	"Choose the steps to skip or force":      "Choisir les étapes à ignorer ou forcer",
+	"There is no step %q. The steps are: %s": "Il n'y a pas d'étape %q. Les étapes sont : %s",
+
+	// Config sections
+	"Input":                    "Entrée",
+	"Outputs":                  "Sorties",
+	"Startup programs":         "Programmes au démarrage",
+	"Environment":              "Environnement",
+	"Window rules":             "Règles de fenêtres",
+	"Key bindings":             "Raccourcis clavier",
+	"Other settings":           "Autres réglages",
+	"Configure Niri: Sections": "Configurer Niri : sections",
+	"Keep mine":                "Garder les miens",
+	"Apply":                    "Appliquer",
+	"Apply: merge the template's settings for this part into your config; your other settings stay. Keep mine: leave this part of your config alone.": "Appliquer : fusionner les réglages du modèle pour cette partie dans votre config ; vos autres réglages restent. Garder les miens : ne pas toucher à cette partie de votre config.",
+	"Pick at least one section to apply":                                                    "Choisissez au moins une section à appliquer",
+	"Your config already has everything these sections of the template set.":                "Votre config contient déjà tout ce que ces sections du modèle définissent.",
+	"niri is running and will reload the changed config immediately.\n\nWrite the changes?": "niri est en cours d'exécution et rechargera aussitôt la config modifiée.\n\nÉcrire les modifications ?",
+	"Write these changes":                              "Écrire ces modifications",
+	"%s already has everything these sections set: %s": "%s contient déjà tout ce que ces sections définissent : %s",
+	"Merged %s from the template into %s:":             "%s du modèle fusionné dans %s :",
+	"Apply only some sections to my config":            "N'appliquer que certaines sections à ma config",
+
+	// Color Scheme
+	"Color Scheme":     "Jeu de couleurs",
+	"Scheme":           "Jeu",
+	"GhostBSD default": "GhostBSD par défaut",
+	"Sets the focus ring and border colors in config.kdl and the colors of waybar, foot, alacritty, fuzzel and mako to match. Dark or light for GTK and Qt programs is set under Appearance.": "Règle les couleurs de l'anneau de focus et des bordures dans config.kdl et les couleurs de waybar, foot, alacritty, fuzzel et mako en accord. Le mode sombre ou clair des programmes GTK et Qt se règle dans Apparence.",
+	"Pick a color scheme": "Choisissez un jeu de couleurs",
+	"Applied the %s color scheme. niri, waybar and mako pick it up right away; new foot, alacritty and fuzzel windows use it.": "Jeu de couleurs %s appliqué. niri, waybar et mako le prennent en compte tout de suite ; les nouvelles fenêtres foot, alacritty et fuzzel l'utilisent.",
+
+	// Answer File
+	"Choose the keyboard layout":                                          "Choisir la disposition du clavier",
+	"Sets the xkb keyboard layout, variant and options in config.kdl.":    "Règle la disposition, la variante et les options xkb du clavier dans config.kdl.",
+	"Adjust the layout":                                                   "Ajuster la disposition des fenêtres",
+	"Sets the gaps, column widths, focus ring and borders in config.kdl.": "Règle les espacements, la largeur des colonnes, l'anneau de focus et les bordures dans config.kdl.",
+	"Profile": "Profil",
+	"Desktop": "Bureau",
+	"Laptop":  "Portable",
+	"Minimal": "Minimal",
+	"Desktop, Laptop, Gaming and Minimal pick the components for that kind of machine; Custom uses the choices below.": "Bureau, Portable, Jeux et Minimal choisissent les composants pour ce type de machine ; Personnalisé utilise les choix ci-dessous.",
+	"Keyboard": "Clavier",
+	"Variant":  "Variante",
+	"Options":  "Options",
+	"xkb layouts, comma-separated, e.g. us or us,de. Empty keeps the layout the system picks.": "Dispositions xkb, séparées par des virgules, par exemple us ou us,de. Vide garde la disposition choisie par le système.",
+	"xkb variants for the layouts, comma-separated, e.g. colemak or ,nodeadkeys.":              "Variantes xkb des dispositions, séparées par des virgules, par exemple colemak ou ,nodeadkeys.",
+	"xkb options, e.g. grp:alt_shift_toggle to switch layouts or ctrl:nocaps.":                 "Options xkb, par exemple grp:alt_shift_toggle pour changer de disposition ou ctrl:nocaps.",
+	"xkb settings can't contain spaces or quotes":                                              "Les réglages xkb ne peuvent pas contenir d'espaces ni de guillemets",
+	"Failed to write the keyboard layout: %v":                                                  "Échec de l'écriture de la disposition du clavier : %v",
+	"Keyboard settings written to %s":                                                          "Réglages du clavier écrits dans %s",
+	"Keyboard layout %s written to %s":                                                         "Disposition du clavier %s écrite dans %s",
+	"%s asked a question the answer file can't answer":                                         "%s a posé une question à laquelle le fichier de réponses ne peut pas répondre",
+
+	// Runtime Directory
+	"XDG_RUNTIME_DIR is unusable: %s": "XDG_RUNTIME_DIR est inutilisable : %s",
+	"Remove the directory and create it again, owned by you with mode 700; or let pam_xdg create /var/run/user/<uid> at login and use that instead.": "Supprimez le répertoire et recréez-le, à vous avec le mode 700 ; ou laissez pam_xdg créer /var/run/user/<uid> à la connexion et utilisez-le à la place.",
+	"Recreate the runtime directory":                                      "Recréer le répertoire d'exécution",
+	"Use the runtime directory of pam_xdg":                                "Utiliser le répertoire d'exécution de pam_xdg",
+	"Created %s again, owned by you with mode 700: OK":                    "%s recréé, à vous avec le mode 700 : OK",
+	"%s already uses pam_xdg: OK":                                         "%s utilise déjà pam_xdg : OK",
+	"Added pam_xdg to %s (backup in %s.bak): OK":                          "pam_xdg ajouté à %s (sauvegarde dans %s.bak) : OK",
+	"Removed XDG_RUNTIME_DIR from %s: OK":                                 "XDG_RUNTIME_DIR retiré de %s : OK",
+	"Removed %s: OK":                                                      "%s supprimé : OK",
+	"Log out and back in; XDG_RUNTIME_DIR will then be /var/run/user/%d.": "Déconnectez-vous puis reconnectez-vous ; XDG_RUNTIME_DIR sera alors /var/run/user/%d.",
+	"Warning: Failed to create runtime directory: %v":                     "Avertissement : échec de la création du répertoire d'exécution : %v",
+	"Warning: Failed to stat runtime directory: %v":                       "Avertissement : impossible d'examiner le répertoire d'exécution : %v",
+	"Warning: XDG_RUNTIME_DIR is unusable: %s. Doctor can repair it.":     "Avertissement : XDG_RUNTIME_DIR est inutilisable : %s. Le Diagnostic peut le réparer.",
+
+	// GPU Driver
+	"GPU Driver":           "Pilote graphique",
+	"Detecting the GPU...": "Détection du GPU...",
+	"Load %s":              "Charger %s",
+	"NiriSetup knows no DRM driver for this GPU (vendor %s), so it gets no render node. niri needs one to draw.": "NiriSetup ne connaît aucun pilote DRM pour ce GPU (fabricant %s), il n'a donc pas de nœud de rendu. niri en a besoin pour dessiner.",
+	"%s isn't installed. It holds %s, the driver that creates the render node for this GPU.":                     "%s n'est pas installé. Il contient %s, le pilote qui crée le nœud de rendu de ce GPU.",
+	"%s is installed, but %s isn't loaded.":                                                "%s est installé, mais %s n'est pas chargé.",
+	"%s is loaded but failed to load its firmware; GPU Firmware installs it.":              "%s est chargé mais n'a pas pu charger son firmware ; Firmware GPU l'installe.",
+	"%s is loaded but created no render node; Doctor shows what the kernel said about it.": "%s est chargé mais n'a créé aucun nœud de rendu ; le Diagnostic montre ce que le noyau en a dit.",
+	"not installed":         "non installé",
+	"loaded":                "chargé",
+	"installed, not loaded": "installé, non chargé",
+	"Driver:":               "Pilote :",
+	"Firmware to install:":  "Firmware à installer :",
+	"%s is loaded, but no render node showed up in /dev/dri":       "%s est chargé, mais aucun nœud de rendu n'est apparu dans /dev/dri",
+	"Failed to detect the GPU: %v":                                 "Échec de la détection du GPU : %v",
+	"There is no DRM render node in /dev/dri, so niri can't start": "Il n'y a aucun nœud de rendu DRM dans /dev/dri, niri ne peut donc pas démarrer",
+	"Install and load the GPU driver":                              "Installer et charger le pilote graphique",
+	"no render device":                                             "aucun périphérique de rendu",
+	"  /dev/dri doesn't exist: no GPU driver is loaded. System › GPU Driver shows your GPU and installs and loads its driver.": "  /dev/dri n'existe pas : aucun pilote graphique n'est chargé. Système › Pilote graphique montre votre GPU, puis installe et charge son pilote.",
+	"  System › GPU Driver shows your GPU and installs and loads its driver.":                                                  "  Système › Pilote graphique montre votre GPU, puis installe et charge son pilote.",
+
+	// Session launcher
+	"Last Session Log":                                  "Dernier journal de session",
+	"Write the session launcher":                        "Écrire le lanceur de session",
+	"%s is up to date: OK":                              "%s est à jour : OK",
+	"Wrote %s, which starts niri and saves its log: OK": "%s écrit ; il lance niri et enregistre son journal : OK",
+	"Last written: %s":                                  "Dernière écriture : %s",
+	"Showing the last %d lines.":                        "Affichage des %d dernières lignes.",
+	"No session log in %s.":                             "Aucun journal de session dans %s.",
+	"Start niri from a TTY with %s, which Setup System writes, to save its log there.":                                "Lancez niri depuis un TTY avec %s, qu'écrit Configurer le système, pour y enregistrer son journal.",
+	"niri logs to its standard error. Start it from a TTY with %s, which Setup System writes, to save its log there.": "niri écrit son journal sur sa sortie d'erreur. Lancez-le depuis un TTY avec %s, qu'écrit Configurer le système, pour l'y enregistrer.",
+	"Explain the errors":      "Expliquer les erreurs",
+	"Show the session before": "Afficher la session précédente",
+	"Show the last session":   "Afficher la dernière session",
+
+	// Waybar workspaces
+	"Waybar Workspaces":                   "Espaces de travail dans waybar",
+	"Setting up the waybar workspaces...": "Configuration des espaces de travail de waybar...",
+	"(focused)":                           "(actif)",
+	"waybar didn't put a bar on screen; run waybar in a terminal inside niri to see why": "waybar n'a affiché aucune barre ; lancez waybar dans un terminal sous niri pour savoir pourquoi",
+	"waybar shows a bar: OK": "waybar affiche une barre : OK",
+	"niri reports these workspaces, which the bar should show: %s": "niri signale ces espaces de travail, que la barre devrait afficher : %s",
+	"Warning: Could not update waybar: %s":                         "Avertissement : impossible de mettre à jour waybar : %s",
+	"Failed to check waybar: %v":                                   "Échec de la vérification de waybar : %v",
+	"waybar has no niri modules":                                   "waybar n'a pas les modules niri",
+	"This waybar was built without its niri modules, which waybar's niri build option adds. Install a waybar built with it, for example from ports, then run this again.": "Ce waybar a été compilé sans ses modules niri, qu'ajoute l'option de compilation niri de waybar. Installez un waybar compilé avec, par exemple depuis les ports, puis relancez ceci.",
+	"waybar has the niri modules: OK":                                            "waybar a les modules niri : OK",
+	"Added niri/workspaces and niri/window to %s: OK":                            "niri/workspaces et niri/window ajoutés à %s : OK",
+	"waybar starts with niri (%s): OK":                                           "waybar démarre avec niri (%s) : OK",
+	"niri isn't running; the bar shows the workspaces from the next session on.": "niri ne tourne pas ; la barre affichera les espaces de travail à partir de la prochaine session.",
+	"Failed to check the bar: %v":                                                "Échec de la vérification de la barre : %v",
+
+	// Setup System
+	"Failed steps (%d): %s": "Étapes en échec (%d) : %s",
+
+	// Layout widths
+	"fixed needs a width in pixels, like fixed 1280": "fixed demande une largeur en pixels, p. ex. fixed 1280",
+	"enter one default column width":                 "indiquez une seule largeur de colonne par défaut",
+
+	// Runtime directory
+	"niri is running and uses the runtime directory; log out of niri first, then run this again from a TTY": "niri est en cours d'exécution et utilise le répertoire d'exécution ; quittez d'abord niri, puis relancez ceci depuis un TTY",
+
+	// Environment snapshot
+	"the packages of the snapshot":                                     "les paquets de l'archive",
+	"Failed to install the packages of the snapshot: %s":               "Échec de l'installation des paquets de l'archive : %s",
+	"Installed the %d packages of the snapshot: OK":                    "Les %d paquets de l'archive sont installés : OK",
+	"Warning: Skipping %s: snapshots only carry *_enable and kld_list": "Avertissement : %s ignoré : les archives ne contiennent que *_enable et kld_list",
+
+	// Runtime directory
+	"pam_xdg sets XDG_RUNTIME_DIR at login, noted in %s: OK": "pam_xdg définit XDG_RUNTIME_DIR à la connexion, noté dans %s : OK",
+
+	// Install
+	"install would install these %d packages:": "install installerait ces %d paquets :",
+	"%s (already installed)":                   "%s (déjà installé)",
+	"Dry run: nothing was installed.":          "Simulation : rien n'a été installé.",
+
+	// Gamepads
+	"Most controllers need %s=1 in %s. It moves every USB keyboard, mouse and other input device to the hidbus stack at the next boot, not only the controllers. Set it?": "La plupart des manettes ont besoin de %s=1 dans %s. Cela fait passer chaque clavier, souris et autre périphérique d'entrée USB sur la pile hidbus au prochain démarrage, pas seulement les manettes. Le définir ?",
+	"Left %s alone: controllers that need the hidbus stack won't show up as gamepads.":                                                                                    "%s laissé tel quel : les manettes qui ont besoin de la pile hidbus n'apparaîtront pas comme manettes.",
+	"Set %s=1 in %s: OK. From the next boot every USB input device, keyboards and mice too, uses the hidbus stack.":                                                       "%s=1 défini dans %s : OK. Dès le prochain démarrage, chaque périphérique d'entrée USB, claviers et souris compris, utilise la pile hidbus.",
+
+	// Remote setup
+	"%s isn't a host: it can't start with -":          "%s n'est pas un hôte : il ne peut pas commencer par -",
+	"Failed to copy the config template %s to %s: %s": "Impossible de copier le modèle de configuration %s vers %s : %s",
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// installedNiriPackages lists the niri packages that are installed.
+func installedNiriPackages() []string {
+	var installed []string
+	for _, pkg := range niriPackages {
+		if isPackageInstalled(pkg) {
+			installed = append(installed, pkg)
+		}
+	}
+	return installed
+}
+
+// lockedNiriPackages lists the niri packages that pkg has locked.
+func lockedNiriPackages() []string {
+	out, err := commandOutput(exec.Command("pkg", "query", "-e", "%k = 1", "%n"))
+	if err != nil {
+		return nil
+	}
+	var locked []string
+	for _, pkg := range strings.Fields(string(out)) {
+		if containsString(niriPackages, pkg) {
+			locked = append(locked, pkg)
+		}
+	}
+	return locked
+}
+
+// setPackageLock locks or unlocks pkgs with pkg lock, so that pkg upgrade
+// leaves them alone.
+func setPackageLock(lock bool, pkgs []string) error {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	verb := "unlock"
+	if lock {
+		verb = "lock"
+	}
+	args := append([]string{"pkg", verb, "-y"}, pkgs...)
+	if out, err := commandCombinedOutput(exec.Command("sudo", args...)); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// lockNiriStack locks or unlocks every installed niri package.
+func lockNiriStack(lock bool) tea.Cmd {
+	return func() tea.Msg {
+		pkgs := installedNiriPackages()
+		if len(pkgs) == 0 {
+			return statusMsg{status: T("None of the niri packages are installed yet"), err: preflightFailure(fmt.Errorf("nothing installed"))}
+		}
+		if err := setPackageLock(lock, pkgs); err != nil {
+			return statusMsg{status: Tf("Failed to change the package locks: %v", err), err: err}
+		}
+		if lock {
+			return statusMsg{status: Tf("Locked %d packages, pkg upgrade now leaves them alone: %s", len(pkgs), strings.Join(pkgs, ", "))}
+		}
+		return statusMsg{status: Tf("Unlocked %d packages: %s", len(pkgs), strings.Join(pkgs, ", "))}
+	}
+}
+
+// withUnlockedStack runs action with the locked niri packages unlocked,
+// and locks them again afterwards, whatever the outcome.
+func withUnlockedStack(action tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		locked := lockedNiriPackages()
+		if err := setPackageLock(false, locked); err != nil {
+			return statusMsg{status: Tf("Failed to unlock the niri packages: %v", err), err: preflightFailure(err)}
+		}
+		msg := action()
+		if len(locked) == 0 {
+			return msg
+		}
+		status, ok := msg.(statusMsg)
+		if !ok {
+			setPackageLock(true, locked)
+			return msg
+		}
+		line := Tf("Unlocked %d packages for the update and locked them again", len(locked))
+		if err := setPackageLock(true, locked); err != nil {
+			line = Tf("Warning: Failed to lock the niri packages again: %v", err)
+			if status.err == nil {
+				status.err = err
+			}
+		}
+		status.status += "\n" + line
+		return status
+	}
+}
+
+// updateNiri upgrades the installed niri packages and their dependencies.
+func updateNiri() tea.Cmd {
+	return func() tea.Msg {
+		pkgs := installedNiriPackages()
+		if len(pkgs) == 0 {
+			return statusMsg{status: T("None of the niri packages are installed yet"), err: preflightFailure(fmt.Errorf("nothing installed"))}
+		}
+		args := append([]string{"pkg", "upgrade", "-y"}, pkgs...)
+		out, err := commandCombinedOutput(exec.Command("sudo", args...))
+		if err != nil {
+			return statusMsg{status: Tf("Failed to update the niri packages: %s", strings.TrimSpace(string(out))), err: err}
+		}
+		if _, err := os.Stat(updateStatusPath); err == nil {
+			// The updates the weekly check found are installed now.
+			runCommand(exec.Command("sudo", "truncate", "-s", "0", updateStatusPath))
+		}
+		return statusMsg{status: Tf("Updated the niri packages (%d checked)", len(pkgs))}
+	}
+}
+
+func newPackageLockForm() *form {
+	value := "Unlocked"
+	if len(lockedNiriPackages()) > 0 {
+		value = "Locked"
+	}
+	return &form{
+		title: "Package Lock",
+		fields: []formField{
+			{label: "niri packages", value: value, options: []string{"Locked", "Unlocked"}},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			return lockNiriStack(values[0] == "Locked"), nil
+		},
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The managed state: every file, rc variable and group membership
+// NiriSetup has ever changed, with the value it set, so that Managed State
+// and nirisetup status can show what it changed and what has drifted since.
+var managedMu sync.Mutex
+
+// Kinds of managedItem.
+const (
+	managedFile      = "file"
+	managedAppend    = "append"
+	managedSysrc     = "sysrc"
+	managedSysrcAdd  = "sysrc+="
+	managedSysrcDrop = "sysrc-x"
+	managedGroup     = "group"
+)
+
+// managedItem is one change. For files, expected is the SHA-256 of the
+// content written; for appends, the text appended. For rc variables, path
+// is the file sysrc edited and name the variable; for groups, path is the
+// group and name the member.
+type managedItem struct {
+	kind     string
+	path     string
+	name     string
+	expected string
+	changed  time.Time
+}
+
+// key identifies what an item is about; a later change to the same thing
+// replaces the earlier one.
+func (it managedItem) key() string {
+	switch it.kind {
+	case managedAppend, managedSysrcAdd:
+		return it.kind + "\t" + it.path + "\t" + it.name + "\t" + it.expected
+	case managedSysrc, managedSysrcDrop:
+		// Setting and removing a variable are about the same thing.
+		return managedSysrc + "\t" + it.path + "\t" + it.name
+	}
+	return it.kind + "\t" + it.path + "\t" + it.name
+}
+
+func managedStatePath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "nirisetup", "managed"), nil
+}
+
+// loadManagedState reads the changes recorded so far, oldest first. A
+// missing file means there are none.
+func loadManagedState() ([]managedItem, error) {
+	path, err := managedStatePath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []managedItem
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 5 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		expected, err := strconv.Unquote(fields[3])
+		if err != nil {
+			continue
+		}
+		changed, _ := time.Parse(time.RFC3339, fields[4])
+		items = append(items, managedItem{fields[0], fields[1], fields[2], expected, changed})
+	}
+	return items, scanner.Err()
+}
+
+// trackChange adds it to the managed state, replacing an earlier change
+// to the same thing. Failing to keep the state doesn't fail the change, so
+// it is only traced.
+func trackChange(it managedItem) {
+	managedMu.Lock()
+	defer managedMu.Unlock()
+	path, err := managedStatePath()
+	if err != nil {
+		return
+	}
+	items, err := loadManagedState()
+	if err != nil {
+		tracef("reading %s failed: %v", path, err)
+		return
+	}
+	it.changed = time.Now()
+	var b strings.Builder
+	b.WriteString("# Changes made by NiriSetup, see nirisetup status.\n")
+	var kept []managedItem
+	for _, old := range items {
+		if old.key() != it.key() {
+			kept = append(kept, old)
+		}
+	}
+	for _, k := range append(kept, it) {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\n", k.kind, k.path, k.name, strconv.Quote(k.expected), k.changed.Format(time.RFC3339))
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(b.String()), 0644)
+	}
+	if err != nil {
+		tracef("write %s failed: %v", path, err)
+		return
+	}
+	chownToUser(path)
+}
+
+// trackFile records a file NiriSetup wrote or appended to. Temporary files,
+// like the configs Test Config runs, aren't part of the setup.
+func trackFile(path string, data []byte, appending bool) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if strings.HasPrefix(path, filepath.Clean(os.TempDir())+"/") {
+		return
+	}
+	if appending {
+		trackChange(managedItem{kind: managedAppend, path: path, expected: string(data)})
+		return
+	}
+	sum := sha256.Sum256(data)
+	trackChange(managedItem{kind: managedFile, path: path, expected: hex.EncodeToString(sum[:])})
+}
+
+// trackCommand records the rc variables and group memberships a
+// successful sudo sysrc or pw groupmod changed.
+func trackCommand(cmd *exec.Cmd, err error) {
+	if err != nil || len(cmd.Args) < 3 || cmd.Args[0] != "sudo" {
+		return
+	}
+	args := cmd.Args[2:]
+	switch filepath.Base(cmd.Args[1]) {
+	case "sysrc":
+		file, drop := "/etc/rc.conf", false
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			switch {
+			case arg == "-f" && i+1 < len(args):
+				file = args[i+1]
+				i++
+			case arg == "-x":
+				drop = true
+			case strings.HasPrefix(arg, "-"):
+			case drop:
+				trackChange(managedItem{kind: managedSysrcDrop, path: file, name: arg})
+			case strings.Contains(arg, "+="):
+				name, value, _ := strings.Cut(arg, "+=")
+				trackChange(managedItem{kind: managedSysrcAdd, path: file, name: name, expected: value})
+			case strings.Contains(arg, "="):
+				name, value, _ := strings.Cut(arg, "=")
+				trackChange(managedItem{kind: managedSysrc, path: file, name: name, expected: value})
+			}
+		}
+	case "pw":
+		if len(args) == 4 && args[0] == "groupmod" && args[2] == "-m" {
+			for _, user := range strings.Split(args[3], ",") {
+				trackChange(managedItem{kind: managedGroup, path: args[1], name: user})
+			}
+		}
+	}
+}
+
+// check returns what it looks like now and whether that is still what
+// NiriSetup set.
+func (it managedItem) check() (string, bool) {
+	switch it.kind {
+	case managedFile, managedAppend:
+		data, err := os.ReadFile(it.path)
+		if os.IsNotExist(err) {
+			return T("missing"), false
+		}
+		if err != nil {
+			return Tf("unreadable: %v", err), false
+		}
+		if it.kind == managedAppend {
+			if strings.Contains(string(data), strings.TrimSpace(it.expected)) {
+				return T("added lines present"), true
+			}
+			return T("added lines removed"), false
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) == it.expected {
+			return T("as written"), true
+		}
+		return T("changed since"), false
+	case managedGroup:
+		if inGroup(it.name, it.path) {
+			return T("member"), true
+		}
+		return T("not a member"), false
+	}
+
+	out, err := commandOutput(exec.Command("sysrc", "-f", it.path, "-n", it.name))
+	current := strings.TrimSpace(string(out))
+	switch {
+	case it.kind == managedSysrcDrop:
+		if err != nil {
+			return T("unset"), true
+		}
+		return Tf("expected unset, now %q", current), false
+	case err != nil:
+		return Tf("expected %q, now unset", it.expected), false
+	case it.kind == managedSysrcAdd:
+		if containsString(strings.Fields(current), it.expected) {
+			return Tf("includes %q", it.expected), true
+		}
+		return Tf("expected to include %q, now %q", it.expected, current), false
+	case current == it.expected:
+		return strconv.Quote(current), true
+	default:
+		return Tf("expected %q, now %q", it.expected, current), false
+	}
+}
+
+// label names what it is about.
+func (it managedItem) label() string {
+	switch it.kind {
+	case managedFile:
+		return it.path
+	case managedAppend:
+		first, _, _ := strings.Cut(strings.TrimSpace(it.expected), "\n")
+		return Tf("%s (appended %s)", it.path, first)
+	case managedGroup:
+		return Tf("%s in group %s", it.name, it.path)
+	}
+	return it.path + " " + it.name
+}
+
+// managedStateReport lists every change NiriSetup made with its current
+// state. It returns how many have drifted from what NiriSetup set.
+func managedStateReport() (string, int, error) {
+	items, err := loadManagedState()
+	if err != nil {
+		return "", 0, err
+	}
+	if len(items) == 0 {
+		return T("NiriSetup hasn't changed anything for this user yet"), 0, nil
+	}
+	sections := []struct {
+		title string
+		kinds []string
+	}{
+		{"Files", []string{managedFile, managedAppend}},
+		{"System settings", []string{managedSysrc, managedSysrcAdd, managedSysrcDrop}},
+		{"Groups", []string{managedGroup}},
+	}
+	var b strings.Builder
+	drifted := 0
+	for _, sec := range sections {
+		var lines []string
+		for _, it := range items {
+			if !containsString(sec.kinds, it.kind) {
+				continue
+			}
+			state, ok := it.check()
+			mark := "✓"
+			if !ok {
+				mark = "✗"
+				drifted++
+			}
+			lines = append(lines, fmt.Sprintf("  %s %s: %s (%s)", mark, it.label(), state, it.changed.Format("2006-01-02 15:04")))
+		}
+		if len(lines) > 0 {
+			b.WriteString(T(sec.title) + "\n" + strings.Join(lines, "\n") + "\n\n")
+		}
+	}
+	summary := Tf("NiriSetup made %d changes; all are as it left them.", len(items))
+	if drifted > 0 {
+		summary = Tf("NiriSetup made %d changes; %d differ from what it set.", len(items), drifted)
+	}
+	return summary + "\n\n" + strings.TrimRight(b.String(), "\n"), drifted, nil
+}
+
+// managedStateView shows the managed state on a report screen.
+func managedStateView() tea.Cmd {
+	return func() tea.Msg {
+		body, _, err := managedStateReport()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to read the managed state: %v", err), err: err}
+		}
+		return reportMsg{&report{title: "Managed State", body: body, refresh: managedStateView()}}
+	}
+}
+
+// runStatus is nirisetup status. Changes that have drifted make it a
+// partial failure, so that scripts can notice them.
+func runStatus() (string, error) {
+	body, drifted, err := managedStateReport()
+	if err != nil {
+		return Tf("Failed to read the managed state: %v", err), preflightFailure(err)
+	}
+	if drifted > 0 {
+		return body, errors.New(Tf("%d changes differ from what NiriSetup set", drifted))
+	}
+	return body, nil
+}
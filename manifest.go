@@ -0,0 +1,432 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// manifest is the desired state nirisetup apply brings the system to:
+// packages to install, rc.d services to enable and start, variables for
+// the environment block of config.kdl and fragments of config.kdl.
+type manifest struct {
+	path        string
+	packages    []string
+	services    []string
+	environment []manifestVar
+	config      []string
+}
+
+// manifestVar is one variable of the environment section, in the order of
+// the manifest.
+type manifestVar struct {
+	name, value string
+}
+
+// manifestEdit is one node of the config that a config fragment or the
+// environment section asks for. Repeatable nodes, like window rules, are
+// added next to those already there instead of replacing the first one.
+type manifestEdit struct {
+	path   []string
+	node   string
+	repeat bool
+}
+
+// repeatableKDLNodes may appear more than once in the same block, so a
+// fragment adds them and compares them with all the nodes of that name.
+var repeatableKDLNodes = map[string]bool{
+	"spawn-at-startup": true,
+	"window-rule":      true,
+	"layer-rule":       true,
+}
+
+// readManifest reads and parses the manifest at path.
+func readManifest(path string) (*manifest, error) {
+	path = expandHome(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(path, string(data))
+}
+
+// parseManifest parses the subset of YAML manifests are written in:
+// top-level keys holding a list (block or [flow] style), a map of scalars,
+// or | block scalars for the config fragments, plus # comments and quoted
+// strings.
+func parseManifest(path, data string) (*manifest, error) {
+	m := &manifest{path: path}
+	err := yamlTopLevel(path, data, func(key string, items []string, vars []manifestVar, line int) error {
+		switch {
+		case key == "environment" && items != nil:
+			return fmt.Errorf("%s:%d: environment must map names to values", path, line)
+		case key == "environment":
+			m.environment = append(m.environment, vars...)
+		case vars != nil:
+			return fmt.Errorf("%s:%d: %s must be a list", path, line, key)
+		case key == "packages":
+			m.packages = append(m.packages, items...)
+		case key == "services":
+			m.services = append(m.services, items...)
+		case key == "config":
+			m.config = append(m.config, items...)
+		default:
+			return fmt.Errorf("%s:%d: unknown key %q, expected packages, services, environment or config", path, line, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// yamlTopLevel calls fn with each top-level key of data, the value yamlValue
+// parsed from it and the number of its line, stopping at the first error.
+func yamlTopLevel(path, data string, fn func(key string, items []string, vars []manifestVar, line int) error) error {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); {
+		text := yamlStrip(lines[i])
+		if text == "" || text == "---" {
+			i++
+			continue
+		}
+		if yamlIndent(lines[i]) > 0 {
+			return fmt.Errorf("%s:%d: expected a top-level key", path, i+1)
+		}
+		key, rest, ok := strings.Cut(text, ":")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected \"key:\"", path, i+1)
+		}
+		// The value is everything indented below the key.
+		j := i + 1
+		for j < len(lines) && (strings.TrimSpace(lines[j]) == "" || yamlIndent(lines[j]) > 0) {
+			j++
+		}
+		items, vars, err := yamlValue(path, strings.TrimSpace(rest), lines[i+1:j], i+2)
+		if err != nil {
+			return err
+		}
+		if err := fn(strings.TrimSpace(key), items, vars, i+1); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+// yamlValue parses the value of a top-level key: rest is what follows the
+// colon and body the indented lines below, the first of which is line
+// number first. It returns the items of a list or a single scalar, or the
+// entries of a map.
+func yamlValue(path, rest string, body []string, first int) ([]string, []manifestVar, error) {
+	switch {
+	case rest == "|" || rest == "|-":
+		return []string{yamlBlockScalar(body)}, nil, nil
+	case strings.HasPrefix(rest, "["):
+		if !strings.HasSuffix(rest, "]") {
+			return nil, nil, fmt.Errorf("%s:%d: unterminated [ list", path, first-1)
+		}
+		var items []string
+		for _, item := range strings.Split(rest[1:len(rest)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, yamlUnquote(item))
+			}
+		}
+		return items, nil, nil
+	case rest != "":
+		return []string{yamlUnquote(rest)}, nil, nil
+	}
+
+	var items []string
+	var vars []manifestVar
+	for i := 0; i < len(body); i++ {
+		text := yamlStrip(body[i])
+		if text == "" {
+			continue
+		}
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			if vars != nil {
+				return nil, nil, fmt.Errorf("%s:%d: list item in a map", path, first+i)
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(text, "-"))
+			if value != "|" && value != "|-" {
+				items = append(items, yamlUnquote(value))
+				continue
+			}
+			indent := yamlIndent(body[i])
+			j := i + 1
+			for j < len(body) && (strings.TrimSpace(body[j]) == "" || yamlIndent(body[j]) > indent) {
+				j++
+			}
+			items = append(items, yamlBlockScalar(body[i+1:j]))
+			i = j - 1
+			continue
+		}
+		name, value, ok := strings.Cut(text, ":")
+		if !ok || items != nil {
+			return nil, nil, fmt.Errorf("%s:%d: expected \"- item\" or \"name: value\"", path, first+i)
+		}
+		vars = append(vars, manifestVar{yamlUnquote(strings.TrimSpace(name)), yamlUnquote(strings.TrimSpace(value))})
+	}
+	if items == nil && vars == nil {
+		items = []string{}
+	}
+	return items, vars, nil
+}
+
+// yamlIndent returns the number of leading spaces of line.
+func yamlIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// yamlStrip trims line and drops a trailing # comment outside quotes.
+func yamlStrip(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// yamlUnquote returns the contents of a quoted YAML scalar, or s unchanged
+// if it isn't quoted.
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+// yamlBlockScalar joins the lines of a | block scalar, without the
+// indentation of its first line and without trailing blank lines.
+func yamlBlockScalar(lines []string) string {
+	indent := -1
+	var out []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			out = append(out, "")
+			continue
+		}
+		if indent < 0 {
+			indent = yamlIndent(line)
+		}
+		out = append(out, line[min(indent, yamlIndent(line)):])
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// edits lists the config nodes the environment section and the config
+// fragments ask for.
+func (m *manifest) edits() []manifestEdit {
+	var edits []manifestEdit
+	for _, v := range m.environment {
+		edits = append(edits, manifestEdit{path: []string{"environment"}, node: v.name + " " + kdlQuote(v.value)})
+	}
+	for _, frag := range m.config {
+		edits = append(edits, fragmentEdits(frag, nil)...)
+	}
+	return edits
+}
+
+// fragmentEdits splits a fragment of config.kdl into the nodes it sets, so
+// that each can be compared with the config on its own. Blocks are walked
+// into, except for repeatable ones and key bindings, which are set whole.
+// Blocks with a name, like output "eDP-1", are told apart by it.
+func fragmentEdits(frag string, path []string) []manifestEdit {
+	var edits []manifestEdit
+	for _, sp := range kdlChildren(frag, 0, len(frag)) {
+		name := kdlNodeName(frag, sp)
+		if strings.HasPrefix(name, "/-") {
+			continue
+		}
+		node := frag[sp.start:sp.end]
+		open, close, ok := kdlBody(frag, sp)
+		switch {
+		case repeatableKDLNodes[name]:
+			edits = append(edits, manifestEdit{path: path, node: node, repeat: true})
+		case ok && (len(path) == 0 || path[len(path)-1] != "binds"):
+			elem := name
+			if arg := kdlFirstArg(frag, sp); arg != "" {
+				elem += " " + arg
+			}
+			inner := append(append([]string(nil), path...), elem)
+			edits = append(edits, fragmentEdits(frag[open:close], inner)...)
+		default:
+			edits = append(edits, manifestEdit{path: path, node: node})
+		}
+	}
+	return edits
+}
+
+// present reports whether cfg already has the node e asks for: the first
+// node of that name for a plain node, any of them for a repeatable one.
+func (e manifestEdit) present(cfg string) bool {
+	from, to, ok := kdlLocate(cfg, e.path)
+	if !ok {
+		return false
+	}
+	name := kdlNodeName(e.node, kdlSpan{0, len(e.node)})
+	want := strings.Join(strings.Fields(e.node), " ")
+	for _, sp := range kdlChildren(cfg, from, to) {
+		if kdlNodeName(cfg, sp) != name {
+			continue
+		}
+		if strings.Join(strings.Fields(cfg[sp.start:sp.end]), " ") == want {
+			return true
+		}
+		if !e.repeat {
+			return false
+		}
+	}
+	return false
+}
+
+func (e manifestEdit) apply(cfg string) string {
+	if e.repeat {
+		return addKDLNode(cfg, e.path, e.node)
+	}
+	return setKDLNode(cfg, e.path, e.node)
+}
+
+func (e manifestEdit) String() string {
+	node := strings.Join(strings.Fields(e.node), " ")
+	if len(e.path) == 0 {
+		return node
+	}
+	return strings.Join(e.path, " > ") + ": " + node
+}
+
+// manifestPlan is what the system lacks of a manifest.
+type manifestPlan struct {
+	packages []string
+	services []string
+	edits    []manifestEdit
+	present  int
+}
+
+// planManifest compares m with the installed packages, the running
+// services and the niri config. Without a config.kdl every config node is
+// missing.
+func planManifest(m *manifest) *manifestPlan {
+	p := &manifestPlan{}
+	for _, pkg := range m.packages {
+		if isPackageInstalled(pkg) {
+			p.present++
+		} else {
+			p.packages = append(p.packages, pkg)
+		}
+	}
+	for _, svc := range m.services {
+		if serviceRunning(svc) {
+			p.present++
+		} else {
+			p.services = append(p.services, svc)
+		}
+	}
+	_, cfg, err := readNiriConfig()
+	for _, e := range m.edits() {
+		if err == nil && e.present(cfg) {
+			p.present++
+		} else {
+			p.edits = append(p.edits, e)
+		}
+	}
+	return p
+}
+
+func (p *manifestPlan) missing() int {
+	return len(p.packages) + len(p.services) + len(p.edits)
+}
+
+// describe lists the changes the plan makes.
+func (p *manifestPlan) describe(m *manifest) string {
+	if p.missing() == 0 {
+		return Tf("Everything in %s is already in place (%d items)", m.path, p.present)
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, Tf("%s: %d items missing, %d already in place", m.path, p.missing(), p.present))
+	for _, pkg := range p.packages {
+		fmt.Fprintln(&b, "  + "+Tf("install package %s", pkg))
+	}
+	for _, svc := range p.services {
+		fmt.Fprintln(&b, "  + "+Tf("enable and start service %s", svc))
+	}
+	for _, e := range p.edits {
+		fmt.Fprintln(&b, "  + config.kdl "+e.String())
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// applyManifest makes the changes of the plan for m: packages first, then
+// the services they may provide, while config.kdl is edited alongside.
+func applyManifest(m *manifest) (string, error) {
+	p := planManifest(m)
+	status := p.describe(m)
+	if p.missing() == 0 {
+		return status, nil
+	}
+
+	var steps []setupStep
+	var installs []string
+	for _, pkg := range p.packages {
+		name := Tf("Install %s", pkg)
+		installs = append(installs, name)
+		steps = append(steps, setupStep{name: name, lock: "pkg", run: func() ([]string, error) {
+			line, err := installPackage(pkg)
+			return []string{line}, err
+		}})
+	}
+	for _, svc := range p.services {
+		steps = append(steps, setupStep{
+			name:  Tf("Enable and start %s", svc),
+			needs: installs,
+			lock:  "rc.conf",
+			run:   func() ([]string, error) { return enableService(svc) },
+		})
+	}
+	if len(p.edits) > 0 {
+		steps = append(steps, setupStep{name: "Update config.kdl", run: func() ([]string, error) {
+			path, err := updateNiriConfig(func(cfg string) string {
+				for _, e := range p.edits {
+					cfg = e.apply(cfg)
+				}
+				return cfg
+			})
+			if err != nil {
+				return []string{Tf("Failed to update %s: %v", path, err)}, err
+			}
+			lines := []string{Tf("Updated %s:", path)}
+			for _, e := range p.edits {
+				lines = append(lines, "  "+e.String())
+			}
+			return lines, nil
+		}})
+	}
+
+	logs, failed := runSteps("Applying the manifest...", steps)
+	status += "\n\n" + strings.Join(logs, "\n")
+	if len(failed) > 0 {
+		return status + "\n\n" + Tf("Failed (%d): %s", len(failed), strings.Join(failed, ", ")),
+			errors.New(Tf("%d changes of the manifest failed", len(failed)))
+	}
+	return status, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hasBacklightDevice reports whether the kernel exposes a backlight that
+// backlight(8) or brightnessctl can drive. Desktops usually have none.
+func hasBacklightDevice() bool {
+	entries, err := os.ReadDir("/dev/backlight")
+	return err == nil && len(entries) > 0
+}
+
+// brightnessBinds returns the brightness key bindings for the available
+// backlight tool, installing brightnessctl when base backlight(8) is missing.
+func brightnessBinds(logs *[]string) []string {
+	if !hasBacklightDevice() {
+		*logs = append(*logs, T("No backlight device in /dev/backlight, skipping brightness keys"))
+		return nil
+	}
+
+	var up, down string
+	switch {
+	case hasCommand("backlight"):
+		*logs = append(*logs, T("Using backlight(8) for brightness keys"))
+		up, down = kdlArgs("backlight", "incr", "5"), kdlArgs("backlight", "decr", "5")
+	default:
+		line, err := installPackage("brightnessctl")
+		*logs = append(*logs, line)
+		if err != nil {
+			*logs = append(*logs, T("Warning: No brightness tool available, skipping brightness keys"))
+			return nil
+		}
+		up, down = kdlArgs("brightnessctl", "set", "5%+"), kdlArgs("brightnessctl", "set", "5%-")
+	}
+	return []string{
+		fmt.Sprintf("XF86MonBrightnessUp allow-when-locked=true { spawn %s; }", up),
+		fmt.Sprintf("XF86MonBrightnessDown allow-when-locked=true { spawn %s; }", down),
+	}
+}
+
+// volumeBinds returns the volume key bindings, using wpctl when the
+// PipeWire stack is installed and the base mixer(8) otherwise.
+func volumeBinds(logs *[]string) []string {
+	var raise, lower, mute, micMute []string
+	switch {
+	case isPackageInstalled("wireplumber") && hasCommand("wpctl"):
+		*logs = append(*logs, T("Using wpctl (PipeWire) for volume keys"))
+		raise = []string{"wpctl", "set-volume", "@DEFAULT_AUDIO_SINK@", "0.05+"}
+		lower = []string{"wpctl", "set-volume", "@DEFAULT_AUDIO_SINK@", "0.05-"}
+		mute = []string{"wpctl", "set-mute", "@DEFAULT_AUDIO_SINK@", "toggle"}
+		micMute = []string{"wpctl", "set-mute", "@DEFAULT_AUDIO_SOURCE@", "toggle"}
+	case hasCommand("mixer"):
+		*logs = append(*logs, T("Using mixer(8) for volume keys"))
+		raise = []string{"mixer", "vol.volume=+5%"}
+		lower = []string{"mixer", "vol.volume=-5%"}
+		mute = []string{"mixer", "vol.mute=^"}
+		micMute = []string{"mixer", "rec.mute=^"}
+	default:
+		*logs = append(*logs, T("Warning: Neither mixer nor wpctl found, skipping volume keys"))
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("XF86AudioRaiseVolume allow-when-locked=true { spawn %s; }", kdlArgs(raise...)),
+		fmt.Sprintf("XF86AudioLowerVolume allow-when-locked=true { spawn %s; }", kdlArgs(lower...)),
+		fmt.Sprintf("XF86AudioMute allow-when-locked=true { spawn %s; }", kdlArgs(mute...)),
+		fmt.Sprintf("XF86AudioMicMute allow-when-locked=true { spawn %s; }", kdlArgs(micMute...)),
+	}
+}
+
+// setupFunctionKeys binds the laptop brightness and volume keys to whatever
+// tooling is available on this machine.
+func setupFunctionKeys() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		binds := append(brightnessBinds(&logs), volumeBinds(&logs)...)
+		if len(binds) == 0 {
+			return statusMsg{status: strings.Join(logs, "\n"), err: fmt.Errorf("no key bindings to write")}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			for _, bind := range binds {
+				cfg = setKDLNode(cfg, []string{"binds"}, bind)
+			}
+			return cfg
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Wrote %d key bindings to %s", len(binds), path))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// mediaKeyBinds bind the media player keys to playerctl.
+var mediaKeyBinds = []string{
+	`XF86AudioPlay allow-when-locked=true { spawn "playerctl" "play-pause"; }`,
+	`XF86AudioPause allow-when-locked=true { spawn "playerctl" "pause"; }`,
+	`XF86AudioStop allow-when-locked=true { spawn "playerctl" "stop"; }`,
+	`XF86AudioNext allow-when-locked=true { spawn "playerctl" "next"; }`,
+	`XF86AudioPrev allow-when-locked=true { spawn "playerctl" "previous"; }`,
+}
+
+// setupMediaKeys installs playerctl and binds the media player keys to it.
+func setupMediaKeys() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		line, err := installPackage("playerctl")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			for _, bind := range mediaKeyBinds {
+				cfg = setKDLNode(cfg, []string{"binds"}, bind)
+			}
+			return cfg
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Bound media player keys to playerctl in %s", path))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
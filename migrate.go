@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A migration is a config of another window manager translated into niri
+// config nodes, plus the lines that had no niri equivalent. file is set
+// while reading files other than the config itself, such as ~/.xinitrc.
+type migration struct {
+	from    string
+	file    string
+	nodes   []migratedNode
+	skipped []string
+	notes   []string
+}
+
+// migratedNode is one translated setting. Binds replace any bind for the
+// same key combination, nodes marked once (such as spawn-at-startup or
+// window-rule) are added unless the config already has them, and
+// everything else replaces the node of the same name in the block at path,
+// or removes it if remove is set.
+type migratedNode struct {
+	where  string
+	path   []string
+	node   string
+	remove bool
+	once   bool
+}
+
+// importer translates the config of one window manager, which is found
+// at the first of defaultPaths that exists.
+type importer struct {
+	parse        func(path, data string) *migration
+	defaultPaths []string
+}
+
+// importers are the window managers whose configs can be migrated.
+var importers = map[string]importer{
+	"Sway":     {parseSwayConfig, []string{"~/.config/sway/config", "~/.sway/config"}},
+	"i3":       {parseI3Config, []string{"~/.config/i3/config", "~/.i3/config"}},
+	"Hyprland": {parseHyprlandConfig, []string{"~/.config/hypr/hyprland.conf"}},
+}
+
+func importerNames() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// where describes line of the file being read.
+func (mg *migration) where(line int) string {
+	if mg.file != "" {
+		return Tf("%s line %d:", mg.file, line)
+	}
+	return Tf("line %d:", line)
+}
+
+func (mg *migration) add(line int, path []string, node string) {
+	mg.nodes = append(mg.nodes, migratedNode{where: mg.where(line), path: path, node: node})
+}
+
+// addOnce adds a node that may appear several times in a block.
+func (mg *migration) addOnce(line int, path []string, node string) {
+	mg.nodes = append(mg.nodes, migratedNode{where: mg.where(line), path: path, node: node, once: true})
+}
+
+// remove turns off a flag such as tap, which the niri template may set.
+func (mg *migration) remove(line int, path []string, name string) {
+	mg.nodes = append(mg.nodes, migratedNode{where: mg.where(line), path: path, node: name, remove: true})
+}
+
+func (mg *migration) skip(line int, text, reason string) {
+	mg.skipped = append(mg.skipped, mg.where(line)+" "+strings.TrimSpace(text)+" ("+T(reason)+")")
+}
+
+// note adds a remark about the translation as a whole, once.
+func (mg *migration) note(text string) {
+	if !containsString(mg.notes, text) {
+		mg.notes = append(mg.notes, text)
+	}
+}
+
+// bind adds a key binding for action, a niri action without the braces
+// and semicolon.
+func (mg *migration) bind(line int, key, props, action string) {
+	node := key
+	if props != "" {
+		node += " " + props
+	}
+	mg.add(line, []string{"binds"}, node+" { "+action+"; }")
+}
+
+// spawnAtStartup adds a program to start with niri.
+func (mg *migration) spawnAtStartup(line int, command string) {
+	mg.addOnce(line, nil, "spawn-at-startup "+kdlArgs(commandArgv(command)...))
+}
+
+// commandArgv splits a shell command line for spawn. Anything the shell
+// would have to interpret is run through sh -c.
+func commandArgv(command string) []string {
+	command = strings.TrimSpace(command)
+	if strings.ContainsAny(command, "\"'`$\\|&;<>()*?~{}[]#!=") {
+		return []string{"sh", "-c", command}
+	}
+	return strings.Fields(command)
+}
+
+// normalizeKey puts a key combination in a canonical form, so that
+// Mod4+Shift+q and Shift+Mod+Q are recognized as the same keys.
+func normalizeKey(key string) string {
+	parts := strings.Split(strings.ToLower(key), "+")
+	mods := parts[:len(parts)-1]
+	for i, m := range mods {
+		switch m {
+		case "mod", "mod4", "super", "win", "logo":
+			mods[i] = "super"
+		case "mod1", "alt":
+			mods[i] = "alt"
+		case "control", "ctrl":
+			mods[i] = "ctrl"
+		}
+	}
+	sort.Strings(mods)
+	return strings.Join(append(mods, parts[len(parts)-1]), "+")
+}
+
+// removeKDLBind deletes any bind for the same keys as key.
+func removeKDLBind(cfg, key string) string {
+	from, to, ok := kdlLocate(cfg, []string{"binds"})
+	if !ok {
+		return cfg
+	}
+	want := normalizeKey(key)
+	for _, sp := range kdlChildren(cfg, from, to) {
+		if name := kdlNodeName(cfg, sp); normalizeKey(name) == want {
+			return removeKDLBind(removeKDLNode(cfg, []string{"binds"}, name), key)
+		}
+	}
+	return cfg
+}
+
+// apply writes the translated nodes into cfg.
+func (mg *migration) apply(cfg string) string {
+	for _, n := range mg.nodes {
+		switch {
+		case n.remove:
+			cfg = removeKDLNode(cfg, n.path, n.node)
+		case len(n.path) == 1 && n.path[0] == "binds":
+			key, _, _ := strings.Cut(n.node, " ")
+			cfg = addKDLNode(removeKDLBind(cfg, key), n.path, n.node)
+		case n.once && strings.Contains(cfg, n.node):
+		case n.once && strings.HasPrefix(n.node, "spawn-at-startup "):
+			cfg = setSpawnAtStartupLine(cfg, n.node)
+		case n.once:
+			cfg = addKDLNode(cfg, n.path, n.node)
+		default:
+			cfg = setKDLNode(cfg, n.path, n.node)
+		}
+	}
+	return cfg
+}
+
+// setSpawnAtStartupLine adds a complete spawn-at-startup line after the
+// last one in cfg, or at the end.
+func setSpawnAtStartupLine(cfg, line string) string {
+	lines := strings.Split(cfg, "\n")
+	last := -1
+	for i, l := range lines {
+		if spawnProgram(l) != "" {
+			last = i
+		}
+	}
+	if last < 0 {
+		return strings.TrimRight(cfg, "\n") + "\n\n" + line + "\n"
+	}
+	lines = append(lines[:last+1], append([]string{line}, lines[last+1:]...)...)
+	return strings.Join(lines, "\n")
+}
+
+// describe lists what was translated and what wasn't.
+func (mg *migration) describe() string {
+	var b strings.Builder
+	fmt.Fprintln(&b, Tf("Translated %d settings from %s:", len(mg.nodes), mg.from))
+	for _, n := range mg.nodes {
+		where := ""
+		if len(n.path) > 0 {
+			where = strings.Join(n.path, " > ") + ": "
+		}
+		node := n.node
+		if n.remove {
+			node = Tf("remove %s", node)
+		}
+		fmt.Fprintf(&b, "  %s %s%s\n", n.where, where, node)
+	}
+	if len(mg.skipped) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, Tf("Not translated (%d):", len(mg.skipped)))
+		for _, s := range mg.skipped {
+			fmt.Fprintf(&b, "  %s\n", s)
+		}
+	}
+	if len(mg.notes) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, T("Notes:"))
+		for _, s := range mg.notes {
+			fmt.Fprintf(&b, "  %s\n", s)
+		}
+	}
+	return b.String()
+}
+
+// readMigration reads the config at path, or at the default location if
+// path is empty, and translates it with the importer called from.
+func readMigration(from, path string) (*migration, error) {
+	var imp importer
+	for name, i := range importers {
+		if strings.EqualFold(name, from) {
+			imp = i
+		}
+	}
+	if imp.parse == nil {
+		return nil, fmt.Errorf("unknown config type %s, expected one of %s", from, strings.Join(importerNames(), ", "))
+	}
+	if path == "" {
+		for _, p := range imp.defaultPaths {
+			path = expandHome(p)
+			if _, err := os.Stat(path); err == nil {
+				break
+			}
+		}
+	}
+	path = expandHome(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return imp.parse(path, string(data)), nil
+}
+
+// applyMigration writes mg into the niri config, keeping the previous
+// config as config.kdl.bak, and shows what was and wasn't translated.
+func applyMigration(mg *migration) tea.Cmd {
+	return func() tea.Msg {
+		status, err := runMigration(mg)
+		if err != nil {
+			return statusMsg{status: status, err: err}
+		}
+		return reportMsg{&report{title: "Import Config", body: status}}
+	}
+}
+
+func runMigration(mg *migration) (string, error) {
+	if len(mg.nodes) == 0 {
+		err := errors.New("nothing to import")
+		return Tf("Nothing in %s could be translated", mg.from) + "\n\n" + mg.describe(), preflightFailure(err)
+	}
+	var backup []byte
+	path, err := updateNiriConfig(func(cfg string) string {
+		backup = []byte(cfg)
+		return mg.apply(cfg)
+	})
+	if backup != nil && exitCode(err) != exitValidation {
+		if berr := writeFile(path+".bak", backup, 0644); err == nil {
+			err = berr
+		}
+	}
+	if err != nil {
+		return Tf("Failed to import %s: %v", mg.from, err), err
+	}
+	return Tf("Imported %s into %s (the previous config is in %s.bak)", mg.from, path, path) + "\n\n" + mg.describe(), nil
+}
+
+func newImportConfigForm() *form {
+	return &form{
+		title: "Import Config",
+		fields: []formField{
+			{label: "From", value: importerNames()[0], options: importerNames()},
+			{label: "Config file (empty: default)", value: ""},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			mg, err := readMigration(values[0], strings.TrimSpace(values[1]))
+			if err != nil {
+				return nil, errors.New(Tf("cannot read the config: %v", err))
+			}
+			return applyMigration(mg), nil
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// obsPipeWirePlugin provides OBS's "Screen Capture (PipeWire)" and "Window
+// Capture (PipeWire)" sources, which go through the screencast portal.
+const obsPipeWirePlugin = "/usr/local/lib/obs-plugins/linux-pipewire.so"
+
+// Source types of the screencast portal's AvailableSourceTypes.
+const (
+	portalSourceMonitor = 1
+	portalSourceWindow  = 2
+)
+
+// portalSourceTypes asks the screencast portal what it can capture.
+func portalSourceTypes() (uint32, error) {
+	conn, err := dialSessionBus()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	reply, err := conn.call(portalBus, portalPath, "org.freedesktop.DBus.Properties", "Get", "ss",
+		"org.freedesktop.portal.ScreenCast", "AvailableSourceTypes")
+	if err != nil {
+		return 0, err
+	}
+	types, ok := reply[0].(uint32)
+	if !ok {
+		return 0, errors.New("unexpected AvailableSourceTypes")
+	}
+	return types, nil
+}
+
+// setupOBS installs OBS Studio on top of the screen sharing stack and
+// checks that its PipeWire capture sources can work.
+func setupOBS() tea.Cmd {
+	return func() tea.Msg {
+		logs, err := setupScreenSharing()
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		line, err := installPackage("obs-studio")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		if _, err := os.Stat(obsPipeWirePlugin); err != nil {
+			logs = append(logs, Tf("%s is missing: this OBS build has no PipeWire capture; install a package built with the PIPEWIRE option", obsPipeWirePlugin))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("PipeWire capture plugin: %s", obsPipeWirePlugin))
+
+		types, err := portalSourceTypes()
+		switch {
+		case err != nil:
+			logs = append(logs, Tf("Warning: Could not ask the screencast portal: %v", err))
+			logs = append(logs, T("Inside niri, check it with Screen Sharing."))
+		case types&portalSourceMonitor == 0:
+			logs = append(logs, T("The screencast portal can't capture monitors; check Screen Sharing."))
+			return statusMsg{status: strings.Join(logs, "\n"), err: fmt.Errorf("portal source types %d", types)}
+		default:
+			sources := []string{T("Screen Capture (PipeWire)")}
+			if types&portalSourceWindow != 0 {
+				sources = append(sources, T("Window Capture (PipeWire)"))
+			}
+			logs = append(logs, Tf("Capture sources available in OBS: %s", strings.Join(sources, ", ")))
+		}
+		logs = append(logs, T("Add a PipeWire capture source in OBS and pick the screen or window in the dialog."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// oskProgram is wvkbd's international mobile layout, the one suited to
+// convertibles and touchscreens.
+const oskProgram = "wvkbd-mobintl"
+
+// oskArgs start the keyboard hidden, with heights that leave room for
+// windows in both orientations.
+var oskArgs = []string{"--hidden", "-L", "200", "-H", "280"}
+
+// oskToggleScript shows or hides the keyboard. wvkbd toggles on SIGRTMIN;
+// if it isn't running, for instance after a crash, it is started visible.
+func oskToggleScript() string {
+	return `#!/bin/sh
+# Generated by NiriSetup: show or hide the on-screen keyboard.
+if ! pkill -RTMIN -x ` + oskProgram + `; then
+	` + oskProgram + " " + strings.Join(oskArgs[1:], " ") + ` >/dev/null 2>&1 &
+fi
+`
+}
+
+// oskLayerRule keeps what is typed on the keyboard out of screencasts.
+const oskLayerRule = `layer-rule {
+    match namespace="^wvkbd$"
+    block-out-from "screencast"
+}`
+
+// oskKeys are the keys tried for the toggle binding.
+var oskKeys = []string{"Mod+Ctrl+K", "Mod+Alt+K"}
+
+// setupOSK installs wvkbd, starts it hidden with niri and binds a key and
+// a waybar button to showing and hiding it.
+func setupOSK() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		line, err := installPackage("wvkbd")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		script := filepath.Join(homeDir, ".local", "bin", "nirisetup-osk-toggle")
+		if err := os.MkdirAll(filepath.Dir(script), 0755); err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		if err := writeFile(script, []byte(oskToggleScript()), 0755); err != nil {
+			logs = append(logs, Tf("Failed to write %s: %v", script, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Wrote %s: OK", script))
+
+		key := ""
+		path, err := updateNiriConfig(func(cfg string) string {
+			cfg = setSpawnAtStartup(cfg, append([]string{oskProgram}, oskArgs...)...)
+			if !strings.Contains(cfg, `namespace="^wvkbd$"`) {
+				cfg = addKDLNode(cfg, nil, oskLayerRule)
+			}
+			if strings.Contains(cfg, kdlQuote(script)) {
+				return cfg
+			}
+			if k, ok := (essentialBind{keys: oskKeys}).freeKey(cfg); ok {
+				key = k
+				cfg = addKDLNode(cfg, []string{"binds"}, key+" { spawn "+kdlArgs(script)+"; }")
+			}
+			return cfg
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to spawn-at-startup in %s", oskProgram, path))
+		if key != "" {
+			logs = append(logs, Tf("%s shows and hides the keyboard", key))
+		}
+
+		if waybar, err := waybarConfigPath(); err == nil {
+			if _, err := os.Stat(waybar); err == nil {
+				if _, err := updateWaybarConfig(func(cfg map[string]any) {
+					cfg["custom/osk"] = map[string]any{"format": "⌨", "tooltip": false, "on-click": script}
+					addWaybarModule(cfg, "modules-right", "custom/osk")
+				}); err != nil {
+					logs = append(logs, Tf("Warning: Could not update %s: %v", waybar, err))
+				} else {
+					logs = append(logs, Tf("Added a keyboard button to %s: OK", waybar))
+				}
+			}
+		}
+		logs = append(logs, T("The keyboard starts hidden the next time niri starts."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
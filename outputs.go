@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// outputSetting is one per-output option on the Output Settings screen.
+// apply writes the chosen value into the output's config block.
+type outputSetting struct {
+	output string
+	field  formField
+	apply  func(cfg, output, value string) string
+}
+
+// vrrSetting offers adaptive sync for outputs that advertise support for it.
+func vrrSetting(o niriOutput) (outputSetting, bool) {
+	if !o.VrrSupported {
+		return outputSetting{}, false
+	}
+	current := "Off"
+	if o.VrrEnabled {
+		current = "On"
+	}
+	return outputSetting{
+		output: o.Name,
+		field:  formField{label: o.Name + " VRR", value: current, options: []string{"Off", "On", "On demand"}},
+		apply: func(cfg, output, value string) string {
+			block := []string{"output " + output}
+			switch value {
+			case "On":
+				return setKDLNode(cfg, block, "variable-refresh-rate")
+			case "On demand":
+				return setKDLNode(cfg, block, "variable-refresh-rate on-demand=true")
+			}
+			return removeKDLNode(cfg, block, "variable-refresh-rate")
+		},
+	}, true
+}
+
+// outputTransforms are the values of the transform node, clockwise
+// rotations optionally flipped horizontally first.
+var outputTransforms = []string{"normal", "90", "180", "270", "flipped", "flipped-90", "flipped-180", "flipped-270"}
+
+// ipcTransform converts a transform as `niri msg --json outputs` reports it,
+// e.g. "Flipped90", to the value the config takes.
+func ipcTransform(t string) string {
+	t = strings.ToLower(strings.TrimPrefix(t, "_"))
+	if rest, ok := strings.CutPrefix(t, "flipped"); ok && rest != "" {
+		return "flipped-" + rest
+	}
+	if t == "" {
+		return "normal"
+	}
+	return t
+}
+
+// isSideways reports whether a transform turns the output by a quarter, so
+// that its logical width and height swap.
+func isSideways(t string) bool {
+	return strings.HasSuffix(t, "90") || strings.HasSuffix(t, "270")
+}
+
+// rotateTransform turns t a further 90 degrees clockwise.
+func rotateTransform(t string) string {
+	flipped := strings.HasPrefix(t, "flipped")
+	i := (indexOf(outputTransforms, t) + 1) % 4
+	if flipped {
+		i += 4
+	}
+	return outputTransforms[i]
+}
+
+// flipTransform mirrors t, keeping its rotation.
+func flipTransform(t string) string {
+	return outputTransforms[(indexOf(outputTransforms, t)+4)%8]
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return 0
+}
+
+// setTransform writes the transform node of an output, leaving it out for
+// the default.
+func setTransform(cfg, output, t string) string {
+	block := []string{"output " + output}
+	if t == "normal" {
+		return removeKDLNode(cfg, block, "transform")
+	}
+	return setKDLNode(cfg, block, "transform "+kdlQuote(t))
+}
+
+// transformSetting offers rotating an output, e.g. for a portrait monitor.
+func transformSetting(o niriOutput) outputSetting {
+	current := "normal"
+	if o.Logical != nil {
+		current = ipcTransform(o.Logical.Transform)
+	}
+	return outputSetting{
+		output: o.Name,
+		field: formField{label: o.Name + " transform", value: current, options: outputTransforms,
+			help: "Rotation clockwise; 90 and 270 turn a monitor to portrait and flipped mirrors it. Arrange Monitors previews the layout, r rotates and f flips there."},
+		apply: setTransform,
+	}
+}
+
+// loadOutputForm builds the Output Settings form from the outputs of the
+// running session: the mode and transform of each output and, where
+// supported, VRR.
+func loadOutputForm() tea.Cmd {
+	return func() tea.Msg {
+		outputs, err := niriOutputs()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to query outputs, run this from inside a niri session: %v", err), err: err}
+		}
+
+		// Without a config the modes are still offered, just none is current.
+		_, cfg, _ := readNiriConfig()
+		var settings []outputSetting
+		for _, o := range outputs {
+			settings = append(settings, modeSetting(o, cfg), transformSetting(o))
+			if s, ok := vrrSetting(o); ok {
+				settings = append(settings, s)
+			}
+		}
+		if len(settings) == 0 {
+			return statusMsg{status: T("No outputs detected"), err: fmt.Errorf("no outputs")}
+		}
+
+		fields := make([]formField, len(settings))
+		for i, s := range settings {
+			fields[i] = s.field
+		}
+		return formMsg{&form{
+			title:  "Output Settings",
+			fields: fields,
+			submit: func(values []string) (tea.Cmd, error) {
+				return applyOutputSettings(settings, values), nil
+			},
+		}}
+	}
+}
+
+func applyOutputSettings(settings []outputSetting, values []string) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		path, err := updateNiriConfig(func(cfg string) string {
+			for i, s := range settings {
+				cfg = s.apply(cfg, s.output, values[i])
+				logs = append(logs, Tf("%s: %s", s.field.label, values[i]))
+			}
+			return cfg
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to write output settings: %v", err), err: err}
+		}
+		logs = append(logs, Tf("Updated %s", path))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
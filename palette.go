@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// palette is a color scheme the Color Scheme screen applies to niri, waybar,
+// the terminals, fuzzel and mako at once. id names it on the command line.
+// Colors are #rrggbb; normal and bright are the 16 terminal colors.
+type palette struct {
+	id         string
+	name       string
+	background string
+	foreground string
+	surface    string // selected items and the focused workspace
+	accent     string // focus ring, borders and highlights
+	inactive   string // unfocused windows
+	urgent     string
+	normal     [8]string
+	bright     [8]string
+}
+
+// palettes are the color schemes offered. The GhostBSD default is the one
+// niri and foot ship with, for going back to it.
+var palettes = []palette{
+	{
+		id: "gruvbox", name: "Gruvbox",
+		background: "#282828", foreground: "#ebdbb2", surface: "#3c3836",
+		accent: "#fabd2f", inactive: "#504945", urgent: "#fb4934",
+		normal: [8]string{"#282828", "#cc241d", "#98971a", "#d79921", "#458588", "#b16286", "#689d6a", "#a89984"},
+		bright: [8]string{"#928374", "#fb4934", "#b8bb26", "#fabd2f", "#83a598", "#d3869b", "#8ec07c", "#ebdbb2"},
+	},
+	{
+		id: "catppuccin", name: "Catppuccin",
+		background: "#1e1e2e", foreground: "#cdd6f4", surface: "#313244",
+		accent: "#cba6f7", inactive: "#45475a", urgent: "#f38ba8",
+		normal: [8]string{"#45475a", "#f38ba8", "#a6e3a1", "#f9e2af", "#89b4fa", "#f5c2e7", "#94e2d5", "#bac2de"},
+		bright: [8]string{"#585b70", "#f38ba8", "#a6e3a1", "#f9e2af", "#89b4fa", "#f5c2e7", "#94e2d5", "#a6adc8"},
+	},
+	{
+		id: "nord", name: "Nord",
+		background: "#2e3440", foreground: "#d8dee9", surface: "#3b4252",
+		accent: "#88c0d0", inactive: "#4c566a", urgent: "#bf616a",
+		normal: [8]string{"#3b4252", "#bf616a", "#a3be8c", "#ebcb8b", "#81a1c1", "#b48ead", "#88c0d0", "#e5e9f0"},
+		bright: [8]string{"#4c566a", "#bf616a", "#a3be8c", "#ebcb8b", "#81a1c1", "#b48ead", "#8fbcbb", "#eceff4"},
+	},
+	{
+		id: "ghostbsd", name: "GhostBSD default",
+		background: "#242424", foreground: "#ffffff", surface: "#3a3a3a",
+		accent: "#7fc8ff", inactive: "#505050", urgent: "#f62b5a",
+		normal: [8]string{"#242424", "#f62b5a", "#47b413", "#e3c401", "#24acd4", "#f2affd", "#13c299", "#e6e6e6"},
+		bright: [8]string{"#616161", "#ff4d51", "#35d450", "#e9e836", "#5dc5f8", "#feabf2", "#24dfc4", "#ffffff"},
+	},
+}
+
+// terminalColorNames are the alacritty names of the 8 terminal colors.
+var terminalColorNames = [8]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// paletteByID returns the palette called id on the command line.
+func paletteByID(id string) (palette, bool) {
+	for _, p := range palettes {
+		if strings.EqualFold(p.id, id) {
+			return p, true
+		}
+	}
+	return palette{}, false
+}
+
+func paletteIDs() []string {
+	var ids []string
+	for _, p := range palettes {
+		ids = append(ids, p.id)
+	}
+	return ids
+}
+
+// Markers around the rules the color scheme adds to the waybar style.
+const (
+	waybarPaletteStart = "/* Added by NiriSetup: color scheme. */"
+	waybarPaletteEnd   = "/* End of the NiriSetup color scheme. */"
+)
+
+// waybarPaletteCSS returns style with the color scheme's rules at the end,
+// replacing those of an earlier scheme. Coming last, they win over the
+// rules before them that set the same properties.
+func waybarPaletteCSS(style string, p palette) string {
+	if start := strings.Index(style, waybarPaletteStart); start >= 0 {
+		rest := style[start:]
+		if end := strings.Index(rest, waybarPaletteEnd); end >= 0 {
+			rest = rest[end+len(waybarPaletteEnd):]
+		} else {
+			rest = ""
+		}
+		style = style[:start] + strings.TrimLeft(rest, "\n")
+	}
+	rules := strings.Join([]string{
+		waybarPaletteStart,
+		"window#waybar { background-color: " + p.background + "; color: " + p.foreground + "; }",
+		"tooltip { background-color: " + p.background + "; border-color: " + p.accent + "; }",
+		"tooltip label { color: " + p.foreground + "; }",
+		"#workspaces button { color: " + p.foreground + "; }",
+		"#workspaces button.focused, #workspaces button.active { background-color: " + p.surface + "; color: " + p.accent + "; box-shadow: inset 0 -3px " + p.accent + "; }",
+		"#workspaces button.urgent { background-color: " + p.urgent + "; color: " + p.background + "; }",
+		waybarPaletteEnd,
+	}, "\n")
+	style = strings.TrimRight(style, "\n")
+	if style != "" {
+		style += "\n\n"
+	}
+	return style + rules + "\n"
+}
+
+// writeWaybarPalette adds the color scheme to the waybar style. Without a
+// style of its own, waybar uses the one it ships with, so that is copied
+// first to keep the rest of its look.
+func writeWaybarPalette(homeDir string, p palette) (string, error) {
+	path := filepath.Join(homeDir, ".config", "waybar", "style.css")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, _ = os.ReadFile("/usr/local/etc/xdg/waybar/style.css")
+	} else if err != nil {
+		return path, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return path, err
+	}
+	return path, writeFile(path, []byte(waybarPaletteCSS(string(data), p)), 0644)
+}
+
+// bare drops the # of a #rrggbb color, as foot and fuzzel want it.
+func bare(color string) string {
+	return strings.TrimPrefix(color, "#")
+}
+
+func footPalette(p palette) [][2]string {
+	values := [][2]string{{"foreground", bare(p.foreground)}, {"background", bare(p.background)}}
+	for i := range p.normal {
+		values = append(values, [2]string{"regular" + string(rune('0'+i)), bare(p.normal[i])})
+	}
+	for i := range p.bright {
+		values = append(values, [2]string{"bright" + string(rune('0'+i)), bare(p.bright[i])})
+	}
+	return values
+}
+
+func fuzzelPalette(p palette) [][2]string {
+	return [][2]string{
+		{"background", bare(p.background) + "ff"},
+		{"text", bare(p.foreground) + "ff"},
+		{"match", bare(p.accent) + "ff"},
+		{"selection", bare(p.surface) + "ff"},
+		{"selection-text", bare(p.foreground) + "ff"},
+		{"selection-match", bare(p.accent) + "ff"},
+		{"border", bare(p.accent) + "ff"},
+	}
+}
+
+func makoPalette(p palette) [][2]string {
+	return [][2]string{
+		{"background-color", p.background},
+		{"text-color", p.foreground},
+		{"border-color", p.accent},
+		{"progress-color", "over " + p.surface},
+	}
+}
+
+// writeAlacrittyPalette sets the colors in alacritty.toml, whose tables
+// setINIValue can edit like INI sections as long as the values are quoted.
+func writeAlacrittyPalette(path string, p palette) error {
+	quote := func(color string) string { return `"` + color + `"` }
+	if err := updateINIFile(path, "colors.primary", [][2]string{
+		{"background", quote(p.background)},
+		{"foreground", quote(p.foreground)},
+	}); err != nil {
+		return err
+	}
+	for _, t := range []struct {
+		table  string
+		colors [8]string
+	}{{"colors.normal", p.normal}, {"colors.bright", p.bright}} {
+		var values [][2]string
+		for i, name := range terminalColorNames {
+			values = append(values, [2]string{name, quote(t.colors[i])})
+		}
+		if err := updateINIFile(path, t.table, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paletteWanted reports whether a program's colors should be written: when
+// it is installed or has a config already.
+func paletteWanted(program, path string) bool {
+	if hasCommand(program) {
+		return true
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// applyPalette sets the colors of p in the niri layout, the waybar style,
+// foot, alacritty, fuzzel and mako, and reloads what is running. Programs
+// that are neither installed nor configured are left out.
+func applyPalette(p palette) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		fail := func(path string, err error) tea.Msg {
+			logs = append(logs, Tf("Failed to write %s: %v", path, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			for _, block := range []string{"focus-ring", "border"} {
+				cfg = setKDLNode(cfg, []string{"layout", block}, "active-color "+kdlQuote(p.accent))
+				cfg = setKDLNode(cfg, []string{"layout", block}, "inactive-color "+kdlQuote(p.inactive))
+			}
+			return cfg
+		})
+		if err != nil {
+			return fail(path, err)
+		}
+		logs = append(logs, Tf("Updated %s: OK", path))
+
+		config := filepath.Join(homeDir, ".config")
+		if paletteWanted("waybar", filepath.Join(config, "waybar")) {
+			path, err := writeWaybarPalette(homeDir, p)
+			if err != nil {
+				return fail(path, err)
+			}
+			logs = append(logs, Tf("Updated %s: OK", path))
+			// waybar reloads its style on SIGUSR2.
+			commandCombinedOutput(exec.Command("pkill", "-USR2", "-u", userName(), "-x", "waybar"))
+		}
+
+		inis := []struct {
+			program, path, section string
+			values                 [][2]string
+		}{
+			{"foot", filepath.Join(config, "foot", "foot.ini"), "colors", footPalette(p)},
+			{"fuzzel", filepath.Join(config, "fuzzel", "fuzzel.ini"), "colors", fuzzelPalette(p)},
+			// mako's settings come before its criteria sections.
+			{"mako", filepath.Join(config, "mako", "config"), "", makoPalette(p)},
+		}
+		for _, f := range inis {
+			if !paletteWanted(f.program, f.path) {
+				continue
+			}
+			if err := updateINIFile(f.path, f.section, f.values); err != nil {
+				return fail(f.path, err)
+			}
+			logs = append(logs, Tf("Updated %s: OK", f.path))
+		}
+		if hasCommand("makoctl") {
+			commandCombinedOutput(exec.Command("makoctl", "reload"))
+		}
+
+		alacritty := filepath.Join(config, "alacritty", "alacritty.toml")
+		if paletteWanted("alacritty", alacritty) {
+			if err := writeAlacrittyPalette(alacritty, p); err != nil {
+				return fail(alacritty, err)
+			}
+			logs = append(logs, Tf("Updated %s: OK", alacritty))
+		}
+
+		if err := saveSettings(map[string]string{"color_palette": p.id}); err != nil {
+			tracef("saving the color scheme failed: %v", err)
+		}
+		logs = append(logs, Tf("Applied the %s color scheme. niri, waybar and mako pick it up right away; new foot, alacritty and fuzzel windows use it.", T(p.name)))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+// newPaletteForm picks the color scheme to apply, starting from the one
+// applied last.
+func newPaletteForm() *form {
+	var names []string
+	for _, p := range palettes {
+		names = append(names, p.name)
+	}
+	current := palettes[len(palettes)-1].name
+	if p, ok := paletteByID(loadSettings()["color_palette"]); ok {
+		current = p.name
+	}
+	return &form{
+		title: "Color Scheme",
+		fields: []formField{
+			{label: "Scheme", value: current, options: names,
+				help: "Sets the focus ring and border colors in config.kdl and the colors of waybar, foot, alacritty, fuzzel and mako to match. Dark or light for GTK and Qt programs is set under Appearance."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			for _, p := range palettes {
+				if p.name == values[0] {
+					return applyPalette(p), nil
+				}
+			}
+			return nil, errors.New(T("Pick a color scheme"))
+		},
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// profileAdditions returns what Setup System appends to a .profile that
+// holds profile, in the order setupProfile appends it.
+func profileAdditions(profile string) string {
+	var add string
+	if !strings.Contains(profile, "XDG_RUNTIME_DIR") {
+		add += profileRuntimeDir()
+	}
+	if !strings.Contains(profile, "LIBSEAT_BACKEND") {
+		add += "export LIBSEAT_BACKEND=consolekit2\n"
+	}
+	var missing string
+	for _, d := range xdgBaseDirs {
+		if !profileExport(d.name).MatchString(profile + add) {
+			missing += "export " + d.name + `="$HOME/` + d.dir + "\"\n"
+		}
+	}
+	if missing != "" {
+		add += "\n# XDG base directories\n" + missing
+	}
+	return add
+}
+
+// sysrcValues returns the current value of each rc.conf variable as
+// name="value" lines, leaving out those that aren't set.
+func sysrcValues(values map[string]string, names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		if v, ok := values[name]; ok {
+			fmt.Fprintf(&b, "%s=%q\n", name, v)
+		}
+	}
+	return b.String()
+}
+
+// pendingSetupChanges works out what Setup System would change with its
+// steps skipped or forced as modes says: a unified diff for each file it
+// edits, with the rc.conf variables shown as sysrc would leave them, and
+// the changes that aren't to files.
+func pendingSetupChanges(modes map[string]string) (string, bool) {
+	var diffs, other []string
+	runs := func(step string) bool { return modes[step] != stepSkip }
+
+	homeDir, _ := userHomeDir()
+	profilePath := filepath.Join(homeDir, ".profile")
+	profile, _ := os.ReadFile(profilePath)
+	if add := profileAdditions(string(profile)); add != "" && runs("Prepare the login environment in .profile") {
+		diffs = append(diffs, unifiedDiff(string(profile), string(profile)+add, profilePath, profilePath))
+	}
+
+	var services []string
+	for _, svc := range []string{"dbus", "seatd"} {
+		if runs("Enable and start " + svc) {
+			services = append(services, svc)
+		}
+	}
+	loadDRM := runs("Load the DRM kernel module")
+	names := []string{"kld_list"}
+	before := map[string]string{}
+	for _, svc := range services {
+		names = append(names, svc+"_enable")
+	}
+	for _, name := range names {
+		if out, err := commandOutput(exec.Command("sysrc", "-n", name)); err == nil {
+			before[name] = strings.TrimSpace(string(out))
+		}
+	}
+	after := map[string]string{}
+	for name, v := range before {
+		after[name] = v
+	}
+	for _, svc := range services {
+		after[svc+"_enable"] = "YES"
+	}
+	if loadDRM && !containsString(strings.Fields(before["kld_list"]), "drm") {
+		after["kld_list"] = strings.TrimSpace(before["kld_list"] + " drm")
+	}
+	if diff := unifiedDiff(sysrcValues(before, names), sysrcValues(after, names), "/etc/rc.conf", T("/etc/rc.conf after sysrc")); diff != "" {
+		diffs = append(diffs, diff)
+	}
+
+	if path, cfg, err := readNiriConfig(); err == nil && runs("Set the session environment in config.kdl") {
+		if diff := unifiedDiff(cfg, setSessionEnvironment(cfg), path, path); diff != "" {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	if launcher, err := sessionLauncherPath(); err == nil && runs("Write the session launcher") {
+		current, err := os.ReadFile(launcher)
+		from := launcher
+		if os.IsNotExist(err) {
+			// A new file, as diff shows one.
+			from = "/dev/null"
+		}
+		if diff := unifiedDiff(string(current), sessionLauncherScript, from, launcher); diff != "" {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for _, svc := range services {
+		if runCommand(exec.Command("service", svc, "onestatus")) != nil {
+			other = append(other, Tf("Start the %s service", svc))
+		}
+	}
+	if user := userName(); user != "" && !inGroup(user, "video") && runs("Add you to the video group") {
+		other = append(other, Tf("Add %s to the video group", user))
+	}
+	if loaded, err := loadedModules(); loadDRM && (err != nil || !loaded["drm"]) {
+		other = append(other, T("Load the drm kernel module"))
+	}
+	var skipped []string
+	for _, step := range setupSystemSteps() {
+		if !runs(step.name) {
+			skipped = append(skipped, T(stepLabel(step.name)))
+		}
+	}
+
+	var b strings.Builder
+	if len(skipped) > 0 {
+		b.WriteString(Tf("Skipped, as you chose: %s", strings.Join(skipped, ", ")) + "\n\n")
+	}
+	if len(diffs) == 0 && len(other) == 0 {
+		b.WriteString(T("Setup System has nothing to change: everything it sets up is already in place. Running it again only checks the render device and looks for other desktops."))
+		return b.String(), false
+	}
+	for _, diff := range diffs {
+		b.WriteString(strings.TrimRight(diff, "\n") + "\n\n")
+	}
+	if len(other) > 0 {
+		b.WriteString(T("It also does this:") + "\n")
+		for _, o := range other {
+			b.WriteString("  " + o + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), true
+}
+
+// previewSetupSystem shows what Setup System would change with its steps
+// skipped or forced as modes says, and runs it that way once the user
+// agrees, asking about a boot environment first on ZFS.
+func previewSetupSystem(modes map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		body, changes := pendingSetupChanges(modes)
+		label := T("Apply these changes")
+		if !changes {
+			label = T("Run Setup System anyway")
+		}
+		r := &report{title: "Setup System: pending changes", body: body, refresh: previewSetupSystem(modes)}
+		r.actions = []reportAction{
+			{label: label, cmd: confirmSetupSystem(setupSystemWith(modes))},
+			{label: T("Choose the steps to skip or force"), cmd: func() tea.Msg { return formMsg{newSetupStepsForm()} }},
+		}
+		return reportMsg{r}
+	}
+}
+
+// confirmSetupSystem runs run, asking about a boot environment first on
+// ZFS.
+func confirmSetupSystem(run tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		if zfsRoot() {
+			return confirmMsg{bootEnvironmentConfirmation("Setting up the system...", run)}
+		}
+		return run()
+	}
+}
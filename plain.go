@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// plainUI drives the model with numbered prompts and linear output instead
+// of the full-screen interface, for console screen readers and terminals
+// that can't draw it. Every screen is mapped onto the same key handling the
+// full-screen interface uses, so both behave the same.
+type plainUI struct {
+	m    model
+	in   *bufio.Scanner
+	quit bool
+}
+
+// plainTerminal reports whether the full-screen interface can't be used:
+// TERM is "dumb", stdin/stdout aren't terminals (output piped or logged),
+// or stdout is a serial console.
+func plainTerminal() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return true
+	}
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return true
+		}
+	}
+	return serialConsole()
+}
+
+// serialPrefixes are the device names of serial lines: the uart(4) and
+// USB serial ports, as call-in or call-out devices.
+var serialPrefixes = []string{"/dev/ttyu", "/dev/cuau", "/dev/ttyU", "/dev/cuaU"}
+
+// serialConsole reports whether stdout is a serial line, where redrawing
+// the full-screen interface is slow and garbles whatever logs the line.
+func serialConsole() bool {
+	cmd := exec.Command("tty")
+	cmd.Stdin = os.Stdout
+	out, _ := commandOutput(cmd)
+	name := strings.TrimSpace(string(out))
+	for _, prefix := range serialPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func runPlain(m model) error {
+	p := &plainUI{m: m, in: bufio.NewScanner(os.Stdin)}
+	if check := checkUpdateBanner(); check != nil {
+		// Show what the last check found rather than wait for a new one,
+		// which refreshes the cache for the next run.
+		if updates, _ := cachedUpdates(); len(updates) > 0 {
+			fmt.Println(updateBanner(updates))
+		}
+		go check()
+	}
+	for !p.quit {
+		switch p.m.state {
+		case menuView:
+			p.menu()
+		case formView:
+			p.form()
+		case arrangeView:
+			p.arrange()
+		case reportView:
+			p.report()
+		case confirmView:
+			p.confirm()
+		case wizardView:
+			p.wizard()
+		case tailView:
+			p.tail()
+		default:
+			p.m.state = menuView
+		}
+	}
+	return p.in.Err()
+}
+
+// ask prints prompt and reads one line. End of input quits.
+func (p *plainUI) ask(prompt string) string {
+	fmt.Print(prompt)
+	if !p.in.Scan() {
+		fmt.Println()
+		p.quit = true
+		return ""
+	}
+	return strings.TrimSpace(p.in.Text())
+}
+
+// choose prints a numbered list and returns the index picked, or current
+// when the answer is empty. It keeps asking until the answer is valid.
+func (p *plainUI) choose(prompt string, options []string, current int) int {
+	for i, opt := range options {
+		marker := ""
+		if i == current {
+			marker = " " + T("(current)")
+		}
+		fmt.Printf("  %d. %s%s\n", i+1, T(opt), marker)
+	}
+	for !p.quit {
+		answer := p.ask(prompt + ": ")
+		if answer == "" && current >= 0 {
+			return current
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= 1 && n <= len(options) {
+			return n - 1
+		}
+		fmt.Println(Tf("Please enter a number between 1 and %d.", len(options)))
+	}
+	return current
+}
+
+// yes asks a yes/no question.
+func (p *plainUI) yes(prompt string) bool {
+	answer := strings.ToLower(p.ask(strings.TrimSpace(prompt+" "+T("(y/n)")) + ": "))
+	word := strings.ToLower(T("Yes"))
+	return answer == "y" || answer == "yes" || answer == word || answer == word[:1]
+}
+
+// press feeds a key to the model and runs whatever it starts.
+func (p *plainUI) press(key string) {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	updated, cmd := p.m.Update(msg)
+	p.m = updated.(model)
+	p.run(cmd)
+}
+
+// run executes cmd and everything that follows from it, printing progress
+// and results as it goes.
+func (p *plainUI) run(cmd tea.Cmd) {
+	for cmd != nil {
+		if p.m.state == tailView {
+			// Don't wait on its ticks; p.tail polls on demand.
+			return
+		}
+		switch p.m.state {
+		case installView:
+			fmt.Println(T("Installing Niri..."))
+		case actionView:
+			fmt.Println(T(p.m.actionMsg))
+		}
+		msg := cmd()
+		if _, ok := msg.(tea.QuitMsg); ok {
+			p.quit = true
+			return
+		}
+		if s, ok := msg.(statusMsg); ok && p.m.wizard == nil {
+			fmt.Println(s.status)
+		}
+		var updated tea.Model
+		updated, cmd = p.m.Update(msg)
+		p.m = updated.(model)
+	}
+	if p.m.state == installView || p.m.state == actionView {
+		// The full-screen interface stays on a failed action until the
+		// user leaves; here the result has been printed already.
+		p.m.state = menuView
+		p.m.isProcessing = false
+	}
+}
+
+func (p *plainUI) menu() {
+	fmt.Println()
+	fmt.Println(T("Niri Setup Assistant for GhostBSD"))
+	if p.m.inSession {
+		fmt.Println(T("niri session detected: session actions enabled"))
+	}
+	p.m.cursor = p.choose(Tf("Choose an option (1-%d)", len(p.m.choices)), p.m.choices, -1)
+	if !p.quit {
+		p.press("enter")
+	}
+}
+
+func (p *plainUI) form() {
+	f := p.m.form
+	fmt.Println()
+	fmt.Println(T(f.title))
+	if f.err != "" {
+		fmt.Println(T("Error:"), f.err)
+	}
+	fmt.Println(T("Press enter to keep the value shown in brackets."))
+	for i := range f.fields {
+		field := &f.fields[i]
+		if len(field.options) > 0 {
+			fmt.Println(T(field.label) + ":")
+			current := 0
+			for j, opt := range field.options {
+				if opt == field.value {
+					current = j
+				}
+			}
+			field.value = field.options[p.choose(Tf("Choose 1-%d", len(field.options)), field.options, current)]
+		} else if answer := p.ask(fmt.Sprintf("%s [%s]: ", T(field.label), field.value)); answer != "" {
+			field.value = answer
+		}
+		if p.quit {
+			return
+		}
+	}
+	if p.yes(T("Apply these settings?")) {
+		p.press("enter")
+	} else {
+		p.press("esc")
+	}
+}
+
+func (p *plainUI) arrange() {
+	a := p.m.arrange
+	fmt.Println()
+	fmt.Println(T("Arrange Monitors"))
+	names := make([]string, len(a.outputs))
+	for i, o := range a.outputs {
+		names[i] = fmt.Sprintf("%s (%dx%d)", o.name, o.width, o.height)
+	}
+	fmt.Println(T("Primary output:"))
+	a.primary = p.choose(Tf("Choose 1-%d", len(names)), names, a.primary)
+	sides := []string{sideRight, sideLeft, sideAbove, sideBelow}
+	for i := range a.outputs {
+		if i == a.primary || p.quit {
+			continue
+		}
+		o := &a.outputs[i]
+		fmt.Println(Tf("Place %s:", o.name))
+		current := 0
+		for j, side := range sides {
+			if side == o.side {
+				current = j
+			}
+		}
+		o.side = sides[p.choose(Tf("Choose 1-%d", len(sides)), sides, current)]
+		fmt.Printf("%s %s %s\n", o.name, T(o.side), a.outputs[a.primary].name)
+	}
+	if p.quit {
+		return
+	}
+	a.layout()
+	for _, o := range a.outputs {
+		fmt.Println(Tf("%s: position x=%d y=%d", o.name, o.x, o.y))
+	}
+	if p.yes(T("Write these positions?")) {
+		p.press("enter")
+	} else {
+		p.press("esc")
+	}
+}
+
+func (p *plainUI) report() {
+	r := p.m.report
+	fmt.Println()
+	fmt.Println(T(r.title))
+	fmt.Println(strings.TrimRight(r.body, "\n"))
+	if len(r.actions) > 0 {
+		fmt.Println()
+		fmt.Println(r.actionList())
+		answer := p.ask(T("Type the number of a fix to apply it, or press enter to go back:") + " ")
+		if len(answer) == 1 && answer[0] >= '1' && int(answer[0]-'1') < len(r.actions) {
+			p.press(answer)
+		} else {
+			p.press("esc")
+		}
+		return
+	}
+	if r.refresh == nil {
+		p.ask(T("Press enter to go back.") + " ")
+		p.press("esc")
+		return
+	}
+	if strings.ToLower(p.ask(T("Type r to refresh, or press enter to go back:")+" ")) == "r" {
+		p.press("r")
+	} else {
+		p.press("esc")
+	}
+}
+
+func (p *plainUI) tail() {
+	t := p.m.tail
+	fmt.Println()
+	fmt.Println(T("Watch Session Logs"))
+	for _, l := range t.lines {
+		fmt.Printf("%-7s %s\n", l.source.name, l.text)
+	}
+	t.lines = nil
+	if strings.ToLower(p.ask(T("Press enter to show new lines, or type q to go back:")+" ")) == "q" {
+		p.press("esc")
+		return
+	}
+	t.poll()
+}
+
+func (p *plainUI) confirm() {
+	fmt.Println()
+	fmt.Println(T("Please confirm"))
+	fmt.Println(T(p.m.confirm.prompt))
+	question := T("Continue?")
+	if p.m.confirm.no != nil {
+		// The prompt is the question; no doesn't cancel here.
+		question = ""
+	}
+	if p.yes(question) {
+		p.press("y")
+	} else {
+		p.press("n")
+	}
+}
+
+func (p *plainUI) wizard() {
+	w := p.m.wizard
+	fmt.Println()
+	fmt.Println(Tf("First-run Wizard (%d/%d)", w.current+1, len(w.steps)))
+	for i, step := range w.steps {
+		status := T("pending")
+		switch {
+		case step.status == stepDone:
+			status = T("done")
+		case step.status == stepFailed:
+			status = T("failed")
+		case step.status == stepSkipped:
+			status = T("skipped")
+		case i == w.current:
+			status = T("next")
+		}
+		fmt.Printf("  %d. %s: %s\n", i+1, T(step.name), status)
+	}
+	if w.output != "" {
+		fmt.Println(w.output)
+	}
+	current := w.steps[w.current]
+	if w.current == len(w.steps)-1 && current.status == stepDone {
+		p.ask(T("Press enter to finish.") + " ")
+		p.press("enter")
+		return
+	}
+	fmt.Println(T(current.desc))
+	prompt := T("Press enter to run this step, s to skip, q to leave the wizard:")
+	if current.status == stepFailed {
+		prompt = T("Press enter to retry, s to skip, q to leave the wizard:")
+	}
+	switch strings.ToLower(p.ask(prompt + " ")) {
+	case "":
+		p.press("enter")
+	case "s":
+		p.press("s")
+	case "q":
+		p.press("esc")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// polkitAgent is a polkit authentication agent: the program that asks for
+// the password when a GUI tool needs root. Without one running in the
+// session such requests fail silently.
+type polkitAgent struct {
+	pkg     string
+	program string
+}
+
+var polkitAgents = []polkitAgent{
+	{"lxqt-policykit", "/usr/local/bin/lxqt-policykit-agent"},
+	{"mate-polkit", "/usr/local/libexec/polkit-mate-authentication-agent-1"},
+}
+
+func newPolkitForm() *form {
+	options := make([]string, len(polkitAgents))
+	for i, a := range polkitAgents {
+		options[i] = a.pkg
+	}
+	// Preselect an agent that is already installed.
+	value := options[0]
+	for _, a := range polkitAgents {
+		if isPackageInstalled(a.pkg) {
+			value = a.pkg
+			break
+		}
+	}
+	return &form{
+		title:  "Polkit Agent",
+		fields: []formField{{label: "Agent", value: value, options: options}},
+		submit: func(values []string) (tea.Cmd, error) {
+			for _, a := range polkitAgents {
+				if a.pkg == values[0] {
+					return setupPolkitAgent(a), nil
+				}
+			}
+			return nil, errors.New(T("unknown polkit agent"))
+		},
+	}
+}
+
+// dbusNamePattern matches the bus names in the dbus-send reply to ListNames.
+var dbusNamePattern = regexp.MustCompile(`string "(:[0-9.]+)"`)
+
+// sessionBusPIDs returns the process IDs of the clients connected to the
+// session bus.
+func sessionBusPIDs() (map[int]bool, error) {
+	out, err := commandOutput(exec.Command("dbus-send", "--session", "--print-reply", "--dest=org.freedesktop.DBus",
+		"/org/freedesktop/DBus", "org.freedesktop.DBus.ListNames"))
+	if err != nil {
+		return nil, fmt.Errorf("dbus-send: %v", err)
+	}
+	pids := map[int]bool{}
+	for _, m := range dbusNamePattern.FindAllStringSubmatch(string(out), -1) {
+		reply, err := commandOutput(exec.Command("dbus-send", "--session", "--print-reply", "--dest=org.freedesktop.DBus",
+			"/org/freedesktop/DBus", "org.freedesktop.DBus.GetConnectionUnixProcessID", "string:"+m[1]))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(reply))
+		if len(fields) == 0 {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			pids[pid] = true
+		}
+	}
+	return pids, nil
+}
+
+// agentPIDs returns the process IDs of the user's copies of program.
+func agentPIDs(program string) []int {
+	out, _ := commandOutput(exec.Command("pgrep", "-u", strconv.Itoa(userID()), "-f", program))
+	var pids []int
+	for _, f := range strings.Fields(string(out)) {
+		if pid, err := strconv.Atoi(f); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// verifyPolkitAgent checks that the agent runs and is connected to the
+// session bus. It starts the agent first if it isn't running yet.
+func verifyPolkitAgent(a polkitAgent) (string, error) {
+	if len(agentPIDs(a.program)) == 0 {
+		if out, err := commandCombinedOutput(exec.Command("niri", "msg", "action", "spawn", "--", a.program)); err != nil {
+			return "", fmt.Errorf("niri msg action spawn: %s", strings.TrimSpace(string(out)))
+		}
+		time.Sleep(time.Second)
+	}
+	pids := agentPIDs(a.program)
+	if len(pids) == 0 {
+		return "", fmt.Errorf("%s exited right after starting", a.program)
+	}
+	connected, err := sessionBusPIDs()
+	if err != nil {
+		return "", err
+	}
+	for _, pid := range pids {
+		if connected[pid] {
+			return Tf("%s runs (PID %d) and is connected to the session bus: OK", a.pkg, pid), nil
+		}
+	}
+	return "", fmt.Errorf("%s runs but isn't connected to the session bus; check DBUS_SESSION_BUS_ADDRESS", a.pkg)
+}
+
+// setupPolkitAgent installs the agent, starts it with niri and, inside a
+// niri session, checks that it came up.
+func setupPolkitAgent(a polkitAgent) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		for _, pkg := range []string{"polkit", a.pkg} {
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			for _, other := range polkitAgents {
+				if other != a {
+					cfg = removeSpawnAtStartup(cfg, other.program)
+				}
+			}
+			return setSpawnAtStartup(cfg, a.program)
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to spawn-at-startup in %s", a.program, path))
+
+		if !insideNiri() {
+			logs = append(logs, T("Run this again inside niri to check that the agent starts, or log in again."))
+			return statusMsg{status: strings.Join(logs, "\n")}
+		}
+		line, err := verifyPolkitAgent(a)
+		if err != nil {
+			logs = append(logs, Tf("Warning: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, line)
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// waylandProtocol is a protocol the desktop components rely on. A missing
+// one is only a problem when one of its programs is installed.
+type waylandProtocol struct {
+	name       string
+	interfaces []string
+	programs   []string
+}
+
+var waylandProtocols = []waylandProtocol{
+	{"layer-shell", []string{"zwlr_layer_shell_v1"}, []string{"waybar", "fuzzel", "mako", "swaybg"}},
+	{"screencopy", []string{"zwlr_screencopy_manager_v1"}, []string{"grim", "wf-recorder", "xdg-desktop-portal-wlr"}},
+	{"gamma-control", []string{"zwlr_gamma_control_manager_v1"}, []string{"wlsunset"}},
+	{"session-lock", []string{"ext_session_lock_v1"}, []string{"swaylock"}},
+	{"idle-notify", []string{"ext_idle_notify_v1", "org_kde_kwin_idle"}, []string{"swayidle"}},
+}
+
+var waylandInterface = regexp.MustCompile(`interface: '([\w]+)',\s*version:\s*(\d+)`)
+
+// waylandDisplay finds the compositor socket: the session's own when
+// NiriSetup runs inside it, otherwise the first one in the runtime
+// directory.
+func waylandDisplay() (string, string) {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = runtimeDirPath()
+	}
+	if display := os.Getenv("WAYLAND_DISPLAY"); display != "" {
+		return runtimeDir, display
+	}
+	sockets, _ := filepath.Glob(filepath.Join(runtimeDir, "wayland-[0-9]*"))
+	for _, s := range sockets {
+		if !strings.HasSuffix(s, ".lock") {
+			return runtimeDir, filepath.Base(s)
+		}
+	}
+	return runtimeDir, ""
+}
+
+// waylandInterfaces runs wayland-info against the session and returns the
+// advertised interfaces with their versions.
+func waylandInterfaces(runtimeDir, display string) (map[string]string, error) {
+	cmd := exec.Command("wayland-info")
+	cmd.Env = append(os.Environ(), "XDG_RUNTIME_DIR="+runtimeDir, "WAYLAND_DISPLAY="+display)
+	out, err := commandCombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("wayland-info: %s", strings.TrimSpace(string(out)))
+	}
+	found := map[string]string{}
+	for _, m := range waylandInterface.FindAllStringSubmatch(string(out), -1) {
+		found[m[1]] = m[2]
+	}
+	return found, nil
+}
+
+// checkProtocols reports which of the protocols the components need the
+// running compositor advertises.
+func checkProtocols() (string, bool) {
+	var b strings.Builder
+	runtimeDir, display := waylandDisplay()
+	if display == "" {
+		return T("No Wayland session found. Start niri and run the check from it."), false
+	}
+	line, err := installPackage("wayland-utils")
+	if err != nil {
+		return line, false
+	}
+	found, err := waylandInterfaces(runtimeDir, display)
+	if err != nil {
+		return Tf("Could not query %s: %v", display, err), false
+	}
+
+	fmt.Fprintf(&b, "%-16s %s (%d %s)\n\n", T("Display:"), display, len(found), T("interfaces"))
+	ok := true
+	for _, p := range waylandProtocols {
+		var users []string
+		for _, program := range p.programs {
+			if hasCommand(program) {
+				users = append(users, program)
+			}
+		}
+		advertised := ""
+		for _, iface := range p.interfaces {
+			if v, ok := found[iface]; ok {
+				advertised = fmt.Sprintf("%s v%s", iface, v)
+				break
+			}
+		}
+		switch {
+		case advertised != "":
+			fmt.Fprintf(&b, "%-16s %s\n", p.name, advertised)
+		case len(users) > 0:
+			ok = false
+			fmt.Fprintf(&b, "%-16s %s\n", p.name, Tf("MISSING, needed by %s", strings.Join(users, ", ")))
+		default:
+			fmt.Fprintf(&b, "%-16s %s\n", p.name, T("not advertised (no installed program needs it)"))
+		}
+	}
+
+	fmt.Fprintln(&b)
+	if ok {
+		fmt.Fprint(&b, T("The compositor offers every protocol the installed components need."))
+	} else {
+		fmt.Fprint(&b, T("Programs that need a missing protocol won't start or fall back. Update niri, or replace them with programs that don't need it."))
+	}
+	return b.String(), ok
+}
+
+// runCheckProtocols prints the protocol report for the command line.
+func runCheckProtocols() (string, error) {
+	text, ok := checkProtocols()
+	if !ok {
+		return text, errors.New("protocols missing")
+	}
+	return text, nil
+}
+
+// checkProtocolsCmd shows the protocol report.
+func checkProtocolsCmd() tea.Cmd {
+	return func() tea.Msg {
+		text, _ := checkProtocols()
+		return reportMsg{&report{title: "Wayland Protocols", body: text, refresh: checkProtocolsCmd()}}
+	}
+}
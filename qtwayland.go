@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// qtToolkits pair the package Qt programs of each major version depend on
+// with the package holding its Wayland platform plugin. Without the plugin
+// Qt programs fall back to Xwayland, or fail with QT_QPA_PLATFORM=wayland.
+var qtToolkits = []struct {
+	base   string
+	plugin string
+}{
+	{"qt5-gui", "qt5-wayland"},
+	{"qt6-base", "qt6-wayland"},
+}
+
+// qtPrograms returns the installed packages that depend on the Qt package
+// base, leaving out the other Qt packages.
+func qtPrograms(base string) []string {
+	if !isPackageInstalled(base) {
+		return nil
+	}
+	out, err := commandOutput(exec.Command("pkg", "query", "%rn", base))
+	if err != nil {
+		return nil
+	}
+	var programs []string
+	for _, name := range strings.Fields(string(out)) {
+		if !strings.HasPrefix(name, "qt5-") && !strings.HasPrefix(name, "qt6-") {
+			programs = append(programs, name)
+		}
+	}
+	return programs
+}
+
+// setupQtWayland installs the Wayland platform plugin for each Qt version
+// that installed programs use.
+func setupQtWayland() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		for _, qt := range qtToolkits {
+			programs := qtPrograms(qt.base)
+			if len(programs) == 0 {
+				logs = append(logs, Tf("No programs use %s, skipping %s", qt.base, qt.plugin))
+				continue
+			}
+			logs = append(logs, Tf("Programs using %s: %s", qt.base, strings.Join(programs, ", ")))
+			line, err := installPackage(qt.plugin)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The --record script. Every command that changes the system (everything
+// run through sudo) and every file written is appended to it as plain sh,
+// so the setup can be audited and replayed without NiriSetup.
+var (
+	recordMu  sync.Mutex
+	recordOut *os.File
+)
+
+// heredocEnd ends the here-documents holding file contents.
+const heredocEnd = "NIRISETUP_EOF"
+
+const recordHeader = `#!/bin/sh
+# Recorded by NiriSetup on %s.
+#
+# Replays the changes NiriSetup made: packages, services, system settings
+# and the files it wrote. Run it as the same user; steps that need root use
+# sudo just like NiriSetup does. Commands that failed while recording are
+# marked with a comment.
+`
+
+func startRecording(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(f, recordHeader, time.Now().Format("2006-01-02 15:04"))
+	recordOut = f
+	return nil
+}
+
+func recordLines(lines ...string) {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	if recordOut == nil {
+		return
+	}
+	fmt.Fprintln(recordOut, strings.Join(lines, "\n"))
+}
+
+// shQuote quotes s for sh if it needs it.
+func shQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'$`\\|&;<>()*?[]#~{}!") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shPath quotes path, keeping it relative to $HOME when it's under the
+// home directory so the script works for another user.
+func shPath(path string) string {
+	home, err := userHomeDir()
+	if err == nil && path == home {
+		return `"$HOME"`
+	}
+	if err == nil && strings.HasPrefix(path, home+"/") {
+		return `"$HOME"/` + shQuote(strings.TrimPrefix(path, home+"/"))
+	}
+	return shQuote(path)
+}
+
+// recordCommand appends cmd to the script if it changes the system.
+func recordCommand(cmd *exec.Cmd, err error) {
+	if len(cmd.Args) > 0 && cmd.Args[0] == "sudo" {
+		recordArgs(err, cmd.Args...)
+	}
+}
+
+func recordArgs(err error, argv ...string) {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shQuote(arg)
+	}
+	line := strings.Join(quoted, " ")
+	if err != nil {
+		line += " # failed while recording: " + strings.SplitN(err.Error(), "\n", 2)[0]
+	}
+	recordLines(line)
+}
+
+// recordFile appends a here-document that recreates a written file. With
+// appending set, the content is added to the end of the file instead.
+func recordFile(path string, data []byte, perm os.FileMode, asRoot, appending bool) {
+	content := strings.TrimSuffix(string(data), "\n")
+	redirect := ">"
+	if appending {
+		redirect = ">>"
+	}
+	var lines []string
+	if asRoot {
+		tee := "sudo tee"
+		if appending {
+			tee += " -a"
+		}
+		lines = []string{
+			fmt.Sprintf("%s %s >/dev/null <<'%s'", tee, shQuote(path), heredocEnd),
+			content,
+			heredocEnd,
+			fmt.Sprintf("sudo chmod %o %s", perm, shQuote(path)),
+		}
+	} else {
+		lines = []string{
+			"mkdir -p " + shPath(filepath.Dir(path)),
+			fmt.Sprintf("cat %s %s <<'%s'", redirect, shPath(path), heredocEnd),
+			content,
+			heredocEnd,
+		}
+		if !appending {
+			lines = append(lines, fmt.Sprintf("chmod %o %s", perm, shPath(path)))
+		}
+	}
+	recordLines(append([]string{""}, lines...)...)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// remoteDir is where NiriSetup and its config template are copied on the
+// remote machine, relative to the remote home directory.
+const remoteDir = ".cache/nirisetup"
+
+// remoteScript runs the setup subcommands on the remote machine and exits
+// with the worst exit code among them. A preflight failure stops the run,
+// since the later steps depend on the earlier ones. It is run with sh, as
+// the login shell may not be sh compatible.
+const remoteScript = `cd ` + remoteDir + ` || exit 2
+rc=0
+for step in "install --yes" setup "configure --yes" validate; do
+	./NiriSetup %s $step
+	c=$?
+	[ $c -gt $rc ] && rc=$c
+	[ $c -eq 2 ] && break
+done
+exit $rc`
+
+// remoteArch maps uname -m on the remote machine to a Go architecture.
+var remoteArch = map[string]string{"amd64": "amd64", "arm64": "arm64", "aarch64": "arm64", "i386": "386"}
+
+// checkRemoteHost rejects a host ssh and scp would take for an option.
+func checkRemoteHost(host string) error {
+	if strings.HasPrefix(host, "-") {
+		return errors.New(Tf("%s isn't a host: it can't start with -", host))
+	}
+	return nil
+}
+
+// remoteSetup provisions host (user@host) by copying this binary there and
+// running the setup steps over ssh. Output is streamed to the terminal,
+// and ssh gets a terminal too so that sudo can ask for a password.
+func remoteSetup(host string) error {
+	if err := checkRemoteHost(host); err != nil {
+		return finish("remote", err.Error(), preflightFailure(err))
+	}
+	if !hasCommand("ssh") || !hasCommand("scp") {
+		return finish("remote", T("ssh and scp are needed to set up a remote machine"), preflightFailure(errors.New("ssh not found")))
+	}
+	if runtime.GOOS != "freebsd" {
+		return finish("remote", T("NiriSetup copies itself to the remote machine, so it must be built for FreeBSD (GOOS=freebsd) to do that"), preflightFailure(errors.New("not a FreeBSD build")))
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return finish("remote", T("Failed to determine executable path"), preflightFailure(err))
+	}
+	template, err := findConfigTemplate()
+	if err != nil {
+		return finish("remote", T(err.Error()), preflightFailure(err))
+	}
+
+	fmt.Println(Tf("Checking %s...", host))
+	out, err := commandOutput(exec.Command("ssh", "--", host, "uname", "-sm"))
+	if err != nil {
+		return finish("remote", Tf("Failed to connect to %s: %v", host, err), preflightFailure(err))
+	}
+	system, machine, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	if system != "FreeBSD" || remoteArch[machine] != runtime.GOARCH {
+		return finish("remote", Tf("%s runs %s, but this NiriSetup is built for FreeBSD %s", host, strings.TrimSpace(string(out)), runtime.GOARCH), preflightFailure(errors.New("unsupported remote system")))
+	}
+
+	fmt.Println(Tf("Copying NiriSetup to %s:%s...", host, remoteDir))
+	if out, err := commandCombinedOutput(exec.Command("ssh", "--", host, "mkdir", "-p", remoteDir)); err != nil {
+		return finish("remote", Tf("Failed to prepare %s: %s", host, strings.TrimSpace(string(out))), preflightFailure(err))
+	}
+	copyCmd := exec.Command("scp", "-q", "--", exe, host+":"+remoteDir+"/NiriSetup")
+	if out, err := commandCombinedOutput(copyCmd); err != nil {
+		return finish("remote", Tf("Failed to copy NiriSetup to %s: %s", host, strings.TrimSpace(string(out))), preflightFailure(err))
+	}
+	copyCmd = exec.Command("scp", "-q", "--", template, host+":"+remoteDir+"/"+filepath.Base(template))
+	if out, err := commandCombinedOutput(copyCmd); err != nil {
+		return finish("remote", Tf("Failed to copy the config template %s to %s: %s", template, host, strings.TrimSpace(string(out))), preflightFailure(err))
+	}
+
+	var flags []string
+	if langFlag != "" {
+		flags = append(flags, "--lang", langFlag)
+	}
+	if verboseFlag {
+		flags = append(flags, "--verbose")
+	}
+	script := fmt.Sprintf(remoteScript, strings.Join(flags, " "))
+	cmd := exec.Command("ssh", "-t", "--", host, "sh -c "+shQuote(script))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = runCommand(cmd)
+
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return finish("remote", Tf("Finished setting up %s", host), nil)
+	case errors.As(err, &exit) && exit.ExitCode() == 255:
+		return finish("remote", Tf("Lost the connection to %s", host), err)
+	case errors.As(err, &exit):
+		return finish("remote", Tf("Setting up %s failed", host), &failure{exit.ExitCode(), err})
+	default:
+		return finish("remote", Tf("Failed to run ssh: %v", err), preflightFailure(err))
+	}
+}
+
+func newRemoteForm() *form {
+	return &form{
+		title:  "Remote Setup",
+		fields: []formField{{label: "Host (user@host)", value: ""}},
+		submit: func(values []string) (tea.Cmd, error) {
+			host := strings.TrimSpace(values[0])
+			if host == "" || strings.ContainsAny(host, " \t") {
+				return nil, errors.New(T("enter the machine to set up as user@host"))
+			}
+			if err := checkRemoteHost(host); err != nil {
+				return nil, err
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return nil, err
+			}
+			args := []string{"remote"}
+			if langFlag != "" {
+				args = append(args, "--lang", langFlag)
+			}
+			args = append(args, "--", host)
+			// Runs as a separate process that owns the terminal, so the
+			// remote output and any sudo password prompts come through.
+			return execInteractive(exec.Command(exe, args...), func(err error) tea.Msg {
+				code := exitCode(err)
+				var exit *exec.ExitError
+				if errors.As(err, &exit) {
+					code = exit.ExitCode()
+				}
+				if code != exitOK {
+					return statusMsg{status: Tf("Setting up %s failed (%s)", host, exitStatus[code]), err: err}
+				}
+				return statusMsg{status: Tf("Finished setting up %s", host)}
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reportHeight is how many lines of a report are visible at once.
+const reportHeight = 20
+
+// report is a read-only, scrollable screen of text. If refresh is set, "r"
+// reloads it, and the number keys run its actions.
+type report struct {
+	title   string
+	body    string
+	offset  int
+	refresh tea.Cmd
+	actions []reportAction
+}
+
+// reportAction is a fix offered on a report screen.
+type reportAction struct {
+	label string
+	cmd   tea.Cmd
+}
+
+// reportMsg opens a report screen once its content has been gathered.
+type reportMsg struct {
+	report *report
+}
+
+func (r *report) lines() []string {
+	return strings.Split(strings.TrimRight(r.body, "\n"), "\n")
+}
+
+func (m model) updateReport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.report
+	maxOffset := max(0, len(r.lines())-reportHeight)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "backspace", "enter":
+		m.state = menuView
+		m.report = nil
+		m.isProcessing = false
+	case "up", "k":
+		r.offset = max(0, r.offset-1)
+	case "down", "j":
+		r.offset = min(maxOffset, r.offset+1)
+	case "pgup":
+		r.offset = max(0, r.offset-reportHeight)
+	case "pgdown", " ":
+		r.offset = min(maxOffset, r.offset+reportHeight)
+	case "r":
+		if r.refresh != nil {
+			return m, r.refresh
+		}
+	default:
+		key := msg.String()
+		if len(key) != 1 {
+			break
+		}
+		if i := int(key[0]) - '1'; i >= 0 && i < min(len(r.actions), 9) {
+			m.state = actionView
+			m.actionMsg = r.actions[i].label
+			m.report = nil
+			return m, r.actions[i].cmd
+		}
+	}
+	return m, nil
+}
+
+// actionList numbers the actions of r for the help line.
+func (r *report) actionList() string {
+	var lines []string
+	for i, a := range r.actions[:min(len(r.actions), 9)] {
+		lines = append(lines, fmt.Sprintf("%d: %s", i+1, a.label))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) renderReportView() string {
+	r := m.report
+	lines := r.lines()
+	end := min(len(lines), r.offset+reportHeight)
+	body := strings.Join(lines[r.offset:end], "\n")
+
+	help := T("↑/↓ scroll • esc back")
+	if r.refresh != nil {
+		help = T("↑/↓ scroll • r refresh • esc back")
+	}
+	if len(lines) > reportHeight {
+		help += " • " + Tf("lines %d-%d of %d", r.offset+1, end, len(lines))
+	}
+	views := []string{titleStyle.Render(T(r.title)), logStyle.Render(body)}
+	if len(r.actions) > 0 {
+		views = append(views, formHelpStyle.Render(r.actionList()))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append(views, formHelpStyle.Render(help))...)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// pamXDG is the PAM module that creates /var/run/user/<uid> at login and
+// sets XDG_RUNTIME_DIR to it.
+const pamXDG = "/usr/local/lib/pam_xdg.so"
+
+// pamXDGProfileNote replaces the XDG_RUNTIME_DIR line of ~/.profile once
+// pam_xdg sets it. Setup System sees it and leaves .profile alone.
+const pamXDGProfileNote = "# XDG_RUNTIME_DIR is set by pam_xdg at login."
+
+// runtimeDirIssue reports a runtime directory that exists but that the
+// session can't use, typically left behind owned by root after niri or
+// NiriSetup ran through su or sudo.
+func runtimeDirIssue() (doctorIssue, bool) {
+	dir := runtimeDirPath()
+	if _, err := os.Stat(dir); err != nil {
+		// A missing one is created at the next start.
+		return doctorIssue{}, false
+	}
+	problem := runtimeDirProblem(dir)
+	if problem == "" {
+		return doctorIssue{}, false
+	}
+	return doctorIssue{
+		what:   Tf("XDG_RUNTIME_DIR is unusable: %s", problem),
+		remedy: T("Remove the directory and create it again, owned by you with mode 700; or let pam_xdg create /var/run/user/<uid> at login and use that instead."),
+		label:  "Recreate the runtime directory",
+		fix:    recreateRuntimeDir,
+		alternatives: []doctorFix{
+			{label: "Use the runtime directory of pam_xdg", fix: migrateToPamXDG},
+		},
+	}, true
+}
+
+// pamXDGConfigured reports whether the TTY login runs pam_xdg, which
+// then creates the runtime directory and sets XDG_RUNTIME_DIR.
+func pamXDGConfigured() bool {
+	data, err := os.ReadFile(pamLoginPath)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 2 && fields[0] == "session" && strings.Contains(line, "pam_xdg.so") {
+			return true
+		}
+	}
+	return false
+}
+
+// profileRuntimeDir is what Setup System appends to a .profile that
+// doesn't set XDG_RUNTIME_DIR yet: the export, or pamXDGProfileNote when
+// pam_xdg sets it at login and an export would override it.
+func profileRuntimeDir() string {
+	if pamXDGConfigured() {
+		return "\n" + pamXDGProfileNote + "\n"
+	}
+	return "\n# Set XDG_RUNTIME_DIR for Wayland compositors\nexport XDG_RUNTIME_DIR=" + runtimeDirPath() + "\n"
+}
+
+// runtimeDirInUse returns an error when niri is running, as its IPC socket
+// and Wayland display live in the runtime directory.
+func runtimeDirInUse() error {
+	if insideNiriSession() || niriRunning() {
+		return errors.New(T("niri is running and uses the runtime directory; log out of niri first, then run this again from a TTY"))
+	}
+	return nil
+}
+
+// removeRuntimeDir removes the runtime directory, which may belong to
+// root. It refuses while niri runs.
+func removeRuntimeDir(dir string) error {
+	if err := runtimeDirInUse(); err != nil {
+		return err
+	}
+	if out, err := commandCombinedOutput(exec.Command("sudo", "rm", "-rf", dir)); err != nil {
+		return fmt.Errorf("rm %s: %v: %s", dir, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// recreateRuntimeDir replaces the runtime directory with an empty one the
+// user owns.
+func recreateRuntimeDir() (string, error) {
+	dir := runtimeDirPath()
+	if err := removeRuntimeDir(dir); err != nil {
+		return "", err
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return "", err
+	}
+	// Mkdir is subject to the umask, and the directory belongs to the
+	// user, not to root, when NiriSetup runs through sudo.
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", err
+	}
+	chownToUser(dir)
+	return Tf("Created %s again, owned by you with mode 700: OK", dir), nil
+}
+
+// pamWithXDG adds pam_xdg to the session stack of a PAM service file, after
+// its last session line.
+func pamWithXDG(pam string) string {
+	if strings.Contains(pam, "pam_xdg.so") {
+		return pam
+	}
+	lines := strings.Split(strings.TrimRight(pam, "\n"), "\n")
+	last := -1
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == "session" {
+			last = i
+		}
+	}
+	entry := "session\t\toptional\t" + pamXDG
+	if last < 0 {
+		return strings.Join(append(lines, entry), "\n") + "\n"
+	}
+	out := append(append(append([]string{}, lines[:last+1]...), entry), lines[last+1:]...)
+	return strings.Join(out, "\n") + "\n"
+}
+
+// profileWithoutRuntimeDir replaces the lines of .profile that set
+// XDG_RUNTIME_DIR, and the comment Setup System put above them, with
+// pamXDGProfileNote.
+func profileWithoutRuntimeDir(profile string) string {
+	var out []string
+	noted := false
+	for _, line := range strings.Split(strings.TrimRight(profile, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "# Set XDG_RUNTIME_DIR for Wayland compositors":
+			continue
+		case strings.Contains(trimmed, "XDG_RUNTIME_DIR=") && !strings.HasPrefix(trimmed, "#"):
+			if !noted {
+				out = append(out, pamXDGProfileNote)
+				noted = true
+			}
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// migrateToPamXDG installs pam_xdg and adds it to the TTY login, so that
+// XDG_RUNTIME_DIR is created fresh at every login, drops the
+// XDG_RUNTIME_DIR line from ~/.profile and removes the old directory.
+func migrateToPamXDG() (string, error) {
+	// Check first, rather than stopping halfway once the login is changed.
+	if err := runtimeDirInUse(); err != nil {
+		return err.Error(), err
+	}
+	var logs []string
+	line, err := installPackage("pam_xdg")
+	logs = append(logs, line)
+	if err != nil {
+		return strings.Join(logs, "\n"), err
+	}
+	if _, err := os.Stat(pamXDG); err != nil {
+		return strings.Join(logs, "\n"), fmt.Errorf("%s not found: %v", pamXDG, err)
+	}
+
+	data, err := os.ReadFile(pamLoginPath)
+	if err != nil {
+		return strings.Join(logs, "\n"), err
+	}
+	if updated := pamWithXDG(string(data)); updated == string(data) {
+		logs = append(logs, Tf("%s already uses pam_xdg: OK", pamLoginPath))
+	} else {
+		if err := writeRootFile(pamLoginPath+".bak", string(data), 0644); err != nil {
+			return strings.Join(logs, "\n"), err
+		}
+		if err := writeRootFile(pamLoginPath, updated, 0644); err != nil {
+			return strings.Join(logs, "\n"), err
+		}
+		logs = append(logs, Tf("Added pam_xdg to %s (backup in %s.bak): OK", pamLoginPath, pamLoginPath))
+	}
+
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return strings.Join(logs, "\n"), err
+	}
+	profilePath := filepath.Join(homeDir, ".profile")
+	if profile, err := os.ReadFile(profilePath); err == nil {
+		if updated := profileWithoutRuntimeDir(string(profile)); updated != string(profile) {
+			if err := writeFile(profilePath, []byte(updated), 0644); err != nil {
+				return strings.Join(logs, "\n"), err
+			}
+			logs = append(logs, Tf("Removed XDG_RUNTIME_DIR from %s: OK", profilePath))
+		}
+	}
+
+	dir := runtimeDirPath()
+	if err := removeRuntimeDir(dir); err != nil {
+		return strings.Join(logs, "\n"), err
+	}
+	logs = append(logs, Tf("Removed %s: OK", dir))
+	logs = append(logs, Tf("Log out and back in; XDG_RUNTIME_DIR will then be /var/run/user/%d.", userID()))
+	return strings.Join(logs, "\n"), nil
+}
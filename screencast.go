@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// screenSharingPackages are the screen sharing stack: PipeWire carries the
+// video, and xdg-desktop-portal hands it out through the GNOME backend,
+// which talks to niri's screencast interface.
+var screenSharingPackages = []string{"pipewire", "wireplumber", "xdg-desktop-portal", "xdg-desktop-portal-gnome", "xdg-desktop-portal-gtk"}
+
+// niriPortalsConf routes the portals for XDG_CURRENT_DESKTOP=niri, as the
+// niri-portals.conf niri ships with does.
+const niriPortalsConf = `[preferred]
+default=gnome;gtk;
+org.freedesktop.impl.portal.Access=gtk;
+org.freedesktop.impl.portal.Notification=gtk;
+org.freedesktop.impl.portal.Secret=gnome-keyring;
+org.freedesktop.impl.portal.FileChooser=gtk;
+`
+
+const (
+	portalBus     = "org.freedesktop.portal.Desktop"
+	portalPath    = "/org/freedesktop/portal/desktop"
+	screenCastBus = "org.gnome.Mutter.ScreenCast"
+	// screencastTimeout leaves time to pick a screen in the portal dialog.
+	screencastTimeout = 2 * time.Minute
+)
+
+// portalsConfPath returns the niri-portals.conf xdg-desktop-portal reads,
+// or "" if there is none.
+func portalsConfPath(homeDir string) string {
+	for _, path := range []string{
+		filepath.Join(homeDir, ".config", "xdg-desktop-portal", "niri-portals.conf"),
+		"/usr/local/etc/xdg/xdg-desktop-portal/niri-portals.conf",
+		"/usr/local/share/xdg-desktop-portal/niri-portals.conf",
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// setupScreenSharing installs the stack, starts PipeWire with the session
+// and routes the portals to the GNOME backend.
+func setupScreenSharing() ([]string, error) {
+	var logs []string
+	for _, pkg := range screenSharingPackages {
+		line, err := installPackage(pkg)
+		logs = append(logs, line)
+		if err != nil {
+			return logs, err
+		}
+	}
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return logs, err
+	}
+	if portalsConfPath(homeDir) == "" {
+		path := filepath.Join(homeDir, ".config", "xdg-desktop-portal", "niri-portals.conf")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return logs, err
+		}
+		if err := writeFile(path, []byte(niriPortalsConf), 0644); err != nil {
+			logs = append(logs, Tf("Failed to write %s: %v", path, err))
+			return logs, err
+		}
+		logs = append(logs, Tf("Wrote %s: OK", path))
+	}
+	path, err := updateNiriConfig(func(cfg string) string {
+		for _, program := range []string{"pipewire", "wireplumber"} {
+			cfg = setSpawnAtStartup(cfg, program)
+		}
+		return cfg
+	})
+	if err != nil {
+		logs = append(logs, Tf("Failed to update config: %v", err))
+		return logs, err
+	}
+	logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "pipewire, wireplumber", path))
+	return logs, nil
+}
+
+// screenSharingChecks looks at each link of the pipeline without asking
+// for a screencast, and returns the problems.
+func screenSharingChecks(b *strings.Builder) []string {
+	var problems []string
+	line := func(label, text string) {
+		fmt.Fprintf(b, "%-22s %s\n", label, text)
+	}
+
+	for _, program := range []string{"pipewire", "wireplumber"} {
+		if pids := agentPIDs(program); len(pids) > 0 {
+			line(program+":", T("running"))
+		} else {
+			line(program+":", T("not running"))
+			problems = append(problems, Tf("%s isn't running; set up PipeWire and the portals to start it with niri", program))
+		}
+	}
+
+	homeDir, _ := userHomeDir()
+	if path := portalsConfPath(homeDir); path != "" {
+		line(T("Portal routing:"), path)
+	} else {
+		line(T("Portal routing:"), T("no niri-portals.conf"))
+		problems = append(problems, T("xdg-desktop-portal doesn't know which backend to use in niri"))
+	}
+	if desktop := os.Getenv("XDG_CURRENT_DESKTOP"); desktop != "niri" {
+		problems = append(problems, T("XDG_CURRENT_DESKTOP isn't niri, so niri-portals.conf isn't used; run Setup System"))
+	}
+
+	conn, err := dialSessionBus()
+	if err != nil {
+		line("D-Bus:", err.Error())
+		return append(problems, T("no session bus; start niri with dbus-launch"))
+	}
+	defer conn.Close()
+	for _, name := range []struct{ bus, label, problem string }{
+		{portalBus, "xdg-desktop-portal:", "xdg-desktop-portal isn't running or can't be started"},
+		{screenCastBus, "niri screencast:", "niri doesn't offer screencasting; it may be built without it"},
+	} {
+		if name.bus == portalBus {
+			// Reading a property starts the portal if it isn't running.
+			_, err = conn.call(portalBus, portalPath, "org.freedesktop.DBus.Properties", "Get", "ss", "org.freedesktop.portal.ScreenCast", "version")
+		} else {
+			var reply []any
+			reply, err = conn.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "NameHasOwner", "s", name.bus)
+			if err == nil && reply[0] != true {
+				err = errors.New(T("not on the session bus"))
+			}
+		}
+		if err != nil {
+			line(name.label, err.Error())
+			problems = append(problems, T(name.problem))
+		} else {
+			line(name.label, "OK")
+		}
+	}
+	return problems
+}
+
+// portalRequest calls a portal method that answers with a Response signal
+// on a request object and returns the results.
+func portalRequest(conn *dbusConn, token, method, sig string, args ...any) (map[string]any, error) {
+	sender := strings.ReplaceAll(strings.TrimPrefix(conn.name, ":"), ".", "_")
+	request := portalPath + "/request/" + sender + "/" + token
+	if _, err := conn.call(portalBus, portalPath, "org.freedesktop.portal.ScreenCast", method, sig, args...); err != nil {
+		return nil, err
+	}
+	msg, err := conn.waitSignal(request, "Response", screencastTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.body) < 2 {
+		return nil, errors.New("malformed response")
+	}
+	switch msg.body[0] {
+	case uint32(0):
+		results, _ := msg.body[1].(map[string]any)
+		return results, nil
+	case uint32(1):
+		return nil, errors.New(T("cancelled in the dialog"))
+	}
+	return nil, errors.New(T("the portal reported a failure"))
+}
+
+// requestScreencast asks the portal for a screencast of a monitor, the way
+// video call programs do, and returns the PipeWire node of the stream.
+func requestScreencast() (uint32, error) {
+	conn, err := dialSessionBus()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if _, err := conn.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s",
+		"type='signal',interface='org.freedesktop.portal.Request',member='Response'"); err != nil {
+		return 0, err
+	}
+	token := func(s string) dbusVariant { return dbusVariant{"s", "nirisetup_" + s} }
+
+	results, err := portalRequest(conn, "nirisetup_create", "CreateSession", "a{sv}",
+		map[string]dbusVariant{"handle_token": token("create"), "session_handle_token": token("session")})
+	if err != nil {
+		return 0, fmt.Errorf("CreateSession: %w", err)
+	}
+	session, _ := results["session_handle"].(string)
+	if session == "" {
+		return 0, errors.New("CreateSession: no session handle")
+	}
+	defer conn.call(portalBus, session, "org.freedesktop.portal.Session", "Close", "")
+
+	if _, err := portalRequest(conn, "nirisetup_select", "SelectSources", "oa{sv}", session,
+		map[string]dbusVariant{"handle_token": token("select"), "types": {"u", uint32(1)}, "multiple": {"b", false}}); err != nil {
+		return 0, fmt.Errorf("SelectSources: %w", err)
+	}
+	results, err = portalRequest(conn, "nirisetup_start", "Start", "osa{sv}", session, "",
+		map[string]dbusVariant{"handle_token": token("start")})
+	if err != nil {
+		return 0, fmt.Errorf("Start: %w", err)
+	}
+	streams, _ := results["streams"].([]any)
+	if len(streams) == 0 {
+		return 0, errors.New("Start: no streams")
+	}
+	stream, _ := streams[0].([]any)
+	node, _ := stream[0].(uint32)
+	return node, nil
+}
+
+// testScreenSharing runs the checks and, if asked to, a real screencast
+// request, and reports whether the pipeline works.
+func testScreenSharing(request bool) (string, bool) {
+	var b strings.Builder
+	problems := screenSharingChecks(&b)
+	if request {
+		fmt.Fprintln(&b)
+		node, err := requestScreencast()
+		switch {
+		case err != nil:
+			fmt.Fprintf(&b, "%-22s %s\n", T("Screencast:"), err)
+			problems = append(problems, T("the screencast request failed"))
+		case !hasCommand("pw-cli"):
+			fmt.Fprintf(&b, "%-22s %s\n", T("Screencast:"), Tf("PipeWire node %d", node))
+		default:
+			out, err := commandCombinedOutput(exec.Command("pw-cli", "info", fmt.Sprint(node)))
+			if err != nil || !strings.Contains(string(out), "Video") {
+				fmt.Fprintf(&b, "%-22s %s\n", T("Screencast:"), Tf("PipeWire node %d is missing or not a video stream", node))
+				problems = append(problems, T("niri started the screencast but PipeWire has no video for it"))
+			} else {
+				fmt.Fprintf(&b, "%-22s %s\n", T("Screencast:"), Tf("video stream on PipeWire node %d: OK", node))
+			}
+		}
+	}
+
+	fmt.Fprintln(&b)
+	switch {
+	case len(problems) > 0:
+		fmt.Fprintln(&b, T("Screen sharing won't work in video calls:"))
+		for _, p := range problems {
+			fmt.Fprintf(&b, "• %s\n", p)
+		}
+	case request:
+		fmt.Fprint(&b, T("Screen sharing works: portal, niri and PipeWire delivered a video stream."))
+	default:
+		fmt.Fprint(&b, T("Every link is in place. Run the screencast test to share a screen for real; a dialog asks which one."))
+	}
+	return b.String(), len(problems) == 0
+}
+
+// runScreenSharingSetup sets up the stack for the command line.
+func runScreenSharingSetup() (string, error) {
+	logs, err := setupScreenSharing()
+	if err == nil {
+		logs = append(logs, T("Log out and back in, then run the screencast test."))
+	}
+	return strings.Join(logs, "\n"), err
+}
+
+// runScreencastTest prints the screen sharing test for the command line.
+func runScreencastTest() (string, error) {
+	text, ok := testScreenSharing(true)
+	if !ok {
+		return strings.TrimRight(text, "\n"), errors.New("screen sharing doesn't work")
+	}
+	return text, nil
+}
+
+// screenSharingCmd shows the screen sharing report, after setting up the
+// stack or requesting a screencast if asked to.
+func screenSharingCmd(setup, request bool) tea.Cmd {
+	return func() tea.Msg {
+		var b strings.Builder
+		if setup {
+			logs, err := setupScreenSharing()
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, T("Log out and back in, then run the screencast test."))
+			fmt.Fprintf(&b, "%s\n\n", strings.Join(logs, "\n"))
+		}
+		text, _ := testScreenSharing(request)
+		b.WriteString(text)
+		return reportMsg{&report{
+			title:   "Screen Sharing",
+			body:    b.String(),
+			refresh: screenSharingCmd(false, false),
+			actions: []reportAction{
+				{label: "Set up PipeWire and the portals", cmd: screenSharingCmd(true, false)},
+				{label: "Run the screencast test", cmd: screenSharingCmd(false, true)},
+			},
+		}}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// niriWorkspace mirrors the objects reported by `niri msg --json workspaces`.
+type niriWorkspace struct {
+	ID             uint64  `json:"id"`
+	Idx            int     `json:"idx"`
+	Name           *string `json:"name"`
+	Output         *string `json:"output"`
+	IsActive       bool    `json:"is_active"`
+	IsFocused      bool    `json:"is_focused"`
+	ActiveWindowID *uint64 `json:"active_window_id"`
+}
+
+// niriWindow mirrors the objects reported by `niri msg --json windows`.
+type niriWindow struct {
+	ID          uint64  `json:"id"`
+	Title       *string `json:"title"`
+	AppID       *string `json:"app_id"`
+	PID         *int    `json:"pid"`
+	WorkspaceID *uint64 `json:"workspace_id"`
+	IsFocused   bool    `json:"is_focused"`
+	IsFloating  bool    `json:"is_floating"`
+}
+
+func (w niriWindow) label() string {
+	appID, title := "?", ""
+	if w.AppID != nil {
+		appID = *w.AppID
+	}
+	if w.Title != nil {
+		title = *w.Title
+	}
+	return fmt.Sprintf("%s %q", appID, title)
+}
+
+// activeSession gathers the state of the running niri instance over IPC and
+// shows it as a report that can be refreshed.
+func activeSession() tea.Cmd {
+	return func() tea.Msg {
+		outputs, err := niriOutputs()
+		if err != nil {
+			return statusMsg{status: Tf("Cannot reach niri, is a session running? %v", err), err: err}
+		}
+		var workspaces []niriWorkspace
+		if err := niriMsg(&workspaces, "workspaces"); err != nil {
+			return statusMsg{status: Tf("Failed to query workspaces: %v", err), err: err}
+		}
+		var windows []niriWindow
+		if err := niriMsg(&windows, "windows"); err != nil {
+			return statusMsg{status: Tf("Failed to query windows: %v", err), err: err}
+		}
+
+		s := strings.Builder{}
+		s.WriteString(T("Focused window:") + "\n")
+		focused := false
+		for _, w := range windows {
+			if w.IsFocused {
+				fmt.Fprintf(&s, "  %s\n", w.label())
+				focused = true
+			}
+		}
+		if !focused {
+			s.WriteString("  " + T("(none)") + "\n")
+		}
+
+		s.WriteString("\n" + T("Outputs:") + "\n")
+		for _, o := range outputs {
+			if o.Logical == nil {
+				s.WriteString("  " + Tf("%s: disabled", o.Name) + "\n")
+				continue
+			}
+			mode := ""
+			if m, ok := o.currentMode(); ok {
+				mode = fmt.Sprintf(" %dx%d@%.2f", m.Width, m.Height, float64(m.RefreshRate)/1000)
+			}
+			fmt.Fprintf(&s, "  %s:%s scale %g at %d,%d\n", o.Name, mode, o.Logical.Scale, o.Logical.X, o.Logical.Y)
+		}
+
+		s.WriteString("\n" + T("Workspaces:") + "\n")
+		for _, ws := range workspaces {
+			name := fmt.Sprintf("%d", ws.Idx)
+			if ws.Name != nil {
+				name += " " + *ws.Name
+			}
+			output := "?"
+			if ws.Output != nil {
+				output = *ws.Output
+			}
+			marker := " "
+			if ws.IsFocused {
+				marker = "*"
+			} else if ws.IsActive {
+				marker = "+"
+			}
+			s.WriteString(" " + marker + " " + Tf("%s on %s", name, output) + "\n")
+			for _, w := range windows {
+				if w.WorkspaceID != nil && *w.WorkspaceID == ws.ID {
+					fmt.Fprintf(&s, "      %s\n", w.label())
+				}
+			}
+		}
+		s.WriteString("\n" + Tf("%d windows, * focused, + active on its output", len(windows)))
+
+		return reportMsg{&report{title: "Active Session", body: s.String(), refresh: activeSession()}}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionVar is an environment variable shown on the Session Info screen.
+// expect, if set, returns the value NiriSetup sets up; sessionOnly
+// variables are only checked inside niri.
+type sessionVar struct {
+	name        string
+	expect      func() string
+	sessionOnly bool
+	hint        string
+}
+
+var sessionVars = []sessionVar{
+	{"WAYLAND_DISPLAY", nil, true, "niri sets it for the programs it starts; run NiriSetup from a terminal in niri"},
+	{"DISPLAY", nil, true, "X11 programs can't start; check that xwayland-satellite runs"},
+	{"XDG_RUNTIME_DIR", runtimeDirPath, false, "Setup System sets it in ~/.profile; log in again"},
+	{"DBUS_SESSION_BUS_ADDRESS", nil, true, "start niri with dbus-launch, as Setup System shows"},
+	{"LIBSEAT_BACKEND", func() string { return "consolekit2" }, false, "Setup System sets it in ~/.profile; log in again"},
+	{"XDG_CURRENT_DESKTOP", func() string { return "niri" }, true, "Setup System sets it in the environment block of config.kdl"},
+	{"XDG_SESSION_TYPE", func() string { return "wayland" }, true, "Setup System sets it in the environment block of config.kdl"},
+}
+
+// insideNiri reports whether NiriSetup itself runs in a niri session, so
+// that its environment is the session's.
+func insideNiri() bool {
+	return os.Getenv("NIRI_SOCKET") != ""
+}
+
+// runtimeDirProblem checks that dir exists, belongs to the user and is
+// private, as Wayland requires.
+func runtimeDirProblem(dir string) string {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return Tf("%s doesn't exist", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != userID() {
+		return Tf("%s belongs to UID %d, not to you", dir, st.Uid)
+	}
+	if info.Mode().Perm() != 0700 {
+		return Tf("%s has mode %o instead of 700", dir, info.Mode().Perm())
+	}
+	return ""
+}
+
+// serviceStatus returns the first line of `service name status`.
+func serviceStatus(name string) string {
+	out, err := commandCombinedOutput(exec.Command("service", name, "status"))
+	status := strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+	if status == "" && err != nil {
+		status = err.Error()
+	}
+	return status
+}
+
+// seatStatus describes the ConsoleKit2 sessions of the user.
+func seatStatus() string {
+	if !hasCommand("ck-list-sessions") {
+		return T("ck-list-sessions not found (is consolekit2 installed?)")
+	}
+	out, err := commandOutput(exec.Command("ck-list-sessions"))
+	if err != nil {
+		return Tf("ck-list-sessions failed: %v", err)
+	}
+	var sessions []string
+	var current []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, ":") && !strings.Contains(line, " ") {
+			if len(current) > 0 {
+				sessions = append(sessions, strings.Join(current, ", "))
+			}
+			current = []string{strings.TrimSuffix(line, ":")}
+			continue
+		}
+		for _, key := range []string{"seat", "active", "x11-display", "session-type", "display-device"} {
+			if strings.HasPrefix(line, key+" =") {
+				current = append(current, line)
+			}
+		}
+	}
+	if len(current) > 0 {
+		sessions = append(sessions, strings.Join(current, ", "))
+	}
+	if len(sessions) == 0 {
+		return T("no ConsoleKit2 sessions")
+	}
+	return strings.Join(sessions, "\n    ")
+}
+
+// describeSession lists the session variables, the seat and the services
+// niri needs, and returns the values that differ from the setup.
+func describeSession() (string, []string) {
+	inside := insideNiri()
+	var b strings.Builder
+	var problems []string
+	if !inside {
+		fmt.Fprintln(&b, T("NiriSetup doesn't run inside niri, so the session-only variables below are its own, not the session's."))
+		fmt.Fprintln(&b)
+	}
+	for _, v := range sessionVars {
+		value, set := os.LookupEnv(v.name)
+		shown := value
+		if !set {
+			shown = T("(not set)")
+		}
+		fmt.Fprintf(&b, "%-25s %s\n", v.name, shown)
+		if v.sessionOnly && !inside {
+			continue
+		}
+		var problem string
+		switch {
+		case !set || value == "":
+			problem = Tf("%s is not set: %s", v.name, T(v.hint))
+		case v.name == "XDG_RUNTIME_DIR" && runtimeDirProblem(value) != "":
+			problem = runtimeDirProblem(value)
+		case v.name == "XDG_RUNTIME_DIR" && value == fmt.Sprintf("/var/run/user/%d", userID()):
+			// Set up by pam_xdg instead, which is fine too.
+		case v.expect != nil && value != v.expect():
+			problem = Tf("%s is %s instead of %s: %s", v.name, value, v.expect(), T(v.hint))
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "%-25s %s\n", T("Seat:"), seatStatus())
+	for _, service := range []string{"dbus", "seatd"} {
+		status := serviceStatus(service)
+		fmt.Fprintf(&b, "%-25s %s\n", service, status)
+		if !strings.Contains(status, "is running") {
+			problems = append(problems, Tf("%s is not running: run Setup System", service))
+		}
+	}
+
+	fmt.Fprintln(&b)
+	if len(problems) == 0 {
+		fmt.Fprint(&b, T("Everything matches what NiriSetup set up."))
+	} else {
+		fmt.Fprintln(&b, T("Different from what NiriSetup set up:"))
+		for _, p := range problems {
+			fmt.Fprintf(&b, "• %s\n", p)
+		}
+	}
+	return b.String(), problems
+}
+
+// runSessionInfo prints the session environment for the command line.
+func runSessionInfo() (string, error) {
+	text, problems := describeSession()
+	if len(problems) > 0 {
+		return strings.TrimRight(text, "\n"), errors.New("session differs from the setup")
+	}
+	return text, nil
+}
+
+// sessionInfo shows the session environment.
+func sessionInfo() tea.Cmd {
+	return func() tea.Msg {
+		text, _ := describeSession()
+		return reportMsg{&report{title: "Session Info", body: text, refresh: sessionInfo()}}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ansiEscape matches the color codes niri writes when its log goes to a
+// terminal.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// niriLogError matches the log lines worth pointing at: errors, panics
+// and warnings about EGL or the seat.
+var niriLogError = regexp.MustCompile(`panicked at|\bERROR\b|\bWARN\b.*(?i:egl|seat)`)
+
+// niriLogContext is how many lines around the last error are shown.
+const niriLogContext = 5
+
+// niriLogPatterns are known niri failures and their fixes.
+var niriLogPatterns = []logPattern{
+	{
+		regexp.MustCompile(`panicked at`),
+		"niri panicked",
+		"This is a bug in niri. Report it with Report a Bug, attaching this log.",
+	},
+	{
+		regexp.MustCompile(`(?i)failed to create EGL display|error (creating|initializing) EGL|EGL_BAD|no EGL`),
+		"niri could not set up EGL on the GPU",
+		"Make sure mesa-dri and mesa-libs are installed and the GPU driver is loaded (see Kernel Modules and Doctor). With an NVIDIA GPU, nvidia-drm must be loaded with modeset enabled.",
+	},
+	{
+		regexp.MustCompile(`(?i)could not take seat|error opening seat|failed to (open|take) (the )?seat|libseat.*(error|fail)|no seat`),
+		"niri could not take the seat",
+		"Start niri from a text console (not from inside X), with LIBSEAT_BACKEND=consolekit2 set and dbus running, or run seatd (Setup System sets this up). Only one compositor can hold the seat at a time.",
+	},
+	{
+		regexp.MustCompile(`(?i)(permission denied|failed to open).*(/dev/dri|/dev/input)|no (usable )?(DRM|GPU) (devices|device)|primary node`),
+		"niri could not open the GPU or input devices",
+		"Add your user to the video group (Setup System does this), log out and back in, and check that the GPU driver is loaded.",
+	},
+	{
+		regexp.MustCompile(`XDG_RUNTIME_DIR`),
+		"XDG_RUNTIME_DIR is missing or unusable",
+		"Run Setup System, which sets XDG_RUNTIME_DIR in ~/.profile, then log in again.",
+	},
+	{
+		regexp.MustCompile(`(?i)error (loading|parsing) (the )?config|config.*(error|invalid)`),
+		"niri rejected its config",
+		"Check it with Validate Config and fix the reported lines, or restore config.kdl.bak.",
+	},
+	{
+		regexp.MustCompile(`(?i)xwayland-satellite.*(not found|failed|error)`),
+		"X11 applications can't start",
+		"Install xwayland-satellite (Install Niri includes it).",
+	},
+	{
+		regexp.MustCompile(`(?i)(address already in use|wayland socket).*(error|fail|in use)`),
+		"Another compositor is already running",
+		"Quit the other Wayland session before starting niri, see Desktop Conflicts.",
+	},
+}
+
+// niriLogDir is where the session launcher keeps niri's logs.
+func niriLogDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" && targetUser == nil {
+		return filepath.Join(dir, "niri"), nil
+	}
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "state", "niri"), nil
+}
+
+// latestNiriLog returns the most recently written log in niriLogDir.
+func latestNiriLog() (string, error) {
+	dir, err := niriLogDir()
+	if err != nil {
+		return "", err
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.log*"))
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no niri logs in %s", dir)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, _ := os.Stat(paths[i])
+		b, _ := os.Stat(paths[j])
+		return a != nil && b != nil && a.ModTime().After(b.ModTime())
+	})
+	return paths[0], nil
+}
+
+// analyzeNiriLog highlights the last error in a niri log and explains the
+// known failures in it.
+func analyzeNiriLog(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(ansiEscape.ReplaceAllString(string(data), ""), "\n"), "\n")
+
+	var b strings.Builder
+	fmt.Fprintln(&b, Tf("Log: %s (%d lines)", path, len(lines)))
+	fmt.Fprintln(&b)
+	last := -1
+	for i, line := range lines {
+		if niriLogError.MatchString(line) {
+			last = i
+		}
+	}
+	if last < 0 {
+		fmt.Fprintln(&b, T("No errors found."))
+	} else {
+		fmt.Fprintln(&b, Tf("Last error, at line %d:", last+1))
+		for i := max(0, last-niriLogContext); i <= min(len(lines)-1, last+niriLogContext); i++ {
+			marker := "  "
+			if i == last {
+				marker = "> "
+			}
+			fmt.Fprintf(&b, "%s%s\n", marker, lines[i])
+		}
+	}
+	findings := scanLog(lines, niriLogPatterns)
+	if len(findings) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, T("Known problems:"))
+		b.WriteString(describeFindings(findings))
+	}
+	if len(findings) > 0 || last >= 0 {
+		return b.String(), errors.New("errors found")
+	}
+	return b.String(), nil
+}
+
+// runSessionLogAnalysis analyzes path, or the latest niri log if path is
+// empty.
+func runSessionLogAnalysis(path string) (string, error) {
+	if path == "" {
+		var err error
+		if path, err = latestNiriLog(); err != nil {
+			return Tf("Failed to find a niri log: %v", err) + "\n\n" +
+				Tf("niri logs to its standard error. Start it from a TTY with %s, which Setup System writes, to save its log there.", "~/.local/bin/"+sessionLauncherName), preflightFailure(err)
+		}
+	}
+	status, err := analyzeNiriLog(expandHome(path))
+	if status == "" {
+		return Tf("Failed to read %s: %v", path, err), preflightFailure(err)
+	}
+	return status, err
+}
+
+// sessionLogView shows the analysis of the latest niri log.
+func sessionLogView() tea.Cmd {
+	return func() tea.Msg {
+		body, _ := runSessionLogAnalysis("")
+		return reportMsg{&report{title: "Session Log", body: body, refresh: sessionLogView()}}
+	}
+}
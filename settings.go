@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// settingsPath is the NiriSetup settings file, a list of "key = value" lines.
+func settingsPath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "nirisetup", "nirisetup.conf"), nil
+}
+
+// loadSettings reads the settings file. A missing or unreadable file yields
+// no settings; lines starting with # are comments.
+func loadSettings() map[string]string {
+	settings := map[string]string{}
+	path, err := settingsPath()
+	if err != nil {
+		return settings
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return settings
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		settings[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return settings
+}
+
+// saveSettings sets keys in the settings file, replacing their existing
+// lines and keeping the other settings and comments.
+func saveSettings(values map[string]string) error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	data, _ := os.ReadFile(path)
+	var lines []string
+	written := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		key, _, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if value, set := values[key]; ok && set && !strings.HasPrefix(key, "#") {
+			if written[key] {
+				continue
+			}
+			line = key + " = " + value
+			written[key] = true
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !written[key] {
+			lines = append(lines, key+" = "+values[key])
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
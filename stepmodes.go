@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Modes of a step on the Setup System steps screen.
+const (
+	stepRun   = "Run"   // run it unless it is already done
+	stepSkip  = "Skip"  // leave it alone
+	stepForce = "Force" // run it even if it looks done
+)
+
+// stepLabels are short names for the steps of Setup System, which the
+// steps screen has little room for.
+var stepLabels = map[string]string{
+	"Enable and start dbus":                     "dbus service",
+	"Enable and start seatd":                    "seatd service",
+	"Add you to the video group":                "Video group",
+	"Load the DRM kernel module":                "DRM module",
+	"Prepare the login environment in .profile": ".profile",
+	"Set the session environment in config.kdl": "config.kdl environment",
+	"Check the DRM render device":               "Render device check",
+	"Look for other desktops":                   "Other desktops",
+}
+
+func stepLabel(name string) string {
+	if label, ok := stepLabels[name]; ok {
+		return label
+	}
+	return name
+}
+
+// stepNamed returns the Setup System step called name, by its full or its
+// short name in any case.
+func stepNamed(name string) (string, bool) {
+	for _, s := range setupSystemSteps() {
+		if strings.EqualFold(name, s.name) || strings.EqualFold(name, stepLabel(s.name)) {
+			return s.name, true
+		}
+	}
+	return "", false
+}
+
+// setupStepNames lists the short names of the Setup System steps.
+func setupStepNames() []string {
+	var names []string
+	for _, s := range setupSystemSteps() {
+		names = append(names, stepLabel(s.name))
+	}
+	return names
+}
+
+// applyStepModes marks steps as skipped or forced as modes says.
+func applyStepModes(steps []setupStep, modes map[string]string) []setupStep {
+	for i := range steps {
+		switch modes[steps[i].name] {
+		case stepSkip:
+			steps[i].skip = true
+		case stepForce:
+			steps[i].force = true
+		}
+	}
+	return steps
+}
+
+// savedStepModes returns the steps of Setup System that setup_skip in the
+// settings, a comma-separated list of step names, skips.
+func savedStepModes() map[string]string {
+	modes := map[string]string{}
+	for _, name := range strings.Split(loadSettings()["setup_skip"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			modes[name] = stepSkip
+		}
+	}
+	return modes
+}
+
+// newSetupStepsForm lets the user skip or force each step of Setup System
+// before it runs, then shows its pending changes again with those choices.
+// Skipped steps are saved and stay skipped in later runs; forcing a step
+// only applies to this run.
+func newSetupStepsForm() *form {
+	saved := savedStepModes()
+	steps := setupSystemSteps()
+	f := &form{title: "Setup System Steps"}
+	for _, s := range steps {
+		field := formField{
+			label:   stepLabel(s.name),
+			value:   stepRun,
+			options: []string{stepRun, stepSkip},
+			help:    "Run: run it. Skip: don't touch it, now or in later runs.",
+		}
+		if s.satisfied != nil {
+			field.options = append(field.options, stepForce)
+			field.help = "Run: run it unless it is already done. Skip: don't touch it, now or in later runs. Force: run it even though it looks done."
+		}
+		if saved[s.name] == stepSkip {
+			field.value = stepSkip
+		}
+		f.fields = append(f.fields, field)
+	}
+	f.submit = func(values []string) (tea.Cmd, error) {
+		modes := map[string]string{}
+		var skips []string
+		for i, s := range steps {
+			modes[s.name] = values[i]
+			if values[i] == stepSkip {
+				skips = append(skips, s.name)
+			}
+		}
+		if err := saveSettings(map[string]string{"setup_skip": strings.Join(skips, ", ")}); err != nil {
+			return nil, err
+		}
+		return previewSetupSystem(modes), nil
+	}
+	return f
+}
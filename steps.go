@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// setupStep is one named step of Install Niri or Setup System. run returns
+// the lines describing what it did; an error marks the step failed.
+//
+// needs names the steps that must succeed before this one starts. Steps
+// with the same lock never run at the same time, e.g. those that edit
+// /etc/rc.conf; the others run concurrently. If satisfied reports that the
+// step's work is already done, it is skipped, unless force is set. A step
+// with skip set doesn't run at all, and the steps needing it run anyway.
+type setupStep struct {
+	name      string
+	needs     []string
+	lock      string
+	satisfied func() bool
+	run       func() ([]string, error)
+	skip      bool
+	force     bool
+}
+
+// stepResult is what a step left behind when it finished.
+type stepResult struct {
+	index int
+	lines []string
+	err   error
+}
+
+// runSteps runs steps as their prerequisites allow, showing their progress
+// as a checklist titled title when the full-screen interface runs, or as a
+// line per finished step otherwise. A step whose prerequisite failed
+// doesn't run and says which one it was. It returns the log lines of all
+// steps, in the order of steps, and the names of those that failed or
+// couldn't run.
+func runSteps(title string, steps []setupStep) ([]string, []string) {
+	items := make([]checkItem, len(steps))
+	index := map[string]int{}
+	for i, s := range steps {
+		items[i] = checkItem{name: s.name}
+		index[s.name] = i
+	}
+	show := func() {
+		if program != nil {
+			program.Send(checklistMsg{title: title, items: append([]checkItem(nil), items...)})
+		}
+	}
+	done := 0
+	finish := func(i int, lines []string, err error) {
+		items[i].detail = lines
+		items[i].status = checkDone
+		if err != nil {
+			items[i].status = checkFailed
+		}
+		done++
+		if program == nil {
+			// Without the checklist, say how the run progresses one
+			// line at a time, as plain mode and the subcommands do.
+			status := T("done")
+			if err != nil {
+				status = T("failed")
+			} else if steps[i].skip {
+				status = T("skipped")
+			}
+			fmt.Printf("[%d/%d] %s: %s\n", done, len(steps), T(items[i].name), status)
+		}
+	}
+
+	results := make(chan stepResult)
+	locks := map[string]bool{}
+	running := 0
+	for {
+		// Settle the steps that can't run, then start those that can.
+		for i, s := range steps {
+			if items[i].status != checkPending {
+				continue
+			}
+			if s.skip {
+				finish(i, []string{T("Skipped, as you chose")}, nil)
+				continue
+			}
+			ready, blocked := true, ""
+			for _, need := range s.needs {
+				j, ok := index[need]
+				if !ok || items[j].status == checkFailed {
+					blocked = need
+					break
+				}
+				if items[j].status != checkDone {
+					ready = false
+				}
+			}
+			if _, ok := index[blocked]; blocked != "" && !ok {
+				finish(i, []string{Tf("Not run: there is no step %q", blocked)}, fmt.Errorf("unknown step %q", blocked))
+				continue
+			}
+			if blocked != "" {
+				finish(i, []string{Tf("Not run: it needs \"%s\", which failed", T(blocked))}, fmt.Errorf("%s failed", blocked))
+				continue
+			}
+			if !ready || (s.lock != "" && locks[s.lock]) {
+				continue
+			}
+			if s.satisfied != nil && !s.force && recordOut == nil && s.satisfied() {
+				finish(i, []string{T("Already done")}, nil)
+				continue
+			}
+			items[i].status = checkRunning
+			if s.lock != "" {
+				locks[s.lock] = true
+			}
+			running++
+			go func(i int, s setupStep) {
+				lines, err := s.run()
+				results <- stepResult{i, lines, err}
+			}(i, s)
+		}
+		show()
+		if running == 0 {
+			break
+		}
+		r := <-results
+		running--
+		finish(r.index, r.lines, r.err)
+		if lock := steps[r.index].lock; lock != "" {
+			locks[lock] = false
+		}
+	}
+
+	var logs, failed []string
+	for i, item := range items {
+		if item.status == checkPending {
+			// Only a cycle in needs leaves a step waiting.
+			finish(i, []string{T("Not run: its prerequisites never finished")}, fmt.Errorf("%s never ran", item.name))
+		}
+		logs = append(logs, items[i].detail...)
+		if items[i].status == checkFailed {
+			failed = append(failed, item.name)
+		}
+	}
+	return logs, failed
+}
+
+// serviceRunning reports whether the rc.d service name is enabled and
+// running.
+func serviceRunning(name string) bool {
+	out, _ := commandOutput(exec.Command("sysrc", "-n", name+"_enable"))
+	if !strings.EqualFold(strings.TrimSpace(string(out)), "YES") {
+		return false
+	}
+	return runCommand(exec.Command("service", name, "status")) == nil
+}
+
+// inGroup reports whether user is a member of group.
+func inGroup(user, group string) bool {
+	out, err := commandOutput(exec.Command("id", "-Gn", user))
+	return err == nil && containsString(strings.Fields(string(out)), group)
+}
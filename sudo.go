@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sudoKeepalive is how often NiriSetup refreshes the sudo timestamp, well
+// within sudo's default timeout of five minutes.
+const sudoKeepalive = time.Minute
+
+// The sudo session: the first command run through sudo asks for the
+// password once, and a keepalive refreshes the timestamp from then on, so
+// that later commands never stop to ask for it again. Commands waiting on
+// a password prompt nobody sees would otherwise look like they hang.
+var (
+	sudoMu    sync.Mutex
+	sudoReady bool
+)
+
+// sudoOptionArgs are the sudo options that take a value.
+var sudoOptionArgs = []string{"-C", "-D", "-g", "-h", "-p", "-R", "-r", "-T", "-t", "-U", "-u"}
+
+// sudoNonInteractive reports whether args, what follows sudo on a command
+// line, hold sudo's own -n. The -n of the command sudo runs, as in
+// kldload -n, doesn't count.
+func sudoNonInteractive(args []string) bool {
+	for i := 0; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		switch {
+		case args[i] == "--":
+			return false
+		case args[i] == "-n" || args[i] == "--non-interactive":
+			return true
+		case slices.Contains(sudoOptionArgs, args[i]):
+			i++
+		}
+	}
+	return false
+}
+
+// ensureSudo makes sure cmd, if it runs through sudo, won't stop to ask
+// for a password. It asks for it once on the terminal if needed and fails
+// when there is no terminal to ask on. Commands run with sudo -n handle a
+// missing password themselves.
+func ensureSudo(cmd *exec.Cmd) error {
+	if len(cmd.Args) == 0 || cmd.Args[0] != "sudo" || sudoNonInteractive(cmd.Args[1:]) {
+		return nil
+	}
+	sudoMu.Lock()
+	defer sudoMu.Unlock()
+	if sudoReady || os.Geteuid() == 0 || !hasCommand("sudo") {
+		return nil
+	}
+
+	if exec.Command("sudo", "-n", "-v").Run() != nil {
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return errors.New(T("sudo needs a password, but there is no terminal to ask for it on; run sudo -v first"))
+		}
+		if program != nil {
+			// Hand the terminal to sudo for the prompt.
+			program.ReleaseTerminal()
+			defer program.RestoreTerminal()
+		}
+		fmt.Fprintln(os.Stderr, T("NiriSetup needs administrator rights for the next steps. sudo asks for your password once, for the whole run."))
+		auth := exec.Command("sudo", "-v", "-p", "[NiriSetup] "+T("password for %u: "))
+		auth.Stdin, auth.Stdout, auth.Stderr = os.Stdin, os.Stdout, os.Stderr
+		start := traceStart(auth)
+		err := auth.Run()
+		traceEnd(auth, start, err)
+		if err != nil {
+			return fmt.Errorf("%s: %v", T("sudo authentication failed"), err)
+		}
+	}
+
+	sudoReady = true
+	go func() {
+		for range time.Tick(sudoKeepalive) {
+			if err := exec.Command("sudo", "-n", "-v").Run(); err != nil {
+				tracef("refreshing the sudo timestamp failed: %v", err)
+				sudoMu.Lock()
+				sudoReady = false
+				sudoMu.Unlock()
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// supervisorName is the wrapper script that restarts session components.
+const supervisorName = "nirisetup-supervise"
+
+// supervisorScript runs its arguments and starts them again when they
+// exit, until niri is gone. It gives up on a program that keeps exiting
+// right away, so a broken config doesn't turn into a restart loop.
+const supervisorScript = `#!/bin/sh
+# Generated by NiriSetup: run a session component and restart it when it exits.
+name=$(basename "$1")
+log="${XDG_STATE_HOME:-$HOME/.local/state}/nirisetup/$name.log"
+mkdir -p "$(dirname "$log")"
+quick=0
+while :; do
+	start=$(date +%s)
+	"$@" >>"$log" 2>&1
+	status=$?
+	# Stop with the session.
+	niri msg version >/dev/null 2>&1 || exit 0
+	if [ $(($(date +%s) - start)) -lt 60 ]; then
+		quick=$((quick + 1))
+	else
+		quick=0
+	fi
+	if [ "$quick" -ge 5 ]; then
+		echo "$(date): $name exited 5 times within a minute each, giving up" >>"$log"
+		notify-send -u critical "$name keeps exiting" "Gave up restarting it, see $log" 2>/dev/null
+		exit 1
+	fi
+	echo "$(date): $name exited with status $status, restarting" >>"$log"
+	sleep 2
+done
+`
+
+// supervisedComponents are the session components offered for supervision.
+var supervisedComponents = []string{"waybar", "mako", "swayidle"}
+
+// isSupervisor reports whether program is the supervisor script.
+func isSupervisor(program string) bool {
+	return filepath.Base(program) == supervisorName
+}
+
+// superviseSpawn puts the spawn-at-startup line of program under the
+// supervisor at path, or takes it out again. It reports whether there was
+// a line for program.
+func superviseSpawn(cfg, path, program string, on bool) (string, bool) {
+	lines := strings.Split(cfg, "\n")
+	found := false
+	for i, line := range lines {
+		if spawnProgram(line) != program {
+			continue
+		}
+		found = true
+		args := spawnArgs(line)
+		if isSupervisor(args[0]) {
+			args = args[1:]
+		}
+		if on {
+			args = append([]string{path}, args...)
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		lines[i] = indent + "spawn-at-startup " + kdlArgs(args...)
+	}
+	return strings.Join(lines, "\n"), found
+}
+
+func newSupervisorForm() *form {
+	cfg := ""
+	if path, err := niriConfigPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			cfg = string(data)
+		}
+	}
+	var fields []formField
+	for _, program := range supervisedComponents {
+		value := "Off"
+		for _, line := range strings.Split(cfg, "\n") {
+			if args := spawnArgs(line); spawnProgram(line) == program && isSupervisor(args[0]) {
+				value = "On"
+			}
+		}
+		fields = append(fields, formField{label: program, value: value, options: []string{"On", "Off"}})
+	}
+	return &form{
+		title:  "Supervision",
+		fields: fields,
+		submit: func(values []string) (tea.Cmd, error) {
+			return setupSupervision(values), nil
+		},
+	}
+}
+
+// setupSupervision writes the supervisor script and runs the chosen
+// components through it.
+func setupSupervision(values []string) tea.Cmd {
+	return func() tea.Msg {
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		script := filepath.Join(homeDir, ".local", "bin", supervisorName)
+		var logs []string
+		if err := os.MkdirAll(filepath.Dir(script), 0755); err != nil {
+			return statusMsg{status: Tf("Failed to create %s: %v", filepath.Dir(script), err), err: err}
+		}
+		if err := writeFile(script, []byte(supervisorScript), 0755); err != nil {
+			return statusMsg{status: Tf("Failed to write %s: %v", script, err), err: err}
+		}
+		logs = append(logs, Tf("Wrote %s: OK", script))
+
+		path, err := updateNiriConfig(func(cfg string) string {
+			for i, program := range supervisedComponents {
+				var found bool
+				cfg, found = superviseSpawn(cfg, script, program, values[i] == "On")
+				switch {
+				case !found:
+					logs = append(logs, Tf("%s isn't started by niri, skipping", program))
+				case values[i] == "On":
+					logs = append(logs, Tf("%s is restarted when it exits", program))
+				default:
+					logs = append(logs, Tf("%s runs without supervision", program))
+				}
+			}
+			return cfg
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Updated %s: OK", path))
+		logs = append(logs, T("The change takes effect the next time niri starts."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
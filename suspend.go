@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// suspendCommand suspends to RAM through the sudoers rule Laptop Power
+// writes, so the video group needs no password.
+var suspendCommand = []string{"sudo", "-n", "/usr/sbin/zzz"}
+
+// suspendBindAction locks the screen before suspending. swaylock -f
+// returns once the lock is in place.
+var suspendBindAction = []string{"sh", "-c", "swaylock -f && " + strings.Join(suspendCommand, " ")}
+
+// sessionSnapshot is what a suspend test compares before and after.
+type sessionSnapshot struct {
+	renderNode string
+	niri       bool
+	outputs    []string
+}
+
+func takeSessionSnapshot() sessionSnapshot {
+	s := sessionSnapshot{renderNode: findRenderDevice(), niri: niriRunning()}
+	if s.niri {
+		if outputs, err := niriOutputs(); err == nil {
+			for _, o := range outputs {
+				s.outputs = append(s.outputs, o.Name)
+			}
+		} else {
+			s.niri = false
+		}
+	}
+	return s
+}
+
+// waitForResume returns once the wall clock jumps ahead, which happens
+// when the machine wakes; zzz itself returns before the suspend. It gives
+// up after timeout.
+func waitForResume(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	last := time.Now().Round(0)
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+		now := time.Now().Round(0)
+		if now.Sub(last) > 5*time.Second {
+			return true
+		}
+		last = now
+	}
+	return false
+}
+
+// runSuspendTest suspends the machine and, after it resumes, checks that
+// the DRM render node, the niri session and its outputs came back.
+func runSuspendTest() (string, error) {
+	before := takeSessionSnapshot()
+	if !before.niri {
+		return T("No running niri session to check after resuming"), preflightFailure(errors.New("niri is not running"))
+	}
+	if out, err := commandCombinedOutput(exec.Command(suspendCommand[0], suspendCommand[1:]...)); err != nil {
+		return Tf("Suspend failed: %s", strings.TrimSpace(string(out))) + "\n" + T("Let the video group suspend without a password with Laptop Power."), err
+	}
+	if !waitForResume(2 * time.Minute) {
+		return T("The machine didn't suspend within two minutes"), errors.New("no suspend")
+	}
+	// Give the GPU driver and niri a moment to bring the outputs back.
+	time.Sleep(3 * time.Second)
+	after := takeSessionSnapshot()
+
+	var logs []string
+	failed := 0
+	check := func(ok bool, good, bad string) {
+		if ok {
+			logs = append(logs, good)
+		} else {
+			logs = append(logs, bad)
+			failed++
+		}
+	}
+	node := after.renderNode
+	if node != "" {
+		if f, err := os.OpenFile(node, os.O_RDWR, 0); err == nil {
+			f.Close()
+		} else {
+			node = ""
+		}
+	}
+	check(node != "" && node == before.renderNode,
+		Tf("DRM render node %s: OK", before.renderNode),
+		Tf("DRM render node %s can't be opened after resuming; check dmesg for GPU driver errors", before.renderNode))
+	check(after.niri, T("niri session: OK"), T("niri doesn't answer over IPC after resuming; check Session Log"))
+	var missing []string
+	for _, o := range before.outputs {
+		if !containsString(after.outputs, o) {
+			missing = append(missing, o)
+		}
+	}
+	check(after.niri && len(missing) == 0,
+		Tf("Outputs %s: OK", strings.Join(before.outputs, ", ")),
+		Tf("Outputs missing after resuming: %s", strings.Join(missing, ", ")))
+	if failed > 0 {
+		return strings.Join(logs, "\n"), fmt.Errorf("%d checks failed after resuming", failed)
+	}
+	logs = append(logs, T("Suspend and resume work."))
+	return strings.Join(logs, "\n"), nil
+}
+
+func testSuspendCmd() tea.Cmd {
+	return func() tea.Msg {
+		status, err := runSuspendTest()
+		return statusMsg{status: status, err: err}
+	}
+}
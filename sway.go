@@ -0,0 +1,492 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// swayModifiers maps sway and i3 modifier names to niri's.
+var swayModifiers = map[string]string{
+	"mod4": "Mod", "super": "Mod", "mod1": "Alt", "alt": "Alt",
+	"shift": "Shift", "control": "Ctrl", "ctrl": "Ctrl", "mod5": "ISO_Level3_Shift",
+}
+
+var (
+	swayWorkspace     = regexp.MustCompile(`^workspace\s+(number\s+)?(\d+)$`)
+	swayMoveWorkspace = regexp.MustCompile(`^move\s+(container\s+|window\s+)?(to\s+)?workspace\s+(number\s+)?(\d+)$`)
+	swayResize        = regexp.MustCompile(`^resize\s+(grow|shrink)\s+(width|height)\s+(\d+)\s*(px|ppt)?`)
+	swayMode          = regexp.MustCompile(`^(\d+)x(\d+)(@([\d.]+)\s*(Hz)?)?$`)
+)
+
+// swayActions maps sway commands that take no arguments to niri actions.
+var swayActions = map[string]string{
+	"kill":                           "close-window",
+	"fullscreen":                     "fullscreen-window",
+	"fullscreen toggle":              "fullscreen-window",
+	"floating toggle":                "toggle-window-floating",
+	"focus mode_toggle":              "switch-focus-between-floating-and-tiling",
+	"focus left":                     "focus-column-left",
+	"focus right":                    "focus-column-right",
+	"focus up":                       "focus-window-up",
+	"focus down":                     "focus-window-down",
+	"move left":                      "move-column-left",
+	"move right":                     "move-column-right",
+	"move up":                        "move-window-up",
+	"move down":                      "move-window-down",
+	"focus output left":              "focus-monitor-left",
+	"focus output right":             "focus-monitor-right",
+	"focus output up":                "focus-monitor-up",
+	"focus output down":              "focus-monitor-down",
+	"move workspace to output left":  "move-workspace-to-monitor-left",
+	"move workspace to output right": "move-workspace-to-monitor-right",
+	"move workspace to output up":    "move-workspace-to-monitor-up",
+	"move workspace to output down":  "move-workspace-to-monitor-down",
+	"workspace next":                 "focus-workspace-down",
+	"workspace prev":                 "focus-workspace-up",
+	"workspace back_and_forth":       "focus-workspace-previous",
+	"exit":                           "quit",
+	"exec swaymsg exit":              "quit",
+	"exec i3-msg exit":               "quit",
+}
+
+// swayUnsupported explains why common commands have no translation.
+var swayUnsupported = map[string]string{
+	"reload":     "niri reloads its config by itself",
+	"restart":    "niri reloads its config by itself",
+	"splith":     "niri arranges windows in columns, there are no splits",
+	"splitv":     "niri arranges windows in columns, there are no splits",
+	"split":      "niri arranges windows in columns, there are no splits",
+	"layout":     "niri arranges windows in columns, there are no layouts",
+	"scratchpad": "niri has no scratchpad",
+	"mode":       "niri has no binding modes",
+	"sticky":     "niri has no sticky windows",
+}
+
+// swayInputs maps sway input settings to the niri input block and node.
+// A node ending in = takes the value; flags are set when enabled.
+var swayInputs = map[string]struct {
+	device, node string
+}{
+	"xkb_layout":       {"keyboard xkb", "layout="},
+	"xkb_variant":      {"keyboard xkb", "variant="},
+	"xkb_options":      {"keyboard xkb", "options="},
+	"xkb_model":        {"keyboard xkb", "model="},
+	"repeat_delay":     {"keyboard", "repeat-delay"},
+	"repeat_rate":      {"keyboard", "repeat-rate"},
+	"tap":              {"", "tap"},
+	"natural_scroll":   {"", "natural-scroll"},
+	"dwt":              {"", "dwt"},
+	"left_handed":      {"", "left-handed"},
+	"middle_emulation": {"", "middle-emulation"},
+	"accel_profile":    {"", "accel-profile="},
+	"pointer_accel":    {"", "accel-speed"},
+	"scroll_method":    {"", "scroll-method="},
+}
+
+// parseSwayConfig translates a sway config.
+func parseSwayConfig(path, data string) *migration {
+	return parseI3Style(path, data)
+}
+
+// swayLines joins continued lines, flattens blocks such as `input * { ... }`
+// into single lines with the block's header in front, and returns each
+// line with its line number. Comments and blank lines are dropped.
+func swayLines(data string) ([]string, []int) {
+	var lines []string
+	var numbers []int
+	var prefix []string
+	var skipDepth int
+	pending, start := "", 0
+	for i, raw := range strings.Split(data, "\n") {
+		line := strings.TrimSpace(raw)
+		if pending == "" {
+			start = i + 1
+		}
+		if strings.HasSuffix(line, `\`) {
+			pending += strings.TrimSuffix(line, `\`) + " "
+			continue
+		}
+		line = strings.TrimSpace(pending + line)
+		pending = ""
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if skipDepth > 0 {
+			skipDepth += strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+		if line == "}" {
+			if len(prefix) > 0 {
+				prefix = prefix[:len(prefix)-1]
+			}
+			continue
+		}
+		if header, ok := strings.CutSuffix(line, "{"); ok {
+			header = strings.TrimSpace(header)
+			name, _, _ := strings.Cut(header, " ")
+			if name == "mode" || name == "bar" {
+				// Kept whole, so the importer can say why it's skipped.
+				lines = append(lines, header)
+				numbers = append(numbers, start)
+				skipDepth = 1
+				continue
+			}
+			prefix = append(prefix, header)
+			continue
+		}
+		if len(prefix) > 0 {
+			line = strings.Join(prefix, " ") + " " + line
+		}
+		lines = append(lines, line)
+		numbers = append(numbers, start)
+	}
+	return lines, numbers
+}
+
+// parseI3Style translates a config in the syntax shared by sway and i3.
+func parseI3Style(path, data string) *migration {
+	mg := &migration{from: path}
+	vars := map[string]string{}
+	lines, numbers := swayLines(data)
+	for i, line := range lines {
+		n := numbers[i]
+		fields := strings.Fields(line)
+		if fields[0] == "set" && len(fields) >= 3 && strings.HasPrefix(fields[1], "$") {
+			vars[fields[1]] = expandSwayVars(strings.Join(fields[2:], " "), vars)
+			continue
+		}
+		line = expandSwayVars(line, vars)
+		if fields = strings.Fields(line); len(fields) == 0 {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+
+		switch fields[0] {
+		case "bindsym":
+			translateSwayBind(mg, n, line, rest)
+		case "bindcode":
+			mg.skip(n, line, "key codes can't be translated, use bindsym")
+		case "exec", "exec_always":
+			translateExec(mg, n, line, rest)
+		case "output":
+			translateSwayOutput(mg, n, line, fields[1:])
+		case "input":
+			translateSwayInput(mg, n, line, fields[1:])
+		case "gaps":
+			if len(fields) == 3 && (fields[1] == "inner" || fields[1] == "horizontal") {
+				mg.add(n, []string{"layout"}, "gaps "+fields[2])
+			} else {
+				mg.skip(n, line, "niri has a single gap size, set with gaps inner")
+			}
+		case "focus_follows_mouse":
+			if len(fields) == 2 && fields[1] == "yes" {
+				mg.add(n, []string{"input"}, "focus-follows-mouse")
+			}
+		case "mode":
+			mg.skip(n, line, "niri has no binding modes")
+		case "bar":
+			mg.skip(n, line, "use waybar, which NiriSetup sets up, instead")
+		case "for_window", "assign":
+			mg.skip(n, line, "window rules aren't translated, see window-rule in the niri wiki")
+		default:
+			mg.skip(n, line, "no niri equivalent")
+		}
+	}
+	return mg
+}
+
+// expandSwayVars replaces $variables, longest names first so that $mod
+// doesn't eat the start of $modifier.
+func expandSwayVars(s string, vars map[string]string) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		s = strings.ReplaceAll(s, name, vars[name])
+	}
+	return s
+}
+
+// stripExecFlags drops the i3 exec flags that have no meaning in niri,
+// and the quotes around a command given as a single string.
+func stripExecFlags(command string) string {
+	command = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "--no-startup-id"))
+	if len(command) > 1 && command[0] == '"' && command[len(command)-1] == '"' {
+		command = command[1 : len(command)-1]
+	}
+	return command
+}
+
+// swayKey converts a sway key combination such as Mod4+Shift+q.
+func swayKey(combo string) string {
+	parts := strings.Split(combo, "+")
+	for i, p := range parts[:len(parts)-1] {
+		if mod, ok := swayModifiers[strings.ToLower(p)]; ok {
+			parts[i] = mod
+		}
+	}
+	last := parts[len(parts)-1]
+	if len(last) == 1 {
+		parts[len(parts)-1] = strings.ToUpper(last)
+	}
+	return strings.Join(parts, "+")
+}
+
+func translateSwayBind(mg *migration, n int, line, rest string) {
+	fields := strings.Fields(rest)
+	locked := false
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		if fields[0] == "--locked" {
+			locked = true
+		}
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		mg.skip(n, line, "incomplete binding")
+		return
+	}
+	key := swayKey(fields[0])
+	command := strings.Join(fields[1:], " ")
+	if strings.ContainsAny(command, ";,") && !strings.HasPrefix(command, "exec") {
+		mg.skip(n, line, "a niri binding runs a single action")
+		return
+	}
+	action, reason := swayAction(command)
+	if action == "" {
+		mg.skip(n, line, reason)
+		return
+	}
+	props := ""
+	if locked && strings.HasPrefix(action, "spawn ") {
+		props = "allow-when-locked=true"
+	}
+	mg.bind(n, key, props, action)
+}
+
+// swayAction translates a sway command into a niri action, or returns why
+// it can't be.
+func swayAction(command string) (string, string) {
+	command = strings.Join(strings.Fields(command), " ")
+	if action, ok := swayActions[command]; ok {
+		return action, ""
+	}
+	if exec, ok := strings.CutPrefix(command, "exec "); ok {
+		return bindExec(exec), ""
+	}
+	if m := swayWorkspace.FindStringSubmatch(command); m != nil {
+		return "focus-workspace " + m[2], ""
+	}
+	if m := swayMoveWorkspace.FindStringSubmatch(command); m != nil {
+		return "move-column-to-workspace " + m[4], ""
+	}
+	if m := swayResize.FindStringSubmatch(command); m != nil {
+		sign := "+"
+		if m[1] == "shrink" {
+			sign = "-"
+		}
+		amount := m[3]
+		if m[4] == "ppt" {
+			amount += "%"
+		}
+		if m[2] == "width" {
+			return "set-column-width " + kdlQuote(sign+amount), ""
+		}
+		return "set-window-height " + kdlQuote(sign+amount), ""
+	}
+	first, _, _ := strings.Cut(command, " ")
+	if reason, ok := swayUnsupported[first]; ok {
+		return "", reason
+	}
+	if strings.HasPrefix(command, "move scratchpad") {
+		return "", swayUnsupported["scratchpad"]
+	}
+	return "", "no niri equivalent"
+}
+
+// swayTransforms maps sway output transforms to niri's.
+var swayTransforms = map[string]string{
+	"normal": "normal", "90": "90", "180": "180", "270": "270",
+	"flipped": "flipped", "flipped-90": "flipped-90", "flipped-180": "flipped-180", "flipped-270": "flipped-270",
+}
+
+// swayOutputArgs is how many values each output subcommand takes.
+var swayOutputArgs = map[string]int{
+	"mode": 1, "resolution": 1, "res": 1, "pos": 2, "position": 2, "scale": 1,
+	"transform": 1, "disable": 0, "enable": 0, "bg": 2, "background": 2, "adaptive_sync": 1,
+}
+
+// translateSwayOutput handles an output line, which may hold several
+// subcommands, e.g. `output HDMI-A-1 mode 1920x1080 pos 1920 0`.
+func translateSwayOutput(mg *migration, n int, line string, args []string) {
+	if len(args) < 2 {
+		mg.skip(n, line, "incomplete output setting")
+		return
+	}
+	name, args := args[0], args[1:]
+	for len(args) > 0 {
+		cmd := strings.ToLower(args[0])
+		count, ok := swayOutputArgs[cmd]
+		if !ok {
+			mg.skip(n, "output "+name+" "+strings.Join(args, " "), "no niri equivalent")
+			return
+		}
+		args = args[1:]
+		if cmd == "mode" || cmd == "resolution" || cmd == "res" {
+			if len(args) > 0 && args[0] == "--custom" {
+				args = args[1:]
+			}
+		}
+		if (cmd == "pos" || cmd == "position") && len(args) > 0 && strings.Contains(args[0], ",") {
+			// pos 1920,0
+			x, y, _ := strings.Cut(args[0], ",")
+			args = append([]string{x, y}, args[1:]...)
+		}
+		if (cmd == "bg" || cmd == "background") && len(args) == 1 {
+			count = 1
+		}
+		if len(args) < count {
+			mg.skip(n, line, "incomplete output setting")
+			return
+		}
+		values := args[:count]
+		args = args[count:]
+		if cmd == "transform" && len(args) > 0 && (args[0] == "clockwise" || args[0] == "anticlockwise") {
+			mg.skip(n, line, "relative transforms can't be translated")
+			args = args[1:]
+			continue
+		}
+		translateSwayOutputCommand(mg, n, line, name, cmd, values)
+	}
+}
+
+func translateSwayOutputCommand(mg *migration, n int, line, name, cmd string, values []string) {
+	if cmd == "bg" || cmd == "background" {
+		argv := []string{"swaybg"}
+		if name != "*" {
+			argv = append(argv, "-o", name)
+		}
+		switch {
+		case len(values) > 1 && values[1] == "solid_color":
+			argv = append(argv, "-c", values[0])
+		case len(values) > 1:
+			argv = append(argv, "-i", expandHome(values[0]), "-m", values[1])
+		default:
+			argv = append(argv, "-i", expandHome(values[0]))
+		}
+		mg.addOnce(n, nil, "spawn-at-startup "+kdlArgs(argv...))
+		return
+	}
+	if name == "*" {
+		mg.skip(n, line, "niri settings apply to one output at a time, repeat them per output")
+		return
+	}
+	block := []string{"output " + name}
+	switch cmd {
+	case "mode", "resolution", "res":
+		m := swayMode.FindStringSubmatch(values[0])
+		if m == nil {
+			mg.skip(n, line, "unrecognized mode")
+			return
+		}
+		mode := m[1] + "x" + m[2]
+		if m[4] != "" {
+			mode += "@" + m[4]
+		}
+		mg.add(n, block, "mode "+kdlQuote(mode))
+	case "pos", "position":
+		mg.add(n, block, fmt.Sprintf("position x=%s y=%s", values[0], values[1]))
+	case "scale":
+		mg.add(n, block, "scale "+values[0])
+	case "transform":
+		t, ok := swayTransforms[values[0]]
+		if !ok {
+			mg.skip(n, line, "unrecognized transform")
+			return
+		}
+		mg.add(n, block, "transform "+kdlQuote(t))
+	case "disable":
+		mg.add(n, block, "off")
+	case "enable":
+		mg.remove(n, block, "off")
+	case "adaptive_sync":
+		if values[0] == "on" || values[0] == "enable" {
+			mg.add(n, block, "variable-refresh-rate")
+		} else {
+			mg.remove(n, block, "variable-refresh-rate")
+		}
+	}
+}
+
+// swayInputDevice maps a sway input identifier to a niri input block.
+func swayInputDevice(id string) string {
+	lower := strings.ToLower(id)
+	switch {
+	case lower == "type:keyboard" || strings.Contains(lower, "keyboard"):
+		return "keyboard"
+	case lower == "type:touchpad" || strings.Contains(lower, "touchpad"):
+		return "touchpad"
+	case lower == "type:pointer" || strings.Contains(lower, "mouse"):
+		return "mouse"
+	case lower == "type:tablet_tool":
+		return "tablet"
+	case lower == "type:touch":
+		return "touch"
+	}
+	return ""
+}
+
+func translateSwayInput(mg *migration, n int, line string, args []string) {
+	if len(args) < 3 {
+		mg.skip(n, line, "incomplete input setting")
+		return
+	}
+	id, setting, value := args[0], args[1], strings.Join(args[2:], " ")
+	target, ok := swayInputs[setting]
+	if !ok {
+		mg.skip(n, line, "no niri equivalent")
+		return
+	}
+	var devices []string
+	switch {
+	case target.device != "":
+		if id != "*" && swayInputDevice(id) != "keyboard" {
+			mg.skip(n, line, "niri configures input per device type, not per device")
+			return
+		}
+		devices = []string{target.device}
+	case id == "*" && swayTouchpadOnly[setting]:
+		devices = []string{"touchpad"}
+	case id == "*":
+		devices = []string{"touchpad", "mouse"}
+	default:
+		device := swayInputDevice(id)
+		if device == "" || device == "keyboard" {
+			mg.skip(n, line, "niri configures input per device type, not per device")
+			return
+		}
+		devices = []string{device}
+	}
+
+	for _, device := range devices {
+		path := append([]string{"input"}, strings.Fields(device)...)
+		switch node := target.node; {
+		case strings.HasPrefix(setting, "xkb_"):
+			mg.add(n, path, strings.TrimSuffix(node, "=")+" "+kdlQuote(value))
+		case strings.HasSuffix(node, "="):
+			mg.add(n, path, strings.TrimSuffix(node, "=")+" "+kdlQuote(strings.ReplaceAll(value, "_", "-")))
+		case value == "enabled":
+			mg.add(n, path, node)
+		case value == "disabled":
+			mg.remove(n, path, node)
+		default:
+			mg.add(n, path, node+" "+value)
+		}
+	}
+}
+
+// swayTouchpadOnly are the input settings only touchpads have.
+var swayTouchpadOnly = map[string]bool{"tap": true, "dwt": true, "scroll_method": true}
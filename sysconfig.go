@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// systemConfigPath is where niri looks for a config when a user has none
+// of their own.
+const systemConfigPath = "/usr/local/etc/niri/config.kdl"
+
+// installSystemConfig installs the template as the system-wide default, so
+// every user without a config of their own gets a working session. An
+// existing, different default is kept as config.kdl.bak.
+func installSystemConfig() tea.Cmd {
+	return func() tea.Msg {
+		configStr, renderDev, err := niriConfigTemplate()
+		if err != nil {
+			return statusMsg{status: err.Error(), err: preflightFailure(err)}
+		}
+
+		if err := validateConfigContent("", systemConfigPath, configStr); err != nil {
+			return statusMsg{status: err.Error(), err: validationFailure(err)}
+		}
+
+		var logs []string
+		dir := filepath.Dir(systemConfigPath)
+		if out, err := commandCombinedOutput(exec.Command("sudo", "mkdir", "-p", dir)); err != nil {
+			return statusMsg{status: Tf("Failed to create %s: %v", dir, strings.TrimSpace(string(out))), err: preflightFailure(err)}
+		}
+		if old, err := os.ReadFile(systemConfigPath); err == nil && !bytes.Equal(old, []byte(configStr)) {
+			if out, err := commandCombinedOutput(exec.Command("sudo", "cp", "-p", systemConfigPath, systemConfigPath+".bak")); err != nil {
+				return statusMsg{status: Tf("Failed to back up %s: %s", systemConfigPath, strings.TrimSpace(string(out))), err: err}
+			}
+			logs = append(logs, Tf("Backed up the previous default to %s.bak", systemConfigPath))
+		}
+		if err := writeRootFile(systemConfigPath, configStr, 0644); err != nil {
+			return statusMsg{status: Tf("Failed to write %s: %v", systemConfigPath, err), err: err}
+		}
+		logs = append(logs, Tf("Installed the system-wide default config to %s", systemConfigPath))
+		if renderDev != "" {
+			logs = append(logs, Tf("DRM render device set to: %s", renderDev))
+		}
+
+		logs = append(logs, T("Users without their own ~/.config/niri/config.kdl now start with this config."))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tabletNamePattern matches the evdev names of graphics tablets and pens
+// when libinput can't tell us their capabilities.
+var tabletNamePattern = regexp.MustCompile(`(?i)\b(pen|stylus|tablet|digitizer|wacom|huion|xp-pen|gaomon)\b`)
+
+// tabletDevices returns the names of the connected tablet devices. libinput
+// list-devices knows which devices are tablets but needs access to
+// /dev/input; without it, the evdev device names under kern.evdev.input
+// are matched instead.
+func tabletDevices() []string {
+	var devices []string
+	if out, err := commandOutput(exec.Command("libinput", "list-devices")); err == nil {
+		name := ""
+		for _, line := range strings.Split(string(out), "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "Device":
+				name = value
+			case "Capabilities":
+				if strings.Contains(value, "tablet") && !containsString(devices, name) {
+					devices = append(devices, name)
+				}
+			}
+		}
+		return devices
+	}
+	out, err := commandOutput(exec.Command("sysctl", "kern.evdev.input"))
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if ok && strings.HasSuffix(key, ".name") && tabletNamePattern.MatchString(value) && !containsString(devices, value) {
+			devices = append(devices, value)
+		}
+	}
+	return devices
+}
+
+// newTabletForm prefills the tablet settings from the input block of the
+// user's config and offers the outputs of the running session to map the
+// tablet to.
+func newTabletForm() *form {
+	_, cfg, _ := readNiriConfig()
+	block := []string{"input", "tablet"}
+
+	mapTo := "All outputs"
+	if v, ok := kdlGet(cfg, block, "map-to-output"); ok && v != "" {
+		mapTo = kdlUnquote(v)
+	}
+	outputs := []string{"All outputs"}
+	if running, err := niriOutputs(); err == nil {
+		for _, o := range running {
+			outputs = append(outputs, o.Name)
+		}
+	}
+	if !containsString(outputs, mapTo) {
+		outputs = append(outputs, mapTo)
+	}
+	leftHanded := "Off"
+	if _, ok := kdlGet(cfg, block, "left-handed"); ok {
+		leftHanded = "On"
+	}
+	enabled := "On"
+	if _, ok := kdlGet(cfg, block, "off"); ok {
+		enabled = "Off"
+	}
+
+	detected := "No tablet detected; plug it in and reopen this screen, or add yourself to the video group so libinput can see it."
+	if devices := tabletDevices(); len(devices) > 0 {
+		detected = Tf("Detected: %s", strings.Join(devices, ", "))
+	}
+	return &form{
+		title: "Tablet",
+		fields: []formField{
+			{label: "Tablet input", value: enabled, options: []string{"On", "Off"}, help: detected},
+			{label: "Map to output", value: mapTo, options: outputs,
+				help: "Confines the tablet area to one monitor so the pen's aspect ratio matches the screen. All outputs spreads it over the whole desktop."},
+			{label: "Left-handed", value: leftHanded, options: []string{"Off", "On"},
+				help: "Turns the tablet upside down for holding the pen in the left hand."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			return writeTablet(values[0] == "On", values[1], values[2] == "On"), nil
+		},
+	}
+}
+
+// writeTablet updates the tablet section of the input block. niri has no
+// pressure curve setting; that is left to the drawing program.
+func writeTablet(enabled bool, mapTo string, leftHanded bool) tea.Cmd {
+	return func() tea.Msg {
+		block := []string{"input", "tablet"}
+		path, err := updateNiriConfig(func(cfg string) string {
+			if enabled {
+				cfg = removeKDLNode(cfg, block, "off")
+			} else {
+				cfg = setKDLNode(cfg, block, "off")
+			}
+			if mapTo == "All outputs" {
+				cfg = removeKDLNode(cfg, block, "map-to-output")
+			} else {
+				cfg = setKDLNode(cfg, block, "map-to-output "+kdlQuote(mapTo))
+			}
+			if leftHanded {
+				return setKDLNode(cfg, block, "left-handed")
+			}
+			return removeKDLNode(cfg, block, "left-handed")
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to update config: %v", err), err: err}
+		}
+		logs := []string{Tf("Updated the tablet settings in %s: OK", path)}
+		if enabled {
+			logs = append(logs, T("Set the pressure curve in your drawing program, e.g. Krita's Tablet settings; niri passes pressure through unchanged."))
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
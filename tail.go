@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// tailInterval is how often the log files are checked for new lines.
+	tailInterval = 500 * time.Millisecond
+	// tailKeep is how many lines are kept for scrolling back.
+	tailKeep = 2000
+	// tailBacklog is how much of each file is shown when watching starts.
+	tailBacklog = 4096
+)
+
+// tailColors tell the log sources apart.
+var tailColors = []lipgloss.Color{"#00afff", "#ffaf00", "#af87ff", "#00ff87", "#ff5f87", "#d7d700"}
+
+var tailErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5f5f"))
+
+// tailSource is one log file being watched.
+type tailSource struct {
+	name    string
+	path    string
+	offset  int64
+	partial string
+	style   lipgloss.Style
+}
+
+type tailLine struct {
+	source *tailSource
+	text   string
+}
+
+// logTail is the state of Watch Session Logs: the niri, waybar, mako and
+// other logs in the niri log directory, merged as their lines arrive.
+// While follow is set the view sticks to the newest line.
+type logTail struct {
+	dir     string
+	sources []*tailSource
+	lines   []tailLine
+	offset  int
+	follow  bool
+}
+
+// tailTickMsg asks the log tail to check for new lines.
+type tailTickMsg struct{}
+
+func tailTick() tea.Cmd {
+	return tea.Tick(tailInterval, func(time.Time) tea.Msg { return tailTickMsg{} })
+}
+
+func newLogTail() (*logTail, error) {
+	dir, err := niriLogDir()
+	if err != nil {
+		return nil, err
+	}
+	t := &logTail{dir: dir, follow: true}
+	t.poll()
+	return t, nil
+}
+
+// discover adds the log files that appeared since the last check. Files
+// found when watching starts show their last few lines; later ones are
+// read from the beginning.
+func (t *logTail) discover() {
+	paths, _ := filepath.Glob(filepath.Join(t.dir, "*.log"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		known := false
+		for _, s := range t.sources {
+			known = known || s.path == path
+		}
+		if known {
+			continue
+		}
+		s := &tailSource{
+			name:  strings.TrimSuffix(filepath.Base(path), ".log"),
+			path:  path,
+			style: lipgloss.NewStyle().Foreground(tailColors[len(t.sources)%len(tailColors)]),
+		}
+		if info, err := os.Stat(path); err == nil && t.lines == nil && info.Size() > tailBacklog {
+			s.offset = info.Size() - tailBacklog
+			s.partial = "\x00" // drop the cut-off first line
+		}
+		t.sources = append(t.sources, s)
+	}
+}
+
+// poll reads what was appended to the log files.
+func (t *logTail) poll() {
+	t.discover()
+	for _, s := range t.sources {
+		f, err := os.Open(s.path)
+		if err != nil {
+			continue
+		}
+		if info, err := f.Stat(); err == nil && info.Size() < s.offset {
+			// Rotated or truncated by a new session.
+			s.offset, s.partial = 0, ""
+		}
+		f.Seek(s.offset, io.SeekStart)
+		data, _ := io.ReadAll(f)
+		f.Close()
+		s.offset += int64(len(data))
+
+		parts := strings.Split(s.partial+ansiEscape.ReplaceAllString(string(data), ""), "\n")
+		s.partial = parts[len(parts)-1]
+		for i, line := range parts[:len(parts)-1] {
+			if i == 0 && strings.HasPrefix(line, "\x00") {
+				continue
+			}
+			t.lines = append(t.lines, tailLine{s, line})
+		}
+	}
+	if len(t.lines) > tailKeep {
+		dropped := len(t.lines) - tailKeep
+		t.lines = t.lines[dropped:]
+		t.offset = max(0, t.offset-dropped)
+	}
+	if t.follow {
+		t.offset = max(0, len(t.lines)-reportHeight)
+	}
+}
+
+// render formats line with the color of its source, and errors in red.
+func (l tailLine) render() string {
+	text := l.text
+	if niriLogError.MatchString(text) {
+		text = tailErrorStyle.Render(text)
+	}
+	return l.source.style.Render(fmt.Sprintf("%-7s", l.source.name)) + " " + text
+}
+
+// watchSessionLogs starts watching the session logs.
+func watchSessionLogs() tea.Cmd {
+	return func() tea.Msg {
+		t, err := newLogTail()
+		if err != nil {
+			return statusMsg{status: Tf("Failed to find the session logs: %v", err), err: err}
+		}
+		return tailMsg{t}
+	}
+}
+
+// tailMsg opens the log tail view.
+type tailMsg struct {
+	tail *logTail
+}
+
+func (m model) updateTail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.tail
+	maxOffset := max(0, len(t.lines)-reportHeight)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "backspace":
+		m.state = menuView
+		m.tail = nil
+		m.isProcessing = false
+	case "up", "k":
+		t.offset = max(0, t.offset-1)
+	case "down", "j":
+		t.offset = min(maxOffset, t.offset+1)
+	case "pgup":
+		t.offset = max(0, t.offset-reportHeight)
+	case "pgdown", " ":
+		t.offset = min(maxOffset, t.offset+reportHeight)
+	case "end", "G":
+		t.offset = maxOffset
+	case "c":
+		t.lines, t.offset = nil, 0
+	}
+	// Scrolling back pauses following until the bottom is reached again.
+	t.follow = t.offset >= maxOffset
+	return m, nil
+}
+
+func (m model) renderTailView() string {
+	t := m.tail
+	var body []string
+	if len(t.lines) == 0 {
+		body = append(body, Tf("Waiting for lines in %s...", filepath.Join(t.dir, "*.log")))
+	}
+	end := min(len(t.lines), t.offset+reportHeight)
+	for _, l := range t.lines[t.offset:end] {
+		body = append(body, l.render())
+	}
+	var names []string
+	for _, s := range t.sources {
+		names = append(names, s.style.Render(s.name))
+	}
+	help := T("↑/↓ scroll • G newest • c clear • esc back")
+	if !t.follow {
+		help += " • " + T("paused")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(T("Watch Session Logs")),
+		strings.Join(names, " "),
+		logStyle.Render(strings.Join(body, "\n")),
+		formHelpStyle.Render(help),
+	)
+}
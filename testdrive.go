@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testDriveLogPath keeps the output of the nested niri.
+func testDriveLogPath() string {
+	return filepath.Join(os.TempDir(), "nirisetup-test-drive.log")
+}
+
+// graphicalSession reports whether a Wayland or X11 session is there to
+// open a nested niri window in.
+func graphicalSession() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("DISPLAY") != ""
+}
+
+// runTestDrive starts niri in a window of the running graphical session
+// with the config at path, or with the config template when path is
+// empty, leaving the real config alone. With wait set it returns when
+// the window is closed.
+func runTestDrive(path string, wait bool) (string, error) {
+	if !graphicalSession() {
+		err := errors.New("no graphical session")
+		return T("Test Config opens niri in a window, so run it from a terminal inside niri or another graphical session"), preflightFailure(err)
+	}
+	if !hasCommand("niri") {
+		err := errors.New("niri not found")
+		return T("niri is not installed, run Install Niri first"), preflightFailure(err)
+	}
+
+	var data []byte
+	label := path
+	if path == "" {
+		cfg, _, err := niriConfigTemplate()
+		if err != nil {
+			return err.Error(), preflightFailure(err)
+		}
+		data, label = []byte(cfg), T("the config template")
+	} else {
+		var err error
+		if data, err = os.ReadFile(expandHome(path)); err != nil {
+			return Tf("Failed to read %s: %v", path, err), preflightFailure(err)
+		}
+	}
+	tmp, err := os.CreateTemp("", "nirisetup-test-*.kdl")
+	if err != nil {
+		return Tf("Failed to write the test config: %v", err), preflightFailure(err)
+	}
+	tmp.Write(data)
+	tmp.Close()
+	if err := validateConfigFile(tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return Tf("%s is not a valid niri config: %v", label, err), validationFailure(err)
+	}
+
+	logFile, err := os.Create(testDriveLogPath())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return Tf("Failed to write the test config: %v", err), preflightFailure(err)
+	}
+	cmd := exec.Command("niri", "-c", tmp.Name())
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	finished := func() {
+		logFile.Close()
+		os.Remove(tmp.Name())
+	}
+	if wait {
+		err := runCommand(cmd)
+		finished()
+		if err != nil {
+			return Tf("niri exited with an error, see %s: %v", testDriveLogPath(), err), err
+		}
+		return Tf("Tested %s; your config is unchanged", label), nil
+	}
+	start := traceStart(cmd)
+	if err := cmd.Start(); err != nil {
+		traceEnd(cmd, start, err)
+		finished()
+		return Tf("Failed to start niri: %v", err), err
+	}
+	go func() {
+		err := cmd.Wait()
+		traceEnd(cmd, start, err)
+		finished()
+	}()
+	return Tf("Started niri in a window with %s. Try the key bindings and layout there (Mod is Alt in a window), then close the window. Your config is unchanged; niri's output goes to %s.", label, testDriveLogPath()), nil
+}
+
+// testDrive starts a nested niri without waiting for it.
+func testDrive(path string) tea.Cmd {
+	return func() tea.Msg {
+		status, err := runTestDrive(path, false)
+		return statusMsg{status: status, err: err}
+	}
+}
+
+func newTestConfigForm() *form {
+	return &form{
+		title: "Test Config",
+		fields: []formField{
+			{label: "Config file (empty: the template)", value: ""},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			return testDrive(strings.TrimSpace(values[0])), nil
+		},
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// program is the running full-screen interface, if any. Trace lines are
+// printed above it instead of being written over it.
+var program *tea.Program
+
+// logFilePath is where Save Logs and the verbose trace write to.
+func logFilePath() string {
+	return filepath.Join(os.TempDir(), "nirisetup.log")
+}
+
+// tracef logs one line of the --verbose trace to the screen and the log
+// file. It does nothing unless --verbose was given.
+func tracef(format string, args ...any) {
+	if !verboseFlag {
+		return
+	}
+	line := fmt.Sprintf("[%s] ", time.Now().Format("15:04:05.000")) + fmt.Sprintf(format, args...)
+	if program != nil {
+		program.Println(line)
+	} else {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	if f, err := os.OpenFile(logFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+		fmt.Fprintln(f, line)
+		f.Close()
+	}
+}
+
+// traceStart logs the argv of cmd and the environment it adds on top of
+// ours, and returns the start time for traceEnd.
+func traceStart(cmd *exec.Cmd) time.Time {
+	if verboseFlag {
+		argv := make([]string, len(cmd.Args))
+		for i, arg := range cmd.Args {
+			argv[i] = arg
+			if arg == "" || strings.ContainsAny(arg, " \t\n\"'$\\") {
+				argv[i] = strconv.Quote(arg)
+			}
+		}
+		tracef("$ %s", strings.Join(argv, " "))
+		if cmd.Env != nil {
+			environ := os.Environ()
+			for _, kv := range cmd.Env {
+				if !slices.Contains(environ, kv) {
+					tracef("  env %s", kv)
+				}
+			}
+		}
+	}
+	return time.Now()
+}
+
+func traceEnd(cmd *exec.Cmd, start time.Time, err error) {
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		tracef("  %s failed after %s: %v", filepath.Base(cmd.Path), elapsed, err)
+	} else {
+		tracef("  %s finished in %s", filepath.Base(cmd.Path), elapsed)
+	}
+}
+
+// runCommand, commandOutput and commandCombinedOutput are cmd.Run,
+// cmd.Output and cmd.CombinedOutput with tracing, recording and tracking in
+// the managed state. Commands that take longer than commandTimeout ask
+// whether to keep waiting, and sudo asks for the password only once.
+func runCommand(cmd *exec.Cmd) error {
+	if err := ensureSudo(cmd); err != nil {
+		return err
+	}
+	start := traceStart(cmd)
+	cmd, err := superviseCommand(cmd, nil)
+	traceEnd(cmd, start, err)
+	recordCommand(cmd, err)
+	trackCommand(cmd, err)
+	return err
+}
+
+func commandOutput(cmd *exec.Cmd) ([]byte, error) {
+	if err := ensureSudo(cmd); err != nil {
+		return nil, err
+	}
+	if cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	captureStderr := cmd.Stderr == nil
+	if captureStderr {
+		cmd.Stderr = &stderr
+	}
+	start := traceStart(cmd)
+	cmd, err := superviseCommand(cmd, func() { stdout.Reset(); stderr.Reset() })
+	traceEnd(cmd, start, err)
+	recordCommand(cmd, err)
+	trackCommand(cmd, err)
+	if exitErr, ok := err.(*exec.ExitError); ok && captureStderr {
+		// As with cmd.Output, callers find the error output here.
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+func commandCombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if err := ensureSudo(cmd); err != nil {
+		return nil, err
+	}
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return nil, errors.New("exec: Stdout or Stderr already set")
+	}
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	start := traceStart(cmd)
+	cmd, err := superviseCommand(cmd, out.Reset)
+	traceEnd(cmd, start, err)
+	recordCommand(cmd, err)
+	trackCommand(cmd, err)
+	return out.Bytes(), err
+}
+
+// writeFile is os.WriteFile with tracing and recording.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	err := os.WriteFile(path, data, perm)
+	if err != nil {
+		tracef("write %s failed: %v", path, err)
+		return err
+	}
+	tracef("wrote %s (%d bytes, mode %o)", path, len(data), perm)
+	chownToUser(path)
+	recordFile(path, data, perm, false, false)
+	trackFile(path, data, false)
+	return nil
+}
+
+// appendFile adds text to the end of path, creating it if needed.
+func appendFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err == nil {
+		_, err = f.WriteString(text)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		tracef("append to %s failed: %v", path, err)
+		return err
+	}
+	tracef("appended %d bytes to %s", len(text), path)
+	chownToUser(path)
+	recordFile(path, []byte(text), 0644, false, true)
+	trackFile(path, []byte(text), true)
+	return nil
+}
+
+// setenv is os.Setenv with tracing.
+func setenv(key, value string) {
+	tracef("env %s=%s", key, value)
+	os.Setenv(key, value)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// updateCheckScript is run weekly by periodic(8) as root.
+	updateCheckScript = "/usr/local/etc/periodic/weekly/450.nirisetup-updates"
+	// updateStatusPath lists the outdated niri packages after each check.
+	updateStatusPath = "/var/db/nirisetup/updates"
+)
+
+// periodicUpdateScript checks the package repository for newer versions of
+// the niri stack. Like the base system's periodic scripts it can be turned
+// off in periodic.conf, and its output goes into the weekly report mail.
+func periodicUpdateScript() string {
+	return `#!/bin/sh
+# Generated by NiriSetup: check weekly for updates to the niri stack.
+# Set weekly_nirisetup_updates_enable="NO" in /etc/periodic.conf to turn it off.
+
+if [ -r /etc/defaults/periodic.conf ]; then
+	. /etc/defaults/periodic.conf
+	source_periodic_confs
+fi
+
+case "$weekly_nirisetup_updates_enable" in
+[Nn][Oo]) exit 0 ;;
+esac
+
+status=` + updateStatusPath + `
+mkdir -p "$(dirname "$status")"
+pkg update -q >/dev/null 2>&1
+pkg version -vRL= 2>/dev/null | grep -E '^(` + strings.Join(niriPackages, "|") + `)-[0-9]' > "$status.new"
+chmod 644 "$status.new"
+mv "$status.new" "$status"
+
+if [ -s "$status" ]; then
+	echo ""
+	echo "Updates for the niri stack (run NiriSetup update):"
+	cat "$status"
+fi
+exit 0
+`
+}
+
+// updateNotifyScript runs in the niri session and turns a new result of the
+// weekly check into a notification. The periodic script runs as root
+// without access to the session bus, so it can't notify by itself.
+const updateNotifyScript = `#!/bin/sh
+# Generated by NiriSetup: notify about updates found by the weekly check.
+status=` + updateStatusPath + `
+seen=""
+while :; do
+	if [ -s "$status" ]; then
+		stamp=$(stat -f %m "$status")
+		if [ "$stamp" != "$seen" ]; then
+			notify-send -a NiriSetup "Updates for the niri stack" "$(cat "$status")"
+			seen=$stamp
+		fi
+	fi
+	sleep 3600
+done
+`
+
+func newUpdateCheckForm() *form {
+	value := "Off"
+	if _, err := os.Stat(updateCheckScript); err == nil {
+		value = "On"
+	}
+	return &form{
+		title: "Update Check",
+		fields: []formField{
+			{label: "Weekly update check", value: value, options: []string{"On", "Off"},
+				help: "periodic(8) looks for newer niri packages once a week and a notification in your session lists them. Nothing is installed automatically."},
+		},
+		submit: func(values []string) (tea.Cmd, error) {
+			return setupUpdateCheck(values[0] == "On"), nil
+		},
+	}
+}
+
+// setupUpdateCheck installs or removes the weekly check and its notifier.
+func setupUpdateCheck(on bool) tea.Cmd {
+	return func() tea.Msg {
+		homeDir, err := userHomeDir()
+		if err != nil {
+			return statusMsg{status: T("Failed to determine home directory"), err: err}
+		}
+		notifier := filepath.Join(homeDir, ".local", "bin", "nirisetup-update-notify")
+		var logs []string
+
+		if !on {
+			if out, err := commandCombinedOutput(exec.Command("sudo", "rm", "-f", updateCheckScript)); err != nil {
+				return statusMsg{status: Tf("Failed to remove %s: %s", updateCheckScript, strings.TrimSpace(string(out))), err: err}
+			}
+			logs = append(logs, Tf("Removed %s", updateCheckScript))
+			path, err := updateNiriConfig(func(cfg string) string {
+				return removeSpawnAtStartup(cfg, notifier)
+			})
+			if err != nil {
+				logs = append(logs, Tf("Failed to update config: %v", err))
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+			logs = append(logs, Tf("Removed %s from spawn-at-startup in %s", "nirisetup-update-notify", path))
+			return statusMsg{status: strings.Join(logs, "\n")}
+		}
+
+		line, err := installPackage("libnotify")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		if err := writeRootFile(updateCheckScript, periodicUpdateScript(), 0755); err != nil {
+			logs = append(logs, Tf("Failed to write %s: %v", updateCheckScript, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Wrote %s: OK", updateCheckScript))
+
+		if err := os.MkdirAll(filepath.Dir(notifier), 0755); err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		if err := writeFile(notifier, []byte(updateNotifyScript), 0755); err != nil {
+			logs = append(logs, Tf("Failed to write %s: %v", notifier, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Wrote %s: OK", notifier))
+		path, err := updateNiriConfig(func(cfg string) string {
+			return setSpawnAtStartup(cfg, notifier)
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added %s to spawn-at-startup in %s", "nirisetup-update-notify", path))
+		logs = append(logs, Tf("The first check runs with the next weekly periodic run; to check now, run: sudo %s", updateCheckScript))
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
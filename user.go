@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// targetUser is the account given with --user, or the one that ran sudo,
+// whose home NiriSetup configures while running as root. It is nil when
+// NiriSetup configures the account it runs as.
+var targetUser *user.User
+
+// setTargetUser picks the account to configure. Without --user, a run as
+// root through sudo configures the account that ran sudo: sudo sets
+// SUDO_USER, while USER, LOGNAME and HOME name root.
+func setTargetUser(name string) error {
+	if name == "" {
+		if os.Geteuid() != 0 {
+			return nil
+		}
+		name = os.Getenv("SUDO_USER")
+		if name == "" || name == "root" {
+			return nil
+		}
+		u, err := user.Lookup(name)
+		if err != nil || u.HomeDir == "" || u.HomeDir == "/" {
+			tracef("not configuring the sudo user %s: no such user or no home directory", name)
+			return nil
+		}
+		targetUser = u
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return errors.New("--user needs root, run NiriSetup as root or leave out --user")
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return fmt.Errorf("unknown user %s", name)
+	}
+	if u.HomeDir == "" || u.HomeDir == "/" {
+		return fmt.Errorf("user %s has no home directory", name)
+	}
+	targetUser = u
+	return nil
+}
+
+// currentUser is the account NiriSetup runs as, looked up by uid rather
+// than taken from USER, LOGNAME or HOME, which cron and su may leave unset
+// or pointing elsewhere.
+func currentUser() *user.User {
+	u, err := user.Current()
+	if err != nil {
+		tracef("looking up uid %d failed: %v", os.Geteuid(), err)
+		return nil
+	}
+	return u
+}
+
+// userHomeDir is the home directory of the account being configured.
+func userHomeDir() (string, error) {
+	if targetUser != nil {
+		return targetUser.HomeDir, nil
+	}
+	if u := currentUser(); u != nil && u.HomeDir != "" {
+		return u.HomeDir, nil
+	}
+	return os.UserHomeDir()
+}
+
+// userID is the uid of the account being configured.
+func userID() int {
+	if targetUser != nil {
+		uid, _ := strconv.Atoi(targetUser.Uid)
+		return uid
+	}
+	return os.Geteuid()
+}
+
+// runtimeDirPath is the XDG_RUNTIME_DIR Setup System sets up for the
+// user.
+func runtimeDirPath() string {
+	return fmt.Sprintf("/tmp/%d-runtime-dir", userID())
+}
+
+// userName is the login name of the account being configured, or "" if it
+// can't be told.
+func userName() string {
+	if targetUser != nil {
+		return targetUser.Username
+	}
+	if u := currentUser(); u != nil {
+		return u.Username
+	}
+	return ""
+}
+
+// chownToUser hands path, and the directories above it up to the home
+// directory, to the --user account. Paths outside its home are left alone.
+func chownToUser(path string) {
+	if targetUser == nil {
+		return
+	}
+	uid, _ := strconv.Atoi(targetUser.Uid)
+	gid, _ := strconv.Atoi(targetUser.Gid)
+	home := filepath.Clean(targetUser.HomeDir)
+	for p := filepath.Clean(path); strings.HasPrefix(p, home+"/"); p = filepath.Dir(p) {
+		if err := os.Lchown(p, uid, gid); err != nil {
+			tracef("chown %s failed: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// waybarConfigPath returns the waybar config in use, preferring an existing
+// config over config.jsonc, and defaulting to config for new setups.
+func waybarConfigPath() (string, error) {
+	homeDir, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(homeDir, ".config", "waybar")
+	for _, name := range []string{"config", "config.jsonc"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return filepath.Join(dir, "config"), nil
+}
+
+// defaultWaybarConfig is used when the user has no waybar config yet.
+func defaultWaybarConfig() map[string]any {
+	return map[string]any{
+		"layer":          "top",
+		"position":       "top",
+		"height":         30,
+		"modules-left":   []any{},
+		"modules-center": []any{"clock"},
+		"modules-right":  []any{"tray"},
+	}
+}
+
+// stripJSONComments turns JSONC, the JSON with comments waybar reads, into
+// JSON for encoding/json: it drops // and /* */ comments outside of
+// strings, and the commas left before a closing bracket.
+func stripJSONComments(data string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				b.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case strings.HasPrefix(data[i:], "//"):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case strings.HasPrefix(data[i:], "/*"):
+			end := strings.Index(data[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += 2 + end + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return dropTrailingCommas(b.String())
+}
+
+// dropTrailingCommas drops the commas directly before a ] or }, outside of
+// strings, from JSON without comments.
+func dropTrailingCommas(data string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(data) {
+				b.WriteByte(c)
+				i++
+				c = data[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			rest := strings.TrimLeft(data[i+1:], " \t\r\n")
+			if strings.HasPrefix(rest, "]") || strings.HasPrefix(rest, "}") {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// jsonKeyOrder records the order of the keys of every object in a JSON
+// document, by the path of the object, so that a rewritten config keeps
+// its keys where they were.
+func jsonKeyOrder(dec *json.Decoder, path string, order map[string][]string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			k, _ := key.(string)
+			order[path] = append(order[path], k)
+			if err := jsonKeyOrder(dec, path+"\x00"+k, order); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := jsonKeyOrder(dec, path+"\x00"+strconv.Itoa(i), order); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	}
+	return err
+}
+
+// writeOrderedJSON writes v indented by four spaces like json.MarshalIndent,
+// but with the keys of each object in the order of order, followed by new
+// keys in sorted order, and without escaping < > and & in the Pango markup
+// of waybar formats.
+func writeOrderedJSON(b *bytes.Buffer, v any, path, indent string, order map[string][]string) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		var keys []string
+		for _, k := range order[path] {
+			if _, ok := v[k]; ok && !containsString(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+		var added []string
+		for k := range v {
+			if !containsString(keys, k) {
+				added = append(added, k)
+			}
+		}
+		sort.Strings(added)
+		b.WriteString("{\n")
+		for i, k := range append(keys, added...) {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			b.WriteString(indent + "    ")
+			if err := writeOrderedJSON(b, k, "", "", nil); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			if err := writeOrderedJSON(b, v[k], path+"\x00"+k, indent+"    ", order); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + indent + "}")
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			b.WriteString(indent + "    ")
+			if err := writeOrderedJSON(b, item, path+"\x00"+strconv.Itoa(i), indent+"    ", order); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + indent + "]")
+	default:
+		enc := json.NewEncoder(b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		// Encode ends the value with a newline.
+		b.Truncate(b.Len() - 1)
+	}
+	return nil
+}
+
+// updateWaybarConfig loads the waybar config (or the default one), applies
+// edit to each bar in it and writes it back. A config with several bars is
+// a list of them. The previous config is kept as config.bak, since its
+// comments are not carried over; the keys stay in their order.
+func updateWaybarConfig(edit func(cfg map[string]any)) (string, error) {
+	path, err := waybarConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	var cfg any = defaultWaybarConfig()
+	order := map[string][]string{}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		plain := stripJSONComments(string(data))
+		if err := json.Unmarshal([]byte(plain), &cfg); err != nil {
+			return path, fmt.Errorf("cannot parse %s: %v", path, err)
+		}
+		if err := jsonKeyOrder(json.NewDecoder(strings.NewReader(plain)), "", order); err != nil {
+			return path, fmt.Errorf("cannot parse %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return path, err
+	}
+
+	switch bars := cfg.(type) {
+	case map[string]any:
+		edit(bars)
+	case []any:
+		if len(bars) == 0 {
+			bars = []any{defaultWaybarConfig()}
+		}
+		for _, bar := range bars {
+			if bar, ok := bar.(map[string]any); ok {
+				edit(bar)
+			}
+		}
+		cfg = bars
+	default:
+		return path, fmt.Errorf("cannot parse %s: expected an object or a list of bars", path)
+	}
+
+	var out bytes.Buffer
+	if err := writeOrderedJSON(&out, cfg, "", "", order); err != nil {
+		return path, err
+	}
+	out.WriteByte('\n')
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return path, err
+	}
+	if data != nil && string(data) != out.String() {
+		if err := writeFile(path+".bak", data, 0644); err != nil {
+			return path, err
+		}
+	}
+	return path, writeFile(path, out.Bytes(), 0644)
+}
+
+// addWaybarModule appends module to the given modules list (e.g.
+// "modules-right") unless it is already there.
+func addWaybarModule(cfg map[string]any, list, module string) {
+	modules, _ := cfg[list].([]any)
+	for _, m := range modules {
+		if m == module {
+			return
+		}
+	}
+	cfg[list] = append(modules, module)
+}
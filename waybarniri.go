@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// waybarNiriModules are the modules that show niri's workspaces and the
+// focused window. waybar only has them when it is built with its niri
+// option.
+var waybarNiriModules = []string{"niri/workspaces", "niri/window"}
+
+// waybarForeignModules are the workspace and window modules of other
+// compositors, which show nothing under niri. A config imported from sway
+// or Hyprland still lists them.
+var waybarForeignModules = []string{
+	"sway/workspaces", "sway/window", "sway/mode",
+	"hyprland/workspaces", "hyprland/window", "hyprland/submap",
+	"wlr/workspaces", "river/tags", "river/window", "dwl/tags",
+}
+
+// waybarBarWait is how long Waybar Workspaces waits for the bar to come
+// up after starting or reloading waybar.
+const waybarBarWait = 5 * time.Second
+
+// niriLayer mirrors the objects reported by `niri msg --json layers`.
+type niriLayer struct {
+	Namespace string `json:"namespace"`
+	Output    string `json:"output"`
+	Layer     string `json:"layer"`
+}
+
+// waybarHasNiriModules reports whether the installed waybar was built with
+// the niri modules, which leave their names in the binary.
+func waybarHasNiriModules() (bool, error) {
+	path, err := exec.LookPath("waybar")
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Contains(data, []byte("niri/workspaces")), nil
+}
+
+// setWaybarNiriModules puts the niri modules at the start of modules-left
+// in place of those of other compositors and, unless their format is set
+// already, configures them to show the workspace names, or their numbers
+// when they have none.
+func setWaybarNiriModules(cfg map[string]any) {
+	for _, list := range []string{"modules-left", "modules-center", "modules-right"} {
+		modules, ok := cfg[list].([]any)
+		if !ok && list != "modules-left" {
+			continue
+		}
+		var kept []any
+		for _, m := range modules {
+			name, _ := m.(string)
+			if !containsString(waybarForeignModules, name) && !containsString(waybarNiriModules, name) {
+				kept = append(kept, m)
+			}
+		}
+		if list == "modules-left" {
+			kept = append([]any{waybarNiriModules[0], waybarNiriModules[1]}, kept...)
+		}
+		if kept == nil {
+			kept = []any{}
+		}
+		cfg[list] = kept
+	}
+	for _, name := range waybarForeignModules {
+		delete(cfg, name)
+	}
+	workspaces, _ := cfg["niri/workspaces"].(map[string]any)
+	if workspaces == nil {
+		workspaces = map[string]any{}
+	}
+	if _, ok := workspaces["format"]; !ok {
+		// {value} is the name of a named workspace and the index of the others.
+		workspaces["format"] = "{value}"
+	}
+	cfg["niri/workspaces"] = workspaces
+	window, _ := cfg["niri/window"].(map[string]any)
+	if window == nil {
+		window = map[string]any{}
+	}
+	if _, ok := window["format"]; !ok {
+		window["format"] = "{title}"
+	}
+	if _, ok := window["max-length"]; !ok {
+		window["max-length"] = 60
+	}
+	cfg["niri/window"] = window
+}
+
+// waybarPIDs returns the process IDs of the user's waybar.
+func waybarPIDs() []string {
+	out, _ := commandOutput(exec.Command("pgrep", "-u", userName(), "-x", "waybar"))
+	return strings.Fields(string(out))
+}
+
+// waybarBarShown waits for niri to map a waybar layer surface.
+func waybarBarShown() (bool, error) {
+	deadline := time.Now().Add(waybarBarWait)
+	for {
+		var layers []niriLayer
+		if err := niriMsg(&layers, "layers"); err != nil {
+			return false, err
+		}
+		for _, l := range layers {
+			if l.Namespace == "waybar" {
+				return true, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// niriWorkspaceLabels returns the name, or the number when it has none, of
+// each workspace niri reports over IPC, per output.
+func niriWorkspaceLabels() ([]string, error) {
+	var workspaces []niriWorkspace
+	if err := niriMsg(&workspaces, "workspaces"); err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, ws := range workspaces {
+		label := fmt.Sprint(ws.Idx)
+		if ws.Name != nil {
+			label = *ws.Name
+		}
+		if ws.Output != nil {
+			label = *ws.Output + ":" + label
+		}
+		if ws.IsFocused {
+			label += " " + T("(focused)")
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// verifyWaybarShown reloads waybar in the running session, or starts it
+// through niri so that it inherits NIRI_SOCKET, which the niri modules talk
+// to, checks that the bar comes up and lists the workspaces niri reports.
+// What the modules draw can't be read back, so that is left to the user.
+func verifyWaybarShown() ([]string, error) {
+	if len(waybarPIDs()) > 0 {
+		// waybar reloads its config on SIGUSR2.
+		commandCombinedOutput(exec.Command("pkill", "-USR2", "-u", userName(), "-x", "waybar"))
+	} else if out, err := commandCombinedOutput(exec.Command("niri", "msg", "action", "spawn", "--", "waybar")); err != nil {
+		return nil, fmt.Errorf("niri msg action spawn: %s", strings.TrimSpace(string(out)))
+	}
+	shown, err := waybarBarShown()
+	if err != nil {
+		return nil, err
+	}
+	if !shown {
+		return nil, errors.New(T("waybar didn't put a bar on screen; run waybar in a terminal inside niri to see why"))
+	}
+	labels, err := niriWorkspaceLabels()
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		T("waybar shows a bar: OK"),
+		Tf("niri reports these workspaces, which the bar should show: %s", strings.Join(labels, ", ")),
+	}, nil
+}
+
+// setupWaybarWorkspaces makes waybar show niri's workspaces and the title
+// of the focused window: it installs waybar, checks that it has the niri
+// modules, puts them in the waybar config, starts waybar with niri and,
+// in a running session, checks over IPC that the bar is up.
+func setupWaybarWorkspaces() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		line, err := installPackage("waybar")
+		logs = append(logs, line)
+		if err != nil {
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		ok, err := waybarHasNiriModules()
+		if err == nil && !ok {
+			// Packages built before waybar had them lack the modules.
+			if out, err := commandCombinedOutput(exec.Command("sudo", "pkg", "upgrade", "-y", "waybar")); err != nil {
+				logs = append(logs, Tf("Warning: Could not update waybar: %s", strings.TrimSpace(string(out))))
+			}
+			ok, err = waybarHasNiriModules()
+		}
+		if err != nil {
+			logs = append(logs, Tf("Failed to check waybar: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: preflightFailure(err)}
+		}
+		if !ok {
+			err := errors.New(T("waybar has no niri modules"))
+			logs = append(logs, T("This waybar was built without its niri modules, which waybar's niri build option adds. Install a waybar built with it, for example from ports, then run this again."))
+			return statusMsg{status: strings.Join(logs, "\n"), err: preflightFailure(err)}
+		}
+		logs = append(logs, T("waybar has the niri modules: OK"))
+
+		path, err := updateWaybarConfig(setWaybarNiriModules)
+		if err != nil {
+			logs = append(logs, Tf("Failed to update %s: %v", path, err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("Added niri/workspaces and niri/window to %s: OK", path))
+
+		path, err = updateNiriConfig(func(cfg string) string {
+			for _, line := range strings.Split(cfg, "\n") {
+				if spawnProgram(line) == "waybar" {
+					return cfg
+				}
+			}
+			return setSpawnAtStartup(cfg, "waybar")
+		})
+		if err != nil {
+			logs = append(logs, Tf("Failed to update config: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, Tf("waybar starts with niri (%s): OK", path))
+
+		if !niriRunning() {
+			logs = append(logs, T("niri isn't running; the bar shows the workspaces from the next session on."))
+			return statusMsg{status: strings.Join(logs, "\n")}
+		}
+		lines, err := verifyWaybarShown()
+		if err != nil {
+			logs = append(logs, Tf("Failed to check the bar: %v", err))
+			return statusMsg{status: strings.Join(logs, "\n"), err: err}
+		}
+		logs = append(logs, lines...)
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// webcamPackages provide the USB camera driver and the Video4Linux tools
+// and headers browsers and conferencing programs use.
+var webcamPackages = []string{"webcamd", "v4l-utils", "v4l_compat"}
+
+// setupWebcam installs webcamd, loads the cuse module it needs, starts it
+// at boot and lets the user open /dev/video*.
+func setupWebcam() tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		for _, pkg := range webcamPackages {
+			line, err := installPackage(pkg)
+			logs = append(logs, line)
+			if err != nil {
+				return statusMsg{status: strings.Join(logs, "\n"), err: err}
+			}
+		}
+
+		type step struct {
+			desc string
+			cmd  []string
+		}
+		steps := []step{
+			{"Loading the cuse kernel module", []string{"sudo", "kldload", "-n", "cuse"}},
+			{"Loading cuse at boot", []string{"sudo", "sysrc", "kld_list+=cuse"}},
+			{"Enabling webcamd service", []string{"sudo", "sysrc", "webcamd_enable=YES"}},
+			{"Starting webcamd service", []string{"sudo", "service", "webcamd", "start"}},
+		}
+		if user := userName(); user != "" {
+			steps = append(steps, step{Tf("Adding user '%s' to the webcamd group", user), []string{"sudo", "pw", "groupmod", "webcamd", "-m", user}})
+		}
+		var failed int
+		for _, s := range steps {
+			out, err := commandCombinedOutput(exec.Command(s.cmd[0], s.cmd[1:]...))
+			switch {
+			case err == nil:
+				logs = append(logs, Tf("%s: OK", T(s.desc)))
+			case strings.Contains(string(out), "already running"):
+				logs = append(logs, Tf("%s: already running", T(s.desc)))
+			default:
+				failed++
+				logs = append(logs, Tf("Warning: %s: %s", T(s.desc), strings.TrimSpace(string(out))))
+			}
+		}
+
+		devices, _ := filepath.Glob("/dev/video*")
+		if len(devices) == 0 {
+			logs = append(logs, T("No camera found yet. Plug it in; devd starts webcamd for it and /dev/video0 appears."))
+		} else {
+			logs = append(logs, Tf("Cameras: %s", strings.Join(devices, ", ")))
+		}
+		logs = append(logs, T("Log out and back in for the group change to take effect."))
+		if failed > 0 {
+			return statusMsg{status: strings.Join(logs, "\n"), err: fmt.Errorf("%d webcam steps failed", failed)}
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
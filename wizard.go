@@ -0,0 +1,341 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type stepStatus int
+
+const (
+	stepPending stepStatus = iota
+	stepDone
+	stepFailed
+	stepSkipped
+)
+
+// wizardStep is one stage of the first-run wizard. run starts the step,
+// either by returning a command or by opening a form on the model. answers
+// names the section of an answer file that fills in the step's form.
+type wizardStep struct {
+	name    string
+	desc    string
+	run     func(m *model) tea.Cmd
+	answers string
+	status  stepStatus
+}
+
+// wizard walks a newcomer through the setup in order. err is the error of
+// the step that ran last.
+type wizard struct {
+	steps      []wizardStep
+	current    int
+	output     string
+	err        error
+	components []string
+}
+
+var (
+	wizardDoneStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#00ff00"))
+	wizardFailedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5f5f"))
+	wizardCurrentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00ff00")).Bold(true)
+)
+
+// loginInstructions explains how to start the freshly configured session.
+const loginInstructions = `Setup is complete. To start niri:
+
+  1. Log out and back in so group changes take effect.
+  2. Switch to a TTY (Ctrl+Alt+F2) and log in.
+  3. Run:
+     %s
+
+Inside niri, press Mod+Shift+/ for a list of important hotkeys.`
+
+// wizardComponents are the optional extras offered by the wizard, applied
+// after the config has been written.
+var wizardComponents = []struct {
+	name  string
+	setup func() tea.Cmd
+}{
+	{"Function keys", setupFunctionKeys},
+	{"Media keys", setupMediaKeys},
+	{"Laptop power", func() tea.Cmd { return setupLaptop(300, 900, true, 15, "XF86Sleep") }},
+	{"Qt Wayland plugins", setupQtWayland},
+	{"OBS Studio", setupOBS},
+	{"Gaming", setupGaming},
+	{"CJK and emoji fonts", setupFonts},
+	{"On-screen keyboard", setupOSK},
+}
+
+// wizardProfiles are sets of components for common kinds of machine, which
+// the components form offers instead of picking them one by one.
+var wizardProfiles = []struct {
+	name       string
+	components []string
+}{
+	{"Desktop", []string{"Media keys", "Qt Wayland plugins", "CJK and emoji fonts"}},
+	{"Laptop", []string{"Function keys", "Media keys", "Laptop power", "Qt Wayland plugins", "CJK and emoji fonts"}},
+	{"Gaming", []string{"Media keys", "Qt Wayland plugins", "Gaming", "CJK and emoji fonts"}},
+	{"Minimal", nil},
+}
+
+// wizardProfileNames returns the names of the wizard profiles, lower case,
+// as install and export ansible take them.
+func wizardProfileNames() []string {
+	var names []string
+	for _, p := range wizardProfiles {
+		names = append(names, strings.ToLower(p.name))
+	}
+	return names
+}
+
+// isFirstRun reports whether niri has never been configured for this user.
+func isFirstRun() bool {
+	path, err := niriConfigPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+func newWizard() *wizard {
+	w := &wizard{}
+	w.steps = []wizardStep{
+		{
+			name: "Install packages",
+			desc: "Installs niri, the Wayland helpers and the desktop components with pkg, bootstrapping pkg first on a fresh system.",
+			run: func(m *model) tea.Cmd {
+				m.state = installView
+				return withPkgBootstrap(installNiri())
+			},
+		},
+		{
+			name: "Set up the system",
+			desc: "Enables dbus and seatd, loads the DRM module, adds you to the video group and prepares your login environment.",
+			run: func(m *model) tea.Cmd {
+				m.state = installView
+				return setupSystem()
+			},
+		},
+		{
+			name: "Choose components",
+			desc: "Pick optional extras to set up along with the config.",
+			run: func(m *model) tea.Cmd {
+				m.state = formView
+				m.form = w.componentsForm()
+				return nil
+			},
+			answers: "components",
+		},
+		{
+			name: "Write the config",
+			desc: "Copies the niri config template to ~/.config/niri/config.kdl and applies the chosen components.",
+			run: func(m *model) tea.Cmd {
+				m.state = actionView
+				m.actionMsg = "Configuring Niri..."
+				return w.configure()
+			},
+		},
+		{
+			name: "Choose the keyboard layout",
+			desc: "Sets the xkb keyboard layout, variant and options in config.kdl.",
+			run: func(m *model) tea.Cmd {
+				m.state = formView
+				m.form = newKeyboardForm()
+				return nil
+			},
+			answers: "keyboard",
+		},
+		{
+			name: "Adjust the layout",
+			desc: "Sets the gaps, column widths, focus ring and borders in config.kdl.",
+			run: func(m *model) tea.Cmd {
+				m.state = formView
+				m.form = newLayoutForm()
+				return nil
+			},
+			answers: "layout",
+		},
+		{
+			name: "Validate the config",
+			desc: "Checks the new config with niri validate.",
+			run: func(m *model) tea.Cmd {
+				m.state = actionView
+				m.actionMsg = "Validating Niri config..."
+				return validateNiriConfig()
+			},
+		},
+		{
+			name: "How to log in",
+			desc: "Shows how to start your new niri session.",
+			run: func(m *model) tea.Cmd {
+				return func() tea.Msg { return statusMsg{status: Tf(loginInstructions, startCommand())} }
+			},
+		},
+	}
+	return w
+}
+
+// componentsForm picks the components by profile, or one by one when the
+// profile is Custom.
+func (w *wizard) componentsForm() *form {
+	profiles := []string{"Custom"}
+	for _, p := range wizardProfiles {
+		profiles = append(profiles, p.name)
+	}
+	fields := []formField{{label: "Profile", value: "Custom", options: profiles,
+		help: "Desktop, Laptop, Gaming and Minimal pick the components for that kind of machine; Custom uses the choices below."}}
+	for _, c := range wizardComponents {
+		fields = append(fields, formField{label: c.name, value: "Yes", options: []string{"Yes", "No"}})
+	}
+	return &form{
+		title:  "Choose components",
+		fields: fields,
+		submit: func(values []string) (tea.Cmd, error) {
+			w.components = nil
+			for _, p := range wizardProfiles {
+				if p.name == values[0] {
+					w.components = append(w.components, p.components...)
+				}
+			}
+			for i, v := range values[1:] {
+				if values[0] == "Custom" && v == "Yes" {
+					w.components = append(w.components, wizardComponents[i].name)
+				}
+			}
+			chosen := strings.Join(w.components, ", ")
+			if chosen == "" {
+				chosen = T("none")
+			}
+			components := strings.Join(w.components, ", ")
+			return func() tea.Msg {
+				// export ansible writes a playbook for the same components.
+				if err := saveSettings(map[string]string{"wizard_components": components}); err != nil {
+					tracef("saving the wizard components failed: %v", err)
+				}
+				return statusMsg{status: Tf("Selected components: %s", chosen)}
+			}, nil
+		},
+	}
+}
+
+// configure writes the config and then runs the chosen components one after
+// another, collecting their output.
+func (w *wizard) configure() tea.Cmd {
+	return func() tea.Msg {
+		result := configureNiri()().(statusMsg)
+		if result.err != nil {
+			return result
+		}
+		logs := []string{result.status}
+		var failed []string
+		for _, c := range wizardComponents {
+			if !containsString(w.components, c.name) {
+				continue
+			}
+			msg := c.setup()().(statusMsg)
+			logs = append(logs, "", T(c.name)+":", msg.status)
+			if msg.err != nil {
+				failed = append(failed, c.name)
+			}
+		}
+		if len(failed) > 0 {
+			return statusMsg{status: strings.Join(logs, "\n"), err: errors.New(Tf("components failed: %s", strings.Join(failed, ", ")))}
+		}
+		return statusMsg{status: strings.Join(logs, "\n")}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// finish records the result of the step that just ran and moves on.
+func (w *wizard) finish(msg statusMsg) {
+	step := &w.steps[w.current]
+	step.status = stepDone
+	if msg.err != nil {
+		step.status = stepFailed
+	}
+	w.output = msg.status
+	w.err = msg.err
+	if msg.err == nil && w.current < len(w.steps)-1 {
+		w.current++
+	}
+}
+
+func (m model) updateWizard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	w := m.wizard
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "backspace":
+		m.state = menuView
+		m.wizard = nil
+		m.isProcessing = false
+	case "enter":
+		if w.current == len(w.steps)-1 && w.steps[w.current].status == stepDone {
+			m.state = menuView
+			m.wizard = nil
+			return m, nil
+		}
+		m.isProcessing = true
+		cmd := w.steps[w.current].run(&m)
+		return m, cmd
+	case "s":
+		if w.current < len(w.steps)-1 {
+			w.steps[w.current].status = stepSkipped
+			w.output = ""
+			w.current++
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderWizardView() string {
+	w := m.wizard
+	title := titleStyle.Render(Tf("First-run Wizard (%d/%d)", w.current+1, len(w.steps)))
+
+	steps := strings.Builder{}
+	for i, step := range w.steps {
+		switch {
+		case step.status == stepDone:
+			steps.WriteString(wizardDoneStyle.Render("✓ "+T(step.name)) + "\n")
+		case step.status == stepFailed:
+			steps.WriteString(wizardFailedStyle.Render("✗ "+T(step.name)) + "\n")
+		case step.status == stepSkipped:
+			steps.WriteString(disabledStyle.Render("- "+Tf("%s (skipped)", T(step.name))) + "\n")
+		case i == w.current:
+			steps.WriteString(wizardCurrentStyle.Render("▸ "+T(step.name)) + "\n")
+		default:
+			steps.WriteString(disabledStyle.Render("• "+T(step.name)) + "\n")
+		}
+	}
+
+	parts := []string{title, menuStyle.Render(steps.String())}
+	current := w.steps[w.current]
+	if w.output != "" {
+		parts = append(parts, logStyle.Render(w.output))
+	}
+	help := "enter run step • s skip • esc leave wizard"
+	if w.current == len(w.steps)-1 && current.status == stepDone {
+		help = "enter finish"
+	} else {
+		parts = append(parts, actionStyle.Render(T(current.desc)))
+		if current.status == stepFailed {
+			help = "enter retry • s skip • esc leave wizard"
+		}
+	}
+	parts = append(parts, formHelpStyle.Render(T(help)))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
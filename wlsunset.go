@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type city struct {
+	name      string
+	latitude  float64
+	longitude float64
+}
+
+// cities is a small list of well-known locations so users don't have to look
+// up their coordinates. "Custom" uses the latitude/longitude fields instead.
+var cities = []city{
+	{"Amsterdam", 52.37, 4.90},
+	{"Auckland", -36.85, 174.76},
+	{"Beijing", 39.90, 116.41},
+	{"Berlin", 52.52, 13.40},
+	{"Buenos Aires", -34.60, -58.38},
+	{"Cairo", 30.04, 31.24},
+	{"Chicago", 41.88, -87.63},
+	{"Delhi", 28.61, 77.21},
+	{"Dubai", 25.20, 55.27},
+	{"Johannesburg", -26.20, 28.05},
+	{"Lagos", 6.52, 3.38},
+	{"London", 51.51, -0.13},
+	{"Los Angeles", 34.05, -118.24},
+	{"Madrid", 40.42, -3.70},
+	{"Mexico City", 19.43, -99.13},
+	{"Montreal", 45.50, -73.57},
+	{"Moscow", 55.76, 37.62},
+	{"Mumbai", 19.08, 72.88},
+	{"New York", 40.71, -74.01},
+	{"Paris", 48.86, 2.35},
+	{"Rome", 41.90, 12.50},
+	{"Sao Paulo", -23.55, -46.63},
+	{"Seoul", 37.57, 126.98},
+	{"Singapore", 1.35, 103.82},
+	{"Stockholm", 59.33, 18.07},
+	{"Sydney", -33.87, 151.21},
+	{"Tokyo", 35.68, 139.69},
+	{"Toronto", 43.65, -79.38},
+}
+
+func newWlsunsetForm() *form {
+	options := []string{"Custom"}
+	for _, c := range cities {
+		options = append(options, c.name)
+	}
+	return &form{
+		title: "Night Light (wlsunset)",
+		fields: []formField{
+			{label: "City", value: "Custom", options: options},
+			{label: "Latitude (custom)", value: ""},
+			{label: "Longitude (custom)", value: ""},
+			{label: "Day temperature (K)", value: "6500"},
+			{label: "Night temperature (K)", value: "4000"},
+		},
+		submit: submitWlsunset,
+	}
+}
+
+func submitWlsunset(values []string) (tea.Cmd, error) {
+	var lat, lon float64
+	var err error
+	if values[0] != "Custom" {
+		for _, c := range cities {
+			if c.name == values[0] {
+				lat, lon = c.latitude, c.longitude
+			}
+		}
+	} else {
+		if lat, err = strconv.ParseFloat(strings.TrimSpace(values[1]), 64); err != nil || lat < -90 || lat > 90 {
+			return nil, errors.New(T("latitude must be a number between -90 and 90"))
+		}
+		if lon, err = strconv.ParseFloat(strings.TrimSpace(values[2]), 64); err != nil || lon < -180 || lon > 180 {
+			return nil, errors.New(T("longitude must be a number between -180 and 180"))
+		}
+	}
+
+	day, err := strconv.Atoi(strings.TrimSpace(values[3]))
+	if err != nil || day < 1000 || day > 10000 {
+		return nil, errors.New(T("day temperature must be between 1000 and 10000"))
+	}
+	night, err := strconv.Atoi(strings.TrimSpace(values[4]))
+	if err != nil || night < 1000 || night > 10000 {
+		return nil, errors.New(T("night temperature must be between 1000 and 10000"))
+	}
+	if night >= day {
+		return nil, errors.New(T("night temperature must be lower than day temperature"))
+	}
+
+	return configureWlsunset(lat, lon, day, night), nil
+}
+
+// configureWlsunset writes the wlsunset spawn-at-startup command with the
+// given location and temperatures into the user's config.
+func configureWlsunset(lat, lon float64, day, night int) tea.Cmd {
+	return func() tea.Msg {
+		args := []string{
+			"wlsunset",
+			"-l", strconv.FormatFloat(lat, 'f', -1, 64),
+			"-L", strconv.FormatFloat(lon, 'f', -1, 64),
+			"-T", strconv.Itoa(day),
+			"-t", strconv.Itoa(night),
+		}
+		path, err := updateNiriConfig(func(cfg string) string {
+			return setSpawnAtStartup(cfg, args...)
+		})
+		if err != nil {
+			return statusMsg{status: Tf("Failed to configure wlsunset: %v", err), err: err}
+		}
+		return statusMsg{status: Tf("wlsunset will start with: %s\nUpdated %s", strings.Join(args, " "), path)}
+	}
+}
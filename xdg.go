@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// xdgBaseDirs are the XDG base directories set in ~/.profile, relative to
+// the home directory. Many programs fall back to these paths anyway, but
+// some misplace their files or fail without the variables.
+var xdgBaseDirs = []struct {
+	name string
+	dir  string
+}{
+	{"XDG_CONFIG_HOME", ".config"},
+	{"XDG_DATA_HOME", ".local/share"},
+	{"XDG_CACHE_HOME", ".cache"},
+	{"XDG_STATE_HOME", ".local/state"},
+}
+
+// sessionEnvironment is set in the environment block of config.kdl rather
+// than in ~/.profile, so that only programs started inside niri see it and
+// X11 sessions started from the same login keep their own values.
+var sessionEnvironment = []struct {
+	name  string
+	value string
+}{
+	{"XDG_CURRENT_DESKTOP", "niri"},
+	{"XDG_SESSION_TYPE", "wayland"},
+}
+
+// profileExport matches an assignment of the variable name in a shell
+// profile.
+func profileExport(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^\s*(export\s+)?` + name + `=`)
+}
+
+// setupXDGBaseDirs creates the XDG base directories and exports them from
+// ~/.profile, keeping values the user already set.
+func setupXDGBaseDirs(homeDir, profilePath string) []string {
+	var logs []string
+	profile, _ := os.ReadFile(profilePath)
+	var missing string
+	for _, d := range xdgBaseDirs {
+		dir := filepath.Join(homeDir, d.dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logs = append(logs, Tf("Warning: Could not create %s: %v", dir, err))
+		} else {
+			chownToUser(dir)
+		}
+		if profileExport(d.name).Match(profile) {
+			logs = append(logs, Tf("%s already in .profile: OK", d.name))
+			continue
+		}
+		missing += "export " + d.name + `="$HOME/` + d.dir + "\"\n"
+		logs = append(logs, Tf("Added %s to %s: OK", d.name, profilePath))
+	}
+	if missing == "" {
+		return logs
+	}
+	if err := appendFile(profilePath, "\n# XDG base directories\n"+missing); err != nil {
+		return []string{Tf("Warning: Could not write to %s: %v", profilePath, err)}
+	}
+	return logs
+}
+
+// setSessionEnvironment adds the session variables to the environment
+// block of cfg, leaving any already there alone.
+func setSessionEnvironment(cfg string) string {
+	for _, v := range sessionEnvironment {
+		if _, ok := kdlGet(cfg, []string{"environment"}, v.name); !ok {
+			cfg = setKDLNode(cfg, []string{"environment"}, v.name+" "+kdlQuote(v.value))
+		}
+	}
+	return cfg
+}
+
+// xdgUserDirs are the user directories xdg-user-dirs-update sets up, with
+// their default location relative to the home directory. File managers,
+// browsers and the file chooser portal look them up in user-dirs.dirs.
+var xdgUserDirs = []struct {
+	name string
+	dir  string
+}{
+	{"DESKTOP", "Desktop"},
+	{"DOWNLOAD", "Downloads"},
+	{"TEMPLATES", "Templates"},
+	{"PUBLICSHARE", "Public"},
+	{"DOCUMENTS", "Documents"},
+	{"MUSIC", "Music"},
+	{"PICTURES", "Pictures"},
+	{"VIDEOS", "Videos"},
+}
+
+// userDirsEntry matches a line of user-dirs.dirs, e.g.
+// XDG_PICTURES_DIR="$HOME/Pictures".
+var userDirsEntry = regexp.MustCompile(`(?m)^\s*XDG_([A-Z]+)_DIR="([^"]*)"`)
+
+// readUserDirs returns the user directories listed in user-dirs.dirs as
+// absolute paths, by name.
+func readUserDirs(homeDir string) map[string]string {
+	dirs := map[string]string{}
+	data, _ := os.ReadFile(filepath.Join(homeDir, ".config", "user-dirs.dirs"))
+	for _, m := range userDirsEntry.FindAllStringSubmatch(string(data), -1) {
+		dir := strings.Replace(m[2], "$HOME", homeDir, 1)
+		if filepath.IsAbs(dir) {
+			dirs[m[1]] = dir
+		}
+	}
+	return dirs
+}
+
+// userDir returns the user directory name, e.g. "PICTURES", falling back
+// to its default location.
+func userDir(homeDir, name string) string {
+	if dir, ok := readUserDirs(homeDir)[name]; ok {
+		return dir
+	}
+	for _, d := range xdgUserDirs {
+		if d.name == name {
+			return filepath.Join(homeDir, d.dir)
+		}
+	}
+	return homeDir
+}
+
+// setupUserDirs does what xdg-user-dirs-update does: it creates the user
+// directories and lists them in ~/.config/user-dirs.dirs, keeping the
+// locations already chosen there.
+func setupUserDirs(homeDir string) []string {
+	var logs []string
+	existing := readUserDirs(homeDir)
+	content := "# Generated by NiriSetup, like xdg-user-dirs-update.\n"
+	for _, d := range xdgUserDirs {
+		dir, ok := existing[d.name]
+		if !ok {
+			dir = filepath.Join(homeDir, d.dir)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logs = append(logs, Tf("Warning: Could not create %s: %v", dir, err))
+			continue
+		}
+		chownToUser(dir)
+		shown := dir
+		if rel, err := filepath.Rel(homeDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			shown = "$HOME/" + rel
+		}
+		content += fmt.Sprintf("XDG_%s_DIR=%q\n", d.name, shown)
+	}
+	path := filepath.Join(homeDir, ".config", "user-dirs.dirs")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return append(logs, Tf("Warning: Could not create %s: %v", filepath.Dir(path), err))
+	}
+	if err := writeFile(path, []byte(content), 0644); err != nil {
+		return append(logs, Tf("Warning: Could not write to %s: %v", path, err))
+	}
+	return append(logs, Tf("Created the user directories and listed them in %s: OK", path))
+}
+
+// setScreenshotPath makes niri save screenshots in the Screenshots folder
+// of the user's pictures directory, unless saving them is turned off.
+func setScreenshotPath(cfg, homeDir string) string {
+	if value, ok := kdlGet(cfg, nil, "screenshot-path"); ok && value == "null" {
+		return cfg
+	}
+	dir := filepath.Join(userDir(homeDir, "PICTURES"), "Screenshots")
+	if rel, err := filepath.Rel(homeDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		dir = "~/" + rel
+	}
+	return setKDLNode(cfg, nil, "screenshot-path "+kdlQuote(dir+"/Screenshot from %Y-%m-%d %H-%M-%S.png"))
+}